@@ -1,23 +1,59 @@
 //! The `check` subcommand.
 
 use clap::{Args, ValueEnum};
-use engine::config::{Provider, Severity};
+use engine::analyzer::SourceFile;
+use engine::baseline::Baseline;
+use engine::config::Config;
+use engine::config::{AnalysisScope, Category, Confidence, Provider, Severity};
 use engine::error::EngineError;
-use engine::redact_text;
-use engine::report::{JsonGenerator, MarkdownGenerator, ReportGenerator};
+use engine::report::jsonl::issue_line;
+use engine::report::{
+    sort_issues, CompactTextGenerator, GithubGenerator, GroupBy, JsonGenerator, JunitGenerator,
+    MarkdownGenerator, ReportGenerator, ReportStats, SarifGenerator, SortKey, TextGenerator,
+};
+use engine::scanner::Issue;
 use engine::ReviewEngine;
+use engine::{findings_exit_code, redact_text};
 use std::env;
 use std::fs;
-use std::process::Command;
+use std::io::{self, Read, Write};
+use std::path::{Component, Path, PathBuf};
+use std::process::{Command, Stdio};
+use std::sync::Mutex;
 use std::time::Duration;
 
 use anyhow::Context;
 use indicatif::{ProgressBar, ProgressStyle};
 
-#[derive(Clone, ValueEnum, Debug)]
+#[derive(Clone, Copy, ValueEnum, Debug, PartialEq, Eq)]
 pub enum ReportFormat {
     Md,
     Json,
+    /// SARIF 2.1.0, as accepted by GitHub code scanning.
+    Sarif,
+    /// GitHub Actions workflow commands, printed to stdout unless `--output`
+    /// is given.
+    Github,
+    /// One JSON object per finding and line, written as files are scanned.
+    Jsonl,
+    /// Plain text ending with summary statistics, printed to stdout unless
+    /// `--output` is given.
+    Text,
+    /// One `path:line:col: [severity] rule: title` line per finding, printed
+    /// to stdout unless `--output` is given.
+    TextCompact,
+    /// JUnit XML with one test suite per rule and one failing test case per
+    /// finding.
+    Junit,
+}
+
+/// How file paths are written in reports.
+#[derive(Clone, Copy, ValueEnum, Debug, PartialEq, Eq)]
+pub enum PathStyle {
+    /// Relative to `--repo-root`, or as found in the diff.
+    Relative,
+    /// Absolute, so terminals can link to the file.
+    Absolute,
 }
 
 #[derive(Args, Debug)]
@@ -28,18 +64,52 @@ pub struct CheckArgs {
 
     /// The base reference to compare against for generating a diff.
     /// Use "auto" to detect the upstream of the current branch.
-    #[arg(long, default_value = "auto", alias = "base-ref")]
+    #[arg(long, default_value = "auto", aliases = ["base-ref", "base"])]
     pub diff: String,
 
+    /// Read the unified diff from a file instead of running `git diff`.
+    /// Use `-` to read it from stdin.
+    #[arg(long, value_name = "PATH", conflicts_with = "no_only_changed")]
+    pub diff_file: Option<String>,
+
+    /// Analyze source code read from stdin instead of a diff, reporting it
+    /// as the file given by `--filename`. Meant for editor integrations.
+    #[arg(
+        long,
+        default_value_t = false,
+        requires = "filename",
+        conflicts_with_all = ["diff_file", "apply_fixes", "write_baseline"]
+    )]
+    pub stdin: bool,
+
+    /// The path the source read with `--stdin` is reported under. Its
+    /// extension selects the language rules.
+    #[arg(long, value_name = "PATH", requires = "stdin")]
+    pub filename: Option<String>,
+
+    /// Analyze every file of the tree at this commit, branch or tag, read
+    /// from the git object store instead of the working tree. Works in a bare
+    /// clone.
+    #[arg(
+        long,
+        value_name = "REF",
+        conflicts_with_all = ["diff_file", "stdin", "apply_fixes"]
+    )]
+    pub git_ref: Option<String>,
+
     /// Run in CI mode (non-interactive).
     #[arg(long, default_value_t = false)]
     pub ci: bool,
 
-    /// Analyze only files changed relative to the diff base. Use `--no-only-changed`
-    /// to analyze all files.
-    #[arg(long, default_value_t = true)]
+    /// Report only findings on lines changed relative to the diff base (the
+    /// default). Use `--no-only-changed` to analyze all files.
+    #[arg(long, alias = "diff-only", overrides_with = "no_only_changed")]
     pub only_changed: bool,
 
+    /// Analyze every tracked file instead of only the changed lines.
+    #[arg(long, overrides_with = "only_changed")]
+    pub no_only_changed: bool,
+
     /// Disable progress output.
     #[arg(long, default_value_t = false)]
     pub no_progress: bool,
@@ -52,14 +122,257 @@ pub struct CheckArgs {
     #[arg(long, default_value = ".")]
     pub path: String,
 
-    /// The path to write the review report to.
-    #[arg(short, long)]
-    pub output: Option<String>,
+    /// The path to write the `--format` report to, or `FORMAT=PATH` to write
+    /// a report in another format. Repeatable, so one run can write several
+    /// reports, e.g. `--output sarif=results.sarif --output junit=results.xml`.
+    /// Use `-` as the path to print a report to stdout.
+    #[arg(short, long, value_name = "[FORMAT=]PATH")]
+    pub output: Vec<String>,
+
+    /// How file paths are written in the report. Defaults to `absolute` for
+    /// `text` and `text-compact` reports and `relative` for every other
+    /// format.
+    #[arg(long, value_enum, value_name = "STYLE")]
+    pub path_style: Option<PathStyle>,
+
+    /// The directory relative paths are computed against. Defaults to
+    /// `--path`, leaving paths as they appear in the diff.
+    #[arg(long, value_name = "DIR")]
+    pub repo_root: Option<PathBuf>,
 
     /// Minimum issue severity that will trigger a non-zero exit.
     /// Defaults to the `fail-on` setting in `reviewlens.toml` (`high` if unset).
     #[arg(long, value_enum)]
     pub fail_on: Option<Severity>,
+
+    /// Only findings in this category can trigger a non-zero exit; the rest
+    /// are advisory. Repeatable. Defaults to the `fail-on-category` setting,
+    /// or every category.
+    #[arg(long, value_enum, value_name = "CATEGORY")]
+    pub fail_on_category: Vec<Category>,
+
+    /// Drop findings below this confidence before reporting. Defaults to the
+    /// `[scan]` setting, or keeping every finding.
+    #[arg(long, value_enum, value_name = "LEVEL")]
+    pub min_confidence: Option<Confidence>,
+
+    /// Only analyze paths matching this gitignore-style glob. Repeatable;
+    /// replaces the `[paths]` allow list.
+    #[arg(long, value_name = "GLOB")]
+    pub include: Vec<String>,
+
+    /// Skip paths matching this gitignore-style glob. Repeatable; added to the
+    /// `[paths]` deny list.
+    #[arg(long, value_name = "GLOB")]
+    pub exclude: Vec<String>,
+
+    /// Analyze generated files (marked `// Code generated ... DO NOT EDIT.`),
+    /// which are skipped by default.
+    #[arg(long, default_value_t = false)]
+    pub include_generated: bool,
+
+    /// Number of files to scan in parallel. Defaults to the `[scan]` setting, or
+    /// the number of available CPUs.
+    #[arg(long, value_name = "N")]
+    pub concurrency: Option<usize>,
+
+    /// Seconds the scanners may spend on one file before it is reported as
+    /// timed out. Defaults to the `[scan]` setting, or 30; `0` disables the
+    /// limit.
+    #[arg(long, value_name = "SECONDS")]
+    pub file_timeout: Option<u64>,
+
+    /// How far the taint-tracking rules follow calls: `file` analyzes each
+    /// file on its own, `package` also follows calls into the rest of its
+    /// package, and `module` into the other packages of the Go module.
+    /// Defaults to the `[scan]` setting, or `file`.
+    #[arg(long, value_enum, value_name = "SCOPE")]
+    pub scope: Option<AnalysisScope>,
+
+    /// Directory for cached scanner results. Defaults to the `[scan]` setting,
+    /// or `reviewlens` under the user cache directory.
+    #[arg(long, value_name = "DIR", conflicts_with = "no_cache")]
+    pub cache_dir: Option<PathBuf>,
+
+    /// Analyze every file even if cached results exist, and don't update the
+    /// cache.
+    #[arg(long, default_value_t = false)]
+    pub no_cache: bool,
+
+    /// Baseline file of known findings. Findings recorded in it are reported as
+    /// `info` and do not cause a non-zero exit.
+    #[arg(long, value_name = "PATH")]
+    pub baseline: Option<PathBuf>,
+
+    /// Record the current findings to the `--baseline` file instead of reading it.
+    #[arg(long, default_value_t = false, requires = "baseline")]
+    pub write_baseline: bool,
+
+    /// Rewrite files in place with the suggested fixes of reported findings,
+    /// then format changed Go files with `gofmt`.
+    #[arg(long, default_value_t = false)]
+    pub apply_fixes: bool,
+
+    /// The order findings are listed in, in every report but `jsonl`, which
+    /// is written as files finish. Ties are broken by file, line, column and
+    /// rule. Defaults to `file`, except in Markdown reports, which list the
+    /// most severe findings first.
+    #[arg(long, value_enum, value_name = "KEY")]
+    pub sort: Option<SortKey>,
+
+    /// The sections findings are listed under in text reports.
+    #[arg(long, value_enum, default_value = "file", value_name = "GROUP")]
+    pub group_by: GroupBy,
+
+    /// Print only the summary statistics, without individual findings, in
+    /// text reports and on the console.
+    #[arg(short, long, default_value_t = false)]
+    pub quiet: bool,
+
+    /// Never color text reports. Colors are also off when stdout is not a
+    /// terminal or `NO_COLOR` is set.
+    #[arg(long, default_value_t = false)]
+    pub no_color: bool,
+
+    /// Show how request data reaches each taint finding: its source, the
+    /// assignments, concatenations and calls it passes through, and the
+    /// sink. Printed under each finding in text reports and added as a
+    /// `trace` field in JSON-based reports.
+    #[arg(long, default_value_t = false)]
+    pub explain: bool,
+}
+
+impl CheckArgs {
+    /// Whether findings are limited to changed lines.
+    pub fn changed_only(&self) -> bool {
+        !self.no_only_changed
+    }
+
+    /// The reports to write. Without `--output`, the `--format` report is
+    /// written to its default path. Fails when an `--output` names an
+    /// unknown format or two reports would be written to the same path.
+    pub fn outputs(&self) -> Result<Vec<Output>, EngineError> {
+        let mut outputs = Vec::new();
+        let mut plain = None;
+        for spec in &self.output {
+            match spec.split_once('=') {
+                // A prefix that could be a format name must be one; anything
+                // else, such as `out/a=b.json`, is a path.
+                Some((name, path))
+                    if !name.is_empty()
+                        && name.chars().all(|c| c.is_ascii_alphanumeric() || c == '-') =>
+                {
+                    let format = ReportFormat::from_str(name, true).map_err(|_| {
+                        EngineError::Config(format!(
+                            "unknown report format `{}` in `--output {}`; expected one of {}",
+                            name,
+                            spec,
+                            ReportFormat::value_variants()
+                                .iter()
+                                .filter_map(|f| f.to_possible_value())
+                                .map(|v| v.get_name().to_string())
+                                .collect::<Vec<_>>()
+                                .join(", ")
+                        ))
+                    })?;
+                    outputs.push(Output {
+                        format,
+                        path: path.to_string(),
+                    });
+                }
+                _ if plain.is_some() => {
+                    return Err(EngineError::Config(format!(
+                        "`--output {}`: only one `--output` may omit the format",
+                        spec
+                    )))
+                }
+                _ => plain = Some(spec.clone()),
+            }
+        }
+        if plain.is_some() || outputs.is_empty() {
+            outputs.insert(
+                0,
+                Output {
+                    format: self.format,
+                    path: plain.unwrap_or_else(|| default_output_path(self.format).to_string()),
+                },
+            );
+        }
+
+        let cwd = env::current_dir().unwrap_or_default();
+        for (i, output) in outputs.iter().enumerate() {
+            let target = output.target(&cwd);
+            if let Some(other) = outputs[..i].iter().find(|o| o.target(&cwd) == target) {
+                return Err(EngineError::Config(format!(
+                    "the {} and {} reports would both be written to {}; give each `--output` its own path",
+                    other.name(),
+                    output.name(),
+                    if output.path == "-" { "stdout" } else { &output.path }
+                )));
+            }
+        }
+        Ok(outputs)
+    }
+
+    /// How file paths are written in a report of the given format.
+    pub fn path_style(&self, format: ReportFormat) -> PathStyle {
+        self.path_style.unwrap_or(match format {
+            ReportFormat::Text | ReportFormat::TextCompact => PathStyle::Absolute,
+            _ => PathStyle::Relative,
+        })
+    }
+
+    /// Whether a machine-readable report is printed to stdout, in which case
+    /// logs and the summary must go to stderr to keep it parseable.
+    pub fn report_on_stdout(&self) -> bool {
+        self.outputs().unwrap_or_default().iter().any(|output| {
+            output.path == "-"
+                && matches!(
+                    output.format,
+                    ReportFormat::Json
+                        | ReportFormat::Sarif
+                        | ReportFormat::Jsonl
+                        | ReportFormat::TextCompact
+                        | ReportFormat::Junit
+                )
+        })
+    }
+}
+
+/// A report to write.
+#[derive(Debug, Clone)]
+pub struct Output {
+    pub format: ReportFormat,
+    /// Where the report is written; `-` is stdout.
+    pub path: String,
+}
+
+impl Output {
+    /// The format's name, as given to `--format`.
+    fn name(&self) -> String {
+        self.format
+            .to_possible_value()
+            .map_or_else(String::new, |v| v.get_name().to_string())
+    }
+
+    /// The file the report is written to, for telling outputs apart.
+    fn target(&self, cwd: &Path) -> Option<PathBuf> {
+        (self.path != "-").then(|| normalize(&cwd.join(&self.path)))
+    }
+}
+
+/// Where a report is written when `--output` does not say.
+fn default_output_path(format: ReportFormat) -> &'static str {
+    match format {
+        ReportFormat::Md => "review_report.md",
+        ReportFormat::Json => "review_report.json",
+        ReportFormat::Sarif => "review_report.sarif",
+        ReportFormat::Github => "-",
+        ReportFormat::Jsonl => "review_report.jsonl",
+        ReportFormat::Text => "-",
+        ReportFormat::TextCompact => "-",
+        ReportFormat::Junit => "review_report.xml",
+    }
 }
 
 /// Executes the `check` subcommand.
@@ -80,13 +393,7 @@ pub async fn run(args: CheckArgs, engine: &ReviewEngine) -> i32 {
         }
         match ReviewEngine::new(config) {
             Ok(ci_engine) => match execute(args, &ci_engine).await {
-                Ok(issues_found) => {
-                    if issues_found {
-                        1
-                    } else {
-                        0
-                    }
-                }
+                Ok(code) => code,
                 Err(e) => {
                     if let Some(engine_error) = e.downcast_ref::<EngineError>() {
                         match engine_error {
@@ -112,13 +419,7 @@ pub async fn run(args: CheckArgs, engine: &ReviewEngine) -> i32 {
         }
     } else {
         match execute(args, engine).await {
-            Ok(issues_found) => {
-                if issues_found {
-                    1
-                } else {
-                    0
-                }
-            }
+            Ok(code) => code,
             Err(e) => {
                 if let Some(engine_error) = e.downcast_ref::<EngineError>() {
                     match engine_error {
@@ -140,20 +441,28 @@ pub async fn run(args: CheckArgs, engine: &ReviewEngine) -> i32 {
     }
 }
 
-async fn execute(args: CheckArgs, engine: &ReviewEngine) -> anyhow::Result<bool> {
-    let output_path = args.output.clone().unwrap_or_else(|| match args.format {
-        ReportFormat::Md => "review_report.md".to_string(),
-        ReportFormat::Json => "review_report.json".to_string(),
-    });
+/// Runs the review and returns the exit code derived from its findings.
+async fn execute(args: CheckArgs, engine: &ReviewEngine) -> anyhow::Result<i32> {
+    // Outputs are checked before anything is analyzed, so a conflicting
+    // `--output` fails fast.
+    let outputs = args.outputs()?;
 
     log::info!("Running 'check' with the following arguments:");
     log::info!("  Path: {}", args.path);
-    log::info!("  Output: {}", output_path);
-    log::info!("  Format: {:?}", args.format);
+    for output in &outputs {
+        log::info!("  Output: {:?} to {}", output.format, output.path);
+    }
     log::info!("  CI mode: {}", args.ci);
-    log::info!("  Only changed: {}", args.only_changed);
+    log::info!("  Only changed: {}", args.changed_only());
+    log::info!("  Diff file: {:?}", args.diff_file);
+    log::info!("  Stdin filename: {:?}", args.filename);
+    log::info!("  Git ref: {:?}", args.git_ref);
     log::info!("  No progress: {}", args.no_progress);
     log::info!("  Allow suggest: {}", args.allow_suggest);
+    log::info!("  Baseline: {:?}", args.baseline);
+    log::info!("  Path style: {:?}", args.path_style);
+    log::info!("  Repo root: {:?}", args.repo_root);
+    log::info!("  Sort: {:?}", args.sort);
 
     if args.ci {
         env::set_var("CI", "true");
@@ -162,63 +471,20 @@ async fn execute(args: CheckArgs, engine: &ReviewEngine) -> anyhow::Result<bool>
         log::info!("Starting review...");
     }
 
-    // Resolve the base reference, falling back to upstream if not provided.
-    let base_ref = if args.diff != "auto" {
-        args.diff.clone()
-    } else {
-        let upstream_output = Command::new("git")
-            .args([
-                "-C",
-                &args.path,
-                "rev-parse",
-                "--abbrev-ref",
-                "--symbolic-full-name",
-                "@{u}",
-            ])
-            .output()
-            .map_err(|e| EngineError::Config(format!("failed to detect upstream base: {}", e)))?;
-        if !upstream_output.status.success() {
-            return Err(
-                EngineError::Config("failed to detect upstream base reference".into()).into(),
-            );
+    // 1. Read or generate the diff, or read the source to analyze.
+    let input = match (&args.filename, &args.diff_file) {
+        _ if args.git_ref.is_some() => {
+            Input::Tree(git_tree(&args.path, args.git_ref.as_deref().unwrap())?)
         }
-        String::from_utf8(upstream_output.stdout)
-            .context("upstream output was not valid UTF-8")?
-            .trim()
-            .to_string()
-    };
-    log::info!("  Base ref: {}", base_ref);
-
-    // 1. Generate the diff.
-    let diff_content = if args.only_changed {
-        let diff_output = Command::new("git")
-            .args(["-C", &args.path, "diff", &base_ref])
-            .output()
-            .with_context(|| "failed to execute git diff")?;
-        if !diff_output.status.success() {
-            anyhow::bail!("git diff command failed");
+        (Some(filename), _) if args.stdin => {
+            let mut source = Vec::new();
+            io::stdin()
+                .read_to_end(&mut source)
+                .context("failed to read source from stdin")?;
+            Input::Source(filename.clone(), source)
         }
-        String::from_utf8(diff_output.stdout).context("diff output was not valid UTF-8")?
-    } else {
-        let empty_tree = Command::new("git")
-            .args(["-C", &args.path, "hash-object", "-t", "tree", "/dev/null"])
-            .output()
-            .with_context(|| "failed to hash empty tree")?;
-        if !empty_tree.status.success() {
-            anyhow::bail!("git hash-object command failed");
-        }
-        let empty_tree_ref = String::from_utf8(empty_tree.stdout)
-            .context("empty tree hash output was not valid UTF-8")?
-            .trim()
-            .to_string();
-        let diff_output = Command::new("git")
-            .args(["-C", &args.path, "diff", &empty_tree_ref])
-            .output()
-            .with_context(|| "failed to execute git diff")?;
-        if !diff_output.status.success() {
-            anyhow::bail!("git diff command failed");
-        }
-        String::from_utf8(diff_output.stdout).context("diff output was not valid UTF-8")?
+        (_, Some(path)) => Input::Diff(read_diff_file(path)?),
+        _ => Input::Diff(git_diff(&args)?),
     };
 
     // 2. Call the engine to run the review and capture its report.
@@ -233,17 +499,68 @@ async fn execute(args: CheckArgs, engine: &ReviewEngine) -> anyhow::Result<bool>
         None
     };
 
-    let report = {
+    // Paths are rewritten for each report once findings have been matched
+    // against the baseline and fixed, both of which use the paths as found
+    // in the diff.
+    let paths: Vec<ReportPaths> = outputs
+        .iter()
+        .map(|output| ReportPaths::new(&args, output.format))
+        .collect::<anyhow::Result<_>>()?;
+
+    // The baseline is read up front so streamed findings can be matched
+    // against it as they are written.
+    let known = match &args.baseline {
+        Some(path) if !args.write_baseline => Some(Baseline::load(path)?),
+        _ => None,
+    };
+
+    // JSON Lines output is written while the review runs. The files are
+    // opened before changing directory so `--output` stays relative to the
+    // caller.
+    let streams = outputs
+        .iter()
+        .zip(&paths)
+        .filter(|(output, _)| output.format == ReportFormat::Jsonl)
+        .map(|(output, paths)| {
+            JsonLinesStream::open(&output.path, engine.config(), known.as_ref(), paths)
+        })
+        .collect::<anyhow::Result<Vec<_>>>()?;
+
+    let mut report = {
         let original_dir = env::current_dir().with_context(|| "failed to get current directory")?;
         env::set_current_dir(&args.path)
             .with_context(|| format!("failed to change to directory {}", args.path))?;
         if let Some(pb) = &progress {
             pb.set_message("Running review engine...");
         }
-        let result = engine
-            .run(&diff_content)
-            .await
-            .map_err(|e| anyhow::anyhow!(e));
+        let result = match &input {
+            Input::Diff(diff) => {
+                engine
+                    .run_streaming(diff, |issues| {
+                        for stream in &streams {
+                            stream.write(issues);
+                        }
+                    })
+                    .await
+            }
+            Input::Tree(files) => {
+                engine
+                    .run_tree(files, |issues| {
+                        for stream in &streams {
+                            stream.write(issues);
+                        }
+                    })
+                    .await
+            }
+            Input::Source(filename, source) => {
+                engine.run_source(filename, source).inspect(|report| {
+                    for stream in &streams {
+                        stream.write(&report.issues);
+                    }
+                })
+            }
+        }
+        .map_err(|e| anyhow::anyhow!(e));
         env::set_current_dir(original_dir)
             .with_context(|| "failed to restore working directory")?;
         result?
@@ -252,44 +569,496 @@ async fn execute(args: CheckArgs, engine: &ReviewEngine) -> anyhow::Result<bool>
     if let Some(pb) = progress {
         pb.finish_and_clear();
     }
+    for stream in streams {
+        stream.finish()?;
+    }
 
-    // Print the summary and hotspots to stdout for quick visibility.
-    if args.ci {
-        println!("{}", report.summary);
+    // Downgrade findings that are already recorded in the baseline.
+    if let Some(path) = &args.baseline {
+        let baseline = match known {
+            Some(baseline) => baseline,
+            None => {
+                let baseline = Baseline::from_issues(&report.issues);
+                baseline.save(path)?;
+                log::info!(
+                    "Wrote {} finding(s) to baseline {}",
+                    baseline.findings.len(),
+                    path.display()
+                );
+                baseline
+            }
+        };
+        let matched = baseline.apply(&mut report.issues);
+        log::info!("{} finding(s) matched the baseline", matched);
+    }
+
+    if args.apply_fixes {
+        let changed = engine::fix::apply_fixes(Path::new(&args.path), &report.issues)?;
+        gofmt(&changed);
+        log::info!("Applied fixes to {} file(s)", changed.len());
+    }
+
+    // Sorted after the baseline is applied, so downgraded findings sort by
+    // their reported severity.
+    if let Some(key) = args.sort {
+        sort_issues(&mut report.issues, key);
+    }
+
+    // Print the summary and hotspots for quick visibility. They go to stderr
+    // when a machine-readable report is being printed to stdout, and are left
+    // out when a text report, which starts with them, is.
+    let mut overview = String::new();
+    if args.quiet {
+        for line in ReportStats::from_report(&report).lines() {
+            overview.push_str(&format!("{}\n", line));
+        }
+    } else if args.ci {
+        overview.push_str(&format!("{}\n", report.summary));
     } else {
-        println!("Summary: {}", report.summary);
+        overview.push_str(&format!("Summary: {}\n", report.summary));
         if report.hotspots.is_empty() {
-            println!("No hotspots identified.");
+            overview.push_str("No hotspots identified.\n");
         } else {
-            println!("Top hotspots:");
+            overview.push_str("Top hotspots:\n");
             for spot in &report.hotspots {
-                println!("- {}", spot);
+                overview.push_str(&format!("- {}\n", spot));
             }
         }
     }
+    let text_on_stdout = outputs
+        .iter()
+        .any(|output| output.format == ReportFormat::Text && output.path == "-");
+    if args.report_on_stdout() {
+        eprint!("{}", overview);
+    } else if !text_on_stdout {
+        print!("{}", overview);
+    }
 
-    // 3. Generate the report and write it to `output_path`.
-    let generator: Box<dyn ReportGenerator> = match args.format {
-        ReportFormat::Md => Box::new(MarkdownGenerator),
-        ReportFormat::Json => Box::new(JsonGenerator),
-    };
-    let report_out = generator
-        .generate(&report)
-        .map_err(|e| anyhow::anyhow!(e))?;
-    let redacted_report = redact_text(engine.config(), &report_out);
-    fs::write(&output_path, &redacted_report)?;
-    log::info!("\nReview complete. Report written to {}.", output_path);
+    // 3. Generate each report and write it to its output. JSON Lines
+    // findings were already written while the review ran.
+    for (output, paths) in outputs.iter().zip(&paths) {
+        let generator: Box<dyn ReportGenerator> = match output.format {
+            ReportFormat::Md => Box::new(MarkdownGenerator { sort: args.sort }),
+            ReportFormat::Json => Box::new(JsonGenerator),
+            ReportFormat::Sarif => Box::new(SarifGenerator),
+            ReportFormat::Github => Box::new(GithubGenerator),
+            ReportFormat::Jsonl => continue,
+            ReportFormat::Text => Box::new(TextGenerator {
+                quiet: args.quiet,
+                color: output.path == "-" && super::stdout_color(args.no_color),
+                group_by: args.group_by,
+            }),
+            ReportFormat::TextCompact => Box::new(CompactTextGenerator),
+            ReportFormat::Junit => Box::new(JunitGenerator),
+        };
+        let mut rendered = report.clone();
+        paths.rewrite(&mut rendered.issues);
+        let report_out = generator
+            .generate(&rendered)
+            .map_err(|e| anyhow::anyhow!(e))?;
+        let redacted_report = redact_text(engine.config(), &report_out);
+        if output.path == "-" {
+            print!("{}", redacted_report);
+        } else {
+            fs::write(&output.path, &redacted_report)
+                .with_context(|| format!("failed to write {}", output.path))?;
+        }
+    }
+    let written: Vec<&str> = outputs
+        .iter()
+        .filter(|output| output.path != "-")
+        .map(|output| output.path.as_str())
+        .collect();
+    if written.is_empty() {
+        log::info!("\nReview complete.");
+    } else {
+        log::info!(
+            "\nReview complete. Report written to {}.",
+            written.join(", ")
+        );
+    }
 
     // 4. Determine if issues exceed the severity threshold.
     let threshold = args
         .fail_on
         .unwrap_or_else(|| engine.config().fail_on.clone());
-    let issues_found = report
-        .issues
+    let categories = if args.fail_on_category.is_empty() {
+        &engine.config().fail_on_category
+    } else {
+        &args.fail_on_category
+    };
+    Ok(findings_exit_code(&report.issues, &threshold, categories))
+}
+
+/// What `check` reviews.
+enum Input {
+    /// A unified diff of the repository.
+    Diff(String),
+    /// Source read from stdin and the path it is reported under.
+    Source(String, Vec<u8>),
+    /// The files of a git tree.
+    Tree(Vec<SourceFile>),
+}
+
+/// Writes findings as JSON lines while the review runs. Lines are flushed
+/// per file so consumers see them immediately.
+struct JsonLinesStream<'a> {
+    out: Mutex<Box<dyn Write + Send>>,
+    config: &'a Config,
+    baseline: Option<&'a Baseline>,
+    paths: &'a ReportPaths,
+    /// The first write error, reported once the review finishes.
+    error: Mutex<Option<io::Error>>,
+}
+
+impl<'a> JsonLinesStream<'a> {
+    /// Opens `path` for writing, or stdout for `-`.
+    fn open(
+        path: &str,
+        config: &'a Config,
+        baseline: Option<&'a Baseline>,
+        paths: &'a ReportPaths,
+    ) -> anyhow::Result<Self> {
+        let out: Box<dyn Write + Send> = if path == "-" {
+            Box::new(io::stdout())
+        } else {
+            Box::new(io::BufWriter::new(
+                fs::File::create(path).with_context(|| format!("failed to create {}", path))?,
+            ))
+        };
+        Ok(Self {
+            out: Mutex::new(out),
+            config,
+            baseline,
+            paths,
+            error: Mutex::new(None),
+        })
+    }
+
+    fn write(&self, issues: &[Issue]) {
+        let mut issues = issues.to_vec();
+        if let Some(baseline) = self.baseline {
+            baseline.apply(&mut issues);
+        }
+        self.paths.rewrite(&mut issues);
+        let mut out = self.out.lock().unwrap();
+        let result = issues
+            .iter()
+            .try_for_each(|issue| {
+                let line = issue_line(issue).map_err(io::Error::other)?;
+                writeln!(out, "{}", redact_text(self.config, &line))
+            })
+            .and_then(|()| out.flush());
+        if let Err(e) = result {
+            self.error.lock().unwrap().get_or_insert(e);
+        }
+    }
+
+    fn finish(self) -> anyhow::Result<()> {
+        match self.error.into_inner().unwrap() {
+            Some(e) => Err(e).with_context(|| "failed to write JSON Lines output"),
+            None => Ok(()),
+        }
+    }
+}
+
+/// Rewrites the paths of findings, which are relative to `--path`, in the
+/// style chosen for the report.
+struct ReportPaths {
+    style: PathStyle,
+    /// The absolute directory finding paths are relative to.
+    base: PathBuf,
+    /// The absolute directory relative paths are computed against, if it is
+    /// not `base`.
+    root: Option<PathBuf>,
+    /// Whether separators are written as `/` on every platform, as SARIF
+    /// and other machine-readable consumers expect.
+    forward_slashes: bool,
+}
+
+impl ReportPaths {
+    fn new(args: &CheckArgs, format: ReportFormat) -> anyhow::Result<Self> {
+        let cwd = env::current_dir().with_context(|| "failed to get current directory")?;
+        let base = normalize(&cwd.join(&args.path));
+        let root = match &args.repo_root {
+            Some(root) if !root.is_dir() => {
+                return Err(EngineError::Config(format!(
+                    "--repo-root {} is not a directory",
+                    root.display()
+                ))
+                .into())
+            }
+            Some(root) => Some(normalize(&cwd.join(root))),
+            None => None,
+        };
+        Ok(Self {
+            style: args.path_style(format),
+            base,
+            root,
+            forward_slashes: !matches!(format, ReportFormat::Text | ReportFormat::TextCompact),
+        })
+    }
+
+    fn rewrite(&self, issues: &mut [Issue]) {
+        for issue in issues {
+            issue.file_path = self.path(&issue.file_path);
+        }
+    }
+
+    fn path(&self, file_path: &str) -> String {
+        let path = match (self.style, &self.root) {
+            (PathStyle::Relative, None) => PathBuf::from(file_path),
+            (PathStyle::Relative, Some(root)) => {
+                relative_to(&normalize(&self.base.join(file_path)), root)
+            }
+            (PathStyle::Absolute, _) => normalize(&self.base.join(file_path)),
+        };
+        if self.forward_slashes {
+            to_slash(&path)
+        } else {
+            path.display().to_string()
+        }
+    }
+}
+
+/// Removes `.` and resolves `..` components without touching the file
+/// system, so paths of deleted files and git trees can be rewritten too.
+fn normalize(path: &Path) -> PathBuf {
+    let mut normalized = PathBuf::new();
+    for component in path.components() {
+        match component {
+            Component::CurDir => {}
+            Component::ParentDir => {
+                if !normalized.pop() {
+                    normalized.push(component);
+                }
+            }
+            _ => normalized.push(component),
+        }
+    }
+    normalized
+}
+
+/// Returns `path` relative to `root`, going up with `..` when it is outside
+/// it. Both paths are absolute and normalized.
+fn relative_to(path: &Path, root: &Path) -> PathBuf {
+    let path: Vec<Component> = path.components().collect();
+    let root: Vec<Component> = root.components().collect();
+    let shared = path.iter().zip(&root).take_while(|(a, b)| a == b).count();
+    let mut relative = PathBuf::new();
+    for _ in shared..root.len() {
+        relative.push("..");
+    }
+    for component in &path[shared..] {
+        relative.push(component);
+    }
+    relative
+}
+
+/// Writes `path` with `/` separators, as in `C:/src/app/main.go` for a
+/// Windows path.
+fn to_slash(path: &Path) -> String {
+    let mut out = String::new();
+    for component in path.components() {
+        match component {
+            Component::Prefix(prefix) => out.push_str(&prefix.as_os_str().to_string_lossy()),
+            Component::RootDir => out.push('/'),
+            component => {
+                if !out.is_empty() && !out.ends_with('/') {
+                    out.push('/');
+                }
+                out.push_str(&component.as_os_str().to_string_lossy());
+            }
+        }
+    }
+    out
+}
+
+/// Formats fixed Go files with `gofmt` so they stay gofmt-clean. Fixes are
+/// still applied when `gofmt` is not installed.
+fn gofmt(files: &[PathBuf]) {
+    let go_files: Vec<&PathBuf> = files
         .iter()
-        .map(|issue| issue.severity.clone())
-        .max()
-        .map_or(false, |max| max >= threshold);
+        .filter(|f| f.extension().map_or(false, |ext| ext == "go"))
+        .collect();
+    if go_files.is_empty() {
+        return;
+    }
+    match Command::new("gofmt").arg("-w").args(&go_files).status() {
+        Ok(status) if status.success() => {}
+        Ok(status) => log::warn!("gofmt exited with {}", status),
+        Err(e) => log::warn!(
+            "Could not run gofmt; fixed files were not reformatted: {}",
+            e
+        ),
+    }
+}
+
+/// Reads a diff supplied by the caller. `-` reads from stdin.
+fn read_diff_file(path: &str) -> anyhow::Result<String> {
+    if path == "-" {
+        let mut diff = String::new();
+        io::stdin()
+            .read_to_string(&mut diff)
+            .context("failed to read diff from stdin")?;
+        Ok(diff)
+    } else {
+        fs::read_to_string(path).with_context(|| format!("failed to read diff file {}", path))
+    }
+}
+
+/// Reads every file of the tree at `git_ref` from the object store of the
+/// repository at `path`, which may be a bare clone. Paths are relative to the
+/// root of the tree. Symlinks and submodules are skipped.
+fn git_tree(path: &str, git_ref: &str) -> anyhow::Result<Vec<SourceFile>> {
+    if git_ref.starts_with('-') {
+        return Err(EngineError::Config(format!("invalid git ref `{}`", git_ref)).into());
+    }
+    let listing = Command::new("git")
+        .args(["-C", path, "ls-tree", "-r", "-z", "--full-tree", git_ref])
+        .output()
+        .with_context(|| "failed to execute git ls-tree")?;
+    if !listing.status.success() {
+        anyhow::bail!(
+            "failed to read the tree of {}: {}",
+            git_ref,
+            String::from_utf8_lossy(&listing.stderr).trim()
+        );
+    }
+
+    // Entries are `<mode> <type> <object>\t<path>`, separated by NUL bytes.
+    let mut entries = Vec::new();
+    for entry in listing.stdout.split(|&b| b == 0).filter(|e| !e.is_empty()) {
+        let entry =
+            String::from_utf8(entry.to_vec()).context("git ls-tree output was not valid UTF-8")?;
+        let (info, file) = entry
+            .split_once('\t')
+            .with_context(|| format!("unexpected git ls-tree entry `{}`", entry))?;
+        let fields: Vec<&str> = info.split(' ').collect();
+        match fields.as_slice() {
+            [mode, "blob", object] if *mode != "120000" => {
+                entries.push((object.to_string(), file.to_string()))
+            }
+            _ => log::debug!("Skipping {} in {}", file, git_ref),
+        }
+    }
+
+    // Read every blob with a single `git cat-file --batch`, which answers each
+    // object name with `<object> <type> <size>\n<content>\n`.
+    let mut child = Command::new("git")
+        .args(["-C", path, "cat-file", "--batch"])
+        .stdin(Stdio::piped())
+        .stdout(Stdio::piped())
+        .spawn()
+        .with_context(|| "failed to execute git cat-file")?;
+    let mut stdin = child.stdin.take().expect("piped stdin");
+    let request: String = entries
+        .iter()
+        .map(|(object, _)| format!("{}\n", object))
+        .collect();
+    let writer = std::thread::spawn(move || stdin.write_all(request.as_bytes()));
+    let mut output = Vec::new();
+    child
+        .stdout
+        .take()
+        .expect("piped stdout")
+        .read_to_end(&mut output)
+        .context("failed to read git cat-file output")?;
+    writer
+        .join()
+        .expect("git cat-file writer panicked")
+        .context("failed to write to git cat-file")?;
+    if !child.wait()?.success() {
+        anyhow::bail!("git cat-file command failed");
+    }
+
+    let mut files = Vec::with_capacity(entries.len());
+    let mut rest = output.as_slice();
+    for (object, file) in entries {
+        let end = rest
+            .iter()
+            .position(|&b| b == b'\n')
+            .context("truncated git cat-file output")?;
+        let header = String::from_utf8_lossy(&rest[..end]).into_owned();
+        let size = match header.split(' ').collect::<Vec<_>>().as_slice() {
+            [name, "blob", size] if *name == object => size
+                .parse::<usize>()
+                .with_context(|| format!("unexpected git cat-file header `{}`", header))?,
+            _ => anyhow::bail!("failed to read {} from {}: {}", file, git_ref, header),
+        };
+        let content = rest
+            .get(end + 1..end + 1 + size)
+            .context("truncated git cat-file output")?;
+        files.push(SourceFile {
+            path: file,
+            content: content.to_vec(),
+        });
+        rest = rest.get(end + 2 + size..).unwrap_or_default();
+    }
+    Ok(files)
+}
+
+/// Runs `git diff` against the resolved base reference, or against the empty
+/// tree when the whole repository is analyzed.
+fn git_diff(args: &CheckArgs) -> anyhow::Result<String> {
+    let diff = if args.changed_only() {
+        // Resolve the base reference, falling back to upstream if not provided.
+        let base_ref = if args.diff != "auto" {
+            args.diff.clone()
+        } else {
+            let upstream_output = Command::new("git")
+                .args([
+                    "-C",
+                    &args.path,
+                    "rev-parse",
+                    "--abbrev-ref",
+                    "--symbolic-full-name",
+                    "@{u}",
+                ])
+                .output()
+                .map_err(|e| {
+                    EngineError::Config(format!("failed to detect upstream base: {}", e))
+                })?;
+            if !upstream_output.status.success() {
+                return Err(
+                    EngineError::Config("failed to detect upstream base reference".into()).into(),
+                );
+            }
+            String::from_utf8(upstream_output.stdout)
+                .context("upstream output was not valid UTF-8")?
+                .trim()
+                .to_string()
+        };
+        log::info!("  Base ref: {}", base_ref);
 
-    Ok(issues_found)
+        let diff_output = Command::new("git")
+            .args(["-C", &args.path, "diff", &base_ref])
+            .output()
+            .with_context(|| "failed to execute git diff")?;
+        if !diff_output.status.success() {
+            anyhow::bail!("git diff command failed");
+        }
+        String::from_utf8(diff_output.stdout).context("diff output was not valid UTF-8")?
+    } else {
+        let empty_tree = Command::new("git")
+            .args(["-C", &args.path, "hash-object", "-t", "tree", "/dev/null"])
+            .output()
+            .with_context(|| "failed to hash empty tree")?;
+        if !empty_tree.status.success() {
+            anyhow::bail!("git hash-object command failed");
+        }
+        let empty_tree_ref = String::from_utf8(empty_tree.stdout)
+            .context("empty tree hash output was not valid UTF-8")?
+            .trim()
+            .to_string();
+        let diff_output = Command::new("git")
+            .args(["-C", &args.path, "diff", &empty_tree_ref])
+            .output()
+            .with_context(|| "failed to execute git diff")?;
+        if !diff_output.status.success() {
+            anyhow::bail!("git diff command failed");
+        }
+        String::from_utf8(diff_output.stdout).context("diff output was not valid UTF-8")?
+    };
+    Ok(diff)
 }
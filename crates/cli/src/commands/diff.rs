@@ -0,0 +1,122 @@
+//! The `diff` subcommand.
+
+use std::path::Path;
+
+use anyhow::Context;
+use clap::{Args, ValueEnum};
+use engine::baseline::{compare, Comparison};
+use engine::config::Severity;
+use engine::error::EngineError;
+use engine::findings_exit_code;
+use engine::scanner::Issue;
+use serde_json::{json, Value};
+
+#[derive(Clone, ValueEnum, Debug)]
+pub enum DiffFormat {
+    Text,
+    Json,
+}
+
+#[derive(Args, Debug, Clone)]
+pub struct DiffArgs {
+    /// The earlier report, such as the one from the base branch.
+    #[arg(value_name = "OLD")]
+    pub old: String,
+
+    /// The later report, such as the one from the pull request.
+    #[arg(value_name = "NEW")]
+    pub new: String,
+
+    /// Minimum severity of an added finding that triggers a non-zero exit.
+    #[arg(long, value_enum, default_value = "high")]
+    pub fail_on: Severity,
+
+    /// Output format for the comparison.
+    #[arg(long, value_enum, default_value = "text")]
+    pub format: DiffFormat,
+}
+
+/// Compares the findings of two reports. Returns `1` when an added finding
+/// is at or above `--fail-on`, and `2` when a report cannot be read.
+pub fn run(args: DiffArgs) -> i32 {
+    let reports = read_issues(Path::new(&args.old))
+        .and_then(|old| Ok((old, read_issues(Path::new(&args.new))?)));
+    let (old, new) = match reports {
+        Ok(reports) => reports,
+        Err(e) => {
+            log::error!("{:#}", e);
+            return 2;
+        }
+    };
+    let comparison = compare(&old, &new);
+    match args.format {
+        DiffFormat::Text => print!("{}", text(&comparison)),
+        DiffFormat::Json => {
+            let out = json!({
+                "added": comparison.added,
+                "fixed": comparison.fixed,
+                "unchanged": comparison.unchanged,
+            });
+            println!("{}", serde_json::to_string_pretty(&out).unwrap_or_default());
+        }
+    }
+    findings_exit_code(&comparison.added, &args.fail_on, &[])
+}
+
+/// Reads the findings of a JSON report, or of a JSON Lines report with one
+/// finding per line. An object is only read as a report when it has an
+/// `issues` array or a `schemaVersion`, so a JSON Lines report with a single
+/// finding is not mistaken for an empty report.
+fn read_issues(path: &Path) -> anyhow::Result<Vec<Issue>> {
+    let content = std::fs::read_to_string(path)
+        .with_context(|| format!("failed to read {}", path.display()))?;
+    let invalid = |e: serde_json::Error| {
+        EngineError::Config(format!(
+            "{} is not a JSON or JSON Lines report: {}",
+            path.display(),
+            e
+        ))
+    };
+    if let Ok(Value::Object(mut report)) = serde_json::from_str::<Value>(&content) {
+        let is_report = report.get("issues").is_some_and(Value::is_array)
+            || report.contains_key("schemaVersion");
+        if is_report {
+            let issues = report.remove("issues").unwrap_or(Value::Array(Vec::new()));
+            return Ok(serde_json::from_value(issues).map_err(invalid)?);
+        }
+    }
+    content
+        .lines()
+        .filter(|line| !line.trim().is_empty())
+        .map(|line| serde_json::from_str(line).map_err(|e| invalid(e).into()))
+        .collect()
+}
+
+fn text(comparison: &Comparison) -> String {
+    let mut out = String::new();
+    let sections = [
+        ("Added", &comparison.added),
+        ("Fixed", &comparison.fixed),
+        ("Unchanged", &comparison.unchanged),
+    ];
+    for (heading, issues) in sections {
+        out.push_str(&format!("{} ({}):\n", heading, issues.len()));
+        for issue in issues {
+            out.push_str(&format!(
+                "  {} {}:{} {}: {}\n",
+                issue.severity.as_str(),
+                issue.file_path,
+                issue.line_number,
+                issue.rule_id,
+                issue.title
+            ));
+        }
+    }
+    out.push_str(&format!(
+        "{} added, {} fixed, {} unchanged\n",
+        comparison.added.len(),
+        comparison.fixed.len(),
+        comparison.unchanged.len()
+    ));
+    out
+}
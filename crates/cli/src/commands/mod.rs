@@ -1,6 +1,21 @@
 //! This module contains the logic for the CLI subcommands.
 
 pub mod check;
+pub mod diff;
 pub mod index;
 pub mod print_config;
+pub mod rules;
+pub mod serve;
+pub mod validate;
 pub mod version;
+pub mod watch;
+
+use std::io::IsTerminal;
+
+/// Whether reports printed to stdout are colored: only when stdout is a
+/// terminal, `NO_COLOR` is unset or empty, and `--no-color` was not given.
+pub fn stdout_color(no_color: bool) -> bool {
+    !no_color
+        && std::env::var_os("NO_COLOR").unwrap_or_default().is_empty()
+        && std::io::stdout().is_terminal()
+}
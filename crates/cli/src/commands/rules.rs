@@ -0,0 +1,136 @@
+//! The `rules` subcommand.
+
+use clap::{Args, Subcommand, ValueEnum};
+use engine::config::RulesConfig;
+use engine::scanner::{owasp_name, registered_rules, rule_info, RuleInfo};
+use serde_json::json;
+
+#[derive(Args, Debug, Clone)]
+pub struct RulesArgs {
+    #[command(subcommand)]
+    pub command: RulesCommand,
+}
+
+#[derive(Subcommand, Debug, Clone)]
+pub enum RulesCommand {
+    /// Lists every rule with its default severity, category and description.
+    List(ListArgs),
+    /// Explains a rule, with an example of code it flags and how to fix it.
+    Explain(ExplainArgs),
+}
+
+#[derive(Clone, ValueEnum, Debug)]
+pub enum ListFormat {
+    Text,
+    Json,
+}
+
+#[derive(Args, Debug, Clone)]
+pub struct ListArgs {
+    /// Output format for the rule list.
+    #[arg(long, value_enum, default_value = "text")]
+    pub format: ListFormat,
+}
+
+#[derive(Args, Debug, Clone)]
+pub struct ExplainArgs {
+    /// The rule id, as used in `[rules.<id>]` and suppression comments.
+    pub id: String,
+}
+
+/// Executes the `rules` subcommand. Rules and their defaults come from the
+/// scanner registry, so the output matches what `check` runs.
+pub fn run(args: RulesArgs) -> anyhow::Result<()> {
+    match args.command {
+        RulesCommand::List(args) => list(args),
+        RulesCommand::Explain(args) => explain(args),
+    }
+}
+
+/// Returns the default severity of a rule.
+fn default_severity(info: &RuleInfo) -> &'static str {
+    RulesConfig::default()
+        .severity(info.id)
+        .map_or("-", |severity| severity.as_str())
+}
+
+fn list(args: ListArgs) -> anyhow::Result<()> {
+    let rules = registered_rules();
+    match args.format {
+        ListFormat::Json => {
+            let entries: Vec<_> = rules
+                .iter()
+                .map(|info| {
+                    json!({
+                        "id": info.id,
+                        "default_severity": default_severity(info),
+                        "category": info.category,
+                        "short_description": info.short_description,
+                        "help_uri": info.help_uri,
+                        "cwe": info.cwe,
+                        "owasp": info.owasp,
+                    })
+                })
+                .collect();
+            println!("{}", serde_json::to_string_pretty(&entries)?);
+        }
+        ListFormat::Text => {
+            let width = rules.iter().map(|info| info.id.len()).max().unwrap_or(0);
+            println!(
+                "{:width$}  {:8}  {:11}  DESCRIPTION",
+                "ID",
+                "SEVERITY",
+                "CATEGORY",
+                width = width
+            );
+            for info in &rules {
+                println!(
+                    "{:width$}  {:8}  {:11}  {}",
+                    info.id,
+                    default_severity(info),
+                    info.category.as_str(),
+                    info.short_description,
+                    width = width
+                );
+            }
+        }
+    }
+    Ok(())
+}
+
+fn explain(args: ExplainArgs) -> anyhow::Result<()> {
+    let info = rule_info(&args.id).ok_or_else(|| {
+        anyhow::anyhow!(
+            "unknown rule `{}`; run `reviewlens rules list` to see the available rules",
+            args.id
+        )
+    })?;
+    println!("{}: {}", info.id, info.short_description);
+    println!();
+    println!("Category: {}", info.category.as_str());
+    println!("Default severity: {}", default_severity(&info));
+    if !info.cwe.is_empty() {
+        println!("CWE: {}", info.cwe.join(", "));
+    }
+    if let Some(owasp) = info.owasp {
+        match owasp_name(owasp) {
+            Some(name) => println!("OWASP Top 10: {} {}", owasp, name),
+            None => println!("OWASP Top 10: {}", owasp),
+        }
+    }
+    println!();
+    println!("{}", info.description);
+    println!();
+    println!("Example:");
+    println!();
+    for line in info.example.lines() {
+        println!("    {}", line);
+    }
+    println!();
+    println!("Remediation:");
+    println!();
+    println!("{}", info.remediation);
+    println!();
+    println!("Documentation: {}", info.help_uri);
+    Ok(())
+}
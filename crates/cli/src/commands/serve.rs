@@ -0,0 +1,394 @@
+//! The `serve` subcommand.
+//!
+//! A small HTTP/1.1 server exposing the analyzer to editors and CI jobs that
+//! share one instance. Each connection carries a single request and is closed
+//! after the response.
+
+use std::sync::Arc;
+use std::time::Duration;
+
+use anyhow::Context;
+use clap::Args;
+use engine::analyzer::{Analyzer, CancellationToken, SourceFile};
+use engine::config::Config;
+use engine::redact_text;
+use serde::Deserialize;
+use serde_json::json;
+use tokio::io::{AsyncReadExt, AsyncWriteExt};
+use tokio::net::{TcpListener, TcpStream};
+use tokio::sync::Semaphore;
+
+/// The most bytes read for the request line and headers.
+const MAX_HEAD_BYTES: usize = 16 * 1024;
+
+/// How long a client may take to send the head or the body of its request.
+const READ_TIMEOUT: Duration = Duration::from_secs(30);
+
+#[derive(Args, Debug, Clone)]
+pub struct ServeArgs {
+    /// The address to listen on. A bare `:PORT`, as in `:8080`, listens on
+    /// every interface.
+    #[arg(long, value_name = "ADDR", default_value = "127.0.0.1:8080")]
+    pub addr: String,
+
+    /// The largest request body accepted, in bytes. Larger requests are
+    /// rejected with `413 Payload Too Large` before their body is read.
+    #[arg(long, value_name = "BYTES", default_value_t = 10 * 1024 * 1024)]
+    pub max_body_size: usize,
+
+    /// Number of scan requests handled at once. Further requests wait for a
+    /// free slot before their body is read, so at most this many bodies are
+    /// held in memory.
+    #[arg(long, value_name = "N", default_value_t = 4)]
+    pub max_concurrent: usize,
+
+    /// Number of connections served at once. Further connections wait in
+    /// the listen queue until one closes, so slow clients cannot hold an
+    /// unbounded number of sockets and header buffers.
+    #[arg(long, value_name = "N", default_value_t = 256)]
+    pub max_connections: usize,
+
+    /// Seconds the scanners may spend on one file of a request before it is
+    /// reported as timed out. Defaults to the `[scan]` setting, or 30; `0`
+    /// disables the limit.
+    #[arg(long, value_name = "SECONDS")]
+    pub file_timeout: Option<u64>,
+}
+
+/// The body of `POST /scan`.
+#[derive(Deserialize)]
+struct ScanRequest {
+    files: Vec<RequestFile>,
+}
+
+#[derive(Deserialize)]
+struct RequestFile {
+    /// The path the findings are reported under. It is never read from disk.
+    filename: String,
+    source: String,
+}
+
+/// A response, always sent as JSON.
+struct Response {
+    status: u16,
+    body: serde_json::Value,
+    allow: Option<&'static str>,
+}
+
+impl Response {
+    fn ok(body: serde_json::Value) -> Self {
+        Self {
+            status: 200,
+            body,
+            allow: None,
+        }
+    }
+
+    fn error(status: u16, message: impl Into<String>) -> Self {
+        Self {
+            status,
+            body: json!({ "error": message.into() }),
+            allow: None,
+        }
+    }
+
+    fn method_not_allowed(allow: &'static str) -> Self {
+        Self {
+            allow: Some(allow),
+            ..Self::error(405, format!("only {} is supported", allow))
+        }
+    }
+}
+
+/// The request line and headers of a request, and any body bytes read with
+/// them.
+struct Head {
+    method: String,
+    path: String,
+    content_length: Option<usize>,
+    /// Whether a `Transfer-Encoding` header was sent. No transfer coding is
+    /// supported, so such requests are refused.
+    transfer_encoding: bool,
+    /// Whether the client waits for `100 Continue` before sending the body.
+    expect_continue: bool,
+    rest: Vec<u8>,
+}
+
+struct Server {
+    config: Config,
+    analyzer: Analyzer,
+    max_body_size: usize,
+    scans: Semaphore,
+}
+
+/// Turns `:PORT` into an address on every interface.
+fn listen_addr(addr: &str) -> String {
+    match addr.strip_prefix(':') {
+        Some(port) => format!("0.0.0.0:{}", port),
+        None => addr.to_string(),
+    }
+}
+
+/// Serves `POST /scan` and `GET /healthz` on `args.addr` until interrupted.
+pub async fn run(args: ServeArgs, config: Config) -> anyhow::Result<()> {
+    let addr = listen_addr(&args.addr);
+    let listener = TcpListener::bind(&addr)
+        .await
+        .with_context(|| format!("failed to listen on {}", addr))?;
+    let server = Arc::new(Server {
+        analyzer: Analyzer::new(config.clone()),
+        config,
+        max_body_size: args.max_body_size,
+        scans: Semaphore::new(args.max_concurrent.max(1)),
+    });
+    let connections = Arc::new(Semaphore::new(args.max_connections.max(1)));
+    println!("Listening on http://{}", listener.local_addr()?);
+
+    loop {
+        let permit = tokio::select! {
+            permit = Arc::clone(&connections).acquire_owned() => permit?,
+            _ = tokio::signal::ctrl_c() => return Ok(()),
+        };
+        let stream = tokio::select! {
+            accepted = listener.accept() => match accepted {
+                Ok((stream, _)) => stream,
+                Err(e) => {
+                    log::warn!("Failed to accept a connection: {}", e);
+                    continue;
+                }
+            },
+            _ = tokio::signal::ctrl_c() => return Ok(()),
+        };
+        let server = Arc::clone(&server);
+        tokio::spawn(async move {
+            if let Err(e) = server.handle(stream).await {
+                log::debug!("Connection closed with an error: {}", e);
+            }
+            drop(permit);
+        });
+    }
+}
+
+impl Server {
+    /// Reads one request from `stream`, answers it and closes the connection.
+    async fn handle(self: Arc<Self>, mut stream: TcpStream) -> std::io::Result<()> {
+        let response = self.respond(&mut stream).await;
+        let body = redact_text(&self.config, &response.body.to_string());
+        let mut out = format!(
+            "HTTP/1.1 {} {}\r\nContent-Type: application/json\r\nContent-Length: {}\r\nConnection: close\r\n",
+            response.status,
+            reason(response.status),
+            body.len()
+        );
+        if let Some(allow) = response.allow {
+            out.push_str(&format!("Allow: {}\r\n", allow));
+        }
+        out.push_str("\r\n");
+        out.push_str(&body);
+        stream.write_all(out.as_bytes()).await?;
+        stream.shutdown().await
+    }
+
+    async fn respond(self: &Arc<Self>, stream: &mut TcpStream) -> Response {
+        let head = match tokio::time::timeout(READ_TIMEOUT, read_head(stream)).await {
+            Ok(Ok(head)) => head,
+            Ok(Err(response)) => return response,
+            Err(_) => return timed_out(),
+        };
+        match (head.path.as_str(), head.method.as_str()) {
+            ("/healthz", "GET") => Response::ok(json!({ "status": "ok" })),
+            ("/healthz", _) => Response::method_not_allowed("GET"),
+            ("/scan", "POST") => self.scan(stream, head).await,
+            ("/scan", _) => Response::method_not_allowed("POST"),
+            _ => Response::error(404, format!("no such endpoint: {}", head.path)),
+        }
+    }
+
+    /// Reads the body of a `POST /scan` once a scan slot is free and returns
+    /// the findings for its files.
+    async fn scan(self: &Arc<Self>, stream: &mut TcpStream, head: Head) -> Response {
+        if head.transfer_encoding {
+            return Response::error(
+                411,
+                "Transfer-Encoding is not supported; send Content-Length",
+            );
+        }
+        let length = match head.content_length {
+            Some(length) => length,
+            None => return Response::error(411, "Content-Length is required"),
+        };
+        if length > self.max_body_size {
+            return Response::error(
+                413,
+                format!(
+                    "the body is {} bytes; at most {} are accepted",
+                    length, self.max_body_size
+                ),
+            );
+        }
+        let _permit = match self.scans.acquire().await {
+            Ok(permit) => permit,
+            Err(_) => return Response::error(503, "the server is shutting down"),
+        };
+
+        if head.expect_continue
+            && stream
+                .write_all(b"HTTP/1.1 100 Continue\r\n\r\n")
+                .await
+                .is_err()
+        {
+            return Response::error(400, "the connection was closed");
+        }
+        let mut body = head.rest;
+        body.truncate(length);
+        if body.len() < length {
+            let start = body.len();
+            body.resize(length, 0);
+            match tokio::time::timeout(READ_TIMEOUT, stream.read_exact(&mut body[start..])).await {
+                Ok(Ok(_)) => {}
+                Ok(Err(_)) => {
+                    return Response::error(400, "the body is shorter than its Content-Length")
+                }
+                Err(_) => return timed_out(),
+            }
+        }
+        let request: ScanRequest = match serde_json::from_slice(&body) {
+            Ok(request) => request,
+            Err(e) => return Response::error(400, format!("invalid request body: {}", e)),
+        };
+        drop(body);
+        if let Some(i) = request.files.iter().position(|f| f.filename.is_empty()) {
+            return Response::error(400, format!("files[{}] has an empty filename", i));
+        }
+        let files: Vec<SourceFile> = request
+            .files
+            .into_iter()
+            .map(|file| SourceFile {
+                path: file.filename,
+                content: file.source.into_bytes(),
+            })
+            .collect();
+
+        let cancel = CancellationToken::new();
+        let (server, token) = (Arc::clone(self), cancel.clone());
+        let mut scan =
+            tokio::task::spawn_blocking(move || server.analyzer.scan_sources(&files, &token));
+        let scanned = tokio::select! {
+            scanned = &mut scan => scanned,
+            _ = closed(stream) => {
+                log::debug!("The client disconnected; cancelling its scan");
+                cancel.cancel();
+                // Hold the scan slot until the scan stops, after the file
+                // being scanned.
+                let _ = scan.await;
+                return Response::error(400, "the connection was closed");
+            }
+        };
+        match scanned {
+            Ok(Ok(findings)) => Response::ok(json!({ "findings": findings })),
+            Ok(Err(e)) => {
+                log::error!("Scan failed: {}", e);
+                Response::error(500, e.to_string())
+            }
+            Err(e) => {
+                log::error!("Scan task failed: {}", e);
+                Response::error(500, "the scan failed")
+            }
+        }
+    }
+}
+
+/// Completes when the client closes the connection. Anything it sends after
+/// the body is discarded.
+async fn closed(stream: &mut TcpStream) {
+    let mut buf = [0u8; 1024];
+    while let Ok(n) = stream.read(&mut buf).await {
+        if n == 0 {
+            return;
+        }
+    }
+}
+
+/// Reads the request line and headers.
+async fn read_head(stream: &mut TcpStream) -> Result<Head, Response> {
+    let mut buf = Vec::new();
+    let mut chunk = [0u8; 4096];
+    let end = loop {
+        if let Some(end) = buf.windows(4).position(|w| w == b"\r\n\r\n") {
+            break end;
+        }
+        if buf.len() > MAX_HEAD_BYTES {
+            return Err(Response::error(431, "the request headers are too large"));
+        }
+        match stream.read(&mut chunk).await {
+            Ok(0) | Err(_) => return Err(Response::error(400, "incomplete request")),
+            Ok(n) => buf.extend_from_slice(&chunk[..n]),
+        }
+    };
+    let text = std::str::from_utf8(&buf[..end])
+        .map_err(|_| Response::error(400, "the request headers are not valid UTF-8"))?;
+    let mut lines = text.split("\r\n");
+    let mut parts = lines.next().unwrap_or_default().split(' ');
+    let (method, target) = match (parts.next(), parts.next(), parts.next()) {
+        (Some(method), Some(target), Some(version)) if version.starts_with("HTTP/1.") => {
+            (method, target)
+        }
+        _ => return Err(Response::error(400, "malformed request line")),
+    };
+    let mut head = Head {
+        method: method.to_string(),
+        path: target.split('?').next().unwrap_or_default().to_string(),
+        content_length: None,
+        transfer_encoding: false,
+        expect_continue: false,
+        rest: buf[end + 4..].to_vec(),
+    };
+    for line in lines {
+        let (name, value) = match line.split_once(':') {
+            Some((name, value)) => (name.trim(), value.trim()),
+            None => return Err(Response::error(400, "malformed header")),
+        };
+        if name.eq_ignore_ascii_case("content-length") {
+            let length = value
+                .parse()
+                .map_err(|_| Response::error(400, "invalid Content-Length"))?;
+            // RFC 9112 §6.3: differing lengths make the message invalid.
+            if head.content_length.is_some_and(|other| other != length) {
+                return Err(Response::error(400, "conflicting Content-Length headers"));
+            }
+            head.content_length = Some(length);
+        } else if name.eq_ignore_ascii_case("transfer-encoding") {
+            head.transfer_encoding = true;
+        } else if name.eq_ignore_ascii_case("expect") {
+            head.expect_continue = value.eq_ignore_ascii_case("100-continue");
+        }
+    }
+    // A message with both could be framed either way; refuse it rather
+    // than pick one (RFC 9112 §6.3).
+    if head.transfer_encoding && head.content_length.is_some() {
+        return Err(Response::error(
+            400,
+            "Content-Length and Transfer-Encoding must not be sent together",
+        ));
+    }
+    Ok(head)
+}
+
+fn timed_out() -> Response {
+    Response::error(408, "the request was not received in time")
+}
+
+fn reason(status: u16) -> &'static str {
+    match status {
+        200 => "OK",
+        400 => "Bad Request",
+        404 => "Not Found",
+        405 => "Method Not Allowed",
+        408 => "Request Timeout",
+        411 => "Length Required",
+        413 => "Payload Too Large",
+        431 => "Request Header Fields Too Large",
+        503 => "Service Unavailable",
+        _ => "Internal Server Error",
+    }
+}
@@ -0,0 +1,59 @@
+//! The `validate` subcommand.
+
+use std::io::Read;
+
+use anyhow::Context;
+use clap::Args;
+use engine::report::schema::{validate_report, REPORT_SCHEMA, REPORT_SCHEMA_VERSION};
+use serde_json::Value;
+
+#[derive(Args, Debug, Clone)]
+pub struct ValidateArgs {
+    /// The JSON report to check, or `-` to read it from stdin.
+    #[arg(value_name = "REPORT", required_unless_present = "print_schema")]
+    pub report: Option<String>,
+
+    /// Print the embedded report schema instead of validating a report.
+    #[arg(long, default_value_t = false, conflicts_with = "report")]
+    pub print_schema: bool,
+}
+
+/// Checks a JSON report against the embedded schema. Exits with status 1
+/// when the report does not match.
+pub fn run(args: ValidateArgs) -> anyhow::Result<()> {
+    if args.print_schema {
+        print!("{}", REPORT_SCHEMA);
+        return Ok(());
+    }
+    let path = args.report.unwrap_or_default();
+    let content = if path == "-" {
+        let mut content = String::new();
+        std::io::stdin()
+            .read_to_string(&mut content)
+            .context("failed to read the report from stdin")?;
+        content
+    } else {
+        std::fs::read_to_string(&path).with_context(|| format!("failed to read {}", path))?
+    };
+    let report: Value =
+        serde_json::from_str(&content).with_context(|| format!("{} is not valid JSON", path))?;
+
+    let violations = validate_report(&report);
+    if violations.is_empty() {
+        println!(
+            "{}: valid report (schema version {})",
+            path, REPORT_SCHEMA_VERSION
+        );
+        return Ok(());
+    }
+    for violation in &violations {
+        eprintln!("{}: {}", path, violation);
+    }
+    eprintln!(
+        "{}: {} schema violation(s) against schema version {}",
+        path,
+        violations.len(),
+        REPORT_SCHEMA_VERSION
+    );
+    std::process::exit(1);
+}
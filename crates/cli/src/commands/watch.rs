@@ -0,0 +1,154 @@
+//! The `watch` subcommand.
+
+use std::collections::BTreeMap;
+use std::env;
+use std::fs;
+use std::time::Duration;
+
+use anyhow::Context;
+use clap::Args;
+use engine::analyzer::SourceFile;
+use engine::redact_text;
+use engine::report::{ReportGenerator, TextGenerator};
+use engine::scanner::Issue;
+use engine::watch::{with_package_mates, TreeWatcher};
+use engine::ReviewEngine;
+
+#[derive(Args, Debug, Clone)]
+pub struct WatchArgs {
+    /// The tree to watch. A trailing `/...`, as in `./...`, is accepted.
+    #[arg(default_value = ".")]
+    pub path: String,
+
+    /// Milliseconds to wait after a change for further changes before
+    /// re-scanning.
+    #[arg(long, value_name = "MS", default_value_t = 200)]
+    pub debounce_ms: u64,
+
+    /// Milliseconds between polls of the tree for changes, when filesystem
+    /// notifications are unavailable.
+    #[arg(long, value_name = "MS", default_value_t = 250)]
+    pub interval_ms: u64,
+}
+
+impl WatchArgs {
+    /// The directory to watch.
+    pub fn root(&self) -> &str {
+        match self.path.strip_suffix("...") {
+            Some("") => ".",
+            Some(root) => root
+                .strip_suffix('/')
+                .filter(|r| !r.is_empty())
+                .unwrap_or("/"),
+            None => &self.path,
+        }
+    }
+}
+
+/// Findings of the watched tree, by file.
+type Findings = BTreeMap<String, Vec<Issue>>;
+
+/// Scans the tree, then re-scans the files that change, with the other Go
+/// files of their packages, until interrupted. Unchanged files are served
+/// from the analysis cache.
+pub async fn run(args: WatchArgs, engine: &ReviewEngine) -> anyhow::Result<()> {
+    let root = args.root().to_string();
+    env::set_current_dir(&root)
+        .with_context(|| format!("failed to change to directory {}", root))?;
+    let interval = Duration::from_millis(args.interval_ms.max(1));
+    let debounce = Duration::from_millis(args.debounce_ms);
+
+    let mut watcher = TreeWatcher::new(".", &engine.config().paths)?;
+    let mut findings = Findings::new();
+    let files = watcher.files();
+    println!("Scanning {} file(s) in {}", files.len(), root);
+    rescan(engine, &files, &mut findings).await?;
+    if watcher.is_polling() {
+        println!(
+            "Watching {} for changes by polling every {} ms; press Ctrl-C to stop.",
+            root,
+            interval.as_millis()
+        );
+    } else {
+        println!("Watching {} for changes; press Ctrl-C to stop.", root);
+    }
+
+    loop {
+        let changes = tokio::task::block_in_place(|| watcher.wait(interval, debounce))?;
+        for path in &changes.removed {
+            findings.remove(path);
+        }
+        let files = watcher.files();
+        let scan = with_package_mates(&changes.modified, &files);
+        let mut changed: Vec<&String> = changes.modified.iter().chain(&changes.removed).collect();
+        changed.sort();
+        println!();
+        println!(
+            "Changed: {}",
+            changed
+                .iter()
+                .map(|path| path.as_str())
+                .collect::<Vec<_>>()
+                .join(", ")
+        );
+        if scan.is_empty() {
+            print_total(&findings);
+            continue;
+        }
+        rescan(engine, &scan, &mut findings).await?;
+    }
+}
+
+/// Scans `paths`, replaces their findings and prints the result.
+async fn rescan(
+    engine: &ReviewEngine,
+    paths: &[String],
+    findings: &mut Findings,
+) -> anyhow::Result<()> {
+    // A file can disappear between the poll and the read; it is picked up
+    // as removed on the next poll.
+    let sources: Vec<SourceFile> = paths
+        .iter()
+        .filter_map(|path| {
+            fs::read(path).ok().map(|content| SourceFile {
+                path: path.clone(),
+                content,
+            })
+        })
+        .collect();
+    let report = engine
+        .run_tree(&sources, |_| {})
+        .await
+        .map_err(|e| anyhow::anyhow!(e))?;
+    for path in paths {
+        findings.remove(path);
+    }
+    for issue in &report.issues {
+        findings
+            .entry(issue.file_path.clone())
+            .or_default()
+            .push(issue.clone());
+    }
+    let text = TextGenerator {
+        quiet: false,
+        color: super::stdout_color(false),
+        ..Default::default()
+    }
+    .generate(&report)
+    .map_err(|e| anyhow::anyhow!(e))?;
+    print!("{}", redact_text(engine.config(), &text));
+    print_total(findings);
+    Ok(())
+}
+
+fn print_total(findings: &Findings) {
+    let total: usize = findings.values().map(Vec::len).sum();
+    println!(
+        "Tree total: {} finding(s) in {} file(s)",
+        total,
+        findings
+            .values()
+            .filter(|issues| !issues.is_empty())
+            .count()
+    );
+}
@@ -3,6 +3,7 @@
 use chrono::Utc;
 use clap::Parser;
 use engine::{
+    cache::AnalysisCache,
     config::{Config, IndexConfig, Provider},
     error::EngineError,
     ReviewEngine,
@@ -11,7 +12,7 @@ use env_logger::Target;
 use log::LevelFilter;
 use serde_json::json;
 use std::io::Write;
-use std::path::PathBuf;
+use std::path::{Path, PathBuf};
 
 mod commands;
 
@@ -24,9 +25,10 @@ struct Cli {
     #[arg(short, long, action = clap::ArgAction::Count)]
     verbose: u8,
 
-    /// Path to configuration file.
-    #[arg(long, value_name = "PATH", default_value = "reviewlens.toml")]
-    config: PathBuf,
+    /// Path to configuration file. When omitted, the closest `reviewlens.toml`
+    /// in the repository path or one of its parent directories is used.
+    #[arg(long, value_name = "PATH")]
+    config: Option<PathBuf>,
 
     /// Override the LLM provider.
     #[arg(long, value_enum, env = "REVIEWLENS_LLM_PROVIDER")]
@@ -84,13 +86,41 @@ struct Cli {
 #[derive(Parser, Debug)]
 enum Commands {
     /// Checks a diff for issues and generates a review report.
+    #[command(alias = "scan")]
     Check(commands::check::CheckArgs),
+    /// Compares the findings of two JSON reports.
+    Diff(commands::diff::DiffArgs),
     /// Manages the RAG index for a repository.
     Index(commands::index::IndexArgs),
     /// Prints the effective configuration, compiled providers, and resolved base reference.
     PrintConfig(commands::print_config::PrintConfigArgs),
+    /// Lists the available rules and explains what each one detects.
+    Rules(commands::rules::RulesArgs),
+    /// Serves the scanners over HTTP for editors and CI jobs.
+    Serve(commands::serve::ServeArgs),
+    /// Checks a JSON report against the embedded report schema.
+    Validate(commands::validate::ValidateArgs),
     /// Prints the CLI version.
     Version(commands::version::VersionArgs),
+    /// Scans a working tree, then re-scans the files that change.
+    Watch(commands::watch::WatchArgs),
+}
+
+impl Commands {
+    /// Returns the repository path the command operates on.
+    fn repo_path(&self) -> &str {
+        match self {
+            Commands::Check(args) => &args.path,
+            Commands::Index(args) => &args.path,
+            Commands::PrintConfig(args) => &args.path,
+            Commands::Watch(args) => args.root(),
+            Commands::Diff(_)
+            | Commands::Rules(_)
+            | Commands::Serve(_)
+            | Commands::Validate(_)
+            | Commands::Version(_) => ".",
+        }
+    }
 }
 
 #[tokio::main]
@@ -110,7 +140,14 @@ async fn main() -> anyhow::Result<()> {
     if matches!(cli.command, Commands::PrintConfig(_)) && cli.verbose == 0 {
         builder.filter_level(LevelFilter::Info);
     }
-    builder.target(Target::Stdout);
+    // Logs share stdout with the summary, unless a machine-readable report
+    // is printed there.
+    let report_on_stdout = matches!(&cli.command, Commands::Check(args) if args.report_on_stdout());
+    builder.target(if report_on_stdout {
+        Target::Stderr
+    } else {
+        Target::Stdout
+    });
     if ci_logs {
         builder.format(|f, record| {
             let ts = Utc::now().to_rfc3339();
@@ -130,22 +167,43 @@ async fn main() -> anyhow::Result<()> {
     if let Commands::Version(args) = &cli.command {
         return commands::version::run(args.clone());
     }
+    if let Commands::Rules(args) = &cli.command {
+        return commands::rules::run(args.clone());
+    }
+    if let Commands::Validate(args) = &cli.command {
+        return commands::validate::run(args.clone());
+    }
+    if let Commands::Diff(args) = &cli.command {
+        std::process::exit(commands::diff::run(args.clone()));
+    }
 
-    // Load configuration from the path specified in the CLI arguments.
-    // If the file doesn't exist, use the default configuration.
-    let mut config = if cli.config.exists() {
-        if !matches!(cli.command, Commands::PrintConfig(_)) {
-            log::info!("Loading configuration from: {:?}", cli.config);
+    // Load configuration from the path given on the command line, or discover
+    // it by walking up from the repository path. Without either, use the
+    // default configuration.
+    let quiet = matches!(cli.command, Commands::PrintConfig(_));
+    let config_path = cli
+        .config
+        .clone()
+        .or_else(|| Config::discover(Path::new(cli.command.repo_path())));
+    let mut config = match &config_path {
+        Some(path) => {
+            if !quiet {
+                log::info!("Loading configuration from: {:?}", path);
+            }
+            match Config::load_from_path(path) {
+                Ok(config) => config,
+                Err(e) => {
+                    log::error!("{}", e);
+                    std::process::exit(2);
+                }
+            }
         }
-        Config::load_from_path(&cli.config)?
-    } else {
-        if !matches!(cli.command, Commands::PrintConfig(_)) {
-            log::info!(
-                "Configuration file {:?} not found. Using default configuration.",
-                cli.config
-            );
+        None => {
+            if !quiet {
+                log::info!("No configuration file found. Using default configuration.");
+            }
+            Config::default()
         }
-        Config::default()
     };
 
     // Apply environment variable and CLI overrides.
@@ -182,6 +240,49 @@ async fn main() -> anyhow::Result<()> {
     if !cli.privacy_redaction_patterns.is_empty() {
         config.privacy.redaction.patterns = cli.privacy_redaction_patterns.clone();
     }
+    if let Commands::Check(args) = &cli.command {
+        if !args.include.is_empty() {
+            config.paths.allow = args.include.clone();
+        }
+        config.paths.deny.extend(args.exclude.iter().cloned());
+        if args.include_generated {
+            config.paths.include_generated = true;
+        }
+        if let Some(n) = args.concurrency {
+            config.scan.concurrency = Some(n);
+        }
+        if let Some(secs) = args.file_timeout {
+            config.scan.file_timeout = Some(secs);
+        }
+        if let Some(min) = args.min_confidence {
+            config.scan.min_confidence = Some(min);
+        }
+        if let Some(scope) = args.scope {
+            config.scan.scope = Some(scope);
+        }
+        if args.explain {
+            config.scan.explain = true;
+        }
+        if args.no_cache {
+            config.scan.cache_dir = None;
+        } else if let Some(dir) = &args.cache_dir {
+            config.scan.cache_dir = Some(dir.to_string_lossy().into_owned());
+        } else if config.scan.cache_dir.is_none() {
+            config.scan.cache_dir =
+                AnalysisCache::default_dir().map(|dir| dir.to_string_lossy().into_owned());
+        }
+    }
+    if let Commands::Serve(args) = &cli.command {
+        if let Some(secs) = args.file_timeout {
+            config.scan.file_timeout = Some(secs);
+        }
+    }
+    // Watching relies on the cache to skip the package mates of changed
+    // files that did not change themselves.
+    if matches!(cli.command, Commands::Watch(_)) && config.scan.cache_dir.is_none() {
+        config.scan.cache_dir =
+            AnalysisCache::default_dir().map(|dir| dir.to_string_lossy().into_owned());
+    }
 
     match cli.command {
         Commands::Check(args) => {
@@ -205,8 +306,15 @@ async fn main() -> anyhow::Result<()> {
         Commands::PrintConfig(args) => {
             commands::print_config::run(args, &config)?;
         }
-        Commands::Version(_) => {
-            // This case is handled above, but the compiler needs it to be exhaustive.
+        Commands::Watch(args) => {
+            let engine = ReviewEngine::new(config)?;
+            commands::watch::run(args, &engine).await?;
+        }
+        Commands::Serve(args) => {
+            commands::serve::run(args, config).await?;
+        }
+        Commands::Diff(_) | Commands::Rules(_) | Commands::Validate(_) | Commands::Version(_) => {
+            // These cases are handled above, but the compiler needs them to be exhaustive.
             unreachable!()
         }
     }
@@ -0,0 +1,73 @@
+use assert_cmd::Command;
+use serde_json::Value;
+use std::fs;
+use std::process::Command as StdCommand;
+use tempfile::tempdir;
+
+fn git(repo: &str, args: &[&str]) {
+    StdCommand::new("git")
+        .args(["-C", repo])
+        .args(args)
+        .output()
+        .expect("git command failed");
+}
+
+const HANDLER: &str = r#"package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func greet(w http.ResponseWriter, r *http.Request) {
+	user := r.URL.Query().Get("user")
+	fmt.Fprintf(w, "<p>"+user+"</p>")
+}
+"#;
+
+#[test]
+fn apply_fixes_rewrites_files_and_reports_the_fix() {
+    let temp = tempdir().unwrap();
+    let repo = temp.path();
+    let repo_str = repo.to_str().unwrap();
+
+    git(repo_str, &["init"]);
+    git(repo_str, &["config", "user.email", "you@example.com"]);
+    git(repo_str, &["config", "user.name", "Your Name"]);
+    fs::write(repo.join("README.md"), "hello\n").unwrap();
+    git(repo_str, &["add", "README.md"]);
+    git(repo_str, &["commit", "-m", "init"]);
+    fs::write(repo.join("server.go"), HANDLER).unwrap();
+    git(repo_str, &["add", "server.go"]);
+
+    let mut cmd = Command::cargo_bin("reviewlens").unwrap();
+    cmd.current_dir(repo);
+    cmd.args([
+        "check",
+        "--path",
+        repo_str,
+        "--diff",
+        "HEAD",
+        "--no-progress",
+        "--no-cache",
+        "--format",
+        "json",
+        "--output",
+        "report.json",
+        "--apply-fixes",
+    ]);
+    cmd.assert().code(1);
+
+    let report: Value =
+        serde_json::from_str(&fs::read_to_string(repo.join("report.json")).unwrap()).unwrap();
+    let issue = &report["issues"][0];
+    assert_eq!(issue["rule_id"], "xss-go");
+    assert_eq!(
+        issue["fix"]["description"],
+        "Escape `user` with html.EscapeString"
+    );
+
+    let fixed = fs::read_to_string(repo.join("server.go")).unwrap();
+    assert!(fixed.contains("fmt.Fprintf(w, \"<p>\"+html.EscapeString(user)+\"</p>\")"));
+    assert!(fixed.contains("\t\"html\"\n"));
+}
@@ -0,0 +1,97 @@
+use assert_cmd::Command;
+use serde_json::Value;
+use std::fs;
+use std::path::Path;
+use std::process::Command as StdCommand;
+use tempfile::tempdir;
+
+fn git(repo: &str, args: &[&str]) {
+    StdCommand::new("git")
+        .args(["-C", repo])
+        .args(args)
+        .output()
+        .expect("git command failed");
+}
+
+fn check(repo: &Path, extra: &[&str]) -> Command {
+    let repo_str = repo.to_str().unwrap();
+    let mut cmd = Command::cargo_bin("reviewlens").unwrap();
+    cmd.current_dir(repo);
+    cmd.args([
+        "check",
+        "--path",
+        repo_str,
+        "--diff",
+        "HEAD",
+        "--no-progress",
+        "--format",
+        "json",
+        "--output",
+        "report.json",
+        "--baseline",
+        "baseline.json",
+    ]);
+    cmd.args(extra);
+    cmd
+}
+
+#[test]
+fn baselined_findings_do_not_fail_but_new_ones_do() {
+    let temp = tempdir().unwrap();
+    let repo = temp.path();
+    let repo_str = repo.to_str().unwrap();
+
+    git(repo_str, &["init"]);
+    git(repo_str, &["config", "user.email", "you@example.com"]);
+    git(repo_str, &["config", "user.name", "Your Name"]);
+    fs::write(repo.join("config.txt"), "hello\n").unwrap();
+    git(repo_str, &["add", "config.txt"]);
+    git(repo_str, &["commit", "-m", "init"]);
+
+    // Record the existing secret in the baseline.
+    fs::write(
+        repo.join("config.txt"),
+        "hello\napi_key = \"ABCDEFGHIJKLMNOPQRSTUVWX\"\n",
+    )
+    .unwrap();
+    check(repo, &["--write-baseline"]).assert().code(0);
+    assert!(repo.join("baseline.json").exists());
+
+    // Lines inserted above the known finding must not invalidate it.
+    fs::write(
+        repo.join("config.txt"),
+        "# settings\nhello\napi_key = \"ABCDEFGHIJKLMNOPQRSTUVWX\"\n",
+    )
+    .unwrap();
+    check(repo, &[]).assert().code(0);
+    let report: Value =
+        serde_json::from_str(&fs::read_to_string(repo.join("report.json")).unwrap()).unwrap();
+    let issue = &report["issues"][0];
+    assert_eq!(issue["line_number"], 3);
+    assert_eq!(issue["severity"], "info");
+    assert_eq!(issue["baselined"], true);
+
+    // A genuinely new finding still fails the run.
+    fs::write(
+        repo.join("config.txt"),
+        "# settings\nhello\napi_key = \"ABCDEFGHIJKLMNOPQRSTUVWX\"\ntoken = \"ZYXWVUTSRQPONMLKJIHGFEDCBA\"\n",
+    )
+    .unwrap();
+    check(repo, &[]).assert().code(1);
+}
+
+#[test]
+fn missing_baseline_is_a_config_error() {
+    let temp = tempdir().unwrap();
+    let repo = temp.path();
+    let repo_str = repo.to_str().unwrap();
+
+    git(repo_str, &["init"]);
+    git(repo_str, &["config", "user.email", "you@example.com"]);
+    git(repo_str, &["config", "user.name", "Your Name"]);
+    fs::write(repo.join("file.txt"), "hello\n").unwrap();
+    git(repo_str, &["add", "."]);
+    git(repo_str, &["commit", "-m", "init"]);
+
+    check(repo, &[]).assert().code(2);
+}
@@ -0,0 +1,70 @@
+use assert_cmd::Command;
+use std::fs;
+use std::path::Path;
+use tempfile::tempdir;
+
+const DIFF: &str = "diff --git a/config.txt b/config.txt\n--- a/config.txt\n+++ b/config.txt\n@@ -0,0 +1 @@\n+api_key = \"ABCDEFGHIJKLMNOPQRSTUVWX\"\n";
+
+fn check(repo: &Path, extra: &[&str]) -> Command {
+    let mut cmd = Command::cargo_bin("reviewlens").unwrap();
+    cmd.current_dir(repo)
+        .args([
+            "check",
+            "--path",
+            repo.to_str().unwrap(),
+            "--diff-file",
+            "-",
+            "--no-progress",
+            "--output",
+            "report.md",
+        ])
+        .args(extra)
+        .write_stdin(DIFF);
+    cmd
+}
+
+/// Counts the files below `dir`.
+fn entries(dir: &Path) -> usize {
+    fs::read_dir(dir).map_or(0, |children| {
+        children
+            .flatten()
+            .map(|e| {
+                let path = e.path();
+                if path.is_dir() {
+                    entries(&path)
+                } else {
+                    1
+                }
+            })
+            .sum()
+    })
+}
+
+#[test]
+fn cache_dir_stores_results_and_no_cache_bypasses_it() {
+    let temp = tempdir().unwrap();
+    let repo = temp.path().join("repo");
+    fs::create_dir_all(&repo).unwrap();
+    fs::write(
+        repo.join("config.txt"),
+        "api_key = \"ABCDEFGHIJKLMNOPQRSTUVWX\"\n",
+    )
+    .unwrap();
+
+    let bypassed = temp.path().join("bypassed");
+    check(&repo, &["--no-cache"])
+        .env("XDG_CACHE_HOME", &bypassed)
+        .assert()
+        .code(1);
+    assert_eq!(entries(&bypassed), 0);
+
+    let cache_dir = temp.path().join("cache");
+    let cache_str = cache_dir.to_str().unwrap();
+    check(&repo, &["--cache-dir", cache_str]).assert().code(1);
+    assert_eq!(entries(&cache_dir), 1);
+
+    // A cached run reports the same findings.
+    check(&repo, &["--cache-dir", cache_str]).assert().code(1);
+    let report = fs::read_to_string(repo.join("report.md")).unwrap();
+    assert!(report.contains("config.txt:1"));
+}
@@ -0,0 +1,86 @@
+use assert_cmd::Command;
+use std::fs;
+use tempfile::tempdir;
+
+const SECRET: &str = "api_key = \"ABCDEFGHIJKLMNOPQRSTUVWX\"";
+
+fn secret_diff() -> String {
+    format!(
+        "diff --git a/config.txt b/config.txt\n--- a/config.txt\n+++ b/config.txt\n@@ -0,0 +1 @@\n+{}\n",
+        SECRET
+    )
+}
+
+/// Runs `check` in the `app` subdirectory of `repo`, so a configuration in
+/// `repo` is only found by walking up from the scanned path.
+fn check(repo: &std::path::Path, extra: &[&str]) -> Command {
+    let mut cmd = Command::cargo_bin("reviewlens").unwrap();
+    cmd.current_dir(repo.join("app"));
+    cmd.args(extra);
+    cmd.args([
+        "check",
+        "--path",
+        ".",
+        "--diff-file",
+        "-",
+        "--no-progress",
+        "--output",
+        repo.join("report.md").to_str().unwrap(),
+    ]);
+    cmd.write_stdin(secret_diff());
+    cmd
+}
+
+#[test]
+fn discovers_config_in_parent_of_scan_root() {
+    let temp = tempdir().unwrap();
+    let repo = temp.path();
+    fs::create_dir_all(repo.join("app")).unwrap();
+    fs::write(repo.join("app/config.txt"), format!("{}\n", SECRET)).unwrap();
+
+    check(repo, &[]).assert().code(1);
+
+    fs::write(
+        repo.join("reviewlens.toml"),
+        "[rules.secrets]\nenabled = false\n",
+    )
+    .unwrap();
+    check(repo, &[]).assert().code(0);
+}
+
+#[test]
+fn explicit_config_overrides_discovery() {
+    let temp = tempdir().unwrap();
+    let repo = temp.path();
+    fs::create_dir_all(repo.join("app")).unwrap();
+    fs::write(repo.join("app/config.txt"), format!("{}\n", SECRET)).unwrap();
+    fs::write(
+        repo.join("reviewlens.toml"),
+        "[rules.secrets]\nenabled = false\n",
+    )
+    .unwrap();
+    let explicit = repo.join("strict.toml");
+    fs::write(&explicit, "[rules.secrets]\nseverity = \"critical\"\n").unwrap();
+
+    check(repo, &["--config", explicit.to_str().unwrap()])
+        .assert()
+        .code(1);
+}
+
+#[test]
+fn unknown_rule_in_config_exits_with_config_error() {
+    let temp = tempdir().unwrap();
+    let repo = temp.path();
+    fs::create_dir_all(repo.join("app")).unwrap();
+    fs::write(repo.join("app/config.txt"), format!("{}\n", SECRET)).unwrap();
+    fs::write(
+        repo.join("reviewlens.toml"),
+        "[rules.secret]\nenabled = false\n",
+    )
+    .unwrap();
+
+    let output = check(repo, &[]).output().unwrap();
+    assert_eq!(output.status.code(), Some(2));
+    let stdout = String::from_utf8_lossy(&output.stdout);
+    assert!(stdout.contains("unknown field `secret`"), "{}", stdout);
+}
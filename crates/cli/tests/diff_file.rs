@@ -0,0 +1,79 @@
+use assert_cmd::Command;
+use std::fs;
+use tempfile::tempdir;
+
+const SECRET: &str = "api_key = \"ABCDEFGHIJKLMNOPQRSTUVWX\"";
+
+#[test]
+fn reads_diff_from_stdin_and_reports_only_changed_lines() {
+    let temp = tempdir().unwrap();
+    let repo = temp.path();
+    // The secret on line 1 predates the change; only line 3 is added.
+    fs::write(
+        repo.join("config.txt"),
+        format!(
+            "{}\nhello\n{}\n",
+            SECRET,
+            SECRET.replace("api_key", "API_KEY")
+        ),
+    )
+    .unwrap();
+    let diff = format!(
+        "diff --git a/config.txt b/config.txt\n--- a/config.txt\n+++ b/config.txt\n@@ -1,2 +1,3 @@\n {}\n hello\n+{}\n",
+        SECRET,
+        SECRET.replace("api_key", "API_KEY")
+    );
+
+    let mut cmd = Command::cargo_bin("reviewlens").unwrap();
+    let output = cmd
+        .current_dir(repo)
+        .args([
+            "check",
+            "--path",
+            repo.to_str().unwrap(),
+            "--diff-file",
+            "-",
+            "--no-progress",
+            "--output",
+            "report.md",
+        ])
+        .write_stdin(diff)
+        .output()
+        .expect("failed to execute command");
+
+    assert_eq!(output.status.code(), Some(1));
+    let report = fs::read_to_string(repo.join("report.md")).unwrap();
+    assert!(report.contains("config.txt:3"));
+    assert!(!report.contains("config.txt:1`"));
+}
+
+#[test]
+fn skips_deleted_files_from_diff_file() {
+    let temp = tempdir().unwrap();
+    let repo = temp.path();
+    let diff = format!(
+        "diff --git a/gone.txt b/gone.txt\ndeleted file mode 100644\n--- a/gone.txt\n+++ /dev/null\n@@ -1 +0,0 @@\n-{}\n",
+        SECRET
+    );
+    fs::write(repo.join("pr.diff"), diff).unwrap();
+
+    let mut cmd = Command::cargo_bin("reviewlens").unwrap();
+    let output = cmd
+        .current_dir(repo)
+        .args([
+            "check",
+            "--path",
+            repo.to_str().unwrap(),
+            "--diff-file",
+            "pr.diff",
+            "--no-progress",
+            "--output",
+            "report.md",
+        ])
+        .output()
+        .expect("failed to execute command");
+
+    assert!(output.status.success());
+    let stdout = String::from_utf8_lossy(&output.stdout);
+    assert!(stdout.contains("Reviewed 0 files"));
+}
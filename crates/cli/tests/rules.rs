@@ -0,0 +1,73 @@
+use assert_cmd::Command;
+use serde_json::Value;
+
+fn rules(args: &[&str]) -> Command {
+    let mut cmd = Command::cargo_bin("reviewlens").unwrap();
+    cmd.arg("rules").args(args);
+    cmd
+}
+
+#[test]
+fn list_prints_every_rule() {
+    let output = rules(&["list"]).output().unwrap();
+    assert!(output.status.success());
+    let stdout = String::from_utf8(output.stdout).unwrap();
+    let xss = stdout
+        .lines()
+        .find(|line| line.starts_with("xss-go "))
+        .expect("xss-go is listed");
+    assert!(xss.contains("high"));
+    assert!(xss.contains("security"));
+    assert!(stdout.contains("context-propagation-go"));
+}
+
+#[test]
+fn list_as_json() {
+    let output = rules(&["list", "--format", "json"]).output().unwrap();
+    assert!(output.status.success());
+    let entries: Value = serde_json::from_slice(&output.stdout).unwrap();
+    let timeouts = entries
+        .as_array()
+        .unwrap()
+        .iter()
+        .find(|e| e["id"] == "http-timeouts-go")
+        .expect("http-timeouts-go is listed");
+    assert_eq!(timeouts["default_severity"], "medium");
+    assert_eq!(timeouts["category"], "correctness");
+    assert!(timeouts["short_description"].is_string());
+    assert_eq!(timeouts["cwe"], serde_json::json!(["CWE-400"]));
+    assert!(timeouts["owasp"].is_null());
+
+    let xss = entries
+        .as_array()
+        .unwrap()
+        .iter()
+        .find(|e| e["id"] == "xss-go")
+        .expect("xss-go is listed");
+    assert_eq!(xss["cwe"], serde_json::json!(["CWE-79"]));
+    assert_eq!(xss["owasp"], "A03:2021");
+}
+
+#[test]
+fn explain_prints_example_and_remediation() {
+    let output = rules(&["explain", "sql-injection-go"]).output().unwrap();
+    assert!(output.status.success());
+    let stdout = String::from_utf8(output.stdout).unwrap();
+    assert!(stdout.contains("Default severity: critical"));
+    assert!(stdout.contains("CWE: CWE-89"));
+    assert!(stdout.contains("OWASP Top 10: A03:2021 Injection"));
+    assert!(stdout.contains("Example:"));
+    assert!(
+        stdout.contains("    rows, err := db.Query(\"SELECT name FROM users WHERE id = \" + id)")
+    );
+    assert!(stdout.contains("Remediation:"));
+}
+
+#[test]
+fn explain_rejects_unknown_rules() {
+    let output = rules(&["explain", "sql-injection"]).output().unwrap();
+    assert!(!output.status.success());
+    assert!(String::from_utf8(output.stderr)
+        .unwrap()
+        .contains("unknown rule `sql-injection`"));
+}
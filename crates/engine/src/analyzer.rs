@@ -0,0 +1,683 @@
+//! A programmatic API for running the scanners over files.
+//!
+//! [`Analyzer`] runs the enabled scanners without involving an LLM, a diff or
+//! any output, which makes it suitable for embedding in other services. The
+//! [`ReviewEngine`](crate::ReviewEngine) uses the same analyzer for its
+//! scanning stage, so results from both entry points always agree.
+
+use std::any::Any;
+use std::collections::HashSet;
+use std::fs;
+use std::panic::{self, AssertUnwindSafe};
+use std::path::Path;
+use std::sync::atomic::{AtomicBool, AtomicUsize, Ordering};
+use std::sync::{Arc, Mutex};
+use std::thread;
+use std::time::Instant;
+
+use serde::Serialize;
+
+use crate::cache::AnalysisCache;
+use crate::config::{AnalysisScope, Category, Confidence, Config, Severity};
+use crate::diff_parser::{self, ChangedFile};
+use crate::error::{EngineError, Result};
+use crate::paths::SeverityOverrides;
+use crate::scanner::{self, AnalysisContext, Issue, Scanner, ScopeIndex, TraceStep};
+
+/// A cloneable flag used to cancel a running scan. Cancellation is checked
+/// between files; a file that is already being scanned runs to completion.
+#[derive(Debug, Clone, Default)]
+pub struct CancellationToken {
+    cancelled: Arc<AtomicBool>,
+}
+
+impl CancellationToken {
+    /// Creates a token that has not been cancelled.
+    pub fn new() -> Self {
+        Self::default()
+    }
+
+    /// Requests cancellation of every scan using this token.
+    pub fn cancel(&self) {
+        self.cancelled.store(true, Ordering::SeqCst);
+    }
+
+    /// Returns `true` once [`CancellationToken::cancel`] has been called.
+    pub fn is_cancelled(&self) -> bool {
+        self.cancelled.load(Ordering::SeqCst)
+    }
+}
+
+/// A finding reported by [`Analyzer::scan_files`].
+#[derive(Debug, Clone, PartialEq, Eq, Serialize)]
+pub struct Finding {
+    pub rule_id: String,
+    pub severity: Severity,
+    pub category: Category,
+    pub confidence: Confidence,
+    pub message: String,
+    pub file: String,
+    /// 1-based line of the finding.
+    pub start_line: usize,
+    /// 1-based start column, if the rule reports one.
+    pub start_col: Option<usize>,
+    pub end_line: usize,
+    /// 1-based column just past the end of the finding, if known.
+    pub end_col: Option<usize>,
+    /// How request data reaches the finding, when `[scan] explain` is set.
+    #[serde(skip_serializing_if = "Vec::is_empty")]
+    pub trace: Vec<TraceStep>,
+}
+
+impl From<&Issue> for Finding {
+    fn from(issue: &Issue) -> Self {
+        Self {
+            rule_id: issue.rule_id.clone(),
+            severity: issue.severity.clone(),
+            category: issue.category,
+            confidence: issue.confidence,
+            message: format!("{}: {}", issue.title, issue.description),
+            file: issue.file_path.clone(),
+            start_line: issue.line_number,
+            start_col: issue.column,
+            end_line: issue.line_number,
+            end_col: issue.end_column,
+            trace: issue.trace.clone(),
+        }
+    }
+}
+
+/// A file held in memory, such as a blob read from a git tree.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SourceFile {
+    /// Repository-relative path of the file.
+    pub path: String,
+    pub content: Vec<u8>,
+}
+
+/// Findings and notes produced for a single file.
+#[derive(Default)]
+pub(crate) struct FileOutcome {
+    pub issues: Vec<Issue>,
+    pub code_quality: Vec<String>,
+    pub interactions: Vec<(String, String)>,
+    /// Whether the file was skipped without running the scanners.
+    pub skipped: bool,
+}
+
+/// Runs the enabled scanners over files.
+pub struct Analyzer {
+    config: Config,
+    scanners: Vec<Box<dyn Scanner>>,
+    cache: Option<AnalysisCache>,
+    severity_overrides: SeverityOverrides,
+}
+
+impl Analyzer {
+    /// Creates an analyzer running the scanners enabled in `config`. Results
+    /// are cached when `[scan].cache-dir` is set.
+    pub fn new(config: Config) -> Self {
+        let scanners = scanner::load_enabled_scanners(&config);
+        let cache = config
+            .scan
+            .cache_dir
+            .as_ref()
+            .map(|dir| AnalysisCache::new(dir, &config));
+        // Loaded configurations are validated, so this only fails for ones
+        // built in code.
+        let severity_overrides = SeverityOverrides::new(&config.path_severity_overrides)
+            .unwrap_or_else(|e| {
+                log::warn!("Ignoring path-severity-overrides: {}", e);
+                SeverityOverrides::default()
+            });
+        Self {
+            config,
+            scanners,
+            cache,
+            severity_overrides,
+        }
+    }
+
+    /// Returns the analyzer's configuration.
+    pub fn config(&self) -> &Config {
+        &self.config
+    }
+
+    /// Scans whole files and returns every finding, sorted by file, line,
+    /// column and rule id. Inline suppressions are honoured; path filters
+    /// from the configuration are not applied to `paths`.
+    pub fn scan_files<P>(&self, paths: &[P], cancel: &CancellationToken) -> Result<Vec<Finding>>
+    where
+        P: AsRef<Path> + Sync,
+    {
+        let file_paths: Vec<String> = paths
+            .iter()
+            .map(|path| path.as_ref().to_string_lossy().into_owned())
+            .collect();
+        let scope = self.scope_index(&file_paths)?;
+        let outcomes = run_pool(&file_paths, self.config.scan.workers(), cancel, |path| {
+            self.scan_file(path, None, &[], scope.as_ref())
+        });
+
+        let mut issues = Vec::new();
+        for outcome in outcomes {
+            issues.extend(outcome.ok_or(EngineError::Cancelled)??.issues);
+        }
+        sort_issues(&mut issues);
+        Ok(issues.iter().map(Finding::from).collect())
+    }
+
+    /// Scans the files of a diff, keeping only findings on added lines.
+    /// Results are returned in the order of `files`. Each file's sorted
+    /// findings are also passed to `on_scanned` as soon as the file is done,
+    /// from the worker that scanned it.
+    pub(crate) fn scan_changed(
+        &self,
+        files: &[ChangedFile],
+        cancel: &CancellationToken,
+        on_scanned: &(dyn Fn(&[Issue]) + Sync),
+    ) -> Result<Vec<FileOutcome>> {
+        let file_paths: Vec<String> = files.iter().map(|f| f.path.clone()).collect();
+        let scope = self.scope_index(&file_paths)?;
+        run_pool(files, self.config.scan.workers(), cancel, |file| {
+            let changed = changed_lines(file);
+            let mut outcome =
+                self.scan_file(&file.path, Some(&changed), &file_paths, scope.as_ref())?;
+            sort_issues(&mut outcome.issues);
+            if !outcome.issues.is_empty() {
+                on_scanned(&outcome.issues);
+            }
+            Ok(outcome)
+        })
+        .into_iter()
+        .map(|outcome| outcome.ok_or(EngineError::Cancelled)?)
+        .collect()
+    }
+
+    /// Scans files held in memory, reporting on every line. Files that are not
+    /// valid UTF-8 are skipped as binary. Results and callbacks follow
+    /// [`Analyzer::scan_changed`].
+    pub(crate) fn scan_tree(
+        &self,
+        files: &[&SourceFile],
+        cancel: &CancellationToken,
+        on_scanned: &(dyn Fn(&[Issue]) + Sync),
+    ) -> Result<Vec<FileOutcome>> {
+        let scope = self.memory_scope(files);
+        run_pool(files, self.config.scan.workers(), cancel, |file| {
+            let content = match std::str::from_utf8(&file.content) {
+                Ok(content) => content,
+                Err(_) => {
+                    log::debug!("Skipping binary file {}", file.path);
+                    return Ok(FileOutcome {
+                        skipped: true,
+                        ..Default::default()
+                    });
+                }
+            };
+            let mut outcome = self.scan_content(&file.path, content, None, &[], scope.as_ref())?;
+            sort_issues(&mut outcome.issues);
+            if !outcome.issues.is_empty() {
+                on_scanned(&outcome.issues);
+            }
+            Ok(outcome)
+        })
+        .into_iter()
+        .map(|outcome| outcome.ok_or(EngineError::Cancelled)?)
+        .collect()
+    }
+
+    /// Scans source buffers held in memory, such as the files of a request to
+    /// the HTTP API, and returns every finding sorted as in
+    /// [`Analyzer::scan_files`]. Nothing is read from disk: in the `package`
+    /// and `module` scopes, taint is followed between the given files only.
+    /// Each file is handled as in [`ReviewEngine::run_source`](crate::ReviewEngine::run_source),
+    /// so input that cannot be parsed is reported as a `parse-error` finding.
+    pub fn scan_sources(
+        &self,
+        files: &[SourceFile],
+        cancel: &CancellationToken,
+    ) -> Result<Vec<Finding>> {
+        let refs: Vec<&SourceFile> = files.iter().collect();
+        let scope = self.memory_scope(&refs);
+        let outcomes = run_pool(files, self.config.scan.workers(), cancel, |file| {
+            self.scan_source(&file.path, &file.content, scope.as_ref())
+        });
+
+        let mut issues = Vec::new();
+        for outcome in outcomes {
+            issues.extend(outcome.ok_or(EngineError::Cancelled)??.issues);
+        }
+        sort_issues(&mut issues);
+        Ok(issues.iter().map(Finding::from).collect())
+    }
+
+    /// Builds the scope index from files held in memory, skipping those that
+    /// are not valid UTF-8. Returns `None` in the `file` scope.
+    fn memory_scope(&self, files: &[&SourceFile]) -> Option<ScopeIndex> {
+        let scope = self.config.scan.scope();
+        if scope == AnalysisScope::File {
+            return None;
+        }
+        let sources: Vec<(String, String)> = files
+            .iter()
+            .filter_map(|file| {
+                let content = std::str::from_utf8(&file.content).ok()?;
+                Some((file.path.clone(), content.to_string()))
+            })
+            .collect();
+        Some(ScopeIndex::new(scope, &sources, &self.config))
+    }
+
+    /// Builds the index of the Go files that the Go files among `paths` can
+    /// call into: the other files of their packages in the `package` scope,
+    /// or every Go file the configuration includes in the `module` scope.
+    /// Returns `None` in the `file` scope.
+    fn scope_index(&self, paths: &[String]) -> Result<Option<ScopeIndex>> {
+        let scope = self.config.scan.scope();
+        let go_files = paths.iter().filter(|path| path.ends_with(".go"));
+        let mut sources: Vec<String> = match scope {
+            AnalysisScope::File => return Ok(None),
+            AnalysisScope::Package => {
+                let mut dirs: Vec<&Path> = go_files
+                    .clone()
+                    .map(|path| Path::new(path).parent().unwrap_or(Path::new("")))
+                    .collect();
+                dirs.sort();
+                dirs.dedup();
+                let mut sources = Vec::new();
+                for dir in dirs {
+                    let listed = if dir.as_os_str().is_empty() {
+                        fs::read_dir(".")
+                    } else {
+                        fs::read_dir(dir)
+                    };
+                    let entries = match listed {
+                        Ok(entries) => entries,
+                        Err(_) => continue,
+                    };
+                    for entry in entries.filter_map(|entry| entry.ok()) {
+                        let name = entry.file_name().to_string_lossy().into_owned();
+                        if name.ends_with(".go") {
+                            sources.push(dir.join(name).to_string_lossy().into_owned());
+                        }
+                    }
+                }
+                sources
+            }
+            AnalysisScope::Module => scanner::scope::module_files(&self.config)?,
+        };
+        sources.extend(go_files.cloned());
+        sources.sort();
+        sources.dedup();
+        // Files that were deleted or cannot be read have nothing to call.
+        let mut files: Vec<(String, String)> = sources
+            .into_iter()
+            .filter_map(|path| Some((path.clone(), fs::read_to_string(&path).ok()?)))
+            .collect();
+        if let Ok(content) = fs::read_to_string("go.mod") {
+            files.push(("go.mod".to_string(), content));
+        }
+        Ok(Some(ScopeIndex::new(scope, &files, &self.config)))
+    }
+
+    /// Reads one file and scans it with [`Analyzer::scan_content`].
+    fn scan_file(
+        &self,
+        path: &str,
+        changed: Option<&HashSet<usize>>,
+        file_paths: &[String],
+        scope: Option<&ScopeIndex>,
+    ) -> Result<FileOutcome> {
+        let content = fs::read_to_string(path)?;
+        self.scan_content(path, &content, changed, file_paths, scope)
+    }
+
+    /// Scans a source buffer that need not exist on disk, such as an unsaved
+    /// editor buffer, as the file at `path`. Every line is reported on. Input
+    /// that is not valid UTF-8, or Go code whose braces do not balance, adds
+    /// a single `parse-error` issue instead of failing; the scanners that do
+    /// not need balanced code still report their findings.
+    pub(crate) fn scan_source(
+        &self,
+        path: &str,
+        source: &[u8],
+        scope: Option<&ScopeIndex>,
+    ) -> Result<FileOutcome> {
+        let content = match std::str::from_utf8(source) {
+            Ok(content) => content,
+            Err(e) => {
+                let line = source[..e.valid_up_to()]
+                    .iter()
+                    .filter(|&&b| b == b'\n')
+                    .count()
+                    + 1;
+                return Ok(FileOutcome {
+                    issues: vec![parse_error(path, line, "the input is not valid UTF-8")],
+                    ..Default::default()
+                });
+            }
+        };
+        let mut outcome = self.scan_content(path, content, None, &[], scope)?;
+        if path.ends_with(".go") {
+            if let Some((line, message)) = scanner::taint::syntax_error(content) {
+                outcome.issues.push(parse_error(path, line, message));
+            }
+        }
+        sort_issues(&mut outcome.issues);
+        Ok(outcome)
+    }
+
+    /// Runs every scanner over the contents of one file. When `changed` is
+    /// given, only findings on those lines are kept. Findings below the
+    /// configured minimum confidence are dropped after suppressions are
+    /// applied.
+    ///
+    /// A scanner that panics does not abort the run; the panic is reported as
+    /// an `internal-error` issue for the file instead. Generated files are
+    /// skipped unless `[paths] include-generated` is set. With a `scope`,
+    /// the taint rules follow request data passed in from other files, and
+    /// cached findings are only reused while those files are unchanged.
+    fn scan_content(
+        &self,
+        path: &str,
+        content: &str,
+        changed: Option<&HashSet<usize>>,
+        file_paths: &[String],
+        scope: Option<&ScopeIndex>,
+    ) -> Result<FileOutcome> {
+        if !self.config.paths.include_generated && crate::paths::is_generated(content) {
+            log::debug!("Skipping generated file {}", path);
+            return Ok(FileOutcome {
+                skipped: true,
+                ..Default::default()
+            });
+        }
+        let on_changed_line = |line: usize| changed.map_or(true, |c| c.contains(&line));
+
+        let dependencies = scope.and_then(|scope| scope.digest(path));
+        let key = self.cache.as_ref().map(|cache| match dependencies {
+            Some(dependencies) => cache.key_with(path, content, dependencies),
+            None => cache.key(path, content),
+        });
+        let cached = self
+            .cache
+            .as_ref()
+            .zip(key.as_deref())
+            .and_then(|(cache, key)| cache.get(key));
+        let mut internal_errors = Vec::new();
+        let found = match cached {
+            Some(found) => {
+                log::debug!("Using cached findings for {}", path);
+                found
+            }
+            None => {
+                let found = self.run_scanners(path, content, scope, &mut internal_errors)?;
+                // Results from a run where a scanner panicked are incomplete.
+                if let (Some(cache), Some(key)) = (&self.cache, &key) {
+                    if internal_errors.is_empty() {
+                        cache.put(key, &found);
+                    }
+                }
+                found
+            }
+        };
+        // Several scanners can flag the same sink; each rule reports a line
+        // once.
+        let mut found = scanner::dedup_issues(found);
+        if !self.config.scan.explain {
+            for issue in &mut found {
+                issue.trace.clear();
+            }
+        }
+        for issue in &mut found {
+            // Scanners report the severity configured for their rule; path
+            // overrides adjust it afterwards, and baselines later still.
+            issue.severity = self
+                .severity_overrides
+                .apply(Path::new(path), &issue.severity);
+            let info = scanner::rule_info(&issue.rule_id);
+            issue.category = info.as_ref().map(|i| i.category).unwrap_or_default();
+            issue.cwe = info
+                .as_ref()
+                .map(|i| i.cwe.iter().map(|id| id.to_string()).collect())
+                .unwrap_or_default();
+            issue.owasp = info.and_then(|i| i.owasp).map(str::to_string);
+        }
+
+        // Inline suppressions are applied across all scanners before the
+        // results are limited to the diff hunks.
+        let (mut found, unused) = scanner::apply_ignore_directives(path, content, found);
+        // Fingerprints are computed over every finding in the file, not just
+        // the changed lines, so they match baselines written from full scans.
+        crate::baseline::assign_fingerprints(&mut found, content);
+
+        let mut code_quality = Vec::new();
+        for directive in unused.iter().filter(|d| on_changed_line(d.line)) {
+            log::warn!(
+                "Unused suppression `{}` at {}:{}",
+                directive.directive(),
+                path,
+                directive.line
+            );
+            code_quality.push(format!(
+                "{}:{} - Unused suppression `{}`: no matching finding on this or the next line.",
+                path,
+                directive.line,
+                directive.directive()
+            ));
+        }
+
+        found.retain(|issue| on_changed_line(issue.line_number));
+        if let Some(min) = self.config.scan.min_confidence {
+            found.retain(|issue| issue.confidence >= min);
+        }
+        let mut issues = internal_errors;
+        if changed.is_some() {
+            // In diff reviews, convention deviations are reported as code
+            // quality notes rather than issues.
+            for issue in found {
+                if issue.rule_id == "conventions" {
+                    code_quality.push(format!(
+                        "{}:{} - {}",
+                        issue.file_path, issue.line_number, issue.description
+                    ));
+                } else {
+                    issues.push(issue);
+                }
+            }
+        } else {
+            issues.extend(found);
+        }
+
+        let mut interactions = Vec::new();
+        for other in file_paths {
+            if other == path {
+                continue;
+            }
+            let stem = Path::new(other)
+                .file_stem()
+                .and_then(|s| s.to_str())
+                .unwrap_or("");
+            if content.contains(&format!("use {}", stem))
+                || content.contains(&format!("{}::", stem))
+            {
+                interactions.push((path.to_string(), other.clone()));
+            }
+        }
+
+        Ok(FileOutcome {
+            issues,
+            code_quality,
+            interactions,
+            skipped: false,
+        })
+    }
+
+    /// Runs every scanner over `content`. Panics are recorded in
+    /// `internal_errors` rather than aborting the run.
+    fn run_scanners(
+        &self,
+        path: &str,
+        content: &str,
+        scope: Option<&ScopeIndex>,
+        internal_errors: &mut Vec<Issue>,
+    ) -> Result<Vec<Issue>> {
+        // One context is shared so the views of the file it caches are
+        // computed once for all scanners.
+        let timeout = self.config.scan.file_timeout();
+        let ctx = AnalysisContext::new(path, content, &self.config)
+            .with_deadline(timeout.map(|timeout| Instant::now() + timeout))
+            .with_scope(scope);
+        let mut found = Vec::new();
+        for scanner in &self.scanners {
+            match panic::catch_unwind(AssertUnwindSafe(|| scanner.check(&ctx))) {
+                Ok(result) => found.append(&mut result?),
+                Err(payload) => {
+                    let message = panic_message(payload.as_ref());
+                    log::error!(
+                        "{} panicked while scanning {}: {}",
+                        scanner.name(),
+                        path,
+                        message
+                    );
+                    internal_errors.push(Issue {
+                        rule_id: "internal-error".to_string(),
+                        title: "Internal Scanner Error".to_string(),
+                        description: format!(
+                            "{} failed while scanning this file ({}); its findings for the file are missing.",
+                            scanner.name(),
+                            message
+                        ),
+                        file_path: path.to_string(),
+                        line_number: 1,
+                        severity: Severity::Low,
+                        confidence: Confidence::High,
+                        ..Default::default()
+                    });
+                }
+            }
+            if ctx.expired() {
+                let timeout = timeout.unwrap_or_default();
+                log::warn!(
+                    "{} exceeded the {}s time limit while scanning {}",
+                    scanner.name(),
+                    timeout.as_secs(),
+                    path
+                );
+                internal_errors.push(Issue {
+                    rule_id: "internal-error".to_string(),
+                    title: "Analysis Timed Out".to_string(),
+                    description: format!(
+                        "Analysis of this file exceeded the {}s limit while {} was running; its findings may be incomplete and the remaining scanners were skipped.",
+                        timeout.as_secs(),
+                        scanner.name()
+                    ),
+                    file_path: path.to_string(),
+                    line_number: 1,
+                    severity: Severity::Low,
+                    confidence: Confidence::High,
+                    ..Default::default()
+                });
+                break;
+            }
+        }
+        Ok(found)
+    }
+}
+
+/// Sorts issues by file path, line, column and rule id so output does not
+/// depend on worker scheduling.
+pub(crate) fn sort_issues(issues: &mut [Issue]) {
+    crate::report::sort_issues(issues, crate::report::SortKey::File);
+}
+
+/// The issue reported for source that could not be parsed.
+fn parse_error(path: &str, line_number: usize, message: &str) -> Issue {
+    Issue {
+        rule_id: "parse-error".to_string(),
+        title: "Parse Error".to_string(),
+        description: format!(
+            "The file could not be parsed: {}. Findings that depend on its structure may be missing.",
+            message
+        ),
+        file_path: path.to_string(),
+        line_number,
+        severity: Severity::Low,
+        confidence: Confidence::High,
+        ..Default::default()
+    }
+}
+
+/// Returns the new-file line numbers added by a diff.
+fn changed_lines(file: &ChangedFile) -> HashSet<usize> {
+    let mut changed = HashSet::new();
+    for hunk in &file.hunks {
+        let mut new_line = hunk.new_start as usize;
+        for line in &hunk.lines {
+            match line {
+                diff_parser::Line::Added(_) => {
+                    changed.insert(new_line);
+                    new_line += 1;
+                }
+                diff_parser::Line::Context(_) => {
+                    new_line += 1;
+                }
+                diff_parser::Line::Removed(_) => {}
+            }
+        }
+    }
+    changed
+}
+
+/// Applies `f` to every item using up to `workers` threads and returns the
+/// results in the order of `items`. Items not started before `cancel` is
+/// triggered are left as `None`.
+fn run_pool<T, R, F>(
+    items: &[T],
+    workers: usize,
+    cancel: &CancellationToken,
+    f: F,
+) -> Vec<Option<R>>
+where
+    T: Sync,
+    R: Send,
+    F: Fn(&T) -> R + Sync,
+{
+    let workers = workers.clamp(1, items.len().max(1));
+    let next = AtomicUsize::new(0);
+    let results: Mutex<Vec<Option<R>>> = Mutex::new((0..items.len()).map(|_| None).collect());
+    let work = || loop {
+        if cancel.is_cancelled() {
+            break;
+        }
+        let i = next.fetch_add(1, Ordering::Relaxed);
+        if i >= items.len() {
+            break;
+        }
+        let result = f(&items[i]);
+        results.lock().unwrap()[i] = Some(result);
+    };
+
+    if workers == 1 {
+        work();
+    } else {
+        thread::scope(|scope| {
+            for _ in 0..workers {
+                scope.spawn(&work);
+            }
+        });
+    }
+    results.into_inner().unwrap()
+}
+
+/// Extracts a readable message from a panic payload.
+fn panic_message(payload: &(dyn Any + Send)) -> String {
+    if let Some(s) = payload.downcast_ref::<&str>() {
+        s.to_string()
+    } else if let Some(s) = payload.downcast_ref::<String>() {
+        s.clone()
+    } else {
+        "unknown panic".to_string()
+    }
+}
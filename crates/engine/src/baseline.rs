@@ -0,0 +1,224 @@
+//! Baselines of known findings.
+//!
+//! A baseline records the findings present when a project adopts the agent
+//! so that later runs only fail on new ones. Findings are matched by rule id,
+//! file path and a fingerprint of the flagged code rather than by line
+//! number, so inserting unrelated lines above a finding or re-indenting it
+//! does not invalidate the baseline.
+//!
+//! The same matching compares the findings of two reports with [`compare`],
+//! for example a pull request's against its base branch's.
+
+use std::collections::{HashMap, HashSet};
+use std::fs;
+use std::path::Path;
+
+use serde::{Deserialize, Serialize};
+
+use crate::config::Severity;
+use crate::error::{EngineError, Result};
+use crate::scanner::Issue;
+
+/// Version of the on-disk baseline format.
+const BASELINE_VERSION: u32 = 1;
+
+/// A single finding recorded in a baseline.
+#[derive(Debug, Clone, PartialEq, Eq, Hash, Serialize, Deserialize)]
+pub struct BaselineEntry {
+    pub rule_id: String,
+    pub file_path: String,
+    pub fingerprint: String,
+}
+
+impl BaselineEntry {
+    /// Returns the entry recording `issue`, or `None` if it has no
+    /// fingerprint.
+    pub fn for_issue(issue: &Issue) -> Option<Self> {
+        Some(Self {
+            rule_id: issue.rule_id.clone(),
+            file_path: issue.file_path.clone(),
+            fingerprint: issue.fingerprint.clone()?,
+        })
+    }
+}
+
+/// The set of findings accepted as pre-existing.
+#[derive(Debug, Clone, Serialize, Deserialize)]
+pub struct Baseline {
+    pub version: u32,
+    pub findings: Vec<BaselineEntry>,
+}
+
+/// 64-bit FNV-1a. Used instead of `DefaultHasher` because baselines are
+/// persisted and the standard hasher is not stable across Rust releases.
+fn fnv1a(data: &str) -> u64 {
+    let mut hash: u64 = 0xcbf2_9ce4_8422_2325;
+    for byte in data.bytes() {
+        hash ^= u64::from(byte);
+        hash = hash.wrapping_mul(0x0000_0100_0000_01b3);
+    }
+    hash
+}
+
+/// Collapses all whitespace so indentation and spacing changes do not
+/// affect the fingerprint.
+fn normalize(line: &str) -> String {
+    line.split_whitespace().collect::<Vec<_>>().join(" ")
+}
+
+/// Returns the normalized text of the flagged line.
+fn flagged_code(content: &str, line_number: usize) -> String {
+    line_number
+        .checked_sub(1)
+        .and_then(|idx| content.lines().nth(idx))
+        .map(normalize)
+        .unwrap_or_default()
+}
+
+/// Sets the fingerprint of each issue found in a single file.
+///
+/// The fingerprint hashes the rule id, the file path and the normalized text
+/// of the flagged line; the line number itself is not part of it. When the
+/// same rule fires on identical code more than once in a file, the
+/// occurrence index (in line order) is mixed in so each finding keeps a
+/// distinct fingerprint.
+pub fn assign_fingerprints(issues: &mut [Issue], content: &str) {
+    let mut order: Vec<usize> = (0..issues.len()).collect();
+    order.sort_by_key(|&i| issues[i].line_number);
+
+    let mut seen: HashMap<u64, usize> = HashMap::new();
+    for i in order {
+        let issue = &issues[i];
+        let base = fnv1a(&format!(
+            "{}\n{}\n{}",
+            issue.rule_id,
+            issue.file_path,
+            flagged_code(content, issue.line_number)
+        ));
+        let occurrence = seen.entry(base).or_insert(0);
+        let hash = if *occurrence == 0 {
+            base
+        } else {
+            fnv1a(&format!("{:016x}#{}", base, occurrence))
+        };
+        *occurrence += 1;
+        issues[i].fingerprint = Some(format!("{:016x}", hash));
+    }
+}
+
+impl Baseline {
+    /// Builds a baseline from the fingerprinted issues of a run.
+    pub fn from_issues(issues: &[Issue]) -> Self {
+        let mut findings: Vec<BaselineEntry> =
+            issues.iter().filter_map(BaselineEntry::for_issue).collect();
+        findings.sort_by(|a, b| {
+            (&a.file_path, &a.rule_id, &a.fingerprint).cmp(&(
+                &b.file_path,
+                &b.rule_id,
+                &b.fingerprint,
+            ))
+        });
+        findings.dedup();
+        Self {
+            version: BASELINE_VERSION,
+            findings,
+        }
+    }
+
+    /// Loads a baseline from a JSON file.
+    pub fn load(path: &Path) -> Result<Self> {
+        let content = fs::read_to_string(path).map_err(|e| {
+            EngineError::Config(format!("failed to read baseline {}: {}", path.display(), e))
+        })?;
+        let baseline: Baseline = serde_json::from_str(&content).map_err(|e| {
+            EngineError::Config(format!("invalid baseline {}: {}", path.display(), e))
+        })?;
+        if baseline.version != BASELINE_VERSION {
+            return Err(EngineError::Config(format!(
+                "unsupported baseline version {} in {}",
+                baseline.version,
+                path.display()
+            )));
+        }
+        Ok(baseline)
+    }
+
+    /// Writes the baseline to a JSON file.
+    pub fn save(&self, path: &Path) -> Result<()> {
+        let json =
+            serde_json::to_string_pretty(self).map_err(|e| EngineError::Report(e.to_string()))?;
+        fs::write(path, json + "\n")?;
+        Ok(())
+    }
+
+    /// Marks issues present in the baseline as baselined and downgrades them
+    /// to informational. Returns the number of issues matched.
+    pub fn apply(&self, issues: &mut [Issue]) -> usize {
+        let known: HashSet<(&str, &str, &str)> = self
+            .findings
+            .iter()
+            .map(|f| {
+                (
+                    f.rule_id.as_str(),
+                    f.file_path.as_str(),
+                    f.fingerprint.as_str(),
+                )
+            })
+            .collect();
+        let mut matched = 0;
+        for issue in issues.iter_mut() {
+            let in_baseline = issue.fingerprint.as_deref().map_or(false, |fp| {
+                known.contains(&(issue.rule_id.as_str(), issue.file_path.as_str(), fp))
+            });
+            if in_baseline {
+                issue.baselined = true;
+                issue.severity = Severity::Info;
+                matched += 1;
+            }
+        }
+        matched
+    }
+}
+
+/// The findings of two runs, matched as a baseline matches them.
+#[derive(Debug, Clone, Default)]
+pub struct Comparison {
+    /// Findings of the new run that the old one did not have.
+    pub added: Vec<Issue>,
+    /// Findings of the old run that the new one no longer has.
+    pub fixed: Vec<Issue>,
+    /// Findings of the new run that the old one had as well.
+    pub unchanged: Vec<Issue>,
+}
+
+/// Returns the key two runs' findings are matched on. Findings without a
+/// fingerprint, which only come from hand-written reports, are matched on
+/// their line instead.
+fn match_key(issue: &Issue) -> BaselineEntry {
+    BaselineEntry::for_issue(issue).unwrap_or_else(|| BaselineEntry {
+        rule_id: issue.rule_id.clone(),
+        file_path: issue.file_path.clone(),
+        fingerprint: format!("line:{}", issue.line_number),
+    })
+}
+
+/// Compares the findings of an old and a new run. Each list keeps the order
+/// of the run it comes from.
+pub fn compare(old: &[Issue], new: &[Issue]) -> Comparison {
+    let old_keys: HashSet<BaselineEntry> = old.iter().map(match_key).collect();
+    let new_keys: HashSet<BaselineEntry> = new.iter().map(match_key).collect();
+    let (unchanged, added) = new
+        .iter()
+        .cloned()
+        .partition(|issue| old_keys.contains(&match_key(issue)));
+    let fixed = old
+        .iter()
+        .filter(|issue| !new_keys.contains(&match_key(issue)))
+        .cloned()
+        .collect();
+    Comparison {
+        added,
+        fixed,
+        unchanged,
+    }
+}
@@ -0,0 +1,137 @@
+//! On-disk cache of scanner results.
+//!
+//! Entries are keyed by the SHA-256 of the detector set version, the file
+//! path and the file contents, and hold the raw scanner findings for that
+//! file before suppressions, fingerprints or diff filtering are applied. The
+//! detector set version covers the engine version, the rule configuration and
+//! the RAG index used by the conventions rule, so upgrading the tool or
+//! changing any of them invalidates every entry. The path is part of the key
+//! because some rules depend on the file name (e.g. `*_test.go`).
+//!
+//! The cache is best effort: unreadable or corrupt entries are treated as
+//! misses and write failures are logged, never returned.
+
+use std::fs;
+use std::path::{Path, PathBuf};
+
+use sha2::{Digest, Sha256};
+
+use crate::config::Config;
+use crate::scanner::Issue;
+
+/// A directory of cached scanner results.
+#[derive(Debug, Clone)]
+pub struct AnalysisCache {
+    dir: PathBuf,
+    detector_version: String,
+}
+
+impl AnalysisCache {
+    /// Opens a cache in `dir` for the detectors enabled in `config`. The
+    /// directory is created on the first write.
+    pub fn new(dir: impl Into<PathBuf>, config: &Config) -> Self {
+        Self {
+            dir: dir.into(),
+            detector_version: detector_version(config),
+        }
+    }
+
+    /// Returns the platform's per-user cache directory for the agent, if one
+    /// can be determined from the environment.
+    pub fn default_dir() -> Option<PathBuf> {
+        let base = if cfg!(windows) {
+            std::env::var_os("LOCALAPPDATA").map(PathBuf::from)
+        } else if cfg!(target_os = "macos") {
+            std::env::var_os("HOME").map(|home| PathBuf::from(home).join("Library/Caches"))
+        } else {
+            std::env::var_os("XDG_CACHE_HOME")
+                .filter(|dir| !dir.is_empty())
+                .map(PathBuf::from)
+                .or_else(|| std::env::var_os("HOME").map(|home| PathBuf::from(home).join(".cache")))
+        };
+        base.map(|dir| dir.join("reviewlens"))
+    }
+
+    /// Returns the cache key for a file.
+    pub fn key(&self, file_path: &str, content: &str) -> String {
+        let mut hasher = Sha256::new();
+        hasher.update(self.detector_version.as_bytes());
+        hasher.update([0]);
+        hasher.update(file_path.as_bytes());
+        hasher.update([0]);
+        hasher.update(content.as_bytes());
+        format!("{:x}", hasher.finalize())
+    }
+
+    /// Returns the cache key for a file whose findings also depend on other
+    /// files, such as the rest of its package, summarised by the
+    /// `dependencies` digest.
+    pub fn key_with(&self, file_path: &str, content: &str, dependencies: &str) -> String {
+        let mut hasher = Sha256::new();
+        hasher.update(self.key(file_path, content).as_bytes());
+        hasher.update([0]);
+        hasher.update(dependencies.as_bytes());
+        format!("{:x}", hasher.finalize())
+    }
+
+    fn entry_path(&self, key: &str) -> PathBuf {
+        self.dir.join(&key[..2]).join(format!("{}.json", key))
+    }
+
+    /// Returns the cached findings for `key`, if present and readable.
+    pub fn get(&self, key: &str) -> Option<Vec<Issue>> {
+        let path = self.entry_path(key);
+        let content = fs::read_to_string(&path).ok()?;
+        match serde_json::from_str(&content) {
+            Ok(issues) => Some(issues),
+            Err(e) => {
+                log::debug!("Ignoring corrupt cache entry {}: {}", path.display(), e);
+                None
+            }
+        }
+    }
+
+    /// Stores the findings for `key`.
+    pub fn put(&self, key: &str, issues: &[Issue]) {
+        let path = self.entry_path(key);
+        if let Err(e) = write_entry(&path, issues) {
+            log::warn!("Failed to write cache entry {}: {}", path.display(), e);
+        }
+    }
+}
+
+/// Writes an entry through a temporary file so concurrent readers never see
+/// a partial entry.
+fn write_entry(path: &Path, issues: &[Issue]) -> std::io::Result<()> {
+    if let Some(parent) = path.parent() {
+        fs::create_dir_all(parent)?;
+    }
+    let json = serde_json::to_string(issues)?;
+    let tmp = path.with_extension(format!("json.tmp-{}", std::process::id()));
+    fs::write(&tmp, json)?;
+    fs::rename(&tmp, path)
+}
+
+/// Describes everything besides the file itself that scanner output depends
+/// on.
+fn detector_version(config: &Config) -> String {
+    let rules = serde_json::to_string(&(&config.rules, &config.taint)).unwrap_or_default();
+    let registered: Vec<&str> = crate::scanner::registered_rules()
+        .iter()
+        .map(|info| info.id)
+        .collect();
+    let index = config
+        .index_path()
+        .and_then(|path| fs::metadata(path).ok())
+        .and_then(|meta| meta.modified().ok())
+        .and_then(|time| time.duration_since(std::time::UNIX_EPOCH).ok())
+        .map_or(0, |d| d.as_nanos());
+    format!(
+        "{}/{}/{}/{}/{}",
+        env!("CARGO_PKG_VERSION"),
+        crate::RULESET_VERSION,
+        rules,
+        registered.join(","),
+        index
+    )
+}
@@ -6,7 +6,10 @@
 use crate::error::{EngineError, Result};
 use clap::ValueEnum;
 use serde::{Deserialize, Serialize};
-use std::path::Path;
+use std::path::{Path, PathBuf};
+
+/// File name looked up when no configuration file is given explicitly.
+pub const CONFIG_FILE_NAME: &str = "reviewlens.toml";
 
 /// Default path for the RAG index file.
 pub const DEFAULT_INDEX_PATH: &str = ".reviewlens/index/index.json.zst";
@@ -41,6 +44,9 @@ pub struct Config {
     pub paths: PathsConfig,
     #[serde(default)]
     pub telemetry: TelemetryConfig,
+    /// Configuration for the file scanning stage.
+    #[serde(default)]
+    pub scan: ScanConfig,
     /// Configuration for report generation.
     #[serde(default)]
     pub report: ReportConfig,
@@ -53,8 +59,19 @@ pub struct Config {
     pub index_path: Option<String>,
     #[serde(default)]
     pub rules: RulesConfig,
+    /// Project functions the taint-tracking rules should know about.
+    #[serde(default)]
+    pub taint: TaintConfig,
     #[serde(default = "default_fail_on")]
     pub fail_on: Severity,
+    /// Only findings in these categories affect the exit code. Empty means
+    /// every category.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub fail_on_category: Vec<Category>,
+    /// Severity adjustments for findings in matching paths, applied after
+    /// the per-rule severities.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub path_severity_overrides: Vec<PathSeverityOverride>,
 }
 
 // As per PRD: `null | openai | anthropic | deepseek`
@@ -169,12 +186,18 @@ pub struct PrivacyConfig {
 #[derive(Deserialize, Serialize, Debug, Clone, PartialEq, Eq)]
 #[serde(rename_all = "kebab-case")]
 pub struct PathsConfig {
-    /// Paths to include in the analysis. Globs are supported.
-    #[serde(default = "default_include")]
+    /// Paths to include in the analysis, as gitignore-style globs relative to
+    /// the repository root. Also accepted as `include`.
+    #[serde(default = "default_include", alias = "include")]
     pub allow: Vec<String>,
-    /// Paths to exclude from the analysis. Globs are supported.
-    #[serde(default)]
+    /// Paths to exclude from the analysis, as gitignore-style globs relative to
+    /// the repository root. Also accepted as `exclude`.
+    #[serde(default, alias = "exclude")]
     pub deny: Vec<String>,
+    /// Analyze files marked `// Code generated ... DO NOT EDIT.`, which are
+    /// skipped by default.
+    #[serde(default)]
+    pub include_generated: bool,
 }
 
 impl Default for PathsConfig {
@@ -182,6 +205,7 @@ impl Default for PathsConfig {
         Self {
             allow: default_include(),
             deny: vec![],
+            include_generated: false,
         }
     }
 }
@@ -190,6 +214,19 @@ fn default_include() -> Vec<String> {
     vec!["**/*".to_string()]
 }
 
+/// A `[[path-severity-overrides]]` entry: findings in paths matching one of
+/// `paths` have their severity raised or lowered by `adjust` levels.
+#[derive(Deserialize, Serialize, Debug, Clone, PartialEq, Eq)]
+#[serde(rename_all = "kebab-case", deny_unknown_fields)]
+pub struct PathSeverityOverride {
+    /// Gitignore-style globs relative to the repository root, as in
+    /// `[paths]`.
+    pub paths: Vec<String>,
+    /// The number of levels to raise the severity by; negative values lower
+    /// it.
+    pub adjust: i8,
+}
+
 // Telemetry configuration
 #[derive(Deserialize, Serialize, Debug, Clone, PartialEq, Eq)]
 #[serde(rename_all = "kebab-case")]
@@ -209,6 +246,79 @@ impl Default for TelemetryConfig {
     }
 }
 
+// Scanning configuration
+#[derive(Deserialize, Serialize, Debug, Clone, PartialEq, Eq, Default)]
+#[serde(rename_all = "kebab-case")]
+pub struct ScanConfig {
+    /// Number of files scanned in parallel. `0` or unset uses the number of
+    /// available CPUs.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub concurrency: Option<usize>,
+    /// Issues below this confidence are dropped before reporting.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub min_confidence: Option<Confidence>,
+    /// Directory for caching scanner results by file content. Unset disables
+    /// the cache.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub cache_dir: Option<String>,
+    /// Seconds the scanners may spend on one file. Unset uses
+    /// [`DEFAULT_FILE_TIMEOUT_SECS`] and `0` disables the limit.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub file_timeout: Option<u64>,
+    /// How far the taint-tracking rules follow calls. Unset analyses each
+    /// file on its own.
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub scope: Option<AnalysisScope>,
+    /// Keep the trace of how request data reaches each taint finding.
+    #[serde(default, skip_serializing_if = "std::ops::Not::not")]
+    pub explain: bool,
+}
+
+/// The code the taint-tracking rules see when analysing a file.
+#[derive(
+    Deserialize, Serialize, Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, ValueEnum,
+)]
+#[serde(rename_all = "kebab-case")]
+pub enum AnalysisScope {
+    /// Only the file itself. The fastest, and the default.
+    File,
+    /// The other Go files of the file's package, so request data passed to
+    /// a helper in another file of the package is followed.
+    Package,
+    /// Every Go file of the tree, so calls into other packages of the
+    /// module are followed as well.
+    Module,
+}
+
+/// The per-file analysis time limit used when `file-timeout` is unset.
+pub const DEFAULT_FILE_TIMEOUT_SECS: u64 = 30;
+
+impl ScanConfig {
+    /// Returns the number of scan workers to use.
+    pub fn workers(&self) -> usize {
+        match self.concurrency {
+            Some(n) if n > 0 => n,
+            _ => std::thread::available_parallelism()
+                .map(|n| n.get())
+                .unwrap_or(1),
+        }
+    }
+
+    /// Returns the configured analysis scope, `file` when unset.
+    pub fn scope(&self) -> AnalysisScope {
+        self.scope.unwrap_or(AnalysisScope::File)
+    }
+
+    /// Returns the time limit for analysing one file, or `None` when files
+    /// may take as long as they need.
+    pub fn file_timeout(&self) -> Option<std::time::Duration> {
+        match self.file_timeout.unwrap_or(DEFAULT_FILE_TIMEOUT_SECS) {
+            0 => None,
+            secs => Some(std::time::Duration::from_secs(secs)),
+        }
+    }
+}
+
 // As per PRD: `[report.hotspot_weights]` section
 #[derive(Deserialize, Serialize, Debug, Clone, PartialEq, Eq)]
 #[serde(rename_all = "kebab-case")]
@@ -259,19 +369,61 @@ pub enum Severity {
     High,
     Medium,
     Low,
+    /// Informational only; used for findings recorded in a baseline.
+    Info,
 }
 
 impl Severity {
+    /// All severities from most to least severe.
+    pub const ALL: [Severity; 5] = [
+        Severity::Critical,
+        Severity::High,
+        Severity::Medium,
+        Severity::Low,
+        Severity::Info,
+    ];
+
+    /// Returns the kebab-case name of the severity.
+    pub fn as_str(&self) -> &'static str {
+        match self {
+            Severity::Critical => "critical",
+            Severity::High => "high",
+            Severity::Medium => "medium",
+            Severity::Low => "low",
+            Severity::Info => "info",
+        }
+    }
+
+    /// Returns the severity `levels` steps more severe, or less severe for
+    /// negative `levels`, staying between `low` and `critical`. `info` is
+    /// reserved for baselined findings and is returned unchanged.
+    pub fn adjusted(&self, levels: i8) -> Severity {
+        if *self == Severity::Info {
+            return Severity::Info;
+        }
+        let level = (i16::from(self.as_u8()) + i16::from(levels)).clamp(1, 4);
+        // `ALL` runs from critical (4) down to info (0).
+        Severity::ALL[(4 - level) as usize].clone()
+    }
+
     fn as_u8(&self) -> u8 {
         match self {
             Severity::Critical => 4,
             Severity::High => 3,
             Severity::Medium => 2,
             Severity::Low => 1,
+            Severity::Info => 0,
         }
     }
 }
 
+// Default severity matches the default rule severity.
+impl Default for Severity {
+    fn default() -> Self {
+        Severity::Medium
+    }
+}
+
 impl PartialOrd for Severity {
     fn partial_cmp(&self, other: &Self) -> Option<std::cmp::Ordering> {
         self.as_u8().partial_cmp(&other.as_u8())
@@ -284,6 +436,62 @@ impl Ord for Severity {
     }
 }
 
+/// How certain a scanner is that an issue is real. Variants are ordered from
+/// least to most certain.
+#[derive(
+    Deserialize, Serialize, Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, ValueEnum,
+)]
+#[serde(rename_all = "kebab-case")]
+pub enum Confidence {
+    /// A heuristic match that often needs manual review.
+    Low,
+    /// Likely real, but the scanner could not follow every step.
+    Medium,
+    /// The scanner matched a known pattern or followed the full data flow.
+    High,
+}
+
+// Issues from scanners that do not grade their findings are kept by every
+// confidence filter.
+impl Default for Confidence {
+    fn default() -> Self {
+        Confidence::High
+    }
+}
+
+/// The kind of problem a rule detects, declared when the rule is registered.
+#[derive(
+    Deserialize, Serialize, Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, ValueEnum,
+)]
+#[serde(rename_all = "kebab-case")]
+pub enum Category {
+    /// Vulnerabilities and leaked credentials.
+    Security,
+    /// Code that misbehaves at runtime, such as requests that can hang.
+    Correctness,
+    /// Deviations from the repository's conventions.
+    Style,
+}
+
+impl Category {
+    /// Returns the kebab-case name of the category.
+    pub fn as_str(&self) -> &'static str {
+        match self {
+            Category::Security => "security",
+            Category::Correctness => "correctness",
+            Category::Style => "style",
+        }
+    }
+}
+
+// Issues from unregistered rules, such as internal errors, are reported as
+// correctness problems.
+impl Default for Category {
+    fn default() -> Self {
+        Category::Correctness
+    }
+}
+
 #[derive(Deserialize, Serialize, Debug, Clone, PartialEq, Eq)]
 #[serde(rename_all = "kebab-case")]
 pub struct RuleConfig {
@@ -301,23 +509,302 @@ impl Default for RuleConfig {
     }
 }
 
+/// Per-rule settings. Rules omitted from the `[rules]` table keep their
+/// defaults, and a rule table only needs the keys it overrides.
 #[derive(Deserialize, Serialize, Debug, Clone, PartialEq, Eq)]
-#[serde(rename_all = "kebab-case")]
+#[serde(rename_all = "kebab-case", from = "RawRulesConfig")]
 pub struct RulesConfig {
-    #[serde(default = "default_secrets_rule")]
-    pub secrets: RuleConfig,
-    #[serde(default = "default_sql_injection_go_rule")]
+    pub secrets: SecretsRuleConfig,
     pub sql_injection_go: RuleConfig,
-    #[serde(default = "default_http_timeouts_go_rule")]
     pub http_timeouts_go: RuleConfig,
-    #[serde(default = "default_conventions_rule")]
+    pub xss_go: RuleConfig,
+    pub command_injection_go: RuleConfig,
+    pub open_redirect_go: RuleConfig,
+    pub context_propagation_go: RuleConfig,
+    pub unescaped_template_go: RuleConfig,
+    pub weak_crypto_go: RuleConfig,
+    pub path_traversal_go: RuleConfig,
+    pub insecure_tls_go: InsecureTlsRuleConfig,
+    pub ignored_errors_go: IgnoredErrorsRuleConfig,
+    pub missing_auth_go: MissingAuthRuleConfig,
+    pub ssrf_go: RuleConfig,
+    pub unsafe_go: RuleConfig,
+    pub security_headers_go: RuleConfig,
+    pub insecure_deserialization_go: RuleConfig,
     pub conventions: RuleConfig,
 }
 
-fn default_secrets_rule() -> RuleConfig {
-    RuleConfig {
+/// Settings for the `secrets` rule.
+#[derive(Deserialize, Serialize, Debug, Clone, PartialEq, Eq)]
+#[serde(rename_all = "kebab-case")]
+pub struct SecretsRuleConfig {
+    pub enabled: bool,
+    pub severity: Severity,
+    /// Minimum length of a string literal token for the high-entropy check.
+    pub entropy_min_length: usize,
+}
+
+/// Settings for the `insecure-tls-go` rule.
+#[derive(Deserialize, Serialize, Debug, Clone, PartialEq, Eq)]
+#[serde(rename_all = "kebab-case")]
+pub struct InsecureTlsRuleConfig {
+    pub enabled: bool,
+    pub severity: Severity,
+    /// Whether `*_test.go` files are checked.
+    pub include_tests: bool,
+}
+
+/// Settings for the `ignored-errors-go` rule.
+#[derive(Deserialize, Serialize, Debug, Clone, PartialEq, Eq)]
+#[serde(rename_all = "kebab-case")]
+pub struct IgnoredErrorsRuleConfig {
+    pub enabled: bool,
+    pub severity: Severity,
+    /// Functions and methods whose error result must be checked, such as
+    /// `crypto/rand.Read` or `(*database/sql.Rows).Close`.
+    pub functions: Vec<String>,
+}
+
+impl IgnoredErrorsRuleConfig {
+    /// Checks that every entry of `functions` is a function or method
+    /// reference.
+    pub fn validate(&self) -> Result<()> {
+        let invalid = self
+            .functions
+            .iter()
+            .find(|f| split_function_ref(f).is_none() && split_method_ref(f).is_none());
+        match invalid {
+            Some(bad) => Err(EngineError::Config(format!(
+                "rules.ignored-errors-go.functions: `{}` is not a function or method reference; expected e.g. `crypto/rand.Read` or `(*database/sql.Rows).Close`",
+                bad
+            ))),
+            None => Ok(()),
+        }
+    }
+}
+
+/// Settings for the `missing-auth-go` rule.
+#[derive(Deserialize, Serialize, Debug, Clone, PartialEq, Eq)]
+#[serde(rename_all = "kebab-case")]
+pub struct MissingAuthRuleConfig {
+    pub enabled: bool,
+    pub severity: Severity,
+    /// Identifiers whose use in a handler shows that it checks who the
+    /// caller is, such as `RequireAuth` or `CurrentUser`.
+    pub auth_markers: Vec<String>,
+}
+
+impl MissingAuthRuleConfig {
+    /// Checks that every entry of `auth-markers` is an identifier.
+    pub fn validate(&self) -> Result<()> {
+        match self.auth_markers.iter().find(|m| !is_identifier(m)) {
+            Some(bad) => Err(EngineError::Config(format!(
+                "rules.missing-auth-go.auth-markers: `{}` is not an identifier; expected a name such as `RequireAuth`",
+                bad
+            ))),
+            None => Ok(()),
+        }
+    }
+}
+
+/// Overrides for a single rule as written in the configuration file.
+#[derive(Deserialize, Debug, Clone, Default)]
+#[serde(rename_all = "kebab-case", deny_unknown_fields)]
+struct RuleOverride {
+    enabled: Option<bool>,
+    severity: Option<Severity>,
+}
+
+impl RuleOverride {
+    fn apply(self, mut rule: RuleConfig) -> RuleConfig {
+        if let Some(enabled) = self.enabled {
+            rule.enabled = enabled;
+        }
+        if let Some(severity) = self.severity {
+            rule.severity = severity;
+        }
+        rule
+    }
+}
+
+/// Overrides for the `secrets` rule, which has options of its own.
+#[derive(Deserialize, Debug, Clone, Default)]
+#[serde(rename_all = "kebab-case", deny_unknown_fields)]
+struct SecretsRuleOverride {
+    enabled: Option<bool>,
+    severity: Option<Severity>,
+    entropy_min_length: Option<usize>,
+}
+
+impl SecretsRuleOverride {
+    fn apply(self, mut rule: SecretsRuleConfig) -> SecretsRuleConfig {
+        if let Some(enabled) = self.enabled {
+            rule.enabled = enabled;
+        }
+        if let Some(severity) = self.severity {
+            rule.severity = severity;
+        }
+        if let Some(length) = self.entropy_min_length {
+            rule.entropy_min_length = length;
+        }
+        rule
+    }
+}
+
+/// Overrides for the `insecure-tls-go` rule, which has options of its own.
+#[derive(Deserialize, Debug, Clone, Default)]
+#[serde(rename_all = "kebab-case", deny_unknown_fields)]
+struct InsecureTlsRuleOverride {
+    enabled: Option<bool>,
+    severity: Option<Severity>,
+    include_tests: Option<bool>,
+}
+
+impl InsecureTlsRuleOverride {
+    fn apply(self, mut rule: InsecureTlsRuleConfig) -> InsecureTlsRuleConfig {
+        if let Some(enabled) = self.enabled {
+            rule.enabled = enabled;
+        }
+        if let Some(severity) = self.severity {
+            rule.severity = severity;
+        }
+        if let Some(include_tests) = self.include_tests {
+            rule.include_tests = include_tests;
+        }
+        rule
+    }
+}
+
+/// Overrides for the `ignored-errors-go` rule, which has options of its own.
+#[derive(Deserialize, Debug, Clone, Default)]
+#[serde(rename_all = "kebab-case", deny_unknown_fields)]
+struct IgnoredErrorsRuleOverride {
+    enabled: Option<bool>,
+    severity: Option<Severity>,
+    functions: Option<Vec<String>>,
+}
+
+impl IgnoredErrorsRuleOverride {
+    fn apply(self, mut rule: IgnoredErrorsRuleConfig) -> IgnoredErrorsRuleConfig {
+        if let Some(enabled) = self.enabled {
+            rule.enabled = enabled;
+        }
+        if let Some(severity) = self.severity {
+            rule.severity = severity;
+        }
+        if let Some(functions) = self.functions {
+            rule.functions = functions;
+        }
+        rule
+    }
+}
+
+/// Overrides for the `missing-auth-go` rule, which has options of its own.
+#[derive(Deserialize, Debug, Clone, Default)]
+#[serde(rename_all = "kebab-case", deny_unknown_fields)]
+struct MissingAuthRuleOverride {
+    enabled: Option<bool>,
+    severity: Option<Severity>,
+    auth_markers: Option<Vec<String>>,
+}
+
+impl MissingAuthRuleOverride {
+    fn apply(self, mut rule: MissingAuthRuleConfig) -> MissingAuthRuleConfig {
+        if let Some(enabled) = self.enabled {
+            rule.enabled = enabled;
+        }
+        if let Some(severity) = self.severity {
+            rule.severity = severity;
+        }
+        if let Some(markers) = self.auth_markers {
+            rule.auth_markers = markers;
+        }
+        rule
+    }
+}
+
+/// The `[rules]` table as written in the configuration file. Unknown rule ids
+/// are rejected so typos do not silently leave a rule at its defaults.
+#[derive(Deserialize, Debug, Clone, Default)]
+#[serde(rename_all = "kebab-case", deny_unknown_fields)]
+struct RawRulesConfig {
+    secrets: Option<SecretsRuleOverride>,
+    sql_injection_go: Option<RuleOverride>,
+    http_timeouts_go: Option<RuleOverride>,
+    xss_go: Option<RuleOverride>,
+    command_injection_go: Option<RuleOverride>,
+    open_redirect_go: Option<RuleOverride>,
+    context_propagation_go: Option<RuleOverride>,
+    unescaped_template_go: Option<RuleOverride>,
+    weak_crypto_go: Option<RuleOverride>,
+    path_traversal_go: Option<RuleOverride>,
+    insecure_tls_go: Option<InsecureTlsRuleOverride>,
+    ignored_errors_go: Option<IgnoredErrorsRuleOverride>,
+    missing_auth_go: Option<MissingAuthRuleOverride>,
+    ssrf_go: Option<RuleOverride>,
+    unsafe_go: Option<RuleOverride>,
+    security_headers_go: Option<RuleOverride>,
+    insecure_deserialization_go: Option<RuleOverride>,
+    conventions: Option<RuleOverride>,
+}
+
+impl From<RawRulesConfig> for RulesConfig {
+    fn from(raw: RawRulesConfig) -> Self {
+        let apply = |rule: Option<RuleOverride>, default: RuleConfig| {
+            rule.unwrap_or_default().apply(default)
+        };
+        Self {
+            secrets: raw
+                .secrets
+                .unwrap_or_default()
+                .apply(default_secrets_rule()),
+            sql_injection_go: apply(raw.sql_injection_go, default_sql_injection_go_rule()),
+            http_timeouts_go: apply(raw.http_timeouts_go, default_http_timeouts_go_rule()),
+            xss_go: apply(raw.xss_go, default_xss_go_rule()),
+            command_injection_go: apply(
+                raw.command_injection_go,
+                default_command_injection_go_rule(),
+            ),
+            open_redirect_go: apply(raw.open_redirect_go, default_open_redirect_go_rule()),
+            context_propagation_go: apply(
+                raw.context_propagation_go,
+                default_context_propagation_go_rule(),
+            ),
+            unescaped_template_go: apply(
+                raw.unescaped_template_go,
+                default_unescaped_template_go_rule(),
+            ),
+            weak_crypto_go: apply(raw.weak_crypto_go, default_weak_crypto_go_rule()),
+            path_traversal_go: apply(raw.path_traversal_go, default_path_traversal_go_rule()),
+            insecure_tls_go: raw
+                .insecure_tls_go
+                .unwrap_or_default()
+                .apply(default_insecure_tls_go_rule()),
+            ignored_errors_go: raw
+                .ignored_errors_go
+                .unwrap_or_default()
+                .apply(default_ignored_errors_go_rule()),
+            missing_auth_go: raw
+                .missing_auth_go
+                .unwrap_or_default()
+                .apply(default_missing_auth_go_rule()),
+            ssrf_go: apply(raw.ssrf_go, default_ssrf_go_rule()),
+            unsafe_go: apply(raw.unsafe_go, default_unsafe_go_rule()),
+            security_headers_go: apply(raw.security_headers_go, default_security_headers_go_rule()),
+            insecure_deserialization_go: apply(
+                raw.insecure_deserialization_go,
+                default_insecure_deserialization_go_rule(),
+            ),
+            conventions: apply(raw.conventions, default_conventions_rule()),
+        }
+    }
+}
+
+fn default_secrets_rule() -> SecretsRuleConfig {
+    SecretsRuleConfig {
         enabled: true,
         severity: Severity::High,
+        entropy_min_length: 32,
     }
 }
 
@@ -335,6 +822,129 @@ fn default_http_timeouts_go_rule() -> RuleConfig {
     }
 }
 
+fn default_xss_go_rule() -> RuleConfig {
+    RuleConfig {
+        enabled: true,
+        severity: Severity::High,
+    }
+}
+
+fn default_command_injection_go_rule() -> RuleConfig {
+    RuleConfig {
+        enabled: true,
+        severity: Severity::Critical,
+    }
+}
+
+fn default_open_redirect_go_rule() -> RuleConfig {
+    RuleConfig {
+        enabled: true,
+        severity: Severity::Medium,
+    }
+}
+
+fn default_context_propagation_go_rule() -> RuleConfig {
+    RuleConfig {
+        enabled: true,
+        severity: Severity::Low,
+    }
+}
+
+fn default_unescaped_template_go_rule() -> RuleConfig {
+    RuleConfig {
+        enabled: true,
+        severity: Severity::High,
+    }
+}
+
+fn default_weak_crypto_go_rule() -> RuleConfig {
+    RuleConfig {
+        enabled: true,
+        severity: Severity::Medium,
+    }
+}
+
+fn default_path_traversal_go_rule() -> RuleConfig {
+    RuleConfig {
+        enabled: true,
+        severity: Severity::High,
+    }
+}
+
+fn default_insecure_tls_go_rule() -> InsecureTlsRuleConfig {
+    InsecureTlsRuleConfig {
+        enabled: true,
+        severity: Severity::High,
+        include_tests: false,
+    }
+}
+
+fn default_ignored_errors_go_rule() -> IgnoredErrorsRuleConfig {
+    IgnoredErrorsRuleConfig {
+        enabled: true,
+        severity: Severity::Medium,
+        functions: [
+            "crypto/rand.Read",
+            "encoding/json.Unmarshal",
+            "(*database/sql.Rows).Close",
+            "io.Copy",
+        ]
+        .iter()
+        .map(|f| f.to_string())
+        .collect(),
+    }
+}
+
+fn default_missing_auth_go_rule() -> MissingAuthRuleConfig {
+    MissingAuthRuleConfig {
+        enabled: true,
+        severity: Severity::Medium,
+        auth_markers: [
+            "RequireAuth",
+            "RequireLogin",
+            "Authenticate",
+            "Authorize",
+            "CurrentUser",
+            "checkPermission",
+            "HasPermission",
+            "RequireRole",
+        ]
+        .iter()
+        .map(|m| m.to_string())
+        .collect(),
+    }
+}
+
+fn default_ssrf_go_rule() -> RuleConfig {
+    RuleConfig {
+        enabled: true,
+        severity: Severity::High,
+    }
+}
+
+fn default_unsafe_go_rule() -> RuleConfig {
+    RuleConfig {
+        enabled: true,
+        severity: Severity::Medium,
+    }
+}
+
+/// Off by default: whether a header is set is often decided outside the
+/// file, so the rule is opt-in per project.
+fn default_security_headers_go_rule() -> RuleConfig {
+    RuleConfig {
+        enabled: false,
+        severity: Severity::Medium,
+    }
+}
+
+fn default_insecure_deserialization_go_rule() -> RuleConfig {
+    RuleConfig {
+        enabled: true,
+        severity: Severity::Medium,
+    }
+}
+
 fn default_conventions_rule() -> RuleConfig {
     RuleConfig {
         enabled: true,
@@ -342,22 +952,159 @@ fn default_conventions_rule() -> RuleConfig {
     }
 }
 
+impl RulesConfig {
+    /// Returns the severity configured for a rule id, if the rule exists.
+    pub fn severity(&self, id: &str) -> Option<&Severity> {
+        let severity = match id {
+            "secrets" => &self.secrets.severity,
+            "sql-injection-go" => &self.sql_injection_go.severity,
+            "http-timeouts-go" => &self.http_timeouts_go.severity,
+            "xss-go" => &self.xss_go.severity,
+            "command-injection-go" => &self.command_injection_go.severity,
+            "open-redirect-go" => &self.open_redirect_go.severity,
+            "context-propagation-go" => &self.context_propagation_go.severity,
+            "unescaped-template-go" => &self.unescaped_template_go.severity,
+            "weak-crypto-go" => &self.weak_crypto_go.severity,
+            "path-traversal-go" => &self.path_traversal_go.severity,
+            "insecure-tls-go" => &self.insecure_tls_go.severity,
+            "ignored-errors-go" => &self.ignored_errors_go.severity,
+            "missing-auth-go" => &self.missing_auth_go.severity,
+            "ssrf-go" => &self.ssrf_go.severity,
+            "unsafe-go" => &self.unsafe_go.severity,
+            "security-headers-go" => &self.security_headers_go.severity,
+            "insecure-deserialization-go" => &self.insecure_deserialization_go.severity,
+            "conventions" => &self.conventions.severity,
+            _ => return None,
+        };
+        Some(severity)
+    }
+}
+
 impl Default for RulesConfig {
     fn default() -> Self {
         Self {
             secrets: default_secrets_rule(),
             sql_injection_go: default_sql_injection_go_rule(),
             http_timeouts_go: default_http_timeouts_go_rule(),
+            xss_go: default_xss_go_rule(),
+            command_injection_go: default_command_injection_go_rule(),
+            open_redirect_go: default_open_redirect_go_rule(),
+            context_propagation_go: default_context_propagation_go_rule(),
+            unescaped_template_go: default_unescaped_template_go_rule(),
+            weak_crypto_go: default_weak_crypto_go_rule(),
+            path_traversal_go: default_path_traversal_go_rule(),
+            insecure_tls_go: default_insecure_tls_go_rule(),
+            ignored_errors_go: default_ignored_errors_go_rule(),
+            missing_auth_go: default_missing_auth_go_rule(),
+            ssrf_go: default_ssrf_go_rule(),
+            unsafe_go: default_unsafe_go_rule(),
+            security_headers_go: default_security_headers_go_rule(),
+            insecure_deserialization_go: default_insecure_deserialization_go_rule(),
             conventions: default_conventions_rule(),
         }
     }
 }
 
+/// Functions declared in the `[taint]` table, written as an import path and
+/// a function name, e.g. `example.com/app/render.Escape`.
+#[derive(Deserialize, Serialize, Debug, Clone, PartialEq, Eq, Default)]
+#[serde(rename_all = "kebab-case")]
+pub struct TaintConfig {
+    /// Functions that neutralize request data: a value passed through one
+    /// is no longer tainted.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub sanitizers: Vec<String>,
+    /// Functions that are never vulnerable: the taint-tracking rules do not
+    /// report calls to them or sinks inside their arguments.
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub safe_sinks: Vec<String>,
+}
+
+impl TaintConfig {
+    /// Checks that every declared function is an import path followed by a
+    /// function name.
+    pub fn validate(&self) -> Result<()> {
+        let lists = [
+            ("sanitizers", &self.sanitizers),
+            ("safe-sinks", &self.safe_sinks),
+        ];
+        for (key, functions) in lists {
+            if let Some(bad) = functions.iter().find(|f| split_function_ref(f).is_none()) {
+                return Err(EngineError::Config(format!(
+                    "taint.{}: `{}` is not a function reference; expected an import path and a function name such as `example.com/app/render.Escape`",
+                    key, bad
+                )));
+            }
+        }
+        Ok(())
+    }
+}
+
+/// Splits a function reference such as `example.com/app/render.Escape` into
+/// its import path and function name. Returns `None` if either part is not
+/// syntactically valid.
+pub fn split_function_ref(reference: &str) -> Option<(&str, &str)> {
+    let (path, name) = reference.rsplit_once('.')?;
+    let elements_valid = path.split('/').all(|element| {
+        !element.is_empty()
+            && !element.starts_with('.')
+            && element
+                .chars()
+                .all(|c| c.is_ascii_alphanumeric() || matches!(c, '-' | '.' | '_' | '~'))
+    });
+    (elements_valid && is_identifier(name)).then_some((path, name))
+}
+
+/// Splits a method reference such as `(*database/sql.Rows).Close` into the
+/// import path, the receiver type and the method name. The `*` is optional.
+pub fn split_method_ref(reference: &str) -> Option<(&str, &str, &str)> {
+    let (receiver, method) = reference.strip_prefix('(')?.split_once(").")?;
+    let receiver = receiver.strip_prefix('*').unwrap_or(receiver);
+    let (path, type_name) = split_function_ref(receiver)?;
+    is_identifier(method).then_some((path, type_name, method))
+}
+
+fn is_identifier(name: &str) -> bool {
+    let mut chars = name.chars();
+    chars.next().is_some_and(|c| c.is_alphabetic() || c == '_')
+        && chars.all(|c| c.is_alphanumeric() || c == '_')
+}
+
 impl Config {
     /// Loads configuration from a TOML file.
     pub fn load_from_path(path: &Path) -> Result<Self> {
-        let content = std::fs::read_to_string(path)?;
-        toml::from_str(&content).map_err(|e| EngineError::Config(e.to_string()))
+        let content = std::fs::read_to_string(path).map_err(|e| {
+            EngineError::Config(format!("failed to read {}: {}", path.display(), e))
+        })?;
+        let config: Self = toml::from_str(&content)
+            .map_err(|e| EngineError::Config(format!("invalid {}: {}", path.display(), e)))?;
+        config.validate().map_err(|e| match e {
+            EngineError::Config(message) => {
+                EngineError::Config(format!("invalid {}: {}", path.display(), message))
+            }
+            e => e,
+        })?;
+        Ok(config)
+    }
+
+    /// Checks the settings that deserialization alone cannot, such as the
+    /// function references of the `[taint]` table and the globs of
+    /// `path-severity-overrides`.
+    pub fn validate(&self) -> Result<()> {
+        self.taint.validate()?;
+        self.rules.ignored_errors_go.validate()?;
+        self.rules.missing_auth_go.validate()?;
+        crate::paths::SeverityOverrides::new(&self.path_severity_overrides).map(drop)
+    }
+
+    /// Looks for a [`CONFIG_FILE_NAME`] file in `start` and each of its parent
+    /// directories, returning the closest one.
+    pub fn discover(start: &Path) -> Option<PathBuf> {
+        let start = start.canonicalize().unwrap_or_else(|_| start.to_path_buf());
+        start
+            .ancestors()
+            .map(|dir| dir.join(CONFIG_FILE_NAME))
+            .find(|candidate| candidate.is_file())
     }
 
     /// Returns the configured index path, respecting the deprecated field.
@@ -383,12 +1130,16 @@ impl Default for Config {
             privacy: PrivacyConfig::default(),
             paths: PathsConfig::default(),
             telemetry: TelemetryConfig::default(),
+            scan: ScanConfig::default(),
             index: Some(IndexConfig::default()),
             #[allow(deprecated)]
             index_path: None,
             report: ReportConfig::default(),
             rules: RulesConfig::default(),
+            taint: TaintConfig::default(),
             fail_on: default_fail_on(),
+            fail_on_category: Vec::new(),
+            path_severity_overrides: Vec::new(),
         }
     }
 }
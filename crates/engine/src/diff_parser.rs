@@ -6,7 +6,13 @@ use patch::{Line as PatchLine, Patch};
 /// Represents a single changed file in a diff.
 #[derive(Debug)]
 pub struct ChangedFile {
+    /// Path of the file after the change. For deleted files this is the path
+    /// the file had before it was removed.
     pub path: String,
+    /// Previous path of a renamed file.
+    pub old_path: Option<String>,
+    /// Whether the change deletes the file.
+    pub deleted: bool,
     pub hunks: Vec<Hunk>,
 }
 
@@ -63,15 +69,31 @@ pub fn parse(diff_text: &str) -> Result<Vec<ChangedFile>> {
     Ok(files)
 }
 
+/// Path used by git for the missing side of an added or deleted file.
+const DEV_NULL: &str = "/dev/null";
+
 fn parse_segment(segment: &str) -> Result<ChangedFile> {
-    let header_path = segment
+    let mut header = segment
         .lines()
         .next()
-        .and_then(|line| line.split_whitespace().nth(3))
+        .map(|line| line.split_whitespace().skip(2))
+        .ok_or_else(|| EngineError::DiffParser("Malformed diff header".into()))?;
+    let header_old = header
+        .next()
+        .map(|p| p.trim_start_matches("a/").to_string());
+    let header_path = header
+        .next()
         .ok_or_else(|| EngineError::DiffParser("Malformed diff header".into()))?
         .trim_start_matches("b/")
         .to_string();
 
+    let deleted = segment.lines().any(|l| l.starts_with("deleted file mode"));
+    let renamed_from = segment
+        .lines()
+        .find_map(|l| l.strip_prefix("rename from "))
+        .map(str::to_string);
+    let old_path = renamed_from.or(header_old.filter(|old| *old != header_path));
+
     let has_patch = segment.lines().any(|l| l.starts_with("--- "));
     let is_binary = segment
         .lines()
@@ -80,6 +102,8 @@ fn parse_segment(segment: &str) -> Result<ChangedFile> {
     if !has_patch || is_binary {
         return Ok(ChangedFile {
             path: header_path,
+            old_path,
+            deleted,
             hunks: Vec::new(),
         });
     }
@@ -91,7 +115,13 @@ fn parse_segment(segment: &str) -> Result<ChangedFile> {
         .next()
         .ok_or_else(|| EngineError::DiffParser("No patch data found".into()))?;
 
-    let path = patch.new.path.trim_start_matches("b/").to_string();
+    let new_path = patch.new.path.trim_start_matches("b/");
+    let deleted = deleted || new_path == DEV_NULL;
+    let path = if new_path == DEV_NULL {
+        patch.old.path.trim_start_matches("a/").to_string()
+    } else {
+        new_path.to_string()
+    };
     let hunks = patch
         .hunks
         .into_iter()
@@ -115,5 +145,10 @@ fn parse_segment(segment: &str) -> Result<ChangedFile> {
         })
         .collect();
 
-    Ok(ChangedFile { path, hunks })
+    Ok(ChangedFile {
+        old_path: old_path.filter(|old| *old != path),
+        path,
+        deleted,
+        hunks,
+    })
 }
@@ -31,6 +31,9 @@ pub enum EngineError {
     #[error("Report generation error: {0}")]
     Report(String),
 
+    #[error("Scan cancelled")]
+    Cancelled,
+
     #[error("An unknown error occurred")]
     Unknown,
 }
@@ -0,0 +1,141 @@
+//! Machine-applicable fixes attached to issues.
+//!
+//! A fix is a set of text edits against the file an issue was found in.
+//! Positions use the same 1-based, character-counted lines and columns as
+//! issue locations, so a fix can be shown as a diff or exported to SARIF
+//! without reading the file, and applied with [`apply_fixes`].
+
+use std::collections::BTreeMap;
+use std::fs;
+use std::path::{Path, PathBuf};
+
+use serde::{Deserialize, Serialize};
+
+use crate::error::{EngineError, Result};
+use crate::scanner::Issue;
+
+/// A suggested change that resolves an issue.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct Fix {
+    /// What the fix does, e.g. "Escape `user` with html.EscapeString".
+    pub description: String,
+    /// Edits to the issue's file. They never overlap.
+    pub edits: Vec<TextEdit>,
+}
+
+/// Replaces the text between two positions. A range whose start equals its
+/// end inserts `replacement`.
+#[derive(Debug, Clone, PartialEq, Eq, PartialOrd, Ord, Serialize, Deserialize)]
+pub struct TextEdit {
+    /// 1-based line where the replaced range starts.
+    pub start_line: usize,
+    /// 1-based column where the replaced range starts.
+    pub start_column: usize,
+    /// 1-based line where the replaced range ends.
+    pub end_line: usize,
+    /// 1-based column just past the end of the replaced range.
+    pub end_column: usize,
+    pub replacement: String,
+}
+
+impl TextEdit {
+    /// An edit inserting `text` before the given position.
+    pub fn insert(line: usize, column: usize, text: impl Into<String>) -> Self {
+        Self {
+            start_line: line,
+            start_column: column,
+            end_line: line,
+            end_column: column,
+            replacement: text.into(),
+        }
+    }
+}
+
+/// Converts a 1-based line and column into a byte offset in `content`. Line
+/// `n + 1` of a file with `n` lines is its end. A line or column of `0` is
+/// not a position.
+fn offset(content: &str, line: usize, column: usize) -> Option<usize> {
+    if line == 0 || column == 0 {
+        return None;
+    }
+    let mut line_start = 0;
+    for _ in 1..line {
+        line_start += content[line_start..].find('\n')? + 1;
+    }
+    let text = &content[line_start..];
+    let text = &text[..text.find('\n').unwrap_or(text.len())];
+    if column == 1 {
+        return Some(line_start);
+    }
+    let (index, c) = text.char_indices().nth(column - 2)?;
+    Some(line_start + index + c.len_utf8())
+}
+
+/// Applies `edits` to `content`. Identical edits, such as the same import
+/// added by several fixes, are applied once. Edits that overlap an earlier
+/// one are skipped and returned separately.
+pub fn apply_edits(content: &str, edits: &[TextEdit]) -> Result<(String, Vec<TextEdit>)> {
+    let mut edits = edits.to_vec();
+    edits.sort();
+    edits.dedup();
+
+    let mut ranges = Vec::with_capacity(edits.len());
+    let mut skipped = Vec::new();
+    let mut last_end = 0;
+    for edit in edits {
+        let range = offset(content, edit.start_line, edit.start_column)
+            .zip(offset(content, edit.end_line, edit.end_column))
+            .filter(|(start, end)| start <= end)
+            .ok_or_else(|| {
+                EngineError::Scanner(format!(
+                    "fix edit at {}:{} is outside the file",
+                    edit.start_line, edit.start_column
+                ))
+            })?;
+        if range.0 < last_end {
+            skipped.push(edit);
+            continue;
+        }
+        last_end = range.1;
+        ranges.push((range, edit.replacement));
+    }
+
+    let mut out = content.to_string();
+    for ((start, end), replacement) in ranges.into_iter().rev() {
+        out.replace_range(start..end, &replacement);
+    }
+    Ok((out, skipped))
+}
+
+/// Applies the fixes of `issues` to their files under `root`, rewriting them
+/// in place. Baselined issues are left alone. Returns the files that changed.
+pub fn apply_fixes(root: &Path, issues: &[Issue]) -> Result<Vec<PathBuf>> {
+    let mut by_file: BTreeMap<&str, Vec<TextEdit>> = BTreeMap::new();
+    for issue in issues.iter().filter(|i| !i.baselined) {
+        if let Some(fix) = &issue.fix {
+            by_file
+                .entry(&issue.file_path)
+                .or_default()
+                .extend(fix.edits.iter().cloned());
+        }
+    }
+
+    let mut changed = Vec::new();
+    for (file, edits) in by_file {
+        let path = root.join(file);
+        let content = fs::read_to_string(&path)?;
+        let (fixed, skipped) = apply_edits(&content, &edits)?;
+        for edit in skipped {
+            log::warn!(
+                "Skipping fix at {}:{} that overlaps another fix",
+                file,
+                edit.start_line
+            );
+        }
+        if fixed != content {
+            fs::write(&path, fixed)?;
+            changed.push(path);
+        }
+    }
+    Ok(changed)
+}
@@ -7,30 +7,39 @@
 //! - Interacting with LLM providers (`llm`).
 //! - Performing Retrieval-Augmented Generation (`rag`).
 //! - Scanning for vulnerabilities and patterns (`scanner`).
+//! - Running the scanners programmatically over files (`analyzer`).
 //! - Generating reports (`report`).
+//! - Matching findings against a baseline (`baseline`).
+//! - Watching a working tree for changes (`watch`).
 
 // Public modules
+pub mod analyzer;
+pub mod baseline;
+pub mod cache;
 pub mod config;
 pub mod diff_parser;
 pub mod error;
+pub mod fix;
 pub mod llm;
+pub mod paths;
 pub mod rag;
 pub mod report;
 pub mod scanner;
 pub mod telemetry;
+pub mod watch;
 
-use crate::config::{Config, Provider};
+use crate::analyzer::{Analyzer, CancellationToken, FileOutcome, SourceFile};
+use crate::config::{Category, Config, Provider, Severity};
 use crate::error::{EngineError, Result};
 use crate::llm::{create_llm_provider, LlmProvider};
+use crate::paths::{PathFilter, IGNORE_FILE};
 use crate::rag::{InMemoryVectorStore, RagContextRetriever, VectorStore};
 use crate::report::{ReviewReport, RuntimeMetadata, TimingInfo};
-use crate::scanner::{Issue, Scanner};
+use crate::scanner::Issue;
 use crate::telemetry::Telemetry;
-use globset::{Glob, GlobSet, GlobSetBuilder};
 use regex::Regex;
 use std::collections::HashMap;
 use std::collections::HashSet;
-use std::fs;
 use std::path::Path;
 use std::time::Instant;
 
@@ -49,7 +58,7 @@ pub fn compiled_providers() -> Vec<config::Provider> {
 const REDACTION_PLACEHOLDER: &str = "[REDACTED]";
 
 /// Version identifier for the ruleset bundled with the engine.
-const RULESET_VERSION: &str = "1.0.0";
+pub(crate) const RULESET_VERSION: &str = "1.0.0";
 
 /// Redacts sensitive information from the provided text based on the
 /// configured redaction patterns.
@@ -67,6 +76,24 @@ pub fn redact_text(config: &Config, text: &str) -> String {
     redacted
 }
 
+/// Returns the process exit code for a finished review: `1` when at least one
+/// issue is at or above `fail_on`, otherwise `0`. Baselined issues are never
+/// counted, and issues below the threshold are reported without failing. When
+/// `categories` is not empty, issues in other categories are advisory and
+/// never fail the run either.
+pub fn findings_exit_code(issues: &[Issue], fail_on: &Severity, categories: &[Category]) -> i32 {
+    let failing = issues.iter().any(|issue| {
+        !issue.baselined
+            && issue.severity >= *fail_on
+            && (categories.is_empty() || categories.contains(&issue.category))
+    });
+    if failing {
+        1
+    } else {
+        0
+    }
+}
+
 /// Provides a simple on-device summary when no external LLM is configured.
 fn fallback_summary(file_count: usize, issues: &[Issue]) -> String {
     let mut summary = format!(
@@ -95,10 +122,21 @@ fn fallback_summary(file_count: usize, issues: &[Issue]) -> String {
     summary
 }
 
+/// The outcome of the scanning stage of a review.
+struct ScannedFiles {
+    /// Number of files handed to the scanners.
+    reviewed: usize,
+    outcomes: Vec<FileOutcome>,
+    /// Files left out by the path filters.
+    files_skipped: usize,
+    /// Added and removed lines per file, used to rank hotspots.
+    churn_counts: HashMap<String, usize>,
+}
+
 /// The main engine struct.
 pub struct ReviewEngine {
     config: Config,
-    scanners: Vec<Box<dyn Scanner>>,
+    analyzer: Analyzer,
     llm: Box<dyn LlmProvider>,
     telemetry: Option<Telemetry>,
 }
@@ -107,11 +145,11 @@ impl ReviewEngine {
     /// Creates a new instance of the review engine from a given configuration.
     pub fn new(config: Config) -> Result<Self> {
         let llm = create_llm_provider(&config)?;
-        let scanners = crate::scanner::load_enabled_scanners(&config);
+        let analyzer = Analyzer::new(config.clone());
         let telemetry = Telemetry::from_config(&config.telemetry)?;
         Ok(Self {
             config,
-            scanners,
+            analyzer,
             llm,
             telemetry,
         })
@@ -124,6 +162,18 @@ impl ReviewEngine {
 
     /// Runs a complete code review analysis on a given diff.
     pub async fn run(&self, diff: &str) -> Result<ReviewReport> {
+        self.run_streaming(diff, |_| {}).await
+    }
+
+    /// Like [`ReviewEngine::run`], but also passes each file's findings to
+    /// `on_findings` as soon as the file has been scanned, so callers can
+    /// emit them before the whole review finishes. Files are reported in the
+    /// order they finish, which varies between runs when scanning in
+    /// parallel; the returned report is sorted as usual.
+    pub async fn run_streaming<F>(&self, diff: &str, on_findings: F) -> Result<ReviewReport>
+    where
+        F: Fn(&[Issue]) + Sync,
+    {
         log::info!("Engine running with config: {:?}", self.config);
         log::debug!("Analyzing diff: {}", diff);
         let start_time = Instant::now();
@@ -131,21 +181,28 @@ impl ReviewEngine {
             t.run_started();
         }
 
-        let mut total_tokens_used: u32 = 0;
-
         // 1. Parse the diff to identify changed files and hunks.
         let changed_files = diff_parser::parse(diff)?;
 
-        // Build globsets for allowed and denied paths.
-        let allow_set = build_globset(&self.config.paths.allow)?;
-        let deny_set = build_globset(&self.config.paths.deny)?;
+        // Build the filter for allowed, denied and ignored paths. Diff paths
+        // are relative to the repository root, the working directory.
+        let path_filter = PathFilter::new(&self.config.paths)?.with_ignore_files(".");
 
-        // Filter changed files based on glob patterns.
+        // Filter changed files based on glob patterns. Deleted files have no
+        // lines left to review and are not counted as skipped.
+        let mut files_skipped = 0;
         let filtered_files: Vec<_> = changed_files
             .into_iter()
             .filter(|file| {
-                let path = Path::new(&file.path);
-                allow_set.is_match(path) && !deny_set.is_match(path)
+                if file.deleted {
+                    log::debug!("Skipping deleted file {}", file.path);
+                    return false;
+                }
+                let included = path_filter.is_included(Path::new(&file.path));
+                if !included {
+                    files_skipped += 1;
+                }
+                included
             })
             .collect();
 
@@ -167,62 +224,120 @@ impl ReviewEngine {
         }
 
         // 2. Run configured scanners on the filtered files, limiting results to diff hunks.
-        let mut issues = Vec::new();
-        let mut code_quality = Vec::new();
-        let file_paths: Vec<String> = filtered_files.iter().map(|f| f.path.clone()).collect();
-        let mut interactions = HashSet::new();
-        for file in &filtered_files {
-            let content = fs::read_to_string(&file.path)?;
-            let mut changed_lines = HashSet::new();
-            for hunk in &file.hunks {
-                let mut new_line = hunk.new_start as usize;
-                for line in &hunk.lines {
-                    match line {
-                        diff_parser::Line::Added(_) => {
-                            changed_lines.insert(new_line);
-                            new_line += 1;
-                        }
-                        diff_parser::Line::Context(_) => {
-                            new_line += 1;
-                        }
-                        diff_parser::Line::Removed(_) => {}
-                    }
-                }
-            }
+        // Files are independent, so the analyzer spreads them across a bounded pool of workers.
+        log::debug!(
+            "Scanning {} files with {} workers",
+            filtered_files.len(),
+            self.config.scan.workers()
+        );
+        let outcomes =
+            self.analyzer
+                .scan_changed(&filtered_files, &CancellationToken::new(), &on_findings)?;
 
-            for scanner in &self.scanners {
-                let mut found = scanner.scan(&file.path, &content, &self.config)?;
-                found.retain(|issue| changed_lines.contains(&issue.line_number));
-                if scanner.name() == "Convention Deviation Scanner" {
-                    for issue in found {
-                        code_quality.push(format!(
-                            "{}:{} - {}",
-                            issue.file_path, issue.line_number, issue.description
-                        ));
-                    }
-                } else {
-                    if let Some(t) = &self.telemetry {
-                        for issue in &found {
-                            t.finding(&issue.file_path, issue.line_number, &issue.title);
-                        }
-                    }
-                    issues.append(&mut found);
-                }
+        self.build_report(
+            start_time,
+            ScannedFiles {
+                reviewed: filtered_files.len(),
+                outcomes,
+                files_skipped,
+                churn_counts,
+            },
+        )
+        .await
+    }
+
+    /// Reviews every file of a tree held in memory, such as the blobs of a
+    /// git commit read without a checkout. Paths are relative to the root of
+    /// the tree and every line is reviewed. Path filters apply, with the
+    /// `.reviewlensignore` files taken from the tree itself rather than the
+    /// working directory. `on_findings` is called as in
+    /// [`ReviewEngine::run_streaming`].
+    pub async fn run_tree<F>(&self, files: &[SourceFile], on_findings: F) -> Result<ReviewReport>
+    where
+        F: Fn(&[Issue]) + Sync,
+    {
+        log::info!("Engine running with config: {:?}", self.config);
+        let start_time = Instant::now();
+        if let Some(t) = &self.telemetry {
+            t.run_started();
+        }
+
+        let ignore_files = files.iter().filter_map(|file| {
+            let path = Path::new(&file.path);
+            if path.file_name()? != IGNORE_FILE {
+                return None;
             }
+            let dir = path.parent().unwrap_or(Path::new("")).to_path_buf();
+            Some((dir, String::from_utf8_lossy(&file.content).into_owned()))
+        });
+        let path_filter = PathFilter::new(&self.config.paths)?.with_ignore_contents(ignore_files);
 
-            for other in &file_paths {
-                if other == &file.path {
-                    continue;
-                }
-                let stem = Path::new(other)
-                    .file_stem()
-                    .and_then(|s| s.to_str())
-                    .unwrap_or("");
-                if content.contains(&format!("use {}", stem))
-                    || content.contains(&format!("{}::", stem))
-                {
-                    interactions.insert((file.path.clone(), other.clone()));
+        let mut files_skipped = 0;
+        let filtered_files: Vec<&SourceFile> = files
+            .iter()
+            .filter(|file| {
+                let included = path_filter.is_included(Path::new(&file.path));
+                if !included {
+                    files_skipped += 1;
                 }
+                included
+            })
+            .collect();
+
+        log::debug!(
+            "Scanning {} files with {} workers",
+            filtered_files.len(),
+            self.config.scan.workers()
+        );
+        let outcomes =
+            self.analyzer
+                .scan_tree(&filtered_files, &CancellationToken::new(), &on_findings)?;
+
+        self.build_report(
+            start_time,
+            ScannedFiles {
+                reviewed: filtered_files.len(),
+                outcomes,
+                files_skipped,
+                churn_counts: HashMap::new(),
+            },
+        )
+        .await
+    }
+
+    /// Turns the outcome of the scanning stage into the final report, adding
+    /// the diagram, hotspots, retrieved context and summary.
+    async fn build_report(
+        &self,
+        start_time: Instant,
+        scanned: ScannedFiles,
+    ) -> Result<ReviewReport> {
+        let ScannedFiles {
+            reviewed,
+            outcomes,
+            mut files_skipped,
+            mut churn_counts,
+        } = scanned;
+        let mut total_tokens_used: u32 = 0;
+
+        let mut issues = Vec::new();
+        let mut code_quality = Vec::new();
+        let mut interactions = HashSet::new();
+        let mut files_scanned = 0;
+        for outcome in outcomes {
+            if outcome.skipped {
+                files_skipped += 1;
+            } else {
+                files_scanned += 1;
+            }
+            issues.extend(outcome.issues);
+            code_quality.extend(outcome.code_quality);
+            interactions.extend(outcome.interactions);
+        }
+        crate::analyzer::sort_issues(&mut issues);
+        if let Some(t) = &self.telemetry {
+            for issue in &issues {
+                t.finding(&issue.file_path, issue.line_number, &issue.title);
             }
         }
 
@@ -252,6 +367,10 @@ impl ReviewEngine {
         for issue in &issues {
             *issue_counts.entry(issue.file_path.clone()).or_insert(0) += 1;
         }
+        // Files with findings but no churn, as in a tree review, are still ranked.
+        for path in issue_counts.keys() {
+            churn_counts.entry(path.clone()).or_insert(0);
+        }
         let sev_w = self.config.report.hotspot_weights.severity;
         let churn_w = self.config.report.hotspot_weights.churn;
         let mut file_risks: Vec<(String, u32)> = churn_counts
@@ -332,7 +451,7 @@ impl ReviewEngine {
 
         // 7. Produce a summary either via LLM or fallback routine.
         let summary = if self.config.llm.provider == Provider::Null {
-            fallback_summary(filtered_files.len(), &issues)
+            fallback_summary(reviewed, &issues)
         } else {
             if let Some(max) = self.config.budget.tokens.max_per_run {
                 if total_tokens_used >= max {
@@ -366,6 +485,8 @@ impl ReviewEngine {
                 total_ms: elapsed_ms,
             },
             index_warm,
+            files_scanned,
+            files_skipped,
         };
 
         // 9. Build and return the ReviewReport.
@@ -384,15 +505,52 @@ impl ReviewEngine {
 
         Ok(report)
     }
-}
 
-fn build_globset(patterns: &[String]) -> Result<GlobSet> {
-    let mut builder = GlobSetBuilder::new();
-    for pattern in patterns {
-        let glob = Glob::new(pattern).map_err(|e| EngineError::Config(e.to_string()))?;
-        builder.add(glob);
+    /// Reviews a single source buffer, such as an editor's unsaved file, as
+    /// if it were the file at `file_path`. Every line is reviewed and path
+    /// filters are not applied. Unlike [`ReviewEngine::run`], no LLM or
+    /// index is consulted, so the summary is always the on-device one.
+    ///
+    /// Source that cannot be parsed is reported as a `parse-error` finding
+    /// rather than an error.
+    pub fn run_source(&self, file_path: &str, source: &[u8]) -> Result<ReviewReport> {
+        let start_time = Instant::now();
+        if let Some(t) = &self.telemetry {
+            t.run_started();
+        }
+
+        let outcome = self.analyzer.scan_source(file_path, source, None)?;
+        let issues = outcome.issues;
+        if let Some(t) = &self.telemetry {
+            for issue in &issues {
+                t.finding(&issue.file_path, issue.line_number, &issue.title);
+            }
+        }
+
+        let elapsed_ms = start_time.elapsed().as_millis();
+        let issue_count = issues.len();
+        let report = ReviewReport {
+            summary: fallback_summary(1, &issues),
+            issues,
+            code_quality: outcome.code_quality,
+            hotspots: Vec::new(),
+            mermaid_diagram: None,
+            config: self.config.clone(),
+            metadata: RuntimeMetadata {
+                ruleset_version: RULESET_VERSION.to_string(),
+                model: self.config.llm.model.clone(),
+                driver: self.config.llm.provider.as_str().to_string(),
+                timings: TimingInfo {
+                    total_ms: elapsed_ms,
+                },
+                index_warm: false,
+                files_scanned: usize::from(!outcome.skipped),
+                files_skipped: usize::from(outcome.skipped),
+            },
+        };
+        if let Some(t) = &self.telemetry {
+            t.run_finished(issue_count, elapsed_ms);
+        }
+        Ok(report)
     }
-    builder
-        .build()
-        .map_err(|e| EngineError::Config(e.to_string()))
 }
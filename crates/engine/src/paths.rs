@@ -0,0 +1,327 @@
+//! Path filtering for the files that are scanned and indexed.
+//!
+//! Patterns use gitignore-style globs and are matched against paths relative
+//! to the repository root:
+//!
+//! - a pattern without a `/` (other than a trailing one) matches at any depth,
+//!   so `*.pb.go` matches `api/v1/user.pb.go`;
+//! - a pattern containing a `/` is anchored at the root, and a leading `/` is
+//!   ignored, so `/vendor` and `vendor/lib` only match from the root;
+//! - `*` and `?` do not cross directory separators, while `**` does;
+//! - a pattern that matches a directory also matches everything below it, so
+//!   `vendor/` excludes the whole vendored tree.
+//!
+//! The same patterns select the paths whose findings have their severity
+//! adjusted by `path-severity-overrides`.
+//!
+//! On top of the configured patterns, paths can be skipped with
+//! `.reviewlensignore` files, which follow `.gitignore` syntax and semantics:
+//! rules apply relative to the directory of the file they are in, later rules
+//! and deeper files take precedence, `!` re-includes a path, and a trailing
+//! `/` restricts a rule to directories. As in git, a file cannot be
+//! re-included once a directory above it is ignored.
+
+use std::collections::HashMap;
+use std::fs;
+use std::path::{Component, Path, PathBuf};
+use std::sync::{Arc, Mutex};
+
+use globset::{GlobBuilder, GlobMatcher, GlobSet, GlobSetBuilder};
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+use crate::config::{PathSeverityOverride, PathsConfig, Severity};
+use crate::error::{EngineError, Result};
+
+/// The marker Go tools write at the top of generated files.
+static GENERATED_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^// Code generated .* DO NOT EDIT\.$").unwrap());
+
+/// The name of the ignore files read from the repository.
+pub const IGNORE_FILE: &str = ".reviewlensignore";
+
+/// Version control directories, which are never walked.
+pub const VCS_DIRS: [&str; 4] = [".git", ".hg", ".svn", ".bzr"];
+
+/// Decides which repository-relative paths are analyzed.
+#[derive(Debug, Clone)]
+pub struct PathFilter {
+    allow: GlobSet,
+    deny: GlobSet,
+    ignore: Option<IgnoreFiles>,
+}
+
+impl PathFilter {
+    /// Builds a filter from the `[paths]` configuration.
+    pub fn new(paths: &PathsConfig) -> Result<Self> {
+        Self::from_patterns(&paths.allow, &paths.deny)
+    }
+
+    /// Builds a filter from allow and deny patterns.
+    pub fn from_patterns(allow: &[String], deny: &[String]) -> Result<Self> {
+        Ok(Self {
+            allow: build_globset(allow)?,
+            deny: build_globset(deny)?,
+            ignore: None,
+        })
+    }
+
+    /// Also skips paths ignored by the `.reviewlensignore` files of the
+    /// repository at `root`. Ignore files are read the first time a path
+    /// below their directory is checked.
+    pub fn with_ignore_files(mut self, root: impl Into<PathBuf>) -> Self {
+        self.ignore = Some(IgnoreFiles {
+            source: IgnoreSource::Disk(root.into()),
+            loaded: Arc::default(),
+        });
+        self
+    }
+
+    /// Also skips paths ignored by the given `.reviewlensignore` files, read
+    /// from somewhere other than the working tree, such as a git tree. Each
+    /// entry is the repository-relative directory of a file and its content.
+    pub fn with_ignore_contents(
+        mut self,
+        files: impl IntoIterator<Item = (PathBuf, String)>,
+    ) -> Self {
+        let files = files
+            .into_iter()
+            .map(|(dir, content)| (normalize(&dir), content))
+            .collect();
+        self.ignore = Some(IgnoreFiles {
+            source: IgnoreSource::Memory(Arc::new(files)),
+            loaded: Arc::default(),
+        });
+        self
+    }
+
+    /// Returns `true` if `path` is allowed and neither denied nor ignored.
+    pub fn is_included(&self, path: &Path) -> bool {
+        let path = normalize(path);
+        self.allow.is_match(&path)
+            && !self.deny.is_match(&path)
+            && !self.ignore.as_ref().map_or(false, |i| i.is_ignored(&path))
+    }
+}
+
+/// The compiled `path-severity-overrides` entries.
+#[derive(Debug, Clone, Default)]
+pub struct SeverityOverrides {
+    overrides: Vec<(GlobSet, i8)>,
+}
+
+impl SeverityOverrides {
+    /// Compiles the globs of each override. Fails when an override has no
+    /// paths or a pattern is not a valid glob.
+    pub fn new(overrides: &[PathSeverityOverride]) -> Result<Self> {
+        let overrides = overrides
+            .iter()
+            .enumerate()
+            .map(|(i, o)| {
+                if o.paths.is_empty() {
+                    return Err(EngineError::Config(format!(
+                        "path-severity-overrides entry {} has no paths",
+                        i + 1
+                    )));
+                }
+                let globs = build_globset(&o.paths)
+                    .map_err(|e| EngineError::Config(format!("path-severity-overrides: {}", e)))?;
+                Ok((globs, o.adjust))
+            })
+            .collect::<Result<_>>()?;
+        Ok(Self { overrides })
+    }
+
+    /// Returns the severity of a finding in `path` that was reported with
+    /// `severity`. When several overrides match, the last one listed wins.
+    pub fn apply(&self, path: &Path, severity: &Severity) -> Severity {
+        let path = normalize(path);
+        match self
+            .overrides
+            .iter()
+            .rev()
+            .find(|(globs, _)| globs.is_match(&path))
+        {
+            Some((_, adjust)) => severity.adjusted(*adjust),
+            None => severity.clone(),
+        }
+    }
+}
+
+/// One rule of an ignore file.
+#[derive(Debug)]
+struct IgnoreRule {
+    matcher: GlobMatcher,
+    negated: bool,
+    dir_only: bool,
+}
+
+/// Parses the rules of an ignore file, skipping (with a warning) patterns
+/// that are not valid globs.
+fn parse_ignore_file(content: &str, source: &Path) -> Vec<IgnoreRule> {
+    let mut rules = Vec::new();
+    for line in content.lines() {
+        let mut pattern = line.trim_end();
+        if pattern.is_empty() || pattern.starts_with('#') {
+            continue;
+        }
+        let negated = pattern.starts_with('!');
+        if negated {
+            pattern = &pattern[1..];
+        } else if pattern.starts_with("\\!") || pattern.starts_with("\\#") {
+            pattern = &pattern[1..];
+        }
+        let dir_only = pattern.ends_with('/');
+        let pattern = pattern.trim_end_matches('/');
+        if pattern.is_empty() {
+            continue;
+        }
+        let anchored = pattern.contains('/');
+        let pattern = pattern.trim_start_matches('/');
+        let glob = if anchored || pattern.starts_with("**") {
+            pattern.to_string()
+        } else {
+            format!("**/{}", pattern)
+        };
+        match GlobBuilder::new(&glob).literal_separator(true).build() {
+            Ok(glob) => rules.push(IgnoreRule {
+                matcher: glob.compile_matcher(),
+                negated,
+                dir_only,
+            }),
+            Err(e) => log::warn!(
+                "Ignoring invalid pattern `{}` in {}: {}",
+                line,
+                source.display(),
+                e
+            ),
+        }
+    }
+    rules
+}
+
+/// Where the `.reviewlensignore` files are read from.
+#[derive(Debug, Clone)]
+enum IgnoreSource {
+    /// The working tree of the repository at this root.
+    Disk(PathBuf),
+    /// File contents by repository-relative directory.
+    Memory(Arc<HashMap<PathBuf, String>>),
+}
+
+/// The `.reviewlensignore` files of a repository, loaded per directory.
+#[derive(Debug, Clone)]
+struct IgnoreFiles {
+    source: IgnoreSource,
+    /// Rules by repository-relative directory; empty for directories without
+    /// an ignore file.
+    loaded: Arc<Mutex<HashMap<PathBuf, Arc<Vec<IgnoreRule>>>>>,
+}
+
+impl IgnoreFiles {
+    /// Returns the rules of the ignore file in `dir`.
+    fn rules(&self, dir: &Path) -> Arc<Vec<IgnoreRule>> {
+        let mut loaded = self.loaded.lock().unwrap();
+        loaded
+            .entry(dir.to_path_buf())
+            .or_insert_with(|| {
+                let rules = match &self.source {
+                    IgnoreSource::Disk(root) => {
+                        let path = root.join(dir).join(IGNORE_FILE);
+                        match fs::read_to_string(&path) {
+                            Ok(content) => parse_ignore_file(&content, &path),
+                            Err(_) => Vec::new(),
+                        }
+                    }
+                    IgnoreSource::Memory(files) => match files.get(dir) {
+                        Some(content) => parse_ignore_file(content, &dir.join(IGNORE_FILE)),
+                        None => Vec::new(),
+                    },
+                };
+                Arc::new(rules)
+            })
+            .clone()
+    }
+
+    /// Returns `true` if `path`, or a directory above it, is ignored.
+    fn is_ignored(&self, path: &Path) -> bool {
+        let components: Vec<Component> = path.components().collect();
+        let mut parent = PathBuf::new();
+        for (i, component) in components.iter().enumerate() {
+            let candidate = parent.join(component);
+            let is_dir = i + 1 < components.len();
+            if self.matches(&parent, &candidate, is_dir) {
+                return true;
+            }
+            parent = candidate;
+        }
+        false
+    }
+
+    /// Applies the ignore files from the root down to `parent` to
+    /// `candidate`, which is in `parent`. The last matching rule decides.
+    fn matches(&self, parent: &Path, candidate: &Path, is_dir: bool) -> bool {
+        let mut ignored = false;
+        let mut dirs: Vec<&Path> = parent.ancestors().collect();
+        dirs.reverse();
+        for dir in dirs {
+            let relative = candidate.strip_prefix(dir).unwrap_or(candidate);
+            for rule in self.rules(dir).iter() {
+                if (!rule.dir_only || is_dir) && rule.matcher.is_match(relative) {
+                    ignored = !rule.negated;
+                }
+            }
+        }
+        ignored
+    }
+}
+
+/// Drops `.` components so `./src/main.go` and `src/main.go` match alike.
+fn normalize(path: &Path) -> PathBuf {
+    path.components()
+        .filter(|c| !matches!(c, Component::CurDir | Component::RootDir))
+        .collect()
+}
+
+/// Expands a gitignore-style pattern into the globs that implement it.
+fn expand(pattern: &str) -> Vec<String> {
+    let trimmed = pattern.trim_end_matches('/');
+    let anchored = trimmed.starts_with('/') || trimmed.contains('/');
+    let trimmed = trimmed.trim_start_matches('/');
+    let base = if anchored || trimmed.starts_with("**") {
+        trimmed.to_string()
+    } else {
+        format!("**/{}", trimmed)
+    };
+    let descendants = format!("{}/**", base);
+    vec![base, descendants]
+}
+
+fn build_globset(patterns: &[String]) -> Result<GlobSet> {
+    let mut builder = GlobSetBuilder::new();
+    for pattern in patterns {
+        for expanded in expand(pattern) {
+            let glob = GlobBuilder::new(&expanded)
+                .literal_separator(true)
+                .build()
+                .map_err(|e| {
+                    EngineError::Config(format!("invalid path pattern `{}`: {}", pattern, e))
+                })?;
+            builder.add(glob);
+        }
+    }
+    builder
+        .build()
+        .map_err(|e| EngineError::Config(e.to_string()))
+}
+
+/// Returns `true` if `content` starts with a `// Code generated ... DO NOT
+/// EDIT.` comment, following the Go convention. Only the comments before the
+/// first line of code are considered.
+pub fn is_generated(content: &str) -> bool {
+    content
+        .lines()
+        .map(str::trim_end)
+        .take_while(|line| line.is_empty() || line.starts_with("//"))
+        .any(|line| GENERATED_REGEX.is_match(line))
+}
@@ -4,8 +4,8 @@
 //! and retrieving relevant context to inform the LLM's analysis.
 
 use crate::error::{EngineError, Result};
+use crate::paths::{PathFilter, VCS_DIRS};
 use async_trait::async_trait;
-use globset::{Glob, GlobSet, GlobSetBuilder};
 use regex::Regex;
 use serde::{Deserialize, Serialize};
 use std::collections::hash_map::DefaultHasher;
@@ -16,8 +16,6 @@ use std::path::Path;
 use std::time::UNIX_EPOCH;
 use walkdir::WalkDir;
 
-const VCS_DIRS: [&str; 4] = [".git", ".hg", ".svn", ".bzr"];
-
 /// Represents a single indexed document along with extracted metadata.
 #[derive(Clone, Serialize, Deserialize)]
 pub struct Document {
@@ -262,8 +260,8 @@ impl InMemoryVectorStore {
 /// Indexes all files under `path` and populates an `InMemoryVectorStore`.
 ///
 /// Files are filtered using the provided allow and deny glob patterns. Paths
-/// matching any deny pattern or not matching any allow pattern are skipped.
-/// Version control directories such as `.git` are ignored automatically.
+/// matching any deny pattern or not matching any allow pattern are skipped,
+/// as are paths ignored by `.reviewlensignore` files. Version control directories such as `.git` are ignored automatically.
 ///
 /// If `force` is `false` and an index already exists at `output`, the existing
 /// index is loaded from disk and only files whose modification times have
@@ -288,8 +286,7 @@ where
         force
     );
 
-    let allow_set = build_globset(allow)?;
-    let deny_set = build_globset(deny)?;
+    let path_filter = PathFilter::from_patterns(allow, deny)?.with_ignore_files(path_ref);
 
     let mut store = if !force && output_ref.exists() {
         log::info!("Loading existing index from {}", output_ref.display());
@@ -319,7 +316,7 @@ where
     {
         if entry.file_type().is_file() {
             let rel_path = entry.path().strip_prefix(path_ref).unwrap_or(entry.path());
-            if !(allow_set.is_match(rel_path) && !deny_set.is_match(rel_path)) {
+            if !path_filter.is_included(rel_path) {
                 continue;
             }
             let filename = rel_path.display().to_string();
@@ -370,14 +367,3 @@ where
     log::info!("Indexed {} files", store.len());
     Ok(store)
 }
-
-fn build_globset(patterns: &[String]) -> Result<GlobSet> {
-    let mut builder = GlobSetBuilder::new();
-    for pattern in patterns {
-        let glob = Glob::new(pattern).map_err(|e| EngineError::Config(e.to_string()))?;
-        builder.add(glob);
-    }
-    builder
-        .build()
-        .map_err(|e| EngineError::Config(e.to_string()))
-}
@@ -0,0 +1,80 @@
+//! GitHub Actions workflow command output.
+//!
+//! Each issue becomes an `::error`, `::warning` or `::notice` command. When
+//! printed from a workflow step, GitHub turns these into inline annotations
+//! on the pull request.
+
+use super::{ReportGenerator, ReviewReport};
+use crate::config::Severity;
+use crate::error::Result;
+use crate::scanner::Issue;
+
+/// A generator for GitHub Actions workflow commands.
+pub struct GithubGenerator;
+
+/// Maps an issue severity onto a workflow command.
+fn command(severity: &Severity) -> &'static str {
+    match severity {
+        Severity::Critical | Severity::High => "error",
+        Severity::Medium => "warning",
+        Severity::Low | Severity::Info => "notice",
+    }
+}
+
+/// Escapes the message part of a workflow command.
+fn escape_data(value: &str) -> String {
+    value
+        .replace('%', "%25")
+        .replace('\r', "%0D")
+        .replace('\n', "%0A")
+}
+
+/// Escapes a property value, which additionally may not contain `:` or `,`.
+fn escape_property(value: &str) -> String {
+    escape_data(value).replace(':', "%3A").replace(',', "%2C")
+}
+
+fn annotation(issue: &Issue) -> String {
+    let mut properties = vec![
+        format!("file={}", escape_property(&issue.file_path)),
+        format!("line={}", issue.line_number),
+    ];
+    if let Some(column) = issue.column {
+        properties.push(format!("col={}", column));
+    }
+    if let Some(end_column) = issue.end_column {
+        properties.push(format!("endColumn={}", end_column));
+    }
+    properties.push(format!(
+        "title={}",
+        escape_property(&format!(
+            "[{}] {} ({})",
+            issue.category.as_str(),
+            issue.title,
+            issue.rule_id
+        ))
+    ));
+
+    let mut message = issue.description.clone();
+    if let Some(fix) = &issue.suggested_fix {
+        message.push_str("\nSuggested fix: ");
+        message.push_str(fix);
+    }
+    format!(
+        "::{} {}::{}",
+        command(&issue.severity),
+        properties.join(","),
+        escape_data(&message)
+    )
+}
+
+impl ReportGenerator for GithubGenerator {
+    fn generate(&self, report: &ReviewReport) -> Result<String> {
+        let mut out = String::new();
+        for issue in &report.issues {
+            out.push_str(&annotation(issue));
+            out.push('\n');
+        }
+        Ok(out)
+    }
+}
@@ -0,0 +1,29 @@
+//! JSON Lines output.
+//!
+//! Each issue is written as one self-contained JSON object per line, with
+//! the same fields as the entries of the `issues` array in JSON reports.
+//! Lines can be produced one at a time with [`issue_line`], which lets the
+//! CLI stream findings while a review is still running.
+
+use super::{ReportGenerator, ReviewReport};
+use crate::error::{EngineError, Result};
+use crate::scanner::Issue;
+
+/// A generator for JSON Lines reports.
+pub struct JsonLinesGenerator;
+
+/// Encodes a single issue as a JSON line, without the trailing newline.
+pub fn issue_line(issue: &Issue) -> Result<String> {
+    serde_json::to_string(issue).map_err(|e| EngineError::Report(e.to_string()))
+}
+
+impl ReportGenerator for JsonLinesGenerator {
+    fn generate(&self, report: &ReviewReport) -> Result<String> {
+        let mut out = String::new();
+        for issue in &report.issues {
+            out.push_str(&issue_line(issue)?);
+            out.push('\n');
+        }
+        Ok(out)
+    }
+}
@@ -0,0 +1,107 @@
+//! JUnit XML output for build servers that render test results.
+//!
+//! Each rule that reported findings becomes a `<testsuite>` named after the
+//! rule id, and each finding a `<testcase>` in it with a `<failure>` holding
+//! the message and location. Findings matched by the baseline are emitted as
+//! skipped test cases instead, since they do not fail the run. Rules without
+//! findings are left out, and the counts on every suite and on the enclosing
+//! `<testsuites>` element match the test cases emitted.
+
+use std::collections::BTreeMap;
+
+use super::{ReportGenerator, ReviewReport};
+use crate::error::Result;
+use crate::scanner::Issue;
+
+/// A generator for JUnit XML reports.
+pub struct JunitGenerator;
+
+/// Escapes text for use in XML content and attribute values. Characters XML
+/// 1.0 cannot represent are dropped.
+fn escape_xml(value: &str) -> String {
+    let mut out = String::with_capacity(value.len());
+    for c in value.chars() {
+        match c {
+            '&' => out.push_str("&amp;"),
+            '<' => out.push_str("&lt;"),
+            '>' => out.push_str("&gt;"),
+            '"' => out.push_str("&quot;"),
+            '\'' => out.push_str("&apos;"),
+            '\t' | '\n' | '\r' => out.push(c),
+            c if c < ' ' => {}
+            c => out.push(c),
+        }
+    }
+    out
+}
+
+/// The `file:line[:column]` location of an issue.
+fn location(issue: &Issue) -> String {
+    match issue.column {
+        Some(column) => format!("{}:{}:{}", issue.file_path, issue.line_number, column),
+        None => format!("{}:{}", issue.file_path, issue.line_number),
+    }
+}
+
+fn testcase(issue: &Issue) -> String {
+    let location = location(issue);
+    let mut out = format!(
+        "    <testcase name=\"{}\" classname=\"{}\" file=\"{}\" line=\"{}\">\n",
+        escape_xml(&location),
+        escape_xml(&issue.rule_id),
+        escape_xml(&issue.file_path),
+        issue.line_number
+    );
+    if issue.baselined {
+        out.push_str("      <skipped message=\"Recorded in the baseline\"/>\n");
+    } else {
+        let mut body = format!("{}\n{}", location, issue.description);
+        if let Some(fix) = &issue.suggested_fix {
+            body.push_str("\nSuggested fix: ");
+            body.push_str(fix);
+        }
+        out.push_str(&format!(
+            "      <failure message=\"{}\" type=\"{}\">{}</failure>\n",
+            escape_xml(&issue.title),
+            issue.severity.as_str(),
+            escape_xml(&body)
+        ));
+    }
+    out.push_str("    </testcase>\n");
+    out
+}
+
+impl ReportGenerator for JunitGenerator {
+    fn generate(&self, report: &ReviewReport) -> Result<String> {
+        let mut by_rule: BTreeMap<&str, Vec<&Issue>> = BTreeMap::new();
+        for issue in &report.issues {
+            by_rule.entry(&issue.rule_id).or_default().push(issue);
+        }
+
+        let skipped = report.issues.iter().filter(|i| i.baselined).count();
+        let mut out = String::from("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n");
+        out.push_str(&format!(
+            "<testsuites name=\"reviewlens\" tests=\"{}\" failures=\"{}\" errors=\"0\" skipped=\"{}\" time=\"{:.3}\">\n",
+            report.issues.len(),
+            report.issues.len() - skipped,
+            skipped,
+            report.metadata.timings.total_ms as f64 / 1000.0
+        ));
+        for (rule_id, issues) in by_rule {
+            let skipped = issues.iter().filter(|i| i.baselined).count();
+            out.push_str(&format!(
+                "  <testsuite name=\"{}\" tests=\"{}\" failures=\"{}\" errors=\"0\" skipped=\"{}\">\n",
+                escape_xml(rule_id),
+                issues.len(),
+                issues.len() - skipped,
+                skipped
+            ));
+            for issue in issues {
+                out.push_str(&testcase(issue));
+            }
+            out.push_str("  </testsuite>\n");
+        }
+        out.push_str("</testsuites>\n");
+        Ok(out)
+    }
+}
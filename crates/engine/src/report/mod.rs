@@ -28,6 +28,11 @@ pub struct RuntimeMetadata {
     pub timings: TimingInfo,
     /// Whether the vector index was warm (true) or cold (false).
     pub index_warm: bool,
+    /// Number of files from the diff that were analyzed.
+    pub files_scanned: usize,
+    /// Number of files from the diff that were skipped, either by the path
+    /// filters or as generated code.
+    pub files_skipped: usize,
 }
 
 /// Represents the final, consolidated review findings.
@@ -61,11 +66,31 @@ pub trait ReportGenerator {
 }
 
 /// A generator for creating Markdown-formatted reports.
-pub struct MarkdownGenerator;
+#[derive(Debug, Default)]
+pub struct MarkdownGenerator {
+    /// The order of the findings table. Most severe first when unset.
+    pub sort: Option<SortKey>,
+}
 
 /// A generator for creating JSON-formatted reports.
 pub struct JsonGenerator;
 
+pub mod github;
+pub mod jsonl;
+pub mod junit;
+pub mod order;
+pub mod sarif;
+pub mod schema;
+pub mod stats;
+pub mod text;
+pub use github::GithubGenerator;
+pub use jsonl::JsonLinesGenerator;
+pub use junit::JunitGenerator;
+pub use order::{sort_issues, GroupBy, SortKey};
+pub use sarif::SarifGenerator;
+pub use stats::ReportStats;
+pub use text::{CompactTextGenerator, TextGenerator};
+
 impl ReportGenerator for MarkdownGenerator {
     fn generate(&self, report: &ReviewReport) -> Result<String> {
         let mut md = String::new();
@@ -79,17 +104,23 @@ impl ReportGenerator for MarkdownGenerator {
         md.push_str("## 🚨 Security Findings\n\n");
 
         let mut sorted_issues = report.issues.clone();
-        sorted_issues.sort_by(|a, b| b.severity.cmp(&a.severity));
+        match self.sort {
+            Some(key) => sort_issues(&mut sorted_issues, key),
+            None => sorted_issues.sort_by(|a, b| b.severity.cmp(&a.severity)),
+        }
 
         if sorted_issues.is_empty() {
             md.push_str("✅ No issues found.\n");
         } else {
-            md.push_str("| Severity | Title | File:Line | Description | Suggested Fix |\n");
-            md.push_str("|---|---|---|---|---|\n");
+            md.push_str(
+                "| Severity | Category | Title | File:Line | Description | Suggested Fix |\n",
+            );
+            md.push_str("|---|---|---|---|---|---|\n");
             for issue in &sorted_issues {
                 md.push_str(&format!(
-                    "| `{:?}` | {} | `{}:{}` | {} | {} |\n",
+                    "| `{:?}` | {} | {} | `{}:{}` | {} | {} |\n",
                     issue.severity,
+                    issue.category.as_str(),
                     issue.title,
                     issue.file_path,
                     issue.line_number,
@@ -147,6 +178,11 @@ impl ReportGenerator for MarkdownGenerator {
             md.push_str("\n```\n");
         }
 
+        md.push_str("\n## Statistics\n\n");
+        for line in ReportStats::from_report(report).lines() {
+            md.push_str(&format!("- {}\n", line));
+        }
+
         md.push_str("\n---\n\n");
         md.push_str("## Appendix\n\n");
 
@@ -169,9 +205,22 @@ impl ReportGenerator for MarkdownGenerator {
     }
 }
 
+/// The JSON report, led by the version of the schema it follows.
+#[derive(Serialize)]
+struct VersionedReport<'a> {
+    #[serde(rename = "schemaVersion")]
+    schema_version: &'static str,
+    #[serde(flatten)]
+    report: &'a ReviewReport,
+}
+
 impl ReportGenerator for JsonGenerator {
     fn generate(&self, report: &ReviewReport) -> Result<String> {
-        serde_json::to_string_pretty(report)
+        let versioned = VersionedReport {
+            schema_version: schema::REPORT_SCHEMA_VERSION,
+            report,
+        };
+        serde_json::to_string_pretty(&versioned)
             .map_err(|e| crate::error::EngineError::Report(e.to_string()))
     }
 }
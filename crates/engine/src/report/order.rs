@@ -0,0 +1,60 @@
+//! Ordering and grouping of findings in reports.
+//!
+//! Findings are normally listed by file, line and column, the order the
+//! analyzer returns them in. Reviewers can instead sort them by severity for
+//! triage or by rule to look at one kind of problem at a time. Every order
+//! ends with the file, line, column and rule id, so ties are broken the same
+//! way on every run.
+
+use std::cmp::Ordering;
+
+use clap::ValueEnum;
+
+use crate::scanner::Issue;
+
+/// The order findings are listed in.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Default, ValueEnum)]
+pub enum SortKey {
+    /// Most severe first.
+    Severity,
+    /// By file path, line and column. The default.
+    #[default]
+    File,
+    /// By rule id.
+    Rule,
+}
+
+/// How the findings of a text report are split into sections.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Default, ValueEnum)]
+pub enum GroupBy {
+    /// One section per rule id.
+    Rule,
+    /// One section per file. The default.
+    #[default]
+    File,
+    /// A single list without section headers.
+    None,
+}
+
+/// Compares two issues by location, then rule id.
+fn by_location(a: &Issue, b: &Issue) -> Ordering {
+    (&a.file_path, a.line_number, a.column, &a.rule_id).cmp(&(
+        &b.file_path,
+        b.line_number,
+        b.column,
+        &b.rule_id,
+    ))
+}
+
+/// Sorts `issues` by `key`.
+pub fn sort_issues(issues: &mut [Issue], key: SortKey) {
+    match key {
+        SortKey::Severity => {
+            issues.sort_by(|a, b| b.severity.cmp(&a.severity).then_with(|| by_location(a, b)))
+        }
+        SortKey::File => issues.sort_by(by_location),
+        SortKey::Rule => {
+            issues.sort_by(|a, b| a.rule_id.cmp(&b.rule_id).then_with(|| by_location(a, b)))
+        }
+    }
+}
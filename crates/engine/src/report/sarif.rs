@@ -0,0 +1,246 @@
+//! SARIF 2.1.0 report generation.
+//!
+//! The output follows the OASIS SARIF 2.1.0 specification so it can be
+//! uploaded to GitHub code scanning and other SARIF-aware tools.
+
+use std::collections::BTreeSet;
+
+use serde_json::{json, Map, Value};
+
+use super::{ReportGenerator, ReviewReport};
+use crate::config::Severity;
+use crate::error::{EngineError, Result};
+use crate::fix::Fix;
+use crate::scanner::{owasp_name, rule_info, Issue, RuleInfo};
+
+const SARIF_SCHEMA: &str = "https://json.schemastore.org/sarif-2.1.0.json";
+const TOOL_INFORMATION_URI: &str = "https://github.com/Review-LensAi/reviewlens";
+const CWE_TAXONOMY: &str = "CWE";
+const OWASP_TAXONOMY: &str = "OWASP Top 10";
+
+/// A generator for creating SARIF 2.1.0 reports.
+pub struct SarifGenerator;
+
+/// Maps an issue severity onto a SARIF result level.
+fn sarif_level(severity: &Severity) -> &'static str {
+    match severity {
+        Severity::Critical | Severity::High => "error",
+        Severity::Medium => "warning",
+        Severity::Low | Severity::Info => "note",
+    }
+}
+
+/// Builds the `region` object for an issue. Issues without column
+/// information only carry a `startLine`, which is still a valid region.
+fn region(issue: &Issue) -> Value {
+    let mut region = Map::new();
+    region.insert("startLine".into(), json!(issue.line_number));
+    if let Some(column) = issue.column {
+        region.insert("startColumn".into(), json!(column));
+        region.insert("endLine".into(), json!(issue.line_number));
+        if let Some(end_column) = issue.end_column {
+            region.insert("endColumn".into(), json!(end_column));
+        }
+    }
+    Value::Object(region)
+}
+
+/// Builds the `fixes` array for an issue with a suggested fix.
+fn fixes(issue: &Issue, fix: &Fix) -> Value {
+    let replacements: Vec<Value> = fix
+        .edits
+        .iter()
+        .map(|edit| {
+            json!({
+                "deletedRegion": {
+                    "startLine": edit.start_line,
+                    "startColumn": edit.start_column,
+                    "endLine": edit.end_line,
+                    "endColumn": edit.end_column,
+                },
+                "insertedContent": { "text": edit.replacement },
+            })
+        })
+        .collect();
+    json!([{
+        "description": { "text": fix.description },
+        "artifactChanges": [{
+            "artifactLocation": { "uri": issue.file_path },
+            "replacements": replacements,
+        }],
+    }])
+}
+
+/// The tag GitHub code scanning uses to show a CWE, e.g.
+/// `external/cwe/cwe-079` for `CWE-79`.
+fn cwe_tag(id: &str) -> String {
+    let number = id.trim_start_matches("CWE-");
+    match number.parse::<u32>() {
+        Ok(number) => format!("external/cwe/cwe-{:03}", number),
+        Err(_) => format!("external/cwe/{}", id.to_lowercase()),
+    }
+}
+
+/// Links a rule to the CWE and OWASP Top 10 taxa it belongs to.
+fn relationships(info: &RuleInfo) -> Vec<Value> {
+    let cwe = info.cwe.iter().map(|id| (*id, CWE_TAXONOMY));
+    let owasp = info.owasp.map(|id| (id, OWASP_TAXONOMY));
+    cwe.chain(owasp)
+        .map(|(id, taxonomy)| {
+            json!({
+                "target": { "id": id, "toolComponent": { "name": taxonomy } },
+                "kinds": ["superset"],
+            })
+        })
+        .collect()
+}
+
+/// The `taxonomies` describing every CWE and OWASP category referenced by
+/// the rules in the report.
+fn taxonomies(rules: &[RuleInfo]) -> Vec<Value> {
+    let cwe: BTreeSet<&str> = rules
+        .iter()
+        .flat_map(|info| info.cwe.iter().copied())
+        .collect();
+    let owasp: BTreeSet<&str> = rules.iter().filter_map(|info| info.owasp).collect();
+    let mut taxonomies = Vec::new();
+    if !cwe.is_empty() {
+        let taxa: Vec<Value> = cwe
+            .into_iter()
+            .map(|id| {
+                json!({
+                    "id": id,
+                    "helpUri": format!(
+                        "https://cwe.mitre.org/data/definitions/{}.html",
+                        id.trim_start_matches("CWE-")
+                    ),
+                })
+            })
+            .collect();
+        taxonomies.push(json!({
+            "name": CWE_TAXONOMY,
+            "organization": "MITRE",
+            "informationUri": "https://cwe.mitre.org/",
+            "taxa": taxa,
+        }));
+    }
+    if !owasp.is_empty() {
+        let taxa: Vec<Value> = owasp
+            .into_iter()
+            .map(|id| {
+                let mut taxon = json!({ "id": id });
+                if let Some(name) = owasp_name(id) {
+                    taxon["name"] = json!(name);
+                }
+                taxon
+            })
+            .collect();
+        taxonomies.push(json!({
+            "name": OWASP_TAXONOMY,
+            "version": "2021",
+            "organization": "OWASP",
+            "informationUri": "https://owasp.org/Top10/",
+            "taxa": taxa,
+        }));
+    }
+    taxonomies
+}
+
+impl ReportGenerator for SarifGenerator {
+    fn generate(&self, report: &ReviewReport) -> Result<String> {
+        // Only rules that actually fired are described in the driver.
+        let fired: BTreeSet<&str> = report.issues.iter().map(|i| i.rule_id.as_str()).collect();
+        let rule_ids: Vec<&str> = fired.into_iter().collect();
+
+        let rules: Vec<Value> = rule_ids
+            .iter()
+            .map(|id| {
+                let mut rule = Map::new();
+                rule.insert("id".into(), json!(id));
+                match rule_info(id) {
+                    Some(info) => {
+                        rule.insert(
+                            "shortDescription".into(),
+                            json!({ "text": info.short_description }),
+                        );
+                        rule.insert("helpUri".into(), json!(info.help_uri));
+                        let mut tags = vec![info.category.as_str().to_string()];
+                        tags.extend(info.cwe.iter().map(|id| cwe_tag(id)));
+                        rule.insert("properties".into(), json!({ "tags": tags }));
+                        let relationships = relationships(&info);
+                        if !relationships.is_empty() {
+                            rule.insert("relationships".into(), json!(relationships));
+                        }
+                    }
+                    None => {
+                        // Fall back to the title of the first issue for unknown rules.
+                        let title = report
+                            .issues
+                            .iter()
+                            .find(|i| i.rule_id == *id)
+                            .map(|i| i.title.as_str())
+                            .unwrap_or(id);
+                        rule.insert("shortDescription".into(), json!({ "text": title }));
+                    }
+                }
+                Value::Object(rule)
+            })
+            .collect();
+
+        let infos: Vec<RuleInfo> = rule_ids.iter().filter_map(|id| rule_info(id)).collect();
+        let taxonomies = taxonomies(&infos);
+
+        let results: Vec<Value> = report
+            .issues
+            .iter()
+            .map(|issue| {
+                let rule_index = rule_ids.iter().position(|id| *id == issue.rule_id);
+                let mut result = json!({
+                    "ruleId": issue.rule_id,
+                    "ruleIndex": rule_index,
+                    "level": sarif_level(&issue.severity),
+                    "properties": { "category": issue.category.as_str() },
+                    "message": { "text": format!("{}: {}", issue.title, issue.description) },
+                    "locations": [{
+                        "physicalLocation": {
+                            "artifactLocation": { "uri": issue.file_path },
+                            "region": region(issue),
+                        }
+                    }],
+                });
+                if let Some(fix) = &issue.fix {
+                    result["fixes"] = fixes(issue, fix);
+                }
+                result
+            })
+            .collect();
+
+        let mut sarif = json!({
+            "$schema": SARIF_SCHEMA,
+            "version": "2.1.0",
+            "runs": [{
+                "tool": {
+                    "driver": {
+                        "name": "reviewlens",
+                        "version": env!("CARGO_PKG_VERSION"),
+                        "semanticVersion": env!("CARGO_PKG_VERSION"),
+                        "informationUri": TOOL_INFORMATION_URI,
+                        "rules": rules,
+                    }
+                },
+                "columnKind": "unicodeCodePoints",
+                "results": results,
+            }]
+        });
+        if !taxonomies.is_empty() {
+            let names: Vec<Value> = taxonomies
+                .iter()
+                .map(|taxonomy| json!({ "name": taxonomy["name"] }))
+                .collect();
+            sarif["runs"][0]["tool"]["driver"]["supportedTaxonomies"] = json!(names);
+            sarif["runs"][0]["taxonomies"] = json!(taxonomies);
+        }
+
+        serde_json::to_string_pretty(&sarif).map_err(|e| EngineError::Report(e.to_string()))
+    }
+}
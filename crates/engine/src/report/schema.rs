@@ -0,0 +1,211 @@
+//! The JSON schema of the `json` report, and a validator for it.
+//!
+//! The schema is embedded in the binary so `reviewlens validate` works
+//! offline and always checks against the shape the binary writes. Reports
+//! carry the version in a top-level `schemaVersion` field. The version must
+//! be bumped whenever the schema changes, and again in the major component
+//! when a field is removed, renamed or changes type; the schema tests fail
+//! until it is.
+//!
+//! The validator implements the part of JSON Schema the report schema uses:
+//! `type`, `const`, `enum`, `required`, `properties`, `additionalProperties`,
+//! `items`, `minimum` and local `$ref`s. Other keywords are ignored.
+
+use std::fmt;
+
+use serde_json::Value;
+
+/// The version of the report schema, written as `schemaVersion`.
+pub const REPORT_SCHEMA_VERSION: &str = "1.1";
+
+/// The report schema, as JSON Schema (draft 2020-12).
+pub const REPORT_SCHEMA: &str = include_str!("../../schema/report.schema.json");
+
+/// A place where a document does not match the schema.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SchemaViolation {
+    /// JSON pointer to the offending value, e.g. `/issues/0/severity`.
+    pub path: String,
+    pub message: String,
+}
+
+impl fmt::Display for SchemaViolation {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        let path = if self.path.is_empty() {
+            "/"
+        } else {
+            &self.path
+        };
+        write!(f, "{}: {}", path, self.message)
+    }
+}
+
+/// Returns the embedded report schema.
+pub fn report_schema() -> Value {
+    serde_json::from_str(REPORT_SCHEMA).expect("the embedded report schema is valid JSON")
+}
+
+/// Checks a report against the embedded schema and returns every
+/// violation found, in document order.
+pub fn validate_report(report: &Value) -> Vec<SchemaViolation> {
+    let schema = report_schema();
+    let mut violations = Vec::new();
+    validate(&schema, &schema, report, "", &mut violations);
+    violations
+}
+
+/// Returns `true` if `value` is of the JSON Schema type `name`.
+fn type_matches(name: &str, value: &Value) -> bool {
+    match name {
+        "null" => value.is_null(),
+        "boolean" => value.is_boolean(),
+        "string" => value.is_string(),
+        "array" => value.is_array(),
+        "object" => value.is_object(),
+        "number" => value.is_number(),
+        "integer" => value.is_i64() || value.is_u64(),
+        _ => false,
+    }
+}
+
+fn type_name(value: &Value) -> &'static str {
+    match value {
+        Value::Null => "null",
+        Value::Bool(_) => "boolean",
+        Value::String(_) => "string",
+        Value::Array(_) => "array",
+        Value::Object(_) => "object",
+        Value::Number(n) if n.is_f64() => "number",
+        Value::Number(_) => "integer",
+    }
+}
+
+/// Escapes a key for use in a JSON pointer.
+fn pointer_segment(key: &str) -> String {
+    key.replace('~', "~0").replace('/', "~1")
+}
+
+fn fail(violations: &mut Vec<SchemaViolation>, path: &str, message: String) {
+    violations.push(SchemaViolation {
+        path: path.to_string(),
+        message,
+    });
+}
+
+fn validate(
+    root: &Value,
+    schema: &Value,
+    value: &Value,
+    path: &str,
+    violations: &mut Vec<SchemaViolation>,
+) {
+    if let Some(reference) = schema.get("$ref").and_then(Value::as_str) {
+        match reference
+            .strip_prefix('#')
+            .and_then(|pointer| root.pointer(pointer))
+        {
+            Some(target) => validate(root, target, value, path, violations),
+            None => fail(
+                violations,
+                path,
+                format!("unresolvable schema reference `{}`", reference),
+            ),
+        }
+        return;
+    }
+
+    if let Some(expected) = schema.get("type") {
+        let names: Vec<&str> = match expected {
+            Value::String(name) => vec![name.as_str()],
+            Value::Array(names) => names.iter().filter_map(Value::as_str).collect(),
+            _ => Vec::new(),
+        };
+        if !names.iter().any(|name| type_matches(name, value)) {
+            fail(
+                violations,
+                path,
+                format!(
+                    "expected {}, found {}",
+                    names.join(" or "),
+                    type_name(value)
+                ),
+            );
+            return;
+        }
+    }
+    if let Some(expected) = schema.get("const") {
+        if value != expected {
+            fail(
+                violations,
+                path,
+                format!("expected {}, found {}", expected, value),
+            );
+            return;
+        }
+    }
+    if let Some(allowed) = schema.get("enum").and_then(Value::as_array) {
+        if !allowed.contains(value) {
+            let allowed: Vec<String> = allowed.iter().map(Value::to_string).collect();
+            fail(
+                violations,
+                path,
+                format!("expected one of {}, found {}", allowed.join(", "), value),
+            );
+            return;
+        }
+    }
+    if let (Some(minimum), Some(number)) = (
+        schema.get("minimum").and_then(Value::as_f64),
+        value.as_f64(),
+    ) {
+        if number < minimum {
+            fail(
+                violations,
+                path,
+                format!("{} is less than the minimum of {}", number, minimum),
+            );
+        }
+    }
+
+    if let Value::Object(object) = value {
+        if let Some(required) = schema.get("required").and_then(Value::as_array) {
+            for key in required.iter().filter_map(Value::as_str) {
+                if !object.contains_key(key) {
+                    fail(
+                        violations,
+                        path,
+                        format!("missing required property `{}`", key),
+                    );
+                }
+            }
+        }
+        let properties = schema.get("properties").and_then(Value::as_object);
+        for (key, item) in object {
+            let item_path = format!("{}/{}", path, pointer_segment(key));
+            match properties.and_then(|p| p.get(key)) {
+                Some(property) => validate(root, property, item, &item_path, violations),
+                None => match schema.get("additionalProperties") {
+                    Some(Value::Bool(false)) => {
+                        fail(violations, &item_path, "unexpected property".to_string())
+                    }
+                    Some(additional @ Value::Object(_)) => {
+                        validate(root, additional, item, &item_path, violations)
+                    }
+                    _ => {}
+                },
+            }
+        }
+    }
+
+    if let (Value::Array(items), Some(item_schema)) = (value, schema.get("items")) {
+        for (i, item) in items.iter().enumerate() {
+            validate(
+                root,
+                item_schema,
+                item,
+                &format!("{}/{}", path, i),
+                violations,
+            );
+        }
+    }
+}
@@ -0,0 +1,104 @@
+//! Summary statistics for a review.
+//!
+//! Counts are always derived from the report's own `issues`, so a summary
+//! can never disagree with the findings listed next to it.
+
+use std::collections::BTreeMap;
+
+use super::ReviewReport;
+use crate::config::{Category, Severity};
+
+/// Totals shown at the end of text and Markdown reports.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct ReportStats {
+    pub total: usize,
+    /// Finding counts per severity, most severe first. Severities without
+    /// findings are left out.
+    pub by_severity: Vec<(Severity, usize)>,
+    /// Finding counts per category. Categories without findings are left out.
+    pub by_category: BTreeMap<Category, usize>,
+    /// Finding counts per rule id, sorted by id.
+    pub by_rule: BTreeMap<String, usize>,
+    pub files_scanned: usize,
+    pub files_skipped: usize,
+    pub duration_ms: u128,
+}
+
+impl ReportStats {
+    /// Tallies the findings and file counts of `report`.
+    pub fn from_report(report: &ReviewReport) -> Self {
+        let by_severity = Severity::ALL
+            .iter()
+            .map(|severity| {
+                let count = report
+                    .issues
+                    .iter()
+                    .filter(|i| i.severity == *severity)
+                    .count();
+                (severity.clone(), count)
+            })
+            .filter(|(_, count)| *count > 0)
+            .collect();
+        let mut by_category = BTreeMap::new();
+        let mut by_rule = BTreeMap::new();
+        for issue in &report.issues {
+            *by_category.entry(issue.category).or_insert(0) += 1;
+            *by_rule.entry(issue.rule_id.clone()).or_insert(0) += 1;
+        }
+        Self {
+            total: report.issues.len(),
+            by_severity,
+            by_category,
+            by_rule,
+            files_scanned: report.metadata.files_scanned,
+            files_skipped: report.metadata.files_skipped,
+            duration_ms: report.metadata.timings.total_ms,
+        }
+    }
+
+    /// Renders the statistics as plain-text lines.
+    pub fn lines(&self) -> Vec<String> {
+        let join = |counts: Vec<String>| {
+            if counts.is_empty() {
+                "none".to_string()
+            } else {
+                counts.join(", ")
+            }
+        };
+        vec![
+            format!("Findings: {}", self.total),
+            format!(
+                "By severity: {}",
+                join(
+                    self.by_severity
+                        .iter()
+                        .map(|(severity, count)| format!("{} {}", severity.as_str(), count))
+                        .collect()
+                )
+            ),
+            format!(
+                "By category: {}",
+                join(
+                    self.by_category
+                        .iter()
+                        .map(|(category, count)| format!("{} {}", category.as_str(), count))
+                        .collect()
+                )
+            ),
+            format!(
+                "By rule: {}",
+                join(
+                    self.by_rule
+                        .iter()
+                        .map(|(rule, count)| format!("{} {}", rule, count))
+                        .collect()
+                )
+            ),
+            format!(
+                "Files: {} scanned, {} skipped",
+                self.files_scanned, self.files_skipped
+            ),
+            format!("Duration: {:.2}s", self.duration_ms as f64 / 1000.0),
+        ]
+    }
+}
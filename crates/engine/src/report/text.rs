@@ -0,0 +1,150 @@
+//! Plain-text report output.
+//!
+//! The report lists the findings grouped by file, under a header line per
+//! file, followed by a statistics block. Findings can also be grouped by
+//! rule, or listed without headers. In quiet mode only the statistics
+//! are printed, which suits dashboards that track totals. Each finding keeps
+//! its full `path:line:col` location so terminals can still link to it.
+//! Findings that carry a taint trace list its steps, one per line, below.
+//!
+//! The compact form prints one `path:line:col: [severity] rule: title` line
+//! per finding and nothing else, for grep and editor problem matchers.
+
+use super::order::GroupBy;
+use super::stats::ReportStats;
+use super::{ReportGenerator, ReviewReport};
+use crate::config::Severity;
+use crate::error::Result;
+use crate::scanner::Issue;
+
+const RESET: &str = "\x1b[0m";
+const BOLD: &str = "\x1b[1m";
+const CYAN: &str = "\x1b[36m";
+
+/// A generator for plain-text reports.
+#[derive(Debug, Default)]
+pub struct TextGenerator {
+    /// Print only the statistics block.
+    pub quiet: bool,
+    /// Color severities, rule ids and section headers with ANSI escapes.
+    pub color: bool,
+    /// The sections findings are listed under.
+    pub group_by: GroupBy,
+}
+
+impl TextGenerator {
+    fn paint(&self, text: &str, style: &str) -> String {
+        if self.color {
+            format!("{}{}{}", style, text, RESET)
+        } else {
+            text.to_string()
+        }
+    }
+}
+
+/// The ANSI style of a severity: red for critical and high, yellow for
+/// medium, blue for low.
+fn severity_style(severity: &Severity) -> &'static str {
+    match severity {
+        Severity::Critical => "\x1b[1;31m",
+        Severity::High => "\x1b[31m",
+        Severity::Medium => "\x1b[33m",
+        Severity::Low => "\x1b[34m",
+        Severity::Info => "\x1b[2m",
+    }
+}
+
+/// `path:line` or `path:line:col`.
+fn location(issue: &Issue) -> String {
+    let column = issue.column.map_or(String::new(), |c| format!(":{}", c));
+    format!("{}:{}{}", issue.file_path, issue.line_number, column)
+}
+
+/// Groups issues by file or rule id, in the order each group first appears.
+/// Without grouping, all issues form one group with an empty name.
+fn group(issues: &[Issue], by: GroupBy) -> Vec<(&str, Vec<&Issue>)> {
+    let mut groups: Vec<(&str, Vec<&Issue>)> = Vec::new();
+    for issue in issues {
+        let name = match by {
+            GroupBy::File => issue.file_path.as_str(),
+            GroupBy::Rule => issue.rule_id.as_str(),
+            GroupBy::None => "",
+        };
+        match groups.iter_mut().find(|(n, _)| *n == name) {
+            Some((_, group)) => group.push(issue),
+            None => groups.push((name, vec![issue])),
+        }
+    }
+    groups
+}
+
+impl ReportGenerator for TextGenerator {
+    fn generate(&self, report: &ReviewReport) -> Result<String> {
+        let mut out = String::new();
+        if !self.quiet {
+            out.push_str(&format!("Summary: {}\n", report.summary));
+            for (name, issues) in group(&report.issues, self.group_by) {
+                if self.group_by == GroupBy::None {
+                    out.push('\n');
+                } else {
+                    let count = match issues.len() {
+                        1 => "1 finding".to_string(),
+                        n => format!("{} findings", n),
+                    };
+                    out.push_str(&format!("\n{} ({})\n", self.paint(name, BOLD), count));
+                }
+                for issue in issues {
+                    out.push_str(&format!(
+                        "  {} {} {} [{}]: {}\n      {}\n",
+                        self.paint(issue.severity.as_str(), severity_style(&issue.severity)),
+                        location(issue),
+                        self.paint(&issue.rule_id, CYAN),
+                        issue.category.as_str(),
+                        issue.title,
+                        issue.description
+                    ));
+                    if let Some(fix) = &issue.suggested_fix {
+                        out.push_str(&format!("      Fix: {}\n", fix));
+                    }
+                    if !issue.trace.is_empty() {
+                        out.push_str("      Trace:\n");
+                        for step in &issue.trace {
+                            out.push_str(&format!(
+                                "        {}: {} (line {})\n",
+                                step.kind.as_str(),
+                                step.expression,
+                                step.line
+                            ));
+                        }
+                    }
+                }
+            }
+            out.push('\n');
+        }
+        for line in ReportStats::from_report(report).lines() {
+            out.push_str(&line);
+            out.push('\n');
+        }
+        Ok(out)
+    }
+}
+
+/// A generator for one-line-per-finding text reports.
+#[derive(Debug, Default)]
+pub struct CompactTextGenerator;
+
+impl ReportGenerator for CompactTextGenerator {
+    fn generate(&self, report: &ReviewReport) -> Result<String> {
+        let mut out = String::new();
+        for issue in &report.issues {
+            out.push_str(&format!(
+                "{}: [{}] {}: {}\n",
+                location(issue),
+                issue.severity.as_str(),
+                issue.rule_id,
+                issue.title
+            ));
+        }
+        Ok(out)
+    }
+}
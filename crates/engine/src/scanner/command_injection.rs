@@ -0,0 +1,257 @@
+//! A scanner for command injection through `os/exec` in Go code.
+//!
+//! Request values are tracked through each function with the same taint
+//! sources and propagation as the XSS rule. `exec.Command` and
+//! `exec.CommandContext` calls are flagged when the program name or one of
+//! its arguments carries request data. Handing a script to a shell (`sh -c`,
+//! `bash -c`) is reported with the highest confidence, since the shell parses
+//! the whole string; passing request data as a separate argument to another
+//! program can only inject options, so it is reported with lower confidence.
+
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+use crate::config::{Confidence, Config};
+use crate::error::Result;
+use crate::scanner::taint::{self, Taint, TaintTracker};
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+pub struct CommandInjectionGoScanner;
+
+/// `exec.Command(` and `exec.CommandContext(`.
+static SINK_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"\bexec\.Command(Context)?\(").unwrap());
+
+/// A call whose program is a shell run with `-c`, matched against the
+/// original line from the start of the sink.
+static SHELL_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(
+        r#"^exec\.Command(?:Context\(\s*[\w.()]+\s*,|\()\s*"(?:/usr)?(?:/bin/)?(?:ba|z|da)?sh"\s*,\s*"-c"\s*,"#,
+    )
+    .unwrap()
+});
+
+/// Conversions whose result cannot carry shell syntax, and shell quoting
+/// helpers.
+static SANITIZER_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"\bstrconv\.(?:Atoi|Itoa|ParseInt|ParseUint|ParseFloat|ParseBool|FormatInt)\(|\bshellescape\.Quote\(|\bshellquote\.Join\(")
+        .unwrap()
+});
+
+/// A string literal concatenated with another value, or a formatted string,
+/// in already-stripped code.
+static CONCAT_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r#""\s*\+|\+\s*"|\bfmt\.Sprintf\("#).unwrap());
+
+/// How the command in a flagged call is built.
+enum Injection {
+    /// Request data selects the program to run.
+    Program(Taint),
+    /// Request data is part of a script run by a shell.
+    Shell(Taint),
+    /// A shell script is assembled from non-constant parts that could not be
+    /// traced to the request.
+    ShellConcat,
+    /// Request data is passed as a separate argument.
+    Argument(Taint),
+}
+
+/// Classifies the `exec.Command` call starting at `start` in `line`. `code`
+/// is the line with literals stripped and `taint_of` reports the taint of an
+/// argument expression.
+fn classify(
+    line: &str,
+    code: &str,
+    start: usize,
+    end: usize,
+    context: bool,
+    taint_of: impl Fn(&str) -> Option<Taint>,
+) -> Option<Injection> {
+    let mut args = taint::call_args(&code[end..]);
+    if context {
+        if args.is_empty() {
+            return None;
+        }
+        args.remove(0);
+    }
+    let (program, rest) = args.split_first()?;
+    if let Some(taint) = taint_of(program) {
+        return Some(Injection::Program(taint));
+    }
+    if SHELL_REGEX.is_match(&line[start..]) {
+        let script = rest.get(1)?;
+        return match taint_of(script) {
+            Some(taint) => Some(Injection::Shell(taint)),
+            None if CONCAT_REGEX.is_match(script) => Some(Injection::ShellConcat),
+            None => None,
+        };
+    }
+    rest.iter()
+        .find_map(|arg| taint_of(arg))
+        .map(Injection::Argument)
+}
+
+fn command_injection_issue(
+    file_path: &str,
+    line: &str,
+    line_number: usize,
+    start: usize,
+    end: usize,
+    injection: &Injection,
+    config: &Config,
+) -> Issue {
+    let (column, end_column) = columns_for(line, start, end - 1);
+    let (description, confidence) = match injection {
+        Injection::Program(taint) => (
+            format!(
+                "Request data from `{}` selects the program run by `exec.Command`.",
+                taint.origin
+            ),
+            taint.confidence,
+        ),
+        Injection::Shell(taint) => (
+            format!(
+                "Request data from `{}` is part of a script run by a shell through `exec.Command`.",
+                taint.origin
+            ),
+            taint.confidence,
+        ),
+        Injection::ShellConcat => (
+            "A shell script run through `exec.Command` is built from non-constant values."
+                .to_string(),
+            Confidence::Low,
+        ),
+        Injection::Argument(taint) => (
+            format!(
+                "Request data from `{}` is passed as an argument to `exec.Command` and may be read as an option.",
+                taint.origin
+            ),
+            taint.confidence.min(Confidence::Medium),
+        ),
+    };
+    let mut issue = Issue {
+        rule_id: "command-injection-go".to_string(),
+        title: "Potential Command Injection".to_string(),
+        description,
+        file_path: file_path.to_string(),
+        line_number,
+        column: Some(column),
+        end_column: Some(end_column),
+        severity: config.rules.command_injection_go.severity.clone(),
+        confidence,
+        suggested_fix: Some(
+            "Run a fixed program without a shell and pass each value as a separate argument, after `--` where the program supports it.".to_string(),
+        ),
+        diff: None,
+        ..Default::default()
+    };
+    if let Injection::Program(taint) | Injection::Shell(taint) | Injection::Argument(taint) =
+        injection
+    {
+        taint.attach_trace(&mut issue, line);
+    }
+    issue
+}
+
+impl CommandInjectionGoScanner {
+    /// Checks one line for a flagged `exec.Command` call.
+    fn scan_line(
+        &self,
+        file_path: &str,
+        line: &str,
+        code: &str,
+        line_number: usize,
+        taint_of: impl Fn(&str) -> Option<Taint>,
+        config: &Config,
+    ) -> Option<Issue> {
+        let caps = SINK_REGEX.captures(code)?;
+        let m = caps.get(0)?;
+        let injection = classify(
+            line,
+            code,
+            m.start(),
+            m.end(),
+            caps.get(1).is_some(),
+            taint_of,
+        )?;
+        Some(command_injection_issue(
+            file_path,
+            line,
+            line_number,
+            m.start(),
+            m.end(),
+            &injection,
+            config,
+        ))
+    }
+
+    /// Taint-tracking pass over each function body.
+    fn scan_functions(&self, ctx: &AnalysisContext, functions: &[taint::GoFunction]) -> Vec<Issue> {
+        let (file_path, config) = (ctx.file_path, ctx.config);
+        let mut issues = Vec::new();
+        for function in functions {
+            if ctx.expired() {
+                break;
+            }
+            let mut tracker = ctx.tracker(&SANITIZER_REGEX, function);
+            let mut in_raw = false;
+            for (offset, line) in function.lines.iter().enumerate() {
+                let code = taint::strip_literals(line, &mut in_raw);
+                issues.extend(self.scan_line(
+                    file_path,
+                    line,
+                    &code,
+                    function.start_line + offset,
+                    |expr| tracker.tainted_by(expr),
+                    config,
+                ));
+                tracker.observe(line, &code, function.start_line + offset);
+            }
+        }
+        issues
+    }
+
+    /// Per-line fallback used when the file cannot be split into functions.
+    /// Only arguments that read the request directly, or shell scripts built
+    /// by concatenation, are flagged.
+    fn scan_lines(&self, ctx: &AnalysisContext) -> Vec<Issue> {
+        let (file_path, content, config) = (ctx.file_path, ctx.content, ctx.config);
+        let tracker = TaintTracker::new(&SANITIZER_REGEX)
+            .with_sanitizers(ctx.declared_functions().sanitizers());
+        let mut issues = Vec::new();
+        let mut in_raw = false;
+        for (i, line) in content.lines().enumerate() {
+            let code = taint::strip_literals(line, &mut in_raw);
+            issues.extend(self.scan_line(
+                file_path,
+                line,
+                &code,
+                i + 1,
+                |expr| tracker.directly_tainted_by(expr),
+                config,
+            ));
+        }
+        issues
+    }
+}
+
+impl Scanner for CommandInjectionGoScanner {
+    fn name(&self) -> &'static str {
+        "Command Injection Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let issues = match ctx.go_functions() {
+            Some(functions) => self.scan_functions(ctx, functions),
+            None => {
+                log::debug!(
+                    "Could not split {} into functions; using per-line command injection matching",
+                    ctx.file_path
+                );
+                self.scan_lines(ctx)
+            }
+        };
+        Ok(ctx
+            .declared_functions()
+            .drop_safe_sinks(ctx.content, issues))
+    }
+}
@@ -0,0 +1,281 @@
+//! A scanner for outbound Go HTTP requests that drop the caller's context.
+//!
+//! A function has a context in scope when it takes a `context.Context`
+//! parameter, receives an `*http.Request` (whose `Context()` carries the
+//! incoming request's deadline), or derives a context in its body before the
+//! request is built. In such a function, `http.Get`, `http.Head`, `http.Post`
+//! and `http.PostForm` are flagged because they always use a background
+//! context, as is `client.Do(req)` when `req` came from `http.NewRequest`
+//! rather than `http.NewRequestWithContext`. The latter comes with a fix that
+//! passes the context to the request. `main` and `init` are never flagged
+//! since they have no caller whose context could be propagated.
+
+use std::collections::HashMap;
+use std::ops::Range;
+
+use once_cell::sync::Lazy;
+use regex::{Captures, Regex};
+
+use crate::config::{Confidence, Config};
+use crate::error::Result;
+use crate::fix::{Fix, TextEdit};
+use crate::scanner::taint::{self, GoFunction};
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+pub struct ContextPropagationGoScanner;
+
+/// The name of a function or method.
+static FUNC_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^\s*func\s+(?:\([^)]*\)\s*)?(\w+)").unwrap());
+
+/// A `context.Context` parameter.
+static CONTEXT_PARAM_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\b(\w+)\s+context\.Context\b").unwrap());
+
+/// An `*http.Request` parameter.
+static REQUEST_PARAM_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\b(\w+)\s+\*http\.Request\b").unwrap());
+
+/// A context assigned in the body, e.g. `ctx, cancel :=
+/// context.WithTimeout(...)` or `ctx := r.Context()`.
+static LOCAL_CONTEXT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"^\s*(\w+)(?:\s*,\s*\w+)?\s*:?=\s*(?:context\.\w+\(|[\w.]+\.Context\(\))").unwrap()
+});
+
+/// Package-level helpers that always send with a background context.
+static DEFAULT_CLIENT_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\bhttp\.(Get|Head|Post|PostForm)\(").unwrap());
+
+/// `req, err := http.NewRequest(...)`.
+static NEW_REQUEST_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^\s*(\w+)\s*(?:,\s*\w+\s*)?:?=\s*(http\.NewRequest\()").unwrap());
+
+/// `client.Do(req)`.
+static DO_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\b\w+(?:\.\w+)*\.Do\(\s*(\w+)\s*\)").unwrap());
+
+/// An assignment, which replaces a tracked request, e.g. `req =
+/// req.WithContext(ctx)`.
+static ASSIGN_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^\s*(\w+)\s*(?:,\s*\w+\s*)?:?=[^=]").unwrap());
+
+/// A request built without a context.
+struct NewRequest {
+    /// Offset of the line within the function.
+    offset: usize,
+    /// Byte range of `http.NewRequest(` in the line.
+    start: usize,
+    end: usize,
+    /// The context in scope where the request was built.
+    context: String,
+}
+
+/// Returns the context available from the function's parameters, preferring
+/// an explicit `context.Context` over the context of an incoming request.
+fn parameter_context(declaration: &str) -> Option<String> {
+    let explicit = CONTEXT_PARAM_REGEX
+        .captures_iter(declaration)
+        .map(|caps| caps[1].to_string())
+        .find(|name| name != "_");
+    explicit.or_else(|| {
+        REQUEST_PARAM_REGEX
+            .captures_iter(declaration)
+            .map(|caps| caps[1].to_string())
+            .find(|name| name != "_")
+            .map(|name| format!("{}.Context()", name))
+    })
+}
+
+/// A context expression derived from an incoming request is likely, but not
+/// certainly, the right one to propagate.
+fn confidence_for(context: &str) -> Confidence {
+    if context.ends_with(".Context()") {
+        Confidence::Medium
+    } else {
+        Confidence::High
+    }
+}
+
+fn default_client_issue(
+    file_path: &str,
+    line: &str,
+    line_number: usize,
+    caps: &Captures,
+    context: &str,
+    config: &Config,
+) -> Issue {
+    let call = caps.get(0).unwrap();
+    let helper = &caps[1];
+    let (column, end_column) = columns_for(line, call.start(), call.end() - 1);
+    let method = match helper {
+        "Get" => "http.MethodGet",
+        "Head" => "http.MethodHead",
+        _ => "http.MethodPost",
+    };
+    Issue {
+        rule_id: "context-propagation-go".to_string(),
+        title: "HTTP Request Without Context".to_string(),
+        description: format!(
+            "`http.{}` sends the request with a background context, so cancellation and deadlines of `{}` are not propagated.",
+            helper, context
+        ),
+        file_path: file_path.to_string(),
+        line_number,
+        column: Some(column),
+        end_column: Some(end_column),
+        severity: config.rules.context_propagation_go.severity.clone(),
+        confidence: confidence_for(context),
+        suggested_fix: Some(format!(
+            "Build the request with `http.NewRequestWithContext({}, {}, ...)` and send it with `Do`.",
+            context, method
+        )),
+        diff: None,
+        ..Default::default()
+    }
+}
+
+fn do_issue(
+    file_path: &str,
+    function: &GoFunction,
+    offset: usize,
+    call: Range<usize>,
+    request: &NewRequest,
+    config: &Config,
+) -> Issue {
+    let (column, end_column) = columns_for(function.lines[offset], call.start, call.end);
+    let request_line = function.lines[request.offset];
+    let replacement = format!("http.NewRequestWithContext({}, ", request.context);
+    let (edit_start, edit_end) = columns_for(request_line, request.start, request.end);
+    let mut fixed = request_line.to_string();
+    fixed.replace_range(request.start..request.end, &replacement);
+    Issue {
+        rule_id: "context-propagation-go".to_string(),
+        title: "HTTP Request Without Context".to_string(),
+        description: format!(
+            "The request sent here was built with `http.NewRequest` on line {}, so cancellation and deadlines of `{}` are not propagated.",
+            function.start_line + request.offset,
+            request.context
+        ),
+        file_path: file_path.to_string(),
+        line_number: function.start_line + offset,
+        column: Some(column),
+        end_column: Some(end_column),
+        severity: config.rules.context_propagation_go.severity.clone(),
+        confidence: confidence_for(&request.context),
+        suggested_fix: Some(format!(
+            "Build the request with `http.NewRequestWithContext({}, ...)`.",
+            request.context
+        )),
+        diff: Some(format!("-{}\n+{}", request_line.trim(), fixed.trim())),
+        fix: Some(Fix {
+            description: format!("Pass `{}` to the request", request.context),
+            edits: vec![TextEdit {
+                start_line: function.start_line + request.offset,
+                start_column: edit_start,
+                end_line: function.start_line + request.offset,
+                end_column: edit_end,
+                replacement,
+            }],
+        }),
+        ..Default::default()
+    }
+}
+
+impl ContextPropagationGoScanner {
+    fn scan_function(&self, file_path: &str, function: &GoFunction, config: &Config) -> Vec<Issue> {
+        let name = function
+            .lines
+            .first()
+            .and_then(|line| FUNC_REGEX.captures(line))
+            .map(|caps| caps[1].to_string());
+        if matches!(name.as_deref(), Some("main" | "init")) {
+            return Vec::new();
+        }
+
+        // The declaration runs up to the line that opens the body.
+        let mut in_raw = false;
+        let code: Vec<String> = function
+            .lines
+            .iter()
+            .map(|line| taint::strip_literals(line, &mut in_raw))
+            .collect();
+        let declaration_end = code.iter().position(|c| c.contains('{')).unwrap_or(0);
+        let declaration = code[..=declaration_end].join(" ");
+        let mut context = parameter_context(&declaration);
+
+        let mut issues = Vec::new();
+        let mut requests: HashMap<String, NewRequest> = HashMap::new();
+        for (offset, (line, code)) in function.lines.iter().zip(&code).enumerate() {
+            let line_number = function.start_line + offset;
+
+            if let Some(ctx) = &context {
+                if let Some(caps) = DEFAULT_CLIENT_REGEX.captures(code) {
+                    issues.push(default_client_issue(
+                        file_path,
+                        line,
+                        line_number,
+                        &caps,
+                        ctx,
+                        config,
+                    ));
+                }
+            }
+
+            if let Some(caps) = DO_REGEX.captures(code) {
+                if let Some(request) = requests.get(&caps[1]) {
+                    let call = caps.get(0).unwrap().range();
+                    issues.push(do_issue(file_path, function, offset, call, request, config));
+                }
+            }
+
+            if let Some(caps) = NEW_REQUEST_REGEX.captures(code) {
+                if let Some(ctx) = &context {
+                    let call = caps.get(2).unwrap();
+                    requests.insert(
+                        caps[1].to_string(),
+                        NewRequest {
+                            offset,
+                            start: call.start(),
+                            end: call.end(),
+                            context: ctx.clone(),
+                        },
+                    );
+                }
+            } else if let Some(caps) = ASSIGN_REGEX.captures(code) {
+                requests.remove(&caps[1]);
+            }
+
+            if let Some(caps) = LOCAL_CONTEXT_REGEX.captures(code) {
+                if &caps[1] != "_" {
+                    context = Some(caps[1].to_string());
+                }
+            }
+        }
+        issues
+    }
+}
+
+impl Scanner for ContextPropagationGoScanner {
+    fn name(&self) -> &'static str {
+        "Context Propagation Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let (file_path, config) = (ctx.file_path, ctx.config);
+        let functions = match ctx.go_functions() {
+            Some(functions) => functions,
+            None => {
+                log::debug!(
+                    "Could not split {} into functions; skipping context propagation checks",
+                    file_path
+                );
+                return Ok(Vec::new());
+            }
+        };
+        Ok(functions
+            .iter()
+            .take_while(|_| !ctx.expired())
+            .flat_map(|function| self.scan_function(file_path, function, config))
+            .collect())
+    }
+}
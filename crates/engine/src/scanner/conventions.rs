@@ -1,9 +1,9 @@
 use std::sync::Mutex;
 
-use crate::config::Config;
+use crate::config::{Confidence, Config};
 use crate::error::Result;
 use crate::rag::InMemoryVectorStore;
-use crate::scanner::{find_ignore, parse_ignore_directives, Issue, Scanner};
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
 
 #[derive(Default)]
 pub struct ConventionsScanner {
@@ -55,75 +55,66 @@ impl ConventionsScanner {
     }
 }
 
+/// Returns the byte range of the first of `needles` found in `line`.
+fn find_any(line: &str, needles: &[&str]) -> Option<(usize, usize)> {
+    needles
+        .iter()
+        .find_map(|n| line.find(n).map(|start| (start, start + n.len())))
+}
+
 impl Scanner for ConventionsScanner {
     fn name(&self) -> &'static str {
         "Convention Deviation Scanner"
     }
 
-    fn scan(&self, file_path: &str, content: &str, config: &Config) -> Result<Vec<Issue>> {
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let (file_path, content, config) = (ctx.file_path, ctx.content, ctx.config);
         let baseline = match self.ensure_baseline(config) {
             Some(b) => b,
             None => return Ok(vec![]),
         };
 
         let mut issues = Vec::new();
-        let ignores = parse_ignore_directives(content);
         for (i, line) in content.lines().enumerate() {
-            if baseline.prefers_logging_macros
-                && (line.contains("println!") || line.contains("eprintln!"))
-            {
-                if let Some(ignore) = find_ignore(&ignores, i + 1, "conventions") {
-                    log::info!(
-                        "Suppressed conventions at {}:{}{}",
-                        file_path,
-                        i + 1,
-                        ignore
-                            .reason
-                            .as_ref()
-                            .map(|r| format!(" - {}", r))
-                            .unwrap_or_default()
-                    );
-                } else {
-                    issues.push(Issue {
-                        title: "Inconsistent Logging".to_string(),
-                        description:
-                            "Use logging macros (e.g., log::info!) instead of println!/eprintln! per repository conventions."
-                                .to_string(),
-                        file_path: file_path.to_string(),
-                        line_number: i + 1,
-                        severity: config.rules.conventions.severity.clone(),
-                        suggested_fix: Some("Replace println!/eprintln! with appropriate log:: macros.".to_string()),
-                        diff: None,
-                    });
-                }
+            let print_macro = find_any(line, &["eprintln!", "println!"]);
+            if let Some((start, end)) = print_macro.filter(|_| baseline.prefers_logging_macros) {
+                let (column, end_column) = columns_for(line, start, end);
+                issues.push(Issue {
+                    rule_id: "conventions".to_string(),
+                    title: "Inconsistent Logging".to_string(),
+                    description:
+                        "Use logging macros (e.g., log::info!) instead of println!/eprintln! per repository conventions."
+                            .to_string(),
+                    file_path: file_path.to_string(),
+                    line_number: i + 1,
+                    column: Some(column),
+                    end_column: Some(end_column),
+                    severity: config.rules.conventions.severity.clone(),
+                    confidence: Confidence::Medium,
+                    suggested_fix: Some("Replace println!/eprintln! with appropriate log:: macros.".to_string()),
+                    diff: None,
+                    ..Default::default()
+                });
             }
-            if baseline.discourage_unwrap
-                && (line.contains(".unwrap()") || line.contains(".expect("))
-            {
-                if let Some(ignore) = find_ignore(&ignores, i + 1, "conventions") {
-                    log::info!(
-                        "Suppressed conventions at {}:{}{}",
-                        file_path,
-                        i + 1,
-                        ignore
-                            .reason
-                            .as_ref()
-                            .map(|r| format!(" - {}", r))
-                            .unwrap_or_default()
-                    );
-                } else {
-                    issues.push(Issue {
-                        title: "Avoid unwrap/expect".to_string(),
-                        description:
-                            "Prefer error propagation with Result and ? operator instead of unwrap()/expect() per repository conventions."
-                                .to_string(),
-                        file_path: file_path.to_string(),
-                        line_number: i + 1,
-                        severity: config.rules.conventions.severity.clone(),
-                        suggested_fix: Some("Propagate errors using ? or handle them explicitly.".to_string()),
-                        diff: None,
-                    });
-                }
+            let unwrap_call = find_any(line, &[".unwrap()", ".expect("]);
+            if let Some((start, end)) = unwrap_call.filter(|_| baseline.discourage_unwrap) {
+                let (column, end_column) = columns_for(line, start, end);
+                issues.push(Issue {
+                    rule_id: "conventions".to_string(),
+                    title: "Avoid unwrap/expect".to_string(),
+                    description:
+                        "Prefer error propagation with Result and ? operator instead of unwrap()/expect() per repository conventions."
+                            .to_string(),
+                    file_path: file_path.to_string(),
+                    line_number: i + 1,
+                    column: Some(column),
+                    end_column: Some(end_column),
+                    severity: config.rules.conventions.severity.clone(),
+                    confidence: Confidence::Medium,
+                    suggested_fix: Some("Propagate errors using ? or handle them explicitly.".to_string()),
+                    diff: None,
+                    ..Default::default()
+                });
             }
         }
 
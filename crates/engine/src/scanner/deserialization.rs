@@ -0,0 +1,454 @@
+//! A scanner for Go handlers that decode request bodies unsafely.
+//!
+//! The body of each `*http.Request` parameter (or `c.Request.Body` of a gin
+//! context) is followed through the readers, decoders and byte slices made
+//! from it within one function. Three kinds of problems are reported:
+//!
+//! - `gob` decoding of the body into an `interface{}` or `any` value, with
+//!   high confidence: the client picks which registered type is created.
+//!   When the file registers types with `gob.Register`, gob decoding of the
+//!   body into any value is reported with medium confidence, as interface
+//!   fields of the target can then be filled with any of those types.
+//! - `json.Unmarshal` or a JSON decoder reading the body into a generic
+//!   value (`interface{}`, `any`, `map[string]interface{}` or
+//!   `[]interface{}`) without a size limit, with medium confidence. Nesting
+//!   is unbounded, so one request can allocate without limit.
+//! - Any read of the body that is not limited by `http.MaxBytesReader` or
+//!   `io.LimitReader`, with low confidence, since a middleware in another
+//!   file may set the limit. Assigning `r.Body = http.MaxBytesReader(...)` in
+//!   any function of the file, as a middleware does, counts as a limit for
+//!   the whole file.
+//!
+//! Files named `*_test.go` are skipped.
+
+use std::collections::{HashMap, HashSet};
+
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+use crate::config::Confidence;
+use crate::error::Result;
+use crate::scanner::taint;
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+pub struct InsecureDeserializationGoScanner;
+
+/// A parameter holding the request: `r *http.Request` or `c *gin.Context`.
+static REQUEST_PARAM_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\b(\w+)\s+\*(http\.Request|gin\.Context)\b").unwrap());
+
+/// A request body, `r.Body` or `c.Request.Body`.
+static BODY_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"^(\w+)(\.Request)?\.Body$").unwrap());
+
+/// Calls that cap how much of a reader can be read.
+static LIMIT_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^(?:http\.MaxBytesReader|io\.LimitReader)\(").unwrap());
+
+/// `r.Body = http.MaxBytesReader(w, r.Body, n)`.
+static BODY_LIMIT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"^\s*([\w.]+)\s*=\s*(?:http\.MaxBytesReader|io\.LimitReader)\(").unwrap()
+});
+
+/// An assignment of a single variable.
+static ALIAS_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"^\s*(\w+)\s*:?=\s*(.*?)\s*$").unwrap());
+
+static READ_ALL_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\b(?:io|ioutil)\.ReadAll\(").unwrap());
+
+static NEW_DECODER_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\b(json|gob|xml)\.NewDecoder\(").unwrap());
+
+static DECODE_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"\b(\w+)\.Decode\(").unwrap());
+
+static UNMARSHAL_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"\bjson\.Unmarshal\(").unwrap());
+
+static GOB_REGISTER_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\bgob\.Register(?:Name)?\(").unwrap());
+
+/// A generic type that accepts any decoded value.
+const GENERIC_TYPE: &str =
+    r"(?:interface\{\}|any|map\[string\](?:interface\{\}|any)|\[\](?:interface\{\}|any))";
+
+/// `var v any`, `v := map[string]interface{}{}`, `v := make([]any, 0)` or
+/// `v := new(interface{})`.
+static GENERIC_VAR_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(&format!(
+        r"^\s*(?:var\s+(\w+)\s+{0}\s*(?:=|$)|(\w+)\s*:=\s*(?:{0}\{{|make\(\s*{0}|new\(\s*{0}\s*\)))",
+        GENERIC_TYPE
+    ))
+    .unwrap()
+});
+
+/// A body read: the reader it came from, and whether it is size-limited.
+#[derive(Clone)]
+struct Read {
+    origin: String,
+    limited: bool,
+}
+
+/// What is known about the body within one function.
+#[derive(Default)]
+struct BodyState {
+    /// Request parameters whose body is `name.Body`, and gin contexts whose
+    /// body is `name.Request.Body`.
+    requests: HashSet<String>,
+    contexts: HashSet<String>,
+    /// The body of every request has been limited, here or by a middleware
+    /// in the file.
+    limited: bool,
+    /// Readers made from the body.
+    readers: HashMap<String, Read>,
+    /// Byte slices read from the body.
+    bytes: HashMap<String, Read>,
+    /// Decoders reading the body, with their format.
+    decoders: HashMap<String, (String, Read)>,
+    /// Variables of a generic type.
+    generic: HashSet<String>,
+}
+
+impl BodyState {
+    /// Returns the body read through `expr`, if it is the request body or a
+    /// reader made from it.
+    fn reader(&self, expr: &str) -> Option<Read> {
+        let expr = expr.trim();
+        if let Some(caps) = BODY_REGEX.captures(expr) {
+            let known = match caps.get(2) {
+                Some(_) => &self.contexts,
+                None => &self.requests,
+            };
+            return known.contains(&caps[1]).then(|| Read {
+                origin: expr.to_string(),
+                limited: self.limited,
+            });
+        }
+        if let Some(m) = LIMIT_REGEX.find(expr) {
+            let args = taint::call_args(&expr[m.end()..]);
+            // `http.MaxBytesReader` takes the writer first.
+            let inner = if expr.starts_with("http.") {
+                args.get(1)
+            } else {
+                args.first()
+            };
+            return inner.and_then(|inner| self.reader(inner)).map(|read| Read {
+                limited: true,
+                ..read
+            });
+        }
+        self.readers.get(expr).cloned()
+    }
+}
+
+/// Returns the variable a decode target points at, `v` for `&v` or `v`.
+fn target_name(expr: &str) -> &str {
+    expr.trim().trim_start_matches('&').trim()
+}
+
+/// The issue for the code at `span` of `line`, line `line_number`.
+fn deserialization_issue(
+    ctx: &AnalysisContext,
+    (line, line_number, span): (&str, usize, (usize, usize)),
+    title: &str,
+    description: String,
+    confidence: Confidence,
+    fix: &str,
+) -> Issue {
+    let (column, end_column) = columns_for(line, span.0, span.1);
+    Issue {
+        rule_id: "insecure-deserialization-go".to_string(),
+        title: title.to_string(),
+        description,
+        file_path: ctx.file_path.to_string(),
+        line_number,
+        column: Some(column),
+        end_column: Some(end_column),
+        severity: ctx
+            .config
+            .rules
+            .insecure_deserialization_go
+            .severity
+            .clone(),
+        confidence,
+        suggested_fix: Some(fix.to_string()),
+        diff: None,
+        ..Default::default()
+    }
+}
+
+impl InsecureDeserializationGoScanner {
+    /// Returns the finding for decoding `read` as `format` into `target`, if
+    /// the decode is unsafe.
+    fn decode_issue(
+        &self,
+        ctx: &AnalysisContext,
+        state: &BodyState,
+        registered: bool,
+        (format, read, target): (&str, &Read, &str),
+        (line, line_number, span): (&str, usize, (usize, usize)),
+    ) -> Option<Issue> {
+        let target = target_name(target);
+        // A target on the next line, as in `dec.Decode(` and `&v)`, is not
+        // followed.
+        if target.is_empty() {
+            return None;
+        }
+        let generic = state.generic.contains(target);
+        let (title, description, confidence, fix) = match format {
+            "gob" if generic => (
+                "Insecure Deserialization",
+                format!(
+                    "Request data from `{}` is decoded with `gob` into `{}`, an interface value, so the client chooses which registered type is created. This can lead to type confusion in the code that uses the value.",
+                    read.origin, target
+                ),
+                Confidence::High,
+                "Decode into a concrete struct without interface fields, or use a format with a fixed schema such as JSON into a struct.",
+            ),
+            "gob" if registered => (
+                "Insecure Deserialization",
+                format!(
+                    "Request data from `{}` is decoded with `gob` in a file that registers types with `gob.Register`, so interface fields of `{}` can be filled with any of those types.",
+                    read.origin, target
+                ),
+                Confidence::Medium,
+                "Decode request data into structs without interface fields, and keep `gob` for data from trusted peers.",
+            ),
+            "json" if generic && !read.limited => (
+                "Untyped Decoding Without Size Limit",
+                format!(
+                    "Request data from `{}` is decoded into `{}`, a generic value, without a size limit. Objects and arrays can be nested without bound, so a single request can exhaust the server's memory.",
+                    read.origin, target
+                ),
+                Confidence::Medium,
+                "Wrap the body with `http.MaxBytesReader` and decode into a struct that describes the expected fields.",
+            ),
+            _ => return None,
+        };
+        Some(deserialization_issue(
+            ctx,
+            (line, line_number, span),
+            title,
+            description,
+            confidence,
+            fix,
+        ))
+    }
+
+    fn unbounded_issue(
+        &self,
+        ctx: &AnalysisContext,
+        read: &Read,
+        (line, line_number, span): (&str, usize, (usize, usize)),
+    ) -> Issue {
+        deserialization_issue(
+            ctx,
+            (line, line_number, span),
+            "Unbounded Request Body",
+            format!(
+                "`{}` is read without a size limit, so a client can send an arbitrarily large body and exhaust the server's memory.",
+                read.origin
+            ),
+            Confidence::Low,
+            "Limit the body before reading it, e.g. `r.Body = http.MaxBytesReader(w, r.Body, 1<<20)`.",
+        )
+    }
+
+    fn scan_function(
+        &self,
+        ctx: &AnalysisContext,
+        function: &taint::GoFunction,
+        limited: bool,
+        registered: bool,
+    ) -> Vec<Issue> {
+        let mut issues = Vec::new();
+        let mut state = BodyState {
+            limited,
+            ..Default::default()
+        };
+        let mut in_raw = false;
+        for (offset, line) in function.lines.iter().enumerate() {
+            let line_number = function.start_line + offset;
+            let code = taint::strip_literals(line, &mut in_raw);
+
+            for caps in REQUEST_PARAM_REGEX.captures_iter(&code) {
+                match &caps[2] {
+                    "http.Request" => state.requests.insert(caps[1].to_string()),
+                    _ => state.contexts.insert(caps[1].to_string()),
+                };
+            }
+            if let Some(caps) = GENERIC_VAR_REGEX.captures(&code) {
+                let name = caps.get(1).or_else(|| caps.get(2)).unwrap().as_str();
+                state.generic.insert(name.to_string());
+            }
+            if let Some(caps) = BODY_LIMIT_REGEX.captures(&code) {
+                if state.reader(&caps[1]).is_some() {
+                    state.limited = true;
+                    continue;
+                }
+            }
+
+            // The first variable a read or decoder is assigned to.
+            let assigned = taint::assigned_names(&code).into_iter().next();
+            let mut reported = false;
+
+            for m in NEW_DECODER_REGEX.find_iter(&code) {
+                let format = &code[m.start()..m.start() + m.as_str().find('.').unwrap()];
+                let args = taint::call_arg_ranges(&code[m.end()..]);
+                let read = match args
+                    .first()
+                    .and_then(|r| state.reader(&code[m.end() + r.start..m.end() + r.end]))
+                {
+                    Some(read) => read,
+                    None => continue,
+                };
+                let close = m.end() + args.last().unwrap().end;
+                // `json.NewDecoder(r.Body).Decode(&v)`.
+                let rest = &code[(close + 1).min(code.len())..];
+                if let Some(decode) = rest.strip_prefix(".Decode(") {
+                    let open = close + 1 + ".Decode(".len();
+                    let end = open + taint::call_arg_ranges(decode)[0].end;
+                    let target = &code[open..end];
+                    if let Some(issue) = self.decode_issue(
+                        ctx,
+                        &state,
+                        registered,
+                        (format, &read, target),
+                        (line, line_number, (m.start(), (end + 1).min(line.len()))),
+                    ) {
+                        issues.push(issue);
+                        reported = true;
+                    }
+                } else if let Some(name) = &assigned {
+                    state
+                        .decoders
+                        .insert(name.clone(), (format.to_string(), read.clone()));
+                }
+                if !read.limited && !reported {
+                    issues.push(self.unbounded_issue(
+                        ctx,
+                        &read,
+                        (line, line_number, (m.start(), (close + 1).min(line.len()))),
+                    ));
+                    reported = true;
+                }
+            }
+
+            for m in READ_ALL_REGEX.find_iter(&code) {
+                let args = taint::call_arg_ranges(&code[m.end()..]);
+                let read = match args
+                    .first()
+                    .and_then(|r| state.reader(&code[m.end() + r.start..m.end() + r.end]))
+                {
+                    Some(read) => read,
+                    None => continue,
+                };
+                if let Some(name) = &assigned {
+                    state.bytes.insert(name.clone(), read.clone());
+                }
+                if !read.limited && !reported {
+                    let close = m.end() + args.last().unwrap().end;
+                    issues.push(self.unbounded_issue(
+                        ctx,
+                        &read,
+                        (line, line_number, (m.start(), (close + 1).min(line.len()))),
+                    ));
+                    reported = true;
+                }
+            }
+
+            for caps in DECODE_REGEX.captures_iter(&code) {
+                let (format, read) = match state.decoders.get(&caps[1]) {
+                    Some(decoder) => decoder,
+                    None => continue,
+                };
+                let m = caps.get(0).unwrap();
+                let args = taint::call_arg_ranges(&code[m.end()..]);
+                let end = m.end() + args[0].end;
+                let target = &code[m.end()..end];
+                let issue = self.decode_issue(
+                    ctx,
+                    &state,
+                    registered,
+                    (format.as_str(), read, target),
+                    (line, line_number, (m.start(), (end + 1).min(line.len()))),
+                );
+                if let Some(issue) = issue.filter(|_| !reported) {
+                    issues.push(issue);
+                    reported = true;
+                }
+            }
+
+            for m in UNMARSHAL_REGEX.find_iter(&code) {
+                let args = taint::call_arg_ranges(&code[m.end()..]);
+                if args.len() < 2 {
+                    continue;
+                }
+                let data = code[m.end() + args[0].start..m.end() + args[0].end].trim();
+                let read = match state.bytes.get(data) {
+                    Some(read) => read,
+                    None => continue,
+                };
+                let target = &code[m.end() + args[1].start..m.end() + args[1].end];
+                let end = m.end() + args.last().unwrap().end;
+                let issue = self.decode_issue(
+                    ctx,
+                    &state,
+                    registered,
+                    ("json", read, target),
+                    (line, line_number, (m.start(), (end + 1).min(line.len()))),
+                );
+                if let Some(issue) = issue.filter(|_| !reported) {
+                    issues.push(issue);
+                    reported = true;
+                }
+            }
+
+            // Readers made from the body, e.g.
+            // `body := http.MaxBytesReader(w, r.Body, n)`.
+            if let Some(caps) = ALIAS_REGEX.captures(&code) {
+                if let Some(read) = state.reader(&caps[2]) {
+                    state.readers.insert(caps[1].to_string(), read);
+                }
+            }
+        }
+        issues
+    }
+}
+
+impl Scanner for InsecureDeserializationGoScanner {
+    fn name(&self) -> &'static str {
+        "Insecure Deserialization Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let (file_path, content) = (ctx.file_path, ctx.content);
+        if !file_path.ends_with(".go") || file_path.ends_with("_test.go") {
+            return Ok(Vec::new());
+        }
+        let functions = match ctx.go_functions() {
+            Some(functions) => functions,
+            None => {
+                log::debug!(
+                    "Could not split {} into functions; skipping deserialization checks",
+                    file_path
+                );
+                return Ok(Vec::new());
+            }
+        };
+        let mut in_raw = false;
+        let code: Vec<String> = content
+            .lines()
+            .map(|line| taint::strip_literals(line, &mut in_raw))
+            .collect();
+        let registered = code.iter().any(|line| GOB_REGISTER_REGEX.is_match(line));
+        // A middleware that limits every request body.
+        let limited = code.iter().any(|line| {
+            BODY_LIMIT_REGEX
+                .captures(line)
+                .is_some_and(|caps| caps[1].ends_with(".Body"))
+        });
+        Ok(functions
+            .iter()
+            .take_while(|_| !ctx.expired())
+            .flat_map(|function| self.scan_function(ctx, function, limited, registered))
+            .collect())
+    }
+}
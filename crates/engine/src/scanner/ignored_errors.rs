@@ -0,0 +1,356 @@
+//! A scanner for errors ignored from Go calls where a failure is dangerous.
+//!
+//! Only the functions and methods listed in `functions` are checked, so the
+//! rule stays quiet about the many errors that are harmless to drop. The
+//! error of a call is ignored when the call is a statement of its own or has
+//! its last result assigned to `_`. Deferred calls, as in the idiomatic
+//! `defer rows.Close()`, are not reported. What is checked:
+//!
+//! - functions, such as `crypto/rand.Read`, are matched through the name or
+//!   alias their package is imported under;
+//! - methods, such as `(*database/sql.Rows).Close`, are matched on variables
+//!   declared with the receiver type, and for `sql.Rows` on the results of
+//!   `Query` and `QueryContext`;
+//! - `io.Copy`, `io.CopyN` and `io.CopyBuffer` are only reported when the
+//!   copy feeds a security decision: the destination is a hash or MAC, or
+//!   the number of bytes copied is tested afterwards.
+//!
+//! Files named `*_test.go` are skipped.
+
+use std::collections::HashSet;
+
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+use crate::config::{split_function_ref, split_method_ref, Confidence, Config};
+use crate::error::Result;
+use crate::scanner::taint::{self, GoFunction};
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+pub struct IgnoredErrorsGoScanner;
+
+/// Constructors of hashes and MACs, whose output is compared to verify data.
+static HASH_CONSTRUCTOR_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(
+        r"\b(?:md5|sha1|sha256|sha512|sha3|hmac|blake2[bs]|crc32|crc64|fnv|adler32)\.New\w*\(",
+    )
+    .unwrap()
+});
+
+/// Names of variables that hold a hash or MAC.
+static HASH_NAME_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"(?i)^(?:h|hash\w*|hasher|mac|\w*digest|\w*checksum)$").unwrap());
+
+/// `rows, err := db.Query(...)`, capturing the `*sql.Rows` variable.
+static ROWS_CONSTRUCTOR_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"^\s*(?:var\s+)?([A-Za-z_]\w*)\s*,.*=\s*[\w.]+\.(?:Query|QueryContext)\(").unwrap()
+});
+
+/// The start of a condition.
+static CONDITION_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^\s*(?:\}\s*else\s+)?(?:if|switch|for)\b").unwrap());
+
+/// The copy functions, which are only reported when the copy feeds a
+/// security decision.
+const COPY_FUNCTIONS: [&str; 3] = ["io.Copy", "io.CopyN", "io.CopyBuffer"];
+
+/// Why ignoring the error of a checked call is dangerous.
+fn hazard(reference: &str) -> &'static str {
+    match reference {
+        "crypto/rand.Read" => "a failed read can leave the buffer partly filled or zeroed, so a key, token or nonce generated from it can be predictable",
+        "encoding/json.Unmarshal" => "a failed decode leaves the value partly filled or at its zero value, so checks on it can pass by default",
+        "(*database/sql.Rows).Close" | "(database/sql.Rows).Close" => "the error reports a failure of the driver to finish the query, so the results read may be incomplete",
+        "io.Copy" | "io.CopyN" | "io.CopyBuffer" => "a failed copy leaves the data incomplete, so a digest or size computed from it does not describe the input",
+        _ => "it reports a failure the caller must handle",
+    }
+}
+
+/// A configured function or method, resolved against a file's imports.
+struct Target {
+    reference: String,
+    /// Matches calls, ending at the opening parenthesis. For methods, the
+    /// first group captures the receiver.
+    call: Regex,
+    method: Option<Method>,
+}
+
+/// How to find the variables a method can be called on.
+struct Method {
+    /// Declarations of variables of the receiver type, capturing the name.
+    declaration: Regex,
+    /// Calls returning a value of the receiver type, if known.
+    constructor: Option<&'static Regex>,
+}
+
+/// Returns the names the package at `path` is imported under.
+fn local_names<'a>(imports: &'a [(String, String)], path: &'a str) -> Vec<&'a str> {
+    imports
+        .iter()
+        .filter(|(_, import)| import == path)
+        .map(|(local, _)| local.as_str())
+        .collect()
+}
+
+fn targets(references: &[String], imports: &[(String, String)]) -> Vec<Target> {
+    let mut targets = Vec::new();
+    for reference in references {
+        if let Some((path, name)) = split_function_ref(reference) {
+            let locals: Vec<String> = local_names(imports, path)
+                .into_iter()
+                .map(regex::escape)
+                .collect();
+            if locals.is_empty() {
+                continue;
+            }
+            let pattern = format!(r"\b(?:{})\s*\.\s*{}\s*\(", locals.join("|"), name);
+            targets.extend(Regex::new(&pattern).ok().map(|call| Target {
+                reference: reference.clone(),
+                call,
+                method: None,
+            }));
+        } else if let Some((path, type_name, method)) = split_method_ref(reference) {
+            let locals: Vec<String> = local_names(imports, path)
+                .into_iter()
+                .map(regex::escape)
+                .collect();
+            if locals.is_empty() {
+                continue;
+            }
+            let declaration = format!(
+                r"\b([A-Za-z_]\w*)\s+\*?\s*(?:{})\.{}\b",
+                locals.join("|"),
+                type_name
+            );
+            let constructor =
+                (path == "database/sql" && type_name == "Rows").then_some(&*ROWS_CONSTRUCTOR_REGEX);
+            let call = format!(r"\b([A-Za-z_]\w*)\s*\.\s*{}\s*\(", method);
+            if let (Ok(call), Ok(declaration)) = (Regex::new(&call), Regex::new(&declaration)) {
+                targets.push(Target {
+                    reference: reference.clone(),
+                    call,
+                    method: Some(Method {
+                        declaration,
+                        constructor,
+                    }),
+                });
+            }
+        }
+    }
+    targets
+}
+
+/// How the error of a call is dropped.
+#[derive(Debug, Clone, PartialEq, Eq)]
+enum Ignored {
+    /// The call is a statement of its own.
+    Statement,
+    /// The error is assigned to `_`. Holds the variable receiving the first
+    /// result, if any.
+    Blank(Option<String>),
+}
+
+/// Returns how the call starting at `start`, whose argument list opens at
+/// `open`, drops its error, or `None` when the error is kept.
+fn ignored(code: &str, start: usize, open: usize) -> Option<Ignored> {
+    let args = taint::call_arg_ranges(&code[open..]);
+    let close = open + args.last().map_or(0, |range| range.end);
+    // A call whose arguments continue on the next line is taken to end the
+    // statement.
+    if close < code.len() && !code[close + 1..].trim().is_empty() {
+        return None;
+    }
+    let prefix = code[..start].trim();
+    if prefix.is_empty() {
+        return Some(Ignored::Statement);
+    }
+    let lhs = prefix.strip_suffix(":=").or_else(|| {
+        prefix
+            .strip_suffix('=')
+            .filter(|p| !p.ends_with(['=', '!', '<', '>']))
+    })?;
+    let lhs = lhs.trim().strip_prefix("var ").unwrap_or(lhs.trim());
+    let names: Vec<&str> = lhs.split(',').map(str::trim).collect();
+    if names.last() != Some(&"_") {
+        return None;
+    }
+    let first = names
+        .first()
+        .filter(|name| names.len() > 1 && **name != "_")
+        .map(|name| name.to_string());
+    Some(Ignored::Blank(first))
+}
+
+/// Returns `true` if the destination of a copy call, the first argument at
+/// `open`, is a hash or MAC.
+fn copies_into_hash(code: &str, open: usize, hashes: &HashSet<String>) -> bool {
+    let args = taint::call_args(&code[open..]);
+    let destination = args.first().map_or("", |arg| arg.trim());
+    let destination = destination.trim_start_matches('&');
+    hashes.contains(destination)
+        || HASH_NAME_REGEX.is_match(destination)
+        || HASH_CONSTRUCTOR_REGEX.is_match(destination)
+}
+
+/// Returns `true` if `name` is tested by a condition in `lines`.
+fn tested_later(lines: &[String], name: &str) -> bool {
+    let word = match Regex::new(&format!(r"\b{}\b", regex::escape(name))) {
+        Ok(word) => word,
+        Err(_) => return false,
+    };
+    lines
+        .iter()
+        .any(|code| CONDITION_REGEX.is_match(code) && word.is_match(code))
+}
+
+fn ignored_error_issue(
+    file_path: &str,
+    line: &str,
+    line_number: usize,
+    span: (usize, usize),
+    reference: &str,
+    ignored: &Ignored,
+    config: &Config,
+) -> Issue {
+    let (column, end_column) = columns_for(line, span.0, span.1);
+    let how = match ignored {
+        Ignored::Statement => "is discarded",
+        Ignored::Blank(_) => "is assigned to `_`",
+    };
+    Issue {
+        rule_id: "ignored-errors-go".to_string(),
+        title: "Ignored Error".to_string(),
+        description: format!(
+            "The error returned by `{}` {}; {}.",
+            reference,
+            how,
+            hazard(reference)
+        ),
+        file_path: file_path.to_string(),
+        line_number,
+        column: Some(column),
+        end_column: Some(end_column),
+        severity: config.rules.ignored_errors_go.severity.clone(),
+        confidence: Confidence::High,
+        suggested_fix: Some(
+            "Check the error and stop, e.g. `if err := ...; err != nil { return err }`, before using the result.".to_string(),
+        ),
+        ..Default::default()
+    }
+}
+
+impl IgnoredErrorsGoScanner {
+    fn scan_function(
+        &self,
+        ctx: &AnalysisContext,
+        function: &GoFunction,
+        targets: &[Target],
+    ) -> Vec<Issue> {
+        let mut in_raw = false;
+        let code: Vec<String> = function
+            .lines
+            .iter()
+            .map(|line| taint::strip_literals(line, &mut in_raw))
+            .collect();
+        let hashes: HashSet<String> = code
+            .iter()
+            .filter(|line| HASH_CONSTRUCTOR_REGEX.is_match(line))
+            .flat_map(|line| taint::assigned_names(line))
+            .collect();
+
+        let mut issues = Vec::new();
+        for target in targets {
+            let receivers: HashSet<String> = match &target.method {
+                Some(method) => code
+                    .iter()
+                    .flat_map(|line| {
+                        let declared = method
+                            .declaration
+                            .captures_iter(line)
+                            .map(|caps| caps[1].to_string());
+                        let constructed = method
+                            .constructor
+                            .iter()
+                            .filter_map(|constructor| constructor.captures(line))
+                            .map(|caps| caps[1].to_string());
+                        declared.chain(constructed).collect::<Vec<_>>()
+                    })
+                    .collect(),
+                None => HashSet::new(),
+            };
+            if target.method.is_some() && receivers.is_empty() {
+                continue;
+            }
+            let copies = COPY_FUNCTIONS.contains(&target.reference.as_str());
+
+            for (offset, line) in code.iter().enumerate() {
+                for caps in target.call.captures_iter(line) {
+                    let m = caps.get(0).unwrap();
+                    if let Some(receiver) = caps.get(1) {
+                        if !receivers.contains(receiver.as_str()) {
+                            continue;
+                        }
+                    }
+                    let ignored = match ignored(line, m.start(), m.end()) {
+                        Some(ignored) => ignored,
+                        None => continue,
+                    };
+                    if copies {
+                        let counted = match &ignored {
+                            Ignored::Blank(Some(count)) => tested_later(&code[offset + 1..], count),
+                            _ => false,
+                        };
+                        if !counted && !copies_into_hash(line, m.end(), &hashes) {
+                            continue;
+                        }
+                    }
+                    issues.push(ignored_error_issue(
+                        ctx.file_path,
+                        function.lines[offset],
+                        function.start_line + offset,
+                        (m.start(), m.end() - 1),
+                        &target.reference,
+                        &ignored,
+                        ctx.config,
+                    ));
+                }
+            }
+        }
+        issues
+    }
+}
+
+impl Scanner for IgnoredErrorsGoScanner {
+    fn name(&self) -> &'static str {
+        "Ignored Errors Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        if ctx.file_path.ends_with("_test.go") {
+            return Ok(Vec::new());
+        }
+        let imports = taint::imports(ctx.content);
+        let targets = targets(&ctx.config.rules.ignored_errors_go.functions, &imports);
+        if targets.is_empty() {
+            return Ok(Vec::new());
+        }
+        // Without function boundaries, the whole file is scanned as one.
+        let whole_file;
+        let functions = match ctx.go_functions() {
+            Some(functions) => functions,
+            None => {
+                whole_file = [GoFunction {
+                    start_line: 1,
+                    lines: ctx.content.lines().collect(),
+                }];
+                &whole_file[..]
+            }
+        };
+        let mut issues: Vec<Issue> = functions
+            .iter()
+            .take_while(|_| !ctx.expired())
+            .flat_map(|function| self.scan_function(ctx, function, &targets))
+            .collect();
+        issues.sort_by_key(|issue| (issue.line_number, issue.column));
+        Ok(issues)
+    }
+}
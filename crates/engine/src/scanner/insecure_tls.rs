@@ -0,0 +1,231 @@
+//! A scanner for Go TLS configuration that weakens certificate checks or
+//! protocol versions.
+//!
+//! Three settings of `tls.Config` are flagged, in struct literals and in
+//! field assignments alike:
+//!
+//! - `InsecureSkipVerify` set to `true`, or to a value that is not a
+//!   constant, such as a flag or a setting read from the environment, which
+//!   is reported with lower confidence;
+//! - `MinVersion` below TLS 1.2 (`tls.VersionSSL30`, `tls.VersionTLS10` or
+//!   `tls.VersionTLS11`);
+//! - `VerifyPeerCertificate` or `VerifyConnection` set to a callback that
+//!   returns `nil` on every path, either a function literal or a top-level
+//!   function of the file named in the assignment.
+//!
+//! Files named `*_test.go` are skipped unless `include-tests` is set, since
+//! tests commonly talk to servers with self-signed certificates.
+
+use std::collections::HashMap;
+
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+use crate::config::{Confidence, Config};
+use crate::error::Result;
+use crate::scanner::taint;
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+pub struct InsecureTlsGoScanner;
+
+/// `InsecureSkipVerify: <value>` or `InsecureSkipVerify = <value>`, but not a
+/// comparison.
+static SKIP_VERIFY_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\bInsecureSkipVerify\s*[:=]\s*([^=,}\s][^,}]*)").unwrap());
+
+/// `MinVersion` set to a protocol version older than TLS 1.2.
+static MIN_VERSION_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"\bMinVersion\s*[:=]\s*(tls\.Version(?:SSL30|TLS10|TLS11)|0x030[0-2])\b").unwrap()
+});
+
+/// A certificate verification callback and the value it is set to.
+static VERIFY_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"\b(VerifyPeerCertificate|VerifyConnection)\s*[:=]\s*([^=,}\s][^,}]*)").unwrap()
+});
+
+/// The name of a top-level function or method.
+static FUNC_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^func\s+(?:\([^)]*\)\s*)?(\w+)\s*\(").unwrap());
+
+static RETURN_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"\breturn\b([^;}\n]*)").unwrap());
+
+/// A value that names a function, possibly through a receiver.
+static FUNC_NAME_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"^(?:\w+\.)*(\w+)$").unwrap());
+
+/// Returns `true` if the function body has a `return` and every one of them
+/// returns `nil`.
+fn always_returns_nil(body: &str) -> bool {
+    let mut returns = RETURN_REGEX.captures_iter(body).peekable();
+    returns.peek().is_some() && returns.all(|caps| caps[1].trim() == "nil")
+}
+
+/// A weakened setting of `tls.Config`.
+enum Setting<'a> {
+    /// `InsecureSkipVerify` set to `true`, or to the given non-constant
+    /// value.
+    SkipVerify(Option<&'a str>),
+    /// `MinVersion` set to the given version.
+    MinVersion(&'a str),
+    /// The named verification callback always returns `nil`.
+    AcceptAll(&'a str),
+}
+
+impl Setting<'_> {
+    fn description(&self) -> String {
+        match self {
+            Self::SkipVerify(None) => "`InsecureSkipVerify: true` disables certificate and host name verification, so any server, including an attacker in the network path, is accepted.".to_string(),
+            Self::SkipVerify(Some(value)) => format!(
+                "`InsecureSkipVerify` is set from `{}`; whenever it is `true`, certificate and host name verification are disabled.",
+                value
+            ),
+            Self::MinVersion(version) => format!(
+                "`MinVersion` allows `{}`, a protocol version older than TLS 1.2 with known weaknesses.",
+                version
+            ),
+            Self::AcceptAll(callback) => format!(
+                "The `{}` callback returns `nil` on every path, so it accepts any certificate it is given.",
+                callback
+            ),
+        }
+    }
+
+    /// A value that is only sometimes `true` may be a deliberate opt-in for
+    /// development.
+    fn confidence(&self) -> Confidence {
+        match self {
+            Self::SkipVerify(Some(_)) => Confidence::Medium,
+            _ => Confidence::High,
+        }
+    }
+
+    fn remediation(&self) -> &'static str {
+        match self {
+            Self::SkipVerify(_) => "Remove `InsecureSkipVerify` and trust private certificate authorities by adding them to `RootCAs` instead.",
+            Self::MinVersion(_) => "Set `MinVersion: tls.VersionTLS12` or higher, or leave it unset to use Go's default of TLS 1.2.",
+            Self::AcceptAll(_) => "Return an error from the callback when the certificate is not the one expected, or remove it and rely on the default verification.",
+        }
+    }
+}
+
+fn insecure_tls_issue(
+    file_path: &str,
+    line: &str,
+    line_number: usize,
+    range: (usize, usize),
+    setting: &Setting,
+    config: &Config,
+) -> Issue {
+    let (column, end_column) = columns_for(line, range.0, range.1);
+    Issue {
+        rule_id: "insecure-tls-go".to_string(),
+        title: "Insecure TLS Configuration".to_string(),
+        description: setting.description(),
+        file_path: file_path.to_string(),
+        line_number,
+        column: Some(column),
+        end_column: Some(end_column),
+        severity: config.rules.insecure_tls_go.severity.clone(),
+        confidence: setting.confidence(),
+        suggested_fix: Some(setting.remediation().to_string()),
+        diff: None,
+        ..Default::default()
+    }
+}
+
+impl Scanner for InsecureTlsGoScanner {
+    fn name(&self) -> &'static str {
+        "Insecure TLS Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let (file_path, content, config) = (ctx.file_path, ctx.content, ctx.config);
+        if file_path.ends_with("_test.go") && !config.rules.insecure_tls_go.include_tests {
+            return Ok(Vec::new());
+        }
+        if ![
+            "InsecureSkipVerify",
+            "MinVersion",
+            "VerifyPeerCertificate",
+            "VerifyConnection",
+        ]
+        .iter()
+        .any(|field| content.contains(field))
+        {
+            return Ok(Vec::new());
+        }
+
+        let lines: Vec<&str> = content.lines().collect();
+        let mut in_raw = false;
+        let stripped: Vec<String> = lines
+            .iter()
+            .map(|line| taint::strip_literals(line, &mut in_raw))
+            .collect();
+        let functions: HashMap<&str, usize> = stripped
+            .iter()
+            .enumerate()
+            .filter_map(|(i, code)| {
+                FUNC_REGEX
+                    .captures(code)
+                    .map(|caps| (caps.get(1).unwrap().as_str(), i))
+            })
+            .collect();
+
+        let mut issues = Vec::new();
+        for (i, (line, code)) in lines.iter().zip(&stripped).enumerate() {
+            for caps in SKIP_VERIFY_REGEX.captures_iter(code) {
+                let m = caps.get(0).unwrap();
+                let value = caps.get(1).unwrap();
+                let setting = match code[value.range()].trim() {
+                    "false" => continue,
+                    "true" => Setting::SkipVerify(None),
+                    _ => Setting::SkipVerify(Some(line[value.range()].trim())),
+                };
+                issues.push(insecure_tls_issue(
+                    file_path,
+                    line,
+                    i + 1,
+                    (m.start(), m.end()),
+                    &setting,
+                    config,
+                ));
+            }
+
+            for caps in MIN_VERSION_REGEX.captures_iter(code) {
+                let m = caps.get(0).unwrap();
+                issues.push(insecure_tls_issue(
+                    file_path,
+                    line,
+                    i + 1,
+                    (m.start(), m.end()),
+                    &Setting::MinVersion(&caps[1]),
+                    config,
+                ));
+            }
+
+            for caps in VERIFY_REGEX.captures_iter(code) {
+                let m = caps.get(0).unwrap();
+                let value = caps.get(2).unwrap();
+                let callback = if value.as_str().starts_with("func") {
+                    taint::function_body(&stripped, i, value.start())
+                } else {
+                    FUNC_NAME_REGEX
+                        .captures(value.as_str().trim())
+                        .and_then(|name| functions.get(&name[1]))
+                        .and_then(|&start| taint::function_body(&stripped, start, 0))
+                };
+                if !callback.as_deref().is_some_and(always_returns_nil) {
+                    continue;
+                }
+                issues.push(insecure_tls_issue(
+                    file_path,
+                    line,
+                    i + 1,
+                    (m.start(), m.start() + caps[1].len()),
+                    &Setting::AcceptAll(&caps[1]),
+                    config,
+                ));
+            }
+        }
+        Ok(issues)
+    }
+}
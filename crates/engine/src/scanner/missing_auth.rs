@@ -0,0 +1,359 @@
+//! A scanner for Go HTTP handlers that change state without an apparent
+//! authentication or authorization check.
+//!
+//! The rule is a heuristic meant to point reviewers at handlers that may
+//! have been left unprotected, so every finding has low confidence. A
+//! handler is checked when it is
+//!
+//! - registered for `POST`, `PUT`, `PATCH` or `DELETE`, through the method
+//!   helpers of routers such as chi, gin, echo or fiber (`r.Post(...)`,
+//!   `e.DELETE(...)`), a Go 1.22 pattern such as `"POST /items"`, or gorilla
+//!   `HandleFunc(...).Methods("POST")`;
+//! - or a top-level function with a handler signature whose name contains a
+//!   verb that changes state, such as `handleDelete` or `UpdateUser`, and
+//!   that is not registered for `GET` in the same file.
+//!
+//! It is reported when neither its body nor its registration mentions one of
+//! the `auth-markers`. A router that applies middleware mentioning a marker
+//! with `Use`, or that is created from an expression mentioning one, as in
+//! `admin := r.Group("/admin", RequireAuth())`, protects every route
+//! registered on it. Named handlers are only checked when they are declared
+//! in the same file as their registration.
+//!
+//! Files named `*_test.go` are skipped.
+
+use std::collections::{HashMap, HashSet};
+
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+use crate::config::{Confidence, Config};
+use crate::error::Result;
+use crate::scanner::taint;
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+pub struct MissingAuthGoScanner;
+
+/// A router method helper, capturing the receiver, unless the router is the
+/// result of a call, and the method.
+static ROUTE_METHOD_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(
+        r"(?:\b(\w+)|\))\s*\.\s*(Get|Head|Options|Post|Put|Patch|Delete|GET|HEAD|OPTIONS|POST|PUT|PATCH|DELETE)\s*\(",
+    )
+    .unwrap()
+});
+
+/// `Handle` or `HandleFunc`, capturing the receiver unless the router is
+/// the result of a call.
+static HANDLE_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"(?:\b(\w+)|\))\s*\.\s*(?:HandleFunc|Handle)\s*\(").unwrap());
+
+/// Gorilla's method matcher, as chained onto a registration.
+static METHODS_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"^\s*\.\s*Methods\s*\(").unwrap());
+
+/// Router middleware, which applies to every route registered after it.
+static USE_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"\.\s*Use\s*\(").unwrap());
+
+/// A variable assigned a single value, capturing its name.
+static ASSIGN_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^\s*(?:var\s+)?([A-Za-z_]\w*)\s*:?=[^=]").unwrap());
+
+/// The name of a top-level function or method.
+static FUNC_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^func\s+(?:\([^)]*\)\s*)?(\w+)\s*\(").unwrap());
+
+/// The parameters of an HTTP handler in `net/http` and common frameworks.
+static HANDLER_PARAMS_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"http\.ResponseWriter|\*gin\.Context|\becho\.Context|\*fiber\.Ctx").unwrap()
+});
+
+/// A verb that changes state, as a word of a camelCase name.
+static MUTATING_NAME_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(
+        r"(?:^|[a-z0-9_])(?:[Cc]reate|[Uu]pdate|[Dd]elete|[Rr]emove|[Pp]atch|[Ee]dit|[Pp]ut|[Pp]ost|[Ss]ave|[Aa]dd|[Ss]et)(?:[A-Z0-9_]|$)",
+    )
+    .unwrap()
+});
+
+/// `http.HandlerFunc(h)`, which only converts the handler.
+static HANDLER_FUNC_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^http\.HandlerFunc\s*\((.*)\)$").unwrap());
+
+/// A handler named by a function, method value or call, capturing the last
+/// name.
+static HANDLER_NAME_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^(?:\w+\s*\.\s*)*(\w+)\s*(?:\(.*\))?$").unwrap());
+
+/// The HTTP methods whose handlers change state.
+const MUTATING_METHODS: [&str; 4] = ["POST", "PUT", "PATCH", "DELETE"];
+
+/// A route registered in the file.
+struct Route {
+    /// Upper-case HTTP methods, empty when the route accepts any method.
+    methods: Vec<String>,
+    /// The route pattern, when it is a literal.
+    pattern: Option<String>,
+    /// Byte range of the handler expression within the line.
+    handler: (usize, usize),
+    /// Whether the registration or its router mentions an auth marker.
+    protected: bool,
+}
+
+impl Route {
+    fn mutating(&self) -> bool {
+        self.methods
+            .iter()
+            .any(|method| MUTATING_METHODS.contains(&method.as_str()))
+    }
+
+    /// The method and pattern, e.g. `DELETE /items/{id}`.
+    fn describe(&self) -> String {
+        let methods = self
+            .methods
+            .iter()
+            .filter(|method| MUTATING_METHODS.contains(&method.as_str()))
+            .cloned()
+            .collect::<Vec<_>>()
+            .join("|");
+        match &self.pattern {
+            Some(pattern) => format!("{} {}", methods, pattern),
+            None => methods,
+        }
+    }
+}
+
+/// Returns the contents of a Go string literal, or `None` when `expr` is not
+/// one.
+fn string_literal(expr: &str) -> Option<&str> {
+    let expr = expr.trim();
+    let quoted = |q: char| expr.len() >= 2 && expr.starts_with(q) && expr.ends_with(q);
+    (quoted('"') || quoted('`')).then(|| &expr[1..expr.len() - 1])
+}
+
+/// Finds the registrations on the 0-based line `i`. `lines` are the original
+/// lines of the file and `code` the same lines with literals stripped.
+fn routes_on_line(
+    lines: &[&str],
+    code: &[String],
+    i: usize,
+    markers: &Regex,
+    protected_routers: &HashSet<String>,
+) -> Vec<Route> {
+    let (line, stripped) = (lines[i], code[i].as_str());
+    let line_protected = markers.is_match(stripped);
+    let mut routes = Vec::new();
+    let calls = ROUTE_METHOD_REGEX
+        .captures_iter(stripped)
+        .map(|caps| (caps, true))
+        .chain(
+            HANDLE_REGEX
+                .captures_iter(stripped)
+                .map(|caps| (caps, false)),
+        );
+    for (caps, method_helper) in calls {
+        let open = caps.get(0).unwrap().end();
+        let args = taint::call_arg_ranges(&stripped[open..]);
+        if args.len() < 2 {
+            continue;
+        }
+        // Router patterns are absolute, or empty within a group; this also
+        // keeps HTTP client calls such as `http.Post(url, ...)` out.
+        let pattern = string_literal(&line[open + args[0].start..open + args[0].end]);
+        if method_helper && !pattern.is_some_and(|p| p.is_empty() || p.starts_with('/')) {
+            continue;
+        }
+        let mut pattern = pattern.map(str::to_string);
+        let mut methods = Vec::new();
+        if method_helper {
+            methods.push(caps[2].to_uppercase());
+        } else if let Some((method, path)) = pattern
+            .as_deref()
+            .and_then(|p| p.split_once(' '))
+            .filter(|(method, _)| method.chars().all(|c| c.is_ascii_uppercase()))
+        {
+            methods.push(method.to_string());
+            pattern = Some(path.trim().to_string());
+        } else {
+            // Gorilla chains the methods onto the registration.
+            let close = open + args.last().unwrap().end + 1;
+            if let Some(m) = stripped
+                .get(close..)
+                .and_then(|rest| METHODS_REGEX.find(rest))
+            {
+                let list = close + m.end();
+                methods = taint::call_arg_ranges(&stripped[list..])
+                    .into_iter()
+                    .filter_map(|range| string_literal(&line[list + range.start..list + range.end]))
+                    .map(str::to_uppercase)
+                    .collect();
+            }
+        }
+        let handler = args.last().unwrap();
+        let raw = &stripped[open + handler.start..open + handler.end];
+        let start = open + handler.start + (raw.len() - raw.trim_start().len());
+        let receiver = caps.get(1).map(|m| m.as_str());
+        routes.push(Route {
+            methods,
+            pattern,
+            handler: (start, start + raw.trim().len()),
+            protected: line_protected || receiver.is_some_and(|r| protected_routers.contains(r)),
+        });
+    }
+    routes
+}
+
+fn missing_auth_issue(
+    file_path: &str,
+    line: &str,
+    line_number: usize,
+    span: (usize, usize),
+    description: String,
+    config: &Config,
+) -> Issue {
+    let (column, end_column) = columns_for(line, span.0, span.1);
+    Issue {
+        rule_id: "missing-auth-go".to_string(),
+        title: "Missing Authorization Check".to_string(),
+        description,
+        file_path: file_path.to_string(),
+        line_number,
+        column: Some(column),
+        end_column: Some(end_column),
+        severity: config.rules.missing_auth_go.severity.clone(),
+        confidence: Confidence::Low,
+        suggested_fix: Some("Register the route behind the authentication middleware, or check the caller's identity and permissions at the start of the handler. If the check is made through a helper the rule does not know, add its name to `auth-markers`.".to_string()),
+        diff: None,
+        ..Default::default()
+    }
+}
+
+impl Scanner for MissingAuthGoScanner {
+    fn name(&self) -> &'static str {
+        "Missing Authentication Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let (file_path, content, config) = (ctx.file_path, ctx.content, ctx.config);
+        let rule = &config.rules.missing_auth_go;
+        if file_path.ends_with("_test.go") || rule.auth_markers.is_empty() {
+            return Ok(Vec::new());
+        }
+        let functions = match ctx.go_functions() {
+            Some(functions) => functions,
+            None => return Ok(Vec::new()),
+        };
+        let alternatives: Vec<String> =
+            rule.auth_markers.iter().map(|m| regex::escape(m)).collect();
+        let markers = match Regex::new(&format!(r"\b(?:{})\b", alternatives.join("|"))) {
+            Ok(markers) => markers,
+            Err(_) => return Ok(Vec::new()),
+        };
+        let example = &rule.auth_markers[0];
+
+        let lines: Vec<&str> = content.lines().collect();
+        let mut in_raw = false;
+        let code: Vec<String> = lines
+            .iter()
+            .map(|line| taint::strip_literals(line, &mut in_raw))
+            .collect();
+
+        // Top-level functions by name, with their 0-based first line and
+        // their body with literals stripped.
+        let declared: HashMap<&str, (usize, String)> = functions
+            .iter()
+            .filter_map(|function| {
+                let first = function.start_line - 1;
+                let name = FUNC_REGEX.captures(&code[first])?.get(1)?.as_str();
+                let body = code[first..first + function.lines.len()].join("\n");
+                Some((name, (first, body)))
+            })
+            .collect();
+
+        // Middleware added with `Use` covers the whole file; a router built
+        // from an expression naming a marker covers the routes added to it.
+        if code
+            .iter()
+            .any(|c| USE_REGEX.is_match(c) && markers.is_match(c))
+        {
+            return Ok(Vec::new());
+        }
+        let protected_routers: HashSet<String> = code
+            .iter()
+            .filter(|c| markers.is_match(c))
+            .filter_map(|c| ASSIGN_REGEX.captures(c).map(|caps| caps[1].to_string()))
+            .collect();
+
+        let mut issues = Vec::new();
+        let mut registered = HashSet::new();
+        for i in 0..lines.len() {
+            if ctx.expired() {
+                break;
+            }
+            for route in routes_on_line(&lines, &code, i, &markers, &protected_routers) {
+                let expr = code[i][route.handler.0..route.handler.1].trim();
+                let (handler, body) = if expr.starts_with("func") {
+                    (
+                        "The handler".to_string(),
+                        taint::function_body(&code, i, route.handler.0),
+                    )
+                } else {
+                    let expr = HANDLER_FUNC_REGEX
+                        .captures(expr)
+                        .map_or(expr, |caps| caps.get(1).unwrap().as_str().trim());
+                    let name = match HANDLER_NAME_REGEX.captures(expr) {
+                        Some(caps) => caps.get(1).unwrap().as_str(),
+                        None => continue,
+                    };
+                    registered.insert(name);
+                    (
+                        format!("`{}`", name),
+                        declared.get(name).map(|(_, body)| body.clone()),
+                    )
+                };
+                // Handlers declared in other files cannot be checked.
+                let unchecked = matches!(&body, Some(body) if !markers.is_match(body));
+                if !route.mutating() || route.protected || !unchecked {
+                    continue;
+                }
+                issues.push(missing_auth_issue(
+                    file_path,
+                    lines[i],
+                    i + 1,
+                    route.handler,
+                    format!(
+                        "{} handles `{}` but neither it nor its registration references one of the configured `auth-markers`, such as `{}`, so it may be missing an authentication or authorization check.",
+                        handler,
+                        route.describe(),
+                        example
+                    ),
+                    config,
+                ));
+            }
+        }
+
+        for (name, (first, body)) in &declared {
+            let signature = code[*first].split('{').next().unwrap_or_default();
+            if registered.contains(name)
+                || !HANDLER_PARAMS_REGEX.is_match(signature)
+                || !MUTATING_NAME_REGEX.is_match(name)
+                || markers.is_match(body)
+            {
+                continue;
+            }
+            let start = FUNC_REGEX.captures(&code[*first]).unwrap().get(1).unwrap();
+            issues.push(missing_auth_issue(
+                file_path,
+                lines[*first],
+                first + 1,
+                (start.start(), start.end()),
+                format!(
+                    "`{}` looks like a handler that changes state, but it never references one of the configured `auth-markers`, such as `{}`, so it may be missing an authentication or authorization check.",
+                    name, example
+                ),
+                config,
+            ));
+        }
+        issues.sort_by_key(|issue| (issue.line_number, issue.column));
+        Ok(issues)
+    }
+}
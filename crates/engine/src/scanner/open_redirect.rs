@@ -0,0 +1,169 @@
+//! A scanner for open redirects in Go HTTP handlers.
+//!
+//! Request values are tracked through each function with the shared taint
+//! tracker, and `http.Redirect` calls whose target carries request data are
+//! flagged. A variable stops being tainted once the handler validates it in
+//! a condition: a relative-path check with `strings.HasPrefix(v, "/")`, an
+//! allowlist lookup such as `allowed[v]` or `slices.Contains(list, v)`, a
+//! call to a helper whose name mentions validation (`isSafeRedirect(v)`), or
+//! a check of the `Host`, `Scheme` or `IsAbs()` of the URL parsed from it.
+//! Validation is recognised anywhere in the function, not only on the path
+//! leading to the redirect.
+
+use std::collections::HashMap;
+
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+use crate::config::Config;
+use crate::error::Result;
+use crate::scanner::taint::{self, Taint};
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+pub struct OpenRedirectGoScanner;
+
+static SINK_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"\bhttp\.Redirect\(").unwrap());
+
+/// Calls whose result cannot point at another host.
+static SANITIZER_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"\burl\.(?:PathEscape|QueryEscape)\(|\bstrconv\.(?:Itoa|FormatInt)\(").unwrap()
+});
+
+/// Checks that validate the captured variable, matched against the original
+/// line of a condition.
+static VALIDATION_REGEXES: Lazy<Vec<Regex>> = Lazy::new(|| {
+    vec![
+        // A relative-path check.
+        Regex::new(r#"\bstrings\.HasPrefix\(\s*(\w+)\s*,\s*"/"\s*\)"#).unwrap(),
+        // An allowlist lookup, possibly on the parsed URL's host.
+        Regex::new(r"\b\w+\[\s*(\w+)(?:\.\w+(?:\(\))?)?\s*\]").unwrap(),
+        Regex::new(r"\bslices\.Contains\(\s*\w+\s*,\s*(\w+)").unwrap(),
+        // A validation helper.
+        Regex::new(r"(?i)\b\w*(?:valid|allow|safe|trust)\w*\(\s*(\w+)").unwrap(),
+        // A check of the parsed URL.
+        Regex::new(r"\b(\w+)\.(?:Host\b|Hostname\(\)|Scheme\b|IsAbs\(\))").unwrap(),
+    ]
+});
+
+/// `u, err := url.Parse(v)`, linking the parsed URL to the variable it
+/// came from.
+static PARSE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"^\s*(\w+)\s*(?:,\s*\w+\s*)?:?=\s*url\.(?:Parse|ParseRequestURI)\(\s*(\w+)\s*\)")
+        .unwrap()
+});
+
+/// A target that starts with a constant absolute URL including its path, or
+/// with a constant path that is not protocol-relative. Appending to either
+/// cannot change the host.
+static CONSTANT_PREFIX_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r#"^\s*"(?:https?://[^/"]+/|/[^/\\"])"#).unwrap());
+
+fn open_redirect_issue(
+    file_path: &str,
+    line: &str,
+    line_number: usize,
+    start: usize,
+    end: usize,
+    taint: &Taint,
+    config: &Config,
+) -> Issue {
+    let (column, end_column) = columns_for(line, start, end);
+    let mut issue = Issue {
+        rule_id: "open-redirect-go".to_string(),
+        title: "Potential Open Redirect".to_string(),
+        description: format!(
+            "Request data from `{}` is used as the target of `http.Redirect` without checking that it is a relative path or an allowed host.",
+            taint.origin
+        ),
+        file_path: file_path.to_string(),
+        line_number,
+        column: Some(column),
+        end_column: Some(end_column),
+        severity: config.rules.open_redirect_go.severity.clone(),
+        confidence: taint.confidence,
+        suggested_fix: Some(
+            "Only redirect to paths that start with a single `/`, or to hosts on an allowlist."
+                .to_string(),
+        ),
+        diff: None,
+        ..Default::default()
+    };
+    taint.attach_trace(&mut issue, line);
+    issue
+}
+
+impl OpenRedirectGoScanner {
+    fn scan_function(&self, ctx: &AnalysisContext, function: &taint::GoFunction) -> Vec<Issue> {
+        let (file_path, config) = (ctx.file_path, ctx.config);
+        let mut issues = Vec::new();
+        let mut tracker = ctx.tracker(&SANITIZER_REGEX, function);
+        // Parsed URL variable -> the variable it was parsed from.
+        let mut parsed: HashMap<String, String> = HashMap::new();
+        let mut in_raw = false;
+        for (offset, line) in function.lines.iter().enumerate() {
+            let code = taint::strip_literals(line, &mut in_raw);
+
+            for name in taint::validated_names(&code, line, &VALIDATION_REGEXES) {
+                tracker.clear(&name);
+                if let Some(source) = parsed.get(&name) {
+                    tracker.clear(source);
+                }
+            }
+
+            if let Some(m) = SINK_REGEX.find(&code) {
+                let args = taint::call_arg_ranges(&code[m.end()..]);
+                if let Some(range) = args.get(2) {
+                    let range = m.end() + range.start..m.end() + range.end;
+                    let constant_prefix = CONSTANT_PREFIX_REGEX.is_match(&line[range.clone()]);
+                    let taint = tracker
+                        .tainted_by(&code[range])
+                        .filter(|_| !constant_prefix);
+                    if let Some(taint) = taint {
+                        issues.push(open_redirect_issue(
+                            file_path,
+                            line,
+                            function.start_line + offset,
+                            m.start(),
+                            m.end() - 1,
+                            &taint,
+                            config,
+                        ));
+                    }
+                }
+            }
+
+            if let Some(caps) = PARSE_REGEX.captures(&code) {
+                parsed.insert(caps[1].to_string(), caps[2].to_string());
+            }
+            tracker.observe(line, &code, function.start_line + offset);
+        }
+        issues
+    }
+}
+
+impl Scanner for OpenRedirectGoScanner {
+    fn name(&self) -> &'static str {
+        "Open Redirect Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let functions = match ctx.go_functions() {
+            Some(functions) => functions,
+            None => {
+                log::debug!(
+                    "Could not split {} into functions; skipping open redirect checks",
+                    ctx.file_path
+                );
+                return Ok(Vec::new());
+            }
+        };
+        let issues = functions
+            .iter()
+            .take_while(|_| !ctx.expired())
+            .flat_map(|function| self.scan_function(ctx, function))
+            .collect();
+        Ok(ctx
+            .declared_functions()
+            .drop_safe_sinks(ctx.content, issues))
+    }
+}
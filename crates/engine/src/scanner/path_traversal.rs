@@ -0,0 +1,227 @@
+//! A scanner for path traversal in Go file-serving code.
+//!
+//! Request values are tracked through each function with the shared taint
+//! tracker, and calls that open or serve a file (`os.Open`, `os.OpenFile`,
+//! `os.ReadFile`, `ioutil.ReadFile` and `http.ServeFile`) are flagged when
+//! their path carries request data, typically joined onto a base directory
+//! with `filepath.Join`. `filepath.Join` cleans the joined path, but keeps
+//! any `..` elements that climb out of the base, so joining alone is not a
+//! defence.
+//!
+//! A variable stops being tainted once the function checks it in a
+//! condition: a containment check with `strings.HasPrefix` on a path built
+//! with `filepath.Join`, `filepath.Clean` or `filepath.Abs`, a check of the
+//! result of `filepath.Rel`, `filepath.IsLocal`, a search for `..`, an
+//! allowlist lookup, a `switch` over constant cases, or a call to a helper
+//! whose name mentions validation. Taking `filepath.Base` of a value removes
+//! its directories and clears it as well. Files opened through `http.Dir`,
+//! `http.FS` or an `os.Root` are confined to their directory and are never
+//! flagged.
+
+use std::collections::{HashMap, HashSet};
+
+use once_cell::sync::Lazy;
+use regex::{Captures, Regex};
+
+use crate::config::Config;
+use crate::error::Result;
+use crate::scanner::taint::{self, Taint};
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+pub struct PathTraversalGoScanner;
+
+/// Calls that open or serve the file at a path.
+static SINK_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"\b(?:os\.(?:Open|OpenFile|ReadFile)|ioutil\.ReadFile|http\.ServeFile)\(").unwrap()
+});
+
+/// Calls whose result cannot name another directory.
+static SANITIZER_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"\b(?:filepath|path)\.Base\(|\bstrconv\.(?:Itoa|FormatInt)\(").unwrap()
+});
+
+/// Checks that validate the captured variable, matched against the original
+/// line of a condition.
+static VALIDATION_REGEXES: Lazy<Vec<Regex>> = Lazy::new(|| {
+    vec![
+        // A local-path check.
+        Regex::new(r"\bfilepath\.IsLocal\(\s*(\w+)\s*\)").unwrap(),
+        // Rejecting parent directory elements.
+        Regex::new(r#"\bstrings\.Contains\(\s*(\w+)\s*,\s*"\.\."\s*\)"#).unwrap(),
+        // An allowlist lookup.
+        Regex::new(r"\b\w+\[\s*(\w+)\s*\]").unwrap(),
+        Regex::new(r"\bslices\.Contains\(\s*\w+\s*,\s*(\w+)").unwrap(),
+        // A switch over the value, whose cases are constants.
+        Regex::new(r"^\s*switch\s+(\w+)\s*\{").unwrap(),
+        // A validation helper.
+        Regex::new(r"(?i)\b\w*(?:valid|allow|safe|trust)\w*\(\s*(\w+)").unwrap(),
+    ]
+});
+
+/// A prefix check, which only contains a path that has been cleaned.
+static PREFIX_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\bstrings\.HasPrefix\(\s*(\w+)\s*,").unwrap());
+
+/// `p := filepath.Join(...)`, `filepath.Clean(...)` or `filepath.Abs(...)`.
+static CLEANED_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"^\s*(\w+)\s*(?:,\s*\w+\s*)?:?=\s*filepath\.(?:Join|Clean|Abs)\(").unwrap()
+});
+
+/// `rel, err := filepath.Rel(base, p)`, linking the relative path to the
+/// path it was computed from.
+static REL_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"^\s*(\w+)\s*(?:,\s*\w+\s*)?:?=\s*filepath\.Rel\([^,]+,\s*(\w+)\s*\)").unwrap()
+});
+
+/// The index of the path argument of a sink call.
+fn path_arg(call: &str) -> usize {
+    if call.starts_with("http.ServeFile") {
+        2
+    } else {
+        0
+    }
+}
+
+fn path_traversal_issue(
+    file_path: &str,
+    line: &str,
+    line_number: usize,
+    call: &Captures,
+    joined: bool,
+    taint: &Taint,
+    config: &Config,
+) -> Issue {
+    let m = call.get(0).unwrap();
+    let name = m.as_str().trim_end_matches('(');
+    let (column, end_column) = columns_for(line, m.start(), m.end() - 1);
+    let description = if joined {
+        format!(
+            "Request data from `{}` is joined onto a directory and passed to `{}`; `..` elements in it can reach files outside that directory.",
+            taint.origin, name
+        )
+    } else {
+        format!(
+            "Request data from `{}` is used as the path passed to `{}`, so any file the process can read may be opened.",
+            taint.origin, name
+        )
+    };
+    let mut issue = Issue {
+        rule_id: "path-traversal-go".to_string(),
+        title: "Potential Path Traversal".to_string(),
+        description,
+        file_path: file_path.to_string(),
+        line_number,
+        column: Some(column),
+        end_column: Some(end_column),
+        severity: config.rules.path_traversal_go.severity.clone(),
+        confidence: taint.confidence,
+        suggested_fix: Some(
+            "Serve files through `http.Dir` or an `os.Root`, or clean the joined path and check that it stays within the base directory before opening it.".to_string(),
+        ),
+        diff: None,
+        ..Default::default()
+    };
+    taint.attach_trace(&mut issue, line);
+    issue
+}
+
+impl PathTraversalGoScanner {
+    fn scan_function(&self, ctx: &AnalysisContext, function: &taint::GoFunction) -> Vec<Issue> {
+        let (file_path, config) = (ctx.file_path, ctx.config);
+        let mut issues = Vec::new();
+        let mut tracker = ctx.tracker(&SANITIZER_REGEX, function);
+        // Variables holding cleaned paths, and those built with `filepath.Join`.
+        let mut cleaned: HashSet<String> = HashSet::new();
+        let mut joined: HashSet<String> = HashSet::new();
+        // Relative path variable -> the path it was computed from.
+        let mut relative: HashMap<String, String> = HashMap::new();
+        let mut in_raw = false;
+        for (offset, line) in function.lines.iter().enumerate() {
+            let code = taint::strip_literals(line, &mut in_raw);
+
+            let mut validated = taint::validated_names(&code, line, &VALIDATION_REGEXES);
+            // A prefix check only contains a path that has been cleaned.
+            let prefixed =
+                taint::validated_names(&code, line, std::slice::from_ref(&*PREFIX_REGEX));
+            validated.extend(
+                prefixed
+                    .into_iter()
+                    .filter(|name| cleaned.contains(name) || relative.contains_key(name)),
+            );
+            for name in validated {
+                tracker.clear(&name);
+                if let Some(source) = relative.get(&name) {
+                    tracker.clear(source);
+                }
+            }
+
+            for caps in SINK_REGEX.captures_iter(&code) {
+                let end = caps.get(0).unwrap().end();
+                let args = taint::call_arg_ranges(&code[end..]);
+                let range = match args.get(path_arg(&caps[0])) {
+                    Some(range) => end + range.start..end + range.end,
+                    None => continue,
+                };
+                let arg = &code[range];
+                if let Some(taint) = tracker.tainted_by(arg) {
+                    let is_joined = arg.contains("filepath.Join(")
+                        || arg.contains("path.Join(")
+                        || joined.contains(arg.trim());
+                    issues.push(path_traversal_issue(
+                        file_path,
+                        line,
+                        function.start_line + offset,
+                        &caps,
+                        is_joined,
+                        &taint,
+                        config,
+                    ));
+                }
+            }
+
+            for name in taint::assigned_names(&code) {
+                cleaned.remove(&name);
+                joined.remove(&name);
+                relative.remove(&name);
+            }
+            if let Some(caps) = CLEANED_REGEX.captures(&code) {
+                cleaned.insert(caps[1].to_string());
+                if code.contains("filepath.Join(") {
+                    joined.insert(caps[1].to_string());
+                }
+            }
+            if let Some(caps) = REL_REGEX.captures(&code) {
+                relative.insert(caps[1].to_string(), caps[2].to_string());
+            }
+            tracker.observe(line, &code, function.start_line + offset);
+        }
+        issues
+    }
+}
+
+impl Scanner for PathTraversalGoScanner {
+    fn name(&self) -> &'static str {
+        "Path Traversal Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let functions = match ctx.go_functions() {
+            Some(functions) => functions,
+            None => {
+                log::debug!(
+                    "Could not split {} into functions; skipping path traversal checks",
+                    ctx.file_path
+                );
+                return Ok(Vec::new());
+            }
+        };
+        let issues = functions
+            .iter()
+            .take_while(|_| !ctx.expired())
+            .flat_map(|function| self.scan_function(ctx, function))
+            .collect();
+        Ok(ctx
+            .declared_functions()
+            .drop_safe_sinks(ctx.content, issues))
+    }
+}
@@ -0,0 +1,408 @@
+//! Whole-package and whole-module context for the taint-tracking rules.
+//!
+//! In the default `file` scope each file is analysed on its own, so request
+//! data passed to a helper defined in another file is lost at the call. In
+//! the `package` and `module` scopes a [`ScopeIndex`] is built over the Go
+//! files of the reviewed packages, or of the whole tree, before they are
+//! scanned. For each rule's set of sanitizers, every function it holds is
+//! walked with the shared taint tracker and the parameters that some call
+//! passes request data to are recorded. The walk repeats until no new
+//! parameter is found, so taint follows chains of helpers. The rules then
+//! analyse those functions with the recorded parameters already tainted.
+//!
+//! Calls are resolved by name: plain calls and method calls to the
+//! functions and methods of the same package (directory), and in the
+//! `module` scope also `pkg.Func` calls through an import of another package
+//! of the module. A method whose name is declared on several types of the
+//! package is not followed, and neither are function values. Taint that
+//! reaches a parameter this way has at most medium confidence.
+
+use std::collections::HashMap;
+use std::path::Path;
+use std::sync::{Arc, Mutex};
+
+use once_cell::sync::Lazy;
+use regex::Regex;
+use sha2::{Digest, Sha256};
+use walkdir::WalkDir;
+
+use crate::config::{AnalysisScope, Confidence, Config};
+use crate::error::Result;
+use crate::paths::{PathFilter, VCS_DIRS};
+use crate::scanner::taint::{self, DeclaredFunctions, TaintTracker};
+use crate::scanner::{TraceKind, TraceStep};
+
+/// `func Name(`, `func (r *T) Name(` and `func Name[T any](`. Group 1 is
+/// the receiver, if any, and group 2 the name.
+static DECLARATION_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"^\s*func\s*(\([^()]*\))?\s*([A-Za-z_]\w*)\s*(?:\[[^\]]*\])?\s*\(").unwrap()
+});
+
+/// A name followed by the opening parenthesis of a call.
+static CALL_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"\b([A-Za-z_]\w*)\s*\(").unwrap());
+
+/// The text before a call that makes it a function declaration instead.
+static DECLARED_BEFORE_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\bfunc\s*(?:\([^()]*\)\s*)?$").unwrap());
+
+/// The identifier and `.` before a qualified call, as in `store.Save(`.
+static QUALIFIER_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"([A-Za-z_]\w*|\))\s*\.\s*$").unwrap());
+
+static MODULE_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"(?m)^\s*module\s+(\S+)").unwrap());
+
+/// The most times every function is walked before the search for tainted
+/// parameters stops, bounding chains of helpers.
+const MAX_PASSES: usize = 8;
+
+/// Returns the Go files under the working directory that the `[paths]`
+/// filters and `.reviewlensignore` files include, sorted, as the `module`
+/// scope indexes them.
+pub fn module_files(config: &Config) -> Result<Vec<String>> {
+    let filter = PathFilter::new(&config.paths)?.with_ignore_files(".");
+    let mut files: Vec<String> = WalkDir::new(".")
+        .into_iter()
+        .filter_entry(|e| {
+            !(e.file_type().is_dir()
+                && VCS_DIRS.contains(&e.file_name().to_string_lossy().as_ref()))
+        })
+        .filter_map(|e| e.ok())
+        .filter(|e| e.file_type().is_file())
+        .filter_map(|e| {
+            let rel_path = e.path().strip_prefix(".").unwrap_or(e.path());
+            let name = rel_path.to_string_lossy().replace('\\', "/");
+            (name.ends_with(".go") && filter.is_included(rel_path)).then_some(name)
+        })
+        .collect();
+    files.sort();
+    Ok(files)
+}
+
+struct ScopeFile {
+    /// The file's directory, which is its package.
+    dir: String,
+    /// Import paths by local name.
+    imports: HashMap<String, String>,
+    declared: DeclaredFunctions,
+}
+
+struct ScopeFunction {
+    file: usize,
+    start_line: usize,
+    lines: Vec<String>,
+    params: Vec<String>,
+    /// Whether the last parameter collects the remaining arguments.
+    variadic: bool,
+}
+
+/// Taint recorded for the parameters of each function, by function index.
+type ParameterTaint = Vec<Vec<Option<Confidence>>>;
+
+/// The Go functions of the files in scope, resolved for following calls
+/// between them.
+pub struct ScopeIndex {
+    scope: AnalysisScope,
+    files: Vec<ScopeFile>,
+    functions: Vec<ScopeFunction>,
+    /// Functions by package and name.
+    by_name: HashMap<(String, String), usize>,
+    /// Methods by package and name.
+    methods: HashMap<(String, String), Vec<usize>>,
+    /// Functions by file path and declaration line.
+    by_line: HashMap<(String, usize), usize>,
+    /// The module path declared in `go.mod`, if the tree has one.
+    module: Option<String>,
+    /// Digests of the files each package's findings depend on.
+    digests: HashMap<String, String>,
+    /// Parameter taint, computed on first use for each set of sanitizers.
+    tainted: Mutex<HashMap<String, Arc<ParameterTaint>>>,
+}
+
+impl ScopeIndex {
+    /// Indexes the Go files among `files`, given as path and contents with
+    /// paths relative to the root of the tree. A `go.mod` at the root names
+    /// the module the `module` scope resolves imports against.
+    pub fn new(scope: AnalysisScope, files: &[(String, String)], config: &Config) -> Self {
+        let mut index = Self {
+            scope,
+            files: Vec::new(),
+            functions: Vec::new(),
+            by_name: HashMap::new(),
+            methods: HashMap::new(),
+            by_line: HashMap::new(),
+            module: None,
+            digests: HashMap::new(),
+            tainted: Mutex::new(HashMap::new()),
+        };
+        let mut hashers: HashMap<String, Sha256> = HashMap::new();
+        let mut sorted: Vec<&(String, String)> = files.iter().collect();
+        sorted.sort();
+        for (path, content) in sorted {
+            if path == "go.mod" {
+                index.module = MODULE_REGEX
+                    .captures(content)
+                    .map(|caps| caps[1].to_string());
+                continue;
+            }
+            if !path.ends_with(".go") {
+                continue;
+            }
+            let dir = package_of(path);
+            let key = match scope {
+                AnalysisScope::Module => String::new(),
+                _ => dir.clone(),
+            };
+            let hasher = hashers.entry(key).or_default();
+            hasher.update(path.as_bytes());
+            hasher.update([0]);
+            hasher.update(content.as_bytes());
+            hasher.update([0]);
+            index.add_file(path, dir, content, config);
+        }
+        index.digests = hashers
+            .into_iter()
+            .map(|(key, hasher)| (key, format!("{:x}", hasher.finalize())))
+            .collect();
+        index
+    }
+
+    fn add_file(&mut self, path: &str, dir: String, content: &str, config: &Config) {
+        let functions = match taint::split_functions(content) {
+            Some(functions) => functions,
+            None => return,
+        };
+        let file = self.files.len();
+        for function in functions {
+            let header: String = function
+                .lines
+                .iter()
+                .scan(false, |in_raw, line| {
+                    Some(taint::strip_literals(line, in_raw))
+                })
+                .collect::<Vec<_>>()
+                .join(" ");
+            let caps = match DECLARATION_REGEX.captures(&header) {
+                Some(caps) => caps,
+                None => continue,
+            };
+            let end = caps.get(0).unwrap().end();
+            let (params, variadic) = parameter_names(&header[end..]);
+            let name = caps[2].to_string();
+            let id = self.functions.len();
+            if caps.get(1).is_some() {
+                self.methods
+                    .entry((dir.clone(), name))
+                    .or_default()
+                    .push(id);
+            } else {
+                self.by_name.insert((dir.clone(), name), id);
+            }
+            self.by_line
+                .insert((path.to_string(), function.start_line), id);
+            self.functions.push(ScopeFunction {
+                file,
+                start_line: function.start_line,
+                lines: function.lines.iter().map(|line| line.to_string()).collect(),
+                params,
+                variadic,
+            });
+        }
+        self.files.push(ScopeFile {
+            dir,
+            imports: taint::imports(content).into_iter().collect(),
+            declared: DeclaredFunctions::new(&config.taint, content),
+        });
+    }
+
+    /// Returns a digest of the files that the findings of `file_path` can
+    /// depend on, its package or the whole module, or `None` if the file is
+    /// not in scope.
+    pub fn digest(&self, file_path: &str) -> Option<&str> {
+        let key = match self.scope {
+            AnalysisScope::Module => String::new(),
+            _ => package_of(file_path),
+        };
+        self.digests.get(&key).map(String::as_str)
+    }
+
+    /// Returns the parameters of the function declared at `start_line` of
+    /// `file_path` that callers in scope pass request data to, judged with
+    /// `sanitizers` and each caller's declared sanitizers.
+    pub fn tainted_parameters(
+        &self,
+        file_path: &str,
+        start_line: usize,
+        sanitizers: &Regex,
+    ) -> Vec<(String, Confidence)> {
+        let id = match self.by_line.get(&(file_path.to_string(), start_line)) {
+            Some(id) => *id,
+            None => return Vec::new(),
+        };
+        let tainted = {
+            let mut cache = self.tainted.lock().unwrap_or_else(|e| e.into_inner());
+            cache
+                .entry(sanitizers.as_str().to_string())
+                .or_insert_with(|| Arc::new(self.propagate(sanitizers)))
+                .clone()
+        };
+        self.functions[id]
+            .params
+            .iter()
+            .zip(&tainted[id])
+            .filter_map(|(name, confidence)| Some((name.clone(), (*confidence)?)))
+            .filter(|(name, _)| name != "_")
+            .collect()
+    }
+
+    /// Walks every function until no call passes request data to a
+    /// parameter that was not already known to receive it.
+    fn propagate(&self, sanitizers: &Regex) -> ParameterTaint {
+        let mut tainted: ParameterTaint = self
+            .functions
+            .iter()
+            .map(|function| vec![None; function.params.len()])
+            .collect();
+        for _ in 0..MAX_PASSES {
+            let mut changed = false;
+            for (id, function) in self.functions.iter().enumerate() {
+                let file = &self.files[function.file];
+                let mut tracker =
+                    TaintTracker::new(sanitizers).with_sanitizers(file.declared.sanitizers());
+                for (name, confidence) in function.params.iter().zip(&tainted[id]) {
+                    if let Some(confidence) = confidence {
+                        let trace = vec![parameter_step(name, function.start_line)];
+                        tracker.mark(name, *confidence, trace);
+                    }
+                }
+                let mut in_raw = false;
+                for (offset, line) in function.lines.iter().enumerate() {
+                    let code = taint::strip_literals(line, &mut in_raw);
+                    for (callee, args) in self.calls(file, &code) {
+                        let callee_params = &self.functions[callee].params;
+                        for (i, arg) in args.iter().enumerate() {
+                            let param = match i.min(callee_params.len().saturating_sub(1)) {
+                                p if p == i || self.functions[callee].variadic => p,
+                                _ => continue,
+                            };
+                            let confidence = match tracker.tainted_by(arg) {
+                                Some(taint) => taint.confidence.min(Confidence::Medium),
+                                None => continue,
+                            };
+                            let slot = match tainted[callee].get_mut(param) {
+                                Some(slot) => slot,
+                                None => continue,
+                            };
+                            if !slot.is_some_and(|existing| existing >= confidence) {
+                                *slot = Some(confidence);
+                                changed = true;
+                            }
+                        }
+                    }
+                    tracker.observe(line, &code, function.start_line + offset);
+                }
+            }
+            if !changed {
+                break;
+            }
+        }
+        tainted
+    }
+
+    /// Returns the calls in already-stripped `code` that resolve to indexed
+    /// functions, with their arguments.
+    fn calls<'c>(&self, file: &ScopeFile, code: &'c str) -> Vec<(usize, Vec<&'c str>)> {
+        let mut calls = Vec::new();
+        for caps in CALL_REGEX.captures_iter(code) {
+            let m = caps.get(0).unwrap();
+            let before = &code[..m.start()];
+            if DECLARED_BEFORE_REGEX.is_match(before) {
+                continue;
+            }
+            let name = caps[1].to_string();
+            let callee = match QUALIFIER_REGEX.captures(before) {
+                None => self.by_name.get(&(file.dir.clone(), name)).copied(),
+                Some(qualifier) => {
+                    let chained = before[..qualifier.get(0).unwrap().start()].ends_with('.');
+                    match file.imports.get(&qualifier[1]) {
+                        Some(import) if !chained => self.resolve_import(import, name),
+                        _ => match self.methods.get(&(file.dir.clone(), name)) {
+                            Some(methods) if methods.len() == 1 => Some(methods[0]),
+                            _ => None,
+                        },
+                    }
+                }
+            };
+            if let Some(callee) = callee {
+                calls.push((callee, taint::call_args(&code[m.end()..])));
+            }
+        }
+        calls
+    }
+
+    /// Resolves `Name` in a call through the import of `import`, in the
+    /// `module` scope.
+    fn resolve_import(&self, import: &str, name: String) -> Option<usize> {
+        if self.scope != AnalysisScope::Module {
+            return None;
+        }
+        let dir = match &self.module {
+            Some(module) if import == module => String::new(),
+            Some(module) => import
+                .strip_prefix(module.as_str())?
+                .strip_prefix('/')?
+                .to_string(),
+            None => {
+                // Without a go.mod, the package whose directory the import
+                // path ends with.
+                let dirs = self.files.iter().map(|file| file.dir.as_str());
+                dirs.filter(|dir| {
+                    !dir.is_empty() && (import == *dir || import.ends_with(&format!("/{}", dir)))
+                })
+                .max_by_key(|dir| dir.len())?
+                .to_string()
+            }
+        };
+        self.by_name.get(&(dir, name)).copied()
+    }
+}
+
+/// The trace step for request data passed to the parameter `name` of the
+/// function declared at `line`.
+pub(crate) fn parameter_step(name: &str, line: usize) -> TraceStep {
+    TraceStep {
+        kind: TraceKind::Parameter,
+        expression: name.to_string(),
+        line,
+    }
+}
+
+/// The package of a file: its directory, with `/` separators.
+fn package_of(path: &str) -> String {
+    Path::new(path)
+        .parent()
+        .map(|dir| dir.to_string_lossy().replace('\\', "/"))
+        .unwrap_or_default()
+}
+
+/// Returns the parameter names of a declaration whose parameter list starts
+/// at `params`, with `_` for unnamed parameters, and whether the last one is
+/// variadic.
+fn parameter_names(params: &str) -> (Vec<String>, bool) {
+    let parts: Vec<&str> = taint::call_args(params)
+        .into_iter()
+        .map(str::trim)
+        .filter(|part| !part.is_empty())
+        .collect();
+    // In `(a, b string)` the lone `a` is a name; in `(int, string)` every
+    // part is a type.
+    let named = parts.iter().any(|part| part.split_whitespace().count() > 1);
+    let names = parts
+        .iter()
+        .map(|part| match part.split_whitespace().next() {
+            Some(name) if named => name.to_string(),
+            _ => "_".to_string(),
+        })
+        .collect();
+    let variadic = parts.last().is_some_and(|part| part.contains("..."));
+    (names, variadic)
+}
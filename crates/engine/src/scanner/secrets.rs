@@ -1,69 +1,240 @@
 //! A scanner for detecting secrets and credentials.
+//!
+//! Each line is checked against known key formats, assignments of string
+//! constants to credential-like identifiers, and high-entropy tokens inside
+//! string literals. Obvious placeholders are ignored, as are Go test files.
+//! Findings only ever include a redacted form of the detected value.
 
 use once_cell::sync::Lazy;
 use regex::Regex;
 
-use crate::config::Config;
+use crate::config::Confidence;
 use crate::error::Result;
-use crate::scanner::{find_ignore, parse_ignore_directives, Issue, Scanner};
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
 
 pub struct SecretsScanner;
 
-// A set of regexes to detect common secret patterns.
+// A set of regexes to detect common secret patterns. The secret value itself
+// is captured in the `value` group.
 // Using `once_cell::sync::Lazy` for one-time compilation of regexes.
-static SECRET_REGEXES: Lazy<Vec<Regex>> = Lazy::new(|| {
+static SECRET_REGEXES: Lazy<Vec<(&'static str, Confidence, Regex)>> = Lazy::new(|| {
     vec![
-        // Generic API Key
-        Regex::new(r#"(?i)api[_-]?key\s*[:=]\s*['"][a-zA-Z0-9\-_]{16,}['"]"#).unwrap(),
-        // AWS Secret Key
-        Regex::new(r#"(?i)aws_secret_access_key\s*[:=]\s*['"][a-zA-Z0-9/+=]{40}['"]"#).unwrap(),
-        // Generic Token
-        Regex::new(r#"(?i)token\s*[:=]\s*['"][a-zA-Z0-9\-_]{20,}['"]"#).unwrap(),
-        // Private Key
-        Regex::new(r"-----BEGIN [A-Z ]+ PRIVATE KEY-----").unwrap(),
+        (
+            "private key",
+            Confidence::High,
+            Regex::new(r"(?P<value>-----BEGIN [A-Z ]+ PRIVATE KEY-----)").unwrap(),
+        ),
+        (
+            "API key",
+            Confidence::Medium,
+            Regex::new(r#"(?i)api[_-]?key\s*[:=]\s*['"](?P<value>[a-zA-Z0-9\-_]{16,})['"]"#)
+                .unwrap(),
+        ),
+        (
+            "AWS secret access key",
+            Confidence::High,
+            Regex::new(
+                r#"(?i)aws_secret_access_key\s*[:=]\s*['"](?P<value>[a-zA-Z0-9/+=]{40})['"]"#,
+            )
+            .unwrap(),
+        ),
+        (
+            "token",
+            Confidence::Medium,
+            Regex::new(r#"(?i)token\s*[:=]\s*['"](?P<value>[a-zA-Z0-9\-_]{20,})['"]"#).unwrap(),
+        ),
     ]
 });
 
+/// String, raw string and rune literals. The contents are captured in the
+/// first group that participates in the match.
+static LITERAL_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r#""((?:[^"\\]|\\.)*)"|`([^`]*)`|'((?:[^'\\]|\\.)*)'"#).unwrap());
+
+static AWS_ACCESS_KEY_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\b(?:AKIA|ASIA)[0-9A-Z]{16}\b").unwrap());
+
+/// `name := "value"`, `name = "value"`, `var name string = "value"` and
+/// `Name: "value"` in composite literals.
+static ASSIGNMENT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"\b([A-Za-z_]\w*)(?:\s+string)?\s*(?::=|=|:)\s*"((?:[^"\\]|\\.)*)""#).unwrap()
+});
+
+/// Runs of base64 and base64url characters.
+static TOKEN_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"[A-Za-z0-9+/=_-]+").unwrap());
+
+/// Identifier suffixes that mark a variable as holding a credential. Matched
+/// case-insensitively with underscores removed, so `dbPassword`, `API_KEY`
+/// and `authToken` all qualify.
+const CREDENTIAL_NAMES: &[&str] = &["password", "secret", "apikey", "token"];
+
+/// Values commonly used as stand-ins for real credentials.
+const PLACEHOLDERS: &[&str] = &["changeme", "xxx", "todo", "example", "placeholder"];
+
+/// Shannon entropy, in bits per character, that a token must exceed to be
+/// reported. Hex strings such as hashes and UUIDs never exceed 4 bits.
+const ENTROPY_THRESHOLD: f64 = 4.0;
+
+/// A secret found on a line.
+struct Detection {
+    kind: &'static str,
+    confidence: Confidence,
+    start: usize,
+    end: usize,
+}
+
+/// Returns `true` for values that are clearly not real secrets.
+fn is_placeholder(value: &str) -> bool {
+    let mut chars = value.chars();
+    let first = match chars.next() {
+        Some(c) => c,
+        None => return true,
+    };
+    chars.all(|c| c == first) || PLACEHOLDERS.contains(&value.to_ascii_lowercase().as_str())
+}
+
+fn is_credential_name(name: &str) -> bool {
+    let name = name.replace('_', "").to_ascii_lowercase();
+    CREDENTIAL_NAMES.iter().any(|suffix| name.ends_with(suffix))
+}
+
+/// Shannon entropy of `value` in bits per character.
+fn shannon_entropy(value: &str) -> f64 {
+    let mut counts = [0usize; 256];
+    for byte in value.bytes() {
+        counts[byte as usize] += 1;
+    }
+    let len = value.len() as f64;
+    counts
+        .iter()
+        .filter(|&&n| n > 0)
+        .map(|&n| {
+            let p = n as f64 / len;
+            -p * p.log2()
+        })
+        .sum()
+}
+
+/// Shows only the first and last few characters of a secret.
+fn redact(secret: &str) -> String {
+    let chars: Vec<char> = secret.chars().collect();
+    let keep = match chars.len() {
+        n if n >= 16 => 4,
+        n if n >= 8 => 2,
+        _ => 0,
+    };
+    let head: String = chars[..keep].iter().collect();
+    let tail: String = chars[chars.len() - keep..].iter().collect();
+    format!("{}****{}", head, tail)
+}
+
+/// Returns the first secret found on `line`.
+fn detect(line: &str, min_token_length: usize) -> Option<Detection> {
+    for (kind, confidence, regex) in &*SECRET_REGEXES {
+        if let Some(value) = regex.captures(line).and_then(|caps| caps.name("value")) {
+            if !is_placeholder(value.as_str()) {
+                return Some(Detection {
+                    kind,
+                    confidence: *confidence,
+                    start: value.start(),
+                    end: value.end(),
+                });
+            }
+        }
+    }
+
+    let literals: Vec<regex::Match> = LITERAL_REGEX
+        .captures_iter(line)
+        .filter_map(|caps| caps.get(1).or(caps.get(2)).or(caps.get(3)))
+        .collect();
+
+    for literal in &literals {
+        if let Some(m) = AWS_ACCESS_KEY_REGEX.find(literal.as_str()) {
+            return Some(Detection {
+                kind: "AWS access key",
+                confidence: Confidence::High,
+                start: literal.start() + m.start(),
+                end: literal.start() + m.end(),
+            });
+        }
+    }
+
+    for caps in ASSIGNMENT_REGEX.captures_iter(line) {
+        let value = caps.get(2).unwrap();
+        if is_credential_name(&caps[1]) && !is_placeholder(value.as_str()) {
+            return Some(Detection {
+                kind: "hard-coded credential",
+                confidence: Confidence::Medium,
+                start: value.start(),
+                end: value.end(),
+            });
+        }
+    }
+
+    for literal in &literals {
+        for m in TOKEN_REGEX.find_iter(literal.as_str()) {
+            let token = m.as_str();
+            let mixed = token.bytes().any(|b| b.is_ascii_digit())
+                && token.bytes().any(|b| b.is_ascii_alphabetic());
+            if token.len() >= min_token_length
+                && mixed
+                && !is_placeholder(token)
+                && shannon_entropy(token) > ENTROPY_THRESHOLD
+            {
+                return Some(Detection {
+                    kind: "high-entropy token",
+                    confidence: Confidence::Low,
+                    start: literal.start() + m.start(),
+                    end: literal.start() + m.end(),
+                });
+            }
+        }
+    }
+    None
+}
+
 impl Scanner for SecretsScanner {
     fn name(&self) -> &'static str {
         "Secrets Scanner"
     }
 
-    fn scan(&self, file_path: &str, content: &str, config: &Config) -> Result<Vec<Issue>> {
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let (file_path, content, config) = (ctx.file_path, ctx.content, ctx.config);
+        // Test files routinely contain fake credentials.
+        if file_path.ends_with("_test.go") {
+            return Ok(Vec::new());
+        }
+        let rule = &config.rules.secrets;
         let mut issues = Vec::new();
-        let ignores = parse_ignore_directives(content);
         for (i, line) in content.lines().enumerate() {
-            for regex in &*SECRET_REGEXES {
-                if regex.is_match(line) {
-                    if let Some(ignore) = find_ignore(&ignores, i + 1, "secrets") {
-                        log::info!(
-                            "Suppressed secrets at {}:{}{}",
-                            file_path,
-                            i + 1,
-                            ignore
-                                .reason
-                                .as_ref()
-                                .map(|r| format!(" - {}", r))
-                                .unwrap_or_default()
-                        );
-                    } else {
-                        issues.push(Issue {
-                            title: "Potential Secret Found".to_string(),
-                            description: format!(
-                                "A line matching the pattern for a secret was found: `{}`. Please verify and rotate if necessary.",
-                                regex.as_str()
-                            ),
-                            file_path: file_path.to_string(),
-                            line_number: i + 1,
-                            severity: config.rules.secrets.severity.clone(),
-                            suggested_fix: Some("Remove secrets from source control and use secure storage or environment variables.".to_string()),
-                            diff: Some(format!("-{}\n+<redacted>", line.trim())),
-                        });
-                    }
-                    // Don't flag the same line multiple times
-                    break;
-                }
-            }
+            // Only the first secret on a line is reported.
+            let detection = match detect(line, rule.entropy_min_length) {
+                Some(detection) => detection,
+                None => continue,
+            };
+            let secret = &line[detection.start..detection.end];
+            let redacted = redact(secret);
+            let (column, end_column) = columns_for(line, detection.start, detection.end);
+            issues.push(Issue {
+                rule_id: "secrets".to_string(),
+                title: "Potential Secret Found".to_string(),
+                description: format!(
+                    "A possible {} was found: `{}`. Please verify and rotate if necessary.",
+                    detection.kind, redacted
+                ),
+                file_path: file_path.to_string(),
+                line_number: i + 1,
+                column: Some(column),
+                end_column: Some(end_column),
+                severity: rule.severity.clone(),
+                confidence: detection.confidence,
+                suggested_fix: Some("Remove secrets from source control and use secure storage or environment variables.".to_string()),
+                diff: Some(format!(
+                    "-{}\n+<redacted>",
+                    line.replacen(secret, &redacted, 1).trim()
+                )),
+                ..Default::default()
+            });
         }
         Ok(issues)
     }
@@ -0,0 +1,314 @@
+//! A scanner for Go HTTP servers that do not set the security-related
+//! response headers, or set them to weak values.
+//!
+//! Headers are recognised when they are written with `Header().Set`,
+//! `Header().Add` or gin's `c.Header(name, value)` and both arguments are
+//! literals. Three kinds of problems are reported:
+//!
+//! - `Access-Control-Allow-Origin: *` together with
+//!   `Access-Control-Allow-Credentials: true` in the same function, or the
+//!   equivalent `AllowedOrigins`/`AllowOrigins` and `AllowCredentials`
+//!   options of the rs/cors and gin-contrib/cors middleware. This is a bug
+//!   rather than a matter of hardening, so it has high confidence.
+//! - `X-Content-Type-Options` set to anything but `nosniff`, and a
+//!   `Content-Security-Policy` that allows `'unsafe-inline'`,
+//!   `'unsafe-eval'` or any source (`*`) for scripts. Whether a weak value
+//!   matters depends on what the server responds with, so these findings
+//!   have low confidence.
+//! - A file that starts a server (`ListenAndServe`, `ListenAndServeTLS`,
+//!   `ServeTLS` or `http.Serve`) without setting `Content-Security-Policy`
+//!   or `X-Content-Type-Options` anywhere in it, and without using the
+//!   unrolled/secure or helmet middleware. The headers may well be set in
+//!   another file or by a proxy, so these findings have low confidence.
+//!
+//! Files named `*_test.go` are skipped.
+
+use std::collections::HashSet;
+
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+use crate::config::Confidence;
+use crate::error::Result;
+use crate::scanner::taint;
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+pub struct SecurityHeadersGoScanner;
+
+/// A header write whose first two arguments are the name and the value.
+static HEADER_WRITE_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r#"\.Header\(\)\s*\.\s*(?:Set|Add)\(|\.Header\(\s*""#).unwrap());
+
+/// A call that starts serving HTTP.
+static SERVE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"\.(?:ListenAndServe|ListenAndServeTLS|ServeTLS)\(|\bhttp\.Serve\(").unwrap()
+});
+
+/// Middleware that sets the security headers.
+static MIDDLEWARE_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\b(?:secure|helmet)\.New\(").unwrap());
+
+/// CORS middleware options allowing every origin, matched against the
+/// original line.
+static WILDCARD_ORIGINS_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(
+        r#"\bAllow(?:ed)?Origins\s*:\s*\[\]string\{\s*"\*"\s*\}|\bAllowAllOrigins\s*:\s*true\b"#,
+    )
+    .unwrap()
+});
+
+/// CORS middleware options allowing credentials.
+static CREDENTIALS_OPTION_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\bAllowCredentials\s*:\s*true\b").unwrap());
+
+/// A script source in a Content-Security-Policy that allows inline or
+/// arbitrary scripts.
+static WEAK_SCRIPT_SOURCE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)(?:^|;)\s*(?:script-src|default-src)\s[^;]*(?:'unsafe-inline'|'unsafe-eval'|(?:^|\s)\*(?:\s|;|$))").unwrap()
+});
+
+const CSP: &str = "content-security-policy";
+const NOSNIFF: &str = "x-content-type-options";
+const ALLOW_ORIGIN: &str = "access-control-allow-origin";
+const ALLOW_CREDENTIALS: &str = "access-control-allow-credentials";
+
+/// A header written with literal arguments.
+struct HeaderWrite {
+    /// The header name, lower-cased.
+    name: String,
+    value: String,
+    line_number: usize,
+    columns: (usize, usize),
+}
+
+/// Returns the contents of a Go string literal, or `None` when `expr` is not
+/// one.
+fn string_literal(expr: &str) -> Option<&str> {
+    let expr = expr.trim();
+    let quoted = |q: char| expr.len() >= 2 && expr.starts_with(q) && expr.ends_with(q);
+    (quoted('"') || quoted('`')).then(|| &expr[1..expr.len() - 1])
+}
+
+/// Finds the header writes with literal arguments in `lines`, the first of
+/// which is line `start_line`.
+fn header_writes(lines: &[&str], start_line: usize) -> Vec<HeaderWrite> {
+    let mut writes = Vec::new();
+    let mut in_raw = false;
+    for (offset, line) in lines.iter().enumerate() {
+        let code = taint::strip_literals(line, &mut in_raw);
+        for m in HEADER_WRITE_REGEX.find_iter(&code) {
+            // gin's form matches up to the opening quote of the name.
+            let open = if m.as_str().ends_with('"') {
+                m.end() - 1
+            } else {
+                m.end()
+            };
+            let args = taint::call_arg_ranges(&code[open..]);
+            if args.len() < 2 {
+                continue;
+            }
+            let arg = |i: usize| string_literal(&line[open + args[i].start..open + args[i].end]);
+            if let (Some(name), Some(value)) = (arg(0), arg(1)) {
+                // The columns span the whole call, from its receiver.
+                let receiver = code[..m.start()]
+                    .trim_end_matches(|c: char| c.is_alphanumeric() || c == '_' || c == '.');
+                let start = receiver.len() + usize::from(code[receiver.len()..].starts_with('.'));
+                let close = open + args.last().unwrap().end + 1;
+                writes.push(HeaderWrite {
+                    name: name.to_ascii_lowercase(),
+                    value: value.trim().to_string(),
+                    line_number: start_line + offset,
+                    columns: columns_for(line, start, close),
+                });
+            }
+        }
+    }
+    writes
+}
+
+fn headers_issue(
+    ctx: &AnalysisContext,
+    line_number: usize,
+    columns: (usize, usize),
+    title: &str,
+    description: String,
+    confidence: Confidence,
+    fix: &str,
+) -> Issue {
+    Issue {
+        rule_id: "security-headers-go".to_string(),
+        title: title.to_string(),
+        description,
+        file_path: ctx.file_path.to_string(),
+        line_number,
+        column: Some(columns.0),
+        end_column: Some(columns.1),
+        severity: ctx.config.rules.security_headers_go.severity.clone(),
+        confidence,
+        suggested_fix: Some(fix.to_string()),
+        diff: None,
+        ..Default::default()
+    }
+}
+
+impl SecurityHeadersGoScanner {
+    /// Reports credentialed CORS responses that allow every origin within
+    /// one function, or one file when it cannot be split into functions.
+    fn scan_cors(&self, ctx: &AnalysisContext, lines: &[&str], start_line: usize) -> Vec<Issue> {
+        let writes = header_writes(lines, start_line);
+        let mut in_raw = false;
+        let code: Vec<String> = lines
+            .iter()
+            .map(|line| taint::strip_literals(line, &mut in_raw))
+            .collect();
+        let credentials = writes
+            .iter()
+            .any(|w| w.name == ALLOW_CREDENTIALS && w.value.eq_ignore_ascii_case("true"))
+            || code
+                .iter()
+                .any(|line| CREDENTIALS_OPTION_REGEX.is_match(line));
+        if !credentials {
+            return Vec::new();
+        }
+        let fix = "Only echo back an `Origin` that is on an allowlist, and add `Vary: Origin`; or stop allowing credentials for cross-origin requests.";
+        let mut issues: Vec<Issue> = writes
+            .iter()
+            .filter(|w| w.name == ALLOW_ORIGIN && w.value == "*")
+            .map(|w| {
+                headers_issue(
+                    ctx,
+                    w.line_number,
+                    w.columns,
+                    "CORS Wildcard With Credentials",
+                    "`Access-Control-Allow-Origin: *` is sent together with `Access-Control-Allow-Credentials: true`. Browsers reject this combination, and code that works around it by reflecting any origin lets every site read authenticated responses.".to_string(),
+                    Confidence::High,
+                    fix,
+                )
+            })
+            .collect();
+        for (offset, (line, stripped)) in lines.iter().zip(&code).enumerate() {
+            // The option must not be inside a comment or string.
+            let option = WILDCARD_ORIGINS_REGEX
+                .find(line)
+                .filter(|m| stripped.get(m.start()..m.start() + 5) == Some("Allow"));
+            if let Some(m) = option {
+                issues.push(headers_issue(
+                    ctx,
+                    start_line + offset,
+                    columns_for(line, m.start(), m.end()),
+                    "CORS Wildcard With Credentials",
+                    "The CORS middleware allows every origin and credentials at the same time, so any site can make authenticated requests and read the responses.".to_string(),
+                    Confidence::High,
+                    fix,
+                ));
+            }
+        }
+        issues
+    }
+
+    /// Reports headers written with weak values.
+    fn scan_values(&self, ctx: &AnalysisContext, writes: &[HeaderWrite]) -> Vec<Issue> {
+        let mut issues = Vec::new();
+        for write in writes {
+            let (description, fix) = match write.name.as_str() {
+                NOSNIFF if !write.value.eq_ignore_ascii_case("nosniff") => (
+                    format!(
+                        "`X-Content-Type-Options` is set to `{}`; browsers only honour `nosniff` and keep guessing content types otherwise.",
+                        write.value
+                    ),
+                    "Set `X-Content-Type-Options: nosniff`.",
+                ),
+                CSP if WEAK_SCRIPT_SOURCE_REGEX.is_match(&write.value) => (
+                    "The `Content-Security-Policy` allows inline scripts, `eval` or scripts from any source, which defeats its protection against cross-site scripting.".to_string(),
+                    "Remove `'unsafe-inline'`, `'unsafe-eval'` and `*` from `script-src` and `default-src`; use nonces or hashes for the inline scripts you need.",
+                ),
+                _ => continue,
+            };
+            issues.push(headers_issue(
+                ctx,
+                write.line_number,
+                write.columns,
+                "Weak Security Header",
+                description,
+                Confidence::Low,
+                fix,
+            ));
+        }
+        issues
+    }
+
+    /// Reports the servers started in a file that never sets the headers.
+    fn scan_missing(&self, ctx: &AnalysisContext, writes: &[HeaderWrite]) -> Vec<Issue> {
+        let content = ctx.content;
+        let mut in_raw = false;
+        let code: Vec<String> = content
+            .lines()
+            .map(|line| taint::strip_literals(line, &mut in_raw))
+            .collect();
+        if code.iter().any(|line| MIDDLEWARE_REGEX.is_match(line)) {
+            return Vec::new();
+        }
+        let set: HashSet<&str> = writes.iter().map(|w| w.name.as_str()).collect();
+        let missing: Vec<&str> = [
+            (CSP, "Content-Security-Policy"),
+            (NOSNIFF, "X-Content-Type-Options: nosniff"),
+        ]
+        .iter()
+        .filter(|(name, _)| !set.contains(name))
+        .map(|(_, header)| *header)
+        .collect();
+        if missing.is_empty() {
+            return Vec::new();
+        }
+        let headers = missing
+            .iter()
+            .map(|header| format!("`{}`", header))
+            .collect::<Vec<_>>()
+            .join(" and ");
+        let mut issues = Vec::new();
+        for (i, (line, stripped)) in content.lines().zip(&code).enumerate() {
+            if let Some(m) = SERVE_REGEX.find(stripped) {
+                let start = m.start() + usize::from(m.as_str().starts_with('.'));
+                issues.push(headers_issue(
+                    ctx,
+                    i + 1,
+                    columns_for(line, start, m.end() - 1),
+                    "Missing Security Headers",
+                    format!(
+                        "This server never sets {} in this file. Unless a middleware or proxy adds them, responses are open to content sniffing and get no defence in depth against cross-site scripting.",
+                        headers
+                    ),
+                    Confidence::Low,
+                    "Add a middleware around the router that sets `Content-Security-Policy` (for example `default-src 'self'`) and `X-Content-Type-Options: nosniff` on every response.",
+                ));
+            }
+        }
+        issues
+    }
+}
+
+impl Scanner for SecurityHeadersGoScanner {
+    fn name(&self) -> &'static str {
+        "HTTP Security Headers Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let (file_path, content) = (ctx.file_path, ctx.content);
+        if !file_path.ends_with(".go") || file_path.ends_with("_test.go") {
+            return Ok(Vec::new());
+        }
+        let lines: Vec<&str> = content.lines().collect();
+        let writes = header_writes(&lines, 1);
+        let mut issues = match ctx.go_functions() {
+            Some(functions) => functions
+                .iter()
+                .flat_map(|function| self.scan_cors(ctx, &function.lines, function.start_line))
+                .collect(),
+            None => self.scan_cors(ctx, &lines, 1),
+        };
+        issues.extend(self.scan_values(ctx, &writes));
+        issues.extend(self.scan_missing(ctx, &writes));
+        issues.sort_by_key(|issue| (issue.line_number, issue.column));
+        Ok(issues)
+    }
+}
@@ -0,0 +1,161 @@
+//! A scanner for SQL injection in Go code using `database/sql`.
+//!
+//! Two passes run over each file. Pattern matching flags queries built by
+//! concatenation or `fmt.Sprintf` on the line where they are constructed.
+//! Taint tracking then follows request values through each function and flags
+//! `Query`/`Exec`/`Prepare` calls on `sql.DB`, `sql.Tx` and similar receivers
+//! whose query argument carries request data. Values passed as variadic
+//! arguments after the query string are bound parameters and are never
+//! flagged.
+
+use std::collections::HashSet;
+
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+use crate::config::{Confidence, Config};
+use crate::error::Result;
+use crate::scanner::taint;
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+/// Query construction patterns. Concatenating onto a SQL keyword string is
+/// often done safely with constants, so it is reported with lower confidence
+/// than building the query inside the call.
+static SQL_INJECTION_PATTERNS: Lazy<Vec<(Regex, Confidence)>> = Lazy::new(|| {
+    vec![
+        (
+            Regex::new("(?i)db\\.(query|exec|queryrow)\\s*\\(\\s*fmt\\.Sprintf").unwrap(),
+            Confidence::High,
+        ),
+        (
+            Regex::new("(?i)db\\.(query|exec|queryrow)\\s*\\(\\s*\"[^\"]*\"\\s*\\+").unwrap(),
+            Confidence::High,
+        ),
+        (
+            Regex::new("(?i)\"(select|insert|update|delete)[^\"]*\"\\s*\\+").unwrap(),
+            Confidence::Medium,
+        ),
+    ]
+});
+
+/// Query methods shared by `sql.DB`, `sql.Tx` and `sql.Conn`. The `Context`
+/// variants take a `context.Context` before the query string.
+static SQL_SINK_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\.(Query|QueryRow|Exec|Prepare)(Context)?\(").unwrap());
+
+/// Conversions whose result cannot carry SQL syntax.
+static SQL_SANITIZER_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"\bstrconv\.(?:Atoi|Itoa|ParseInt|ParseUint|ParseFloat|ParseBool|FormatInt)\(|\bpq\.Quote(?:Identifier|Literal)\(")
+        .unwrap()
+});
+
+pub struct SqlInjectionGoScanner;
+
+impl SqlInjectionGoScanner {
+    /// Pattern pass: flags query construction by concatenation or `Sprintf`.
+    fn scan_patterns(&self, file_path: &str, content: &str, config: &Config) -> Vec<Issue> {
+        let mut issues = Vec::new();
+        for (i, line) in content.lines().enumerate() {
+            for (regex, confidence) in &*SQL_INJECTION_PATTERNS {
+                if let Some(m) = regex.find(line) {
+                    let (column, end_column) = columns_for(line, m.start(), m.end());
+                    issues.push(Issue {
+                        rule_id: "sql-injection-go".to_string(),
+                        title: "Potential SQL Injection".to_string(),
+                        description: "Dynamic SQL query construction detected. Use parameterized queries instead.".to_string(),
+                        file_path: file_path.to_string(),
+                        line_number: i + 1,
+                        column: Some(column),
+                        end_column: Some(end_column),
+                        severity: config.rules.sql_injection_go.severity.clone(),
+                        confidence: *confidence,
+                        suggested_fix: Some("Use parameterized queries instead of string concatenation.".to_string()),
+                        diff: Some(format!("-{}\n+db.Query(\"...\", params)", line.trim())),
+                        ..Default::default()
+                    });
+                    break;
+                }
+            }
+        }
+        issues
+    }
+
+    /// Taint pass: flags query calls whose query argument carries request
+    /// data. Lines already reported by the pattern pass are skipped, as are
+    /// queries held in variables whose construction was already reported.
+    fn scan_taint(
+        &self,
+        ctx: &AnalysisContext,
+        functions: &[taint::GoFunction],
+        flagged: &HashSet<usize>,
+    ) -> Vec<Issue> {
+        let (file_path, config) = (ctx.file_path, ctx.config);
+        let mut issues = Vec::new();
+        for function in functions {
+            if ctx.expired() {
+                break;
+            }
+            let mut tracker = ctx.tracker(&SQL_SANITIZER_REGEX, function);
+            let mut reported: HashSet<String> = HashSet::new();
+            let mut in_raw = false;
+            for (offset, line) in function.lines.iter().enumerate() {
+                let line_number = function.start_line + offset;
+                let code = taint::strip_literals(line, &mut in_raw);
+                if flagged.contains(&line_number) {
+                    reported.extend(taint::assigned_names(&code));
+                } else if let Some(caps) = SQL_SINK_REGEX.captures(&code) {
+                    let m = caps.get(0).unwrap();
+                    let args = taint::call_args(&code[m.end()..]);
+                    let query_index = if caps.get(2).is_some() { 1 } else { 0 };
+                    let taint = args
+                        .get(query_index)
+                        .and_then(|query| tracker.tainted_by(query))
+                        .filter(|taint| !reported.contains(&taint.origin));
+                    if let Some(taint) = taint {
+                        let method =
+                            format!("{}{}", &caps[1], caps.get(2).map_or("", |c| c.as_str()));
+                        let (column, end_column) = columns_for(line, m.start() + 1, m.end() - 1);
+                        let mut issue = Issue {
+                            rule_id: "sql-injection-go".to_string(),
+                            title: "Potential SQL Injection".to_string(),
+                            description: format!(
+                                "Request data from `{}` flows into the query string passed to `{}`. Use placeholders and pass values as arguments instead.",
+                                taint.origin, method
+                            ),
+                            file_path: file_path.to_string(),
+                            line_number,
+                            column: Some(column),
+                            end_column: Some(end_column),
+                            severity: config.rules.sql_injection_go.severity.clone(),
+                            confidence: taint.confidence,
+                            suggested_fix: Some("Use a constant query with `?` or `$1` placeholders and pass the values as additional arguments.".to_string()),
+                            diff: Some(format!("-{}\n+db.{}(\"... WHERE id = ?\", id)", line.trim(), method)),
+                            ..Default::default()
+                        };
+                        taint.attach_trace(&mut issue, line);
+                        issues.push(issue);
+                    }
+                }
+                tracker.observe(line, &code, line_number);
+            }
+        }
+        issues
+    }
+}
+
+impl Scanner for SqlInjectionGoScanner {
+    fn name(&self) -> &'static str {
+        "SQL Injection Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let (file_path, content, config) = (ctx.file_path, ctx.content, ctx.config);
+        let mut issues = self.scan_patterns(file_path, content, config);
+        if let Some(functions) = ctx.go_functions() {
+            let flagged: HashSet<usize> = issues.iter().map(|i| i.line_number).collect();
+            issues.extend(self.scan_taint(ctx, functions, &flagged));
+            issues.sort_by_key(|i| i.line_number);
+        }
+        Ok(ctx.declared_functions().drop_safe_sinks(content, issues))
+    }
+}
@@ -0,0 +1,261 @@
+//! A scanner for server-side request forgery in Go HTTP handlers.
+//!
+//! Request values are tracked through each function with the shared taint
+//! tracker, and outbound requests and connections (`http.Get`, `http.Head`,
+//! `http.Post`, `http.PostForm`, `http.NewRequest`,
+//! `http.NewRequestWithContext`, `net.Dial` and `net.DialTimeout`) are
+//! flagged when their URL or address carries request data.
+//!
+//! A variable stops being tainted once the function checks it in a
+//! condition: an allowlist lookup, a comparison of the host of the URL parsed
+//! from it, a call to a helper whose name mentions validation, or a check
+//! that rejects internal addresses, such as `ip.IsPrivate()` on an address
+//! resolved from its host. The checks follow the values derived from the
+//! variable (`url.Parse`, `u.Hostname()`, `net.LookupIP`, `net.ParseIP`,
+//! ranging over the results), so validating any of them clears the others.
+//! URLs that start with a constant scheme, host and `/`, directly or through
+//! a variable holding one, cannot be pointed at another host and are never
+//! flagged.
+
+use std::collections::{HashMap, HashSet};
+
+use once_cell::sync::Lazy;
+use regex::{Captures, Regex};
+
+use crate::config::Config;
+use crate::error::Result;
+use crate::scanner::taint::{self, Taint};
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+pub struct SsrfGoScanner;
+
+/// Calls that send a request to a URL or connect to an address.
+static SINK_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(
+        r"\b(?:http\.(?:DefaultClient\.)?(?:Get|Head|Post|PostForm)|http\.NewRequest(?:WithContext)?|net\.Dial(?:Timeout)?)\(",
+    )
+    .unwrap()
+});
+
+/// Calls whose result cannot name another host.
+static SANITIZER_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"\burl\.(?:PathEscape|QueryEscape)\(|\bstrconv\.(?:Itoa|FormatInt)\(").unwrap()
+});
+
+/// Checks that validate the captured variable, matched against the original
+/// line of a condition.
+static VALIDATION_REGEXES: Lazy<Vec<Regex>> = Lazy::new(|| {
+    vec![
+        // An allowlist lookup, possibly on the parsed URL's host.
+        Regex::new(r"\b\w+\[\s*(\w+)(?:\.\w+(?:\(\))?)?\s*\]").unwrap(),
+        Regex::new(r"\bslices\.Contains\(\s*\w+\s*,\s*(\w+)").unwrap(),
+        // A validation helper.
+        Regex::new(r"(?i)\b\w*(?:valid|allow|safe|trust)\w*\(\s*(\w+)").unwrap(),
+        // A comparison or suffix check of the parsed URL's host.
+        Regex::new(r"\b(\w+)\.(?:Host\b|Hostname\(\))\s*(?:==|!=)").unwrap(),
+        Regex::new(r"(?:==|!=)\s*(\w+)\.(?:Host\b|Hostname\(\))").unwrap(),
+        Regex::new(r"^\s*switch\s+(\w+)\.(?:Host\b|Hostname\(\))").unwrap(),
+        Regex::new(r"\bstrings\.HasSuffix\(\s*(\w+)\.(?:Host\b|Hostname\(\))").unwrap(),
+        // Rejecting internal addresses.
+        Regex::new(
+            r"\b(\w+)\.(?:IsPrivate|IsLoopback|IsLinkLocalUnicast|IsLinkLocalMulticast|IsInterfaceLocalMulticast|IsUnspecified|IsGlobalUnicast)\(\)",
+        )
+        .unwrap(),
+    ]
+});
+
+/// `u, err := url.Parse(v)`, `ips, err := net.LookupIP(u.Hostname())` and
+/// similar, linking the result to the variable it was computed from.
+static DERIVED_CALL_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(
+        r"^\s*(\w+)\s*(?:,\s*\w+\s*)*:?=\s*(?:url\.(?:Parse|ParseRequestURI)|net\.(?:ParseIP|LookupIP|LookupHost|SplitHostPort)|netip\.ParseAddr)\(\s*(\w+)",
+    )
+    .unwrap()
+});
+
+/// `host := u.Hostname()` or `host := u.Host`.
+static DERIVED_HOST_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^\s*(\w+)\s*:?=\s*(\w+)\.(?:Host\b|Hostname\(\))").unwrap());
+
+/// `for _, ip := range ips {`.
+static DERIVED_RANGE_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^\s*for\s+\w+\s*,\s*(\w+)\s*:?=\s*range\s+(\w+)").unwrap());
+
+/// A URL that starts with a constant scheme, host and `/`, written as a
+/// literal or as the format of `fmt.Sprintf`. Appending to it cannot change
+/// the host.
+static CONSTANT_URL_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r#"^\s*(?:fmt\.Sprintf\(\s*)?"https?://[^/"]+/"#).unwrap());
+
+/// An address that starts with a constant host and `:`.
+static CONSTANT_ADDRESS_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r#"^\s*(?:fmt\.Sprintf\(\s*)?"[^/":]+:"#).unwrap());
+
+/// A variable assigned a constant URL prefix anywhere in the file, such as
+/// `const apiBase = "https://api.example.com/"`.
+static CONSTANT_BASE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"^\s*(?:const\s+|var\s+)?(\w+)(?:\s+string)?\s*:?=\s*"https?://[^/"]+/"#).unwrap()
+});
+
+/// A URL that starts with a variable, followed by `+`.
+static BASE_PREFIX_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"^\s*(\w+)\s*\+").unwrap());
+
+/// The index of the URL or address argument of a sink call, and whether the
+/// call takes an address rather than a URL.
+fn target_arg(call: &str) -> (usize, bool) {
+    if call.starts_with("net.") {
+        (1, true)
+    } else if call.starts_with("http.NewRequestWithContext") {
+        (2, false)
+    } else if call.starts_with("http.NewRequest") {
+        (1, false)
+    } else {
+        (0, false)
+    }
+}
+
+fn ssrf_issue(
+    file_path: &str,
+    line: &str,
+    line_number: usize,
+    call: &Captures,
+    address: bool,
+    taint: &Taint,
+    config: &Config,
+) -> Issue {
+    let m = call.get(0).unwrap();
+    let name = m.as_str().trim_end_matches('(');
+    let (column, end_column) = columns_for(line, m.start(), m.end() - 1);
+    let mut issue = Issue {
+        rule_id: "ssrf-go".to_string(),
+        title: "Potential Server-Side Request Forgery".to_string(),
+        description: format!(
+            "Request data from `{}` is used as the {} passed to `{}` without checking the host against an allowlist or rejecting internal addresses, so the server can be made to reach internal services.",
+            taint.origin,
+            if address { "address" } else { "URL" },
+            name
+        ),
+        file_path: file_path.to_string(),
+        line_number,
+        column: Some(column),
+        end_column: Some(end_column),
+        severity: config.rules.ssrf_go.severity.clone(),
+        confidence: taint.confidence,
+        suggested_fix: Some(
+            "Parse the URL and only request hosts on an allowlist, or resolve the host and reject loopback, private and link-local addresses before connecting.".to_string(),
+        ),
+        diff: None,
+        ..Default::default()
+    };
+    taint.attach_trace(&mut issue, line);
+    issue
+}
+
+impl SsrfGoScanner {
+    fn scan_function(
+        &self,
+        ctx: &AnalysisContext,
+        function: &taint::GoFunction,
+        bases: &HashSet<String>,
+    ) -> Vec<Issue> {
+        let (file_path, config) = (ctx.file_path, ctx.config);
+        let mut issues = Vec::new();
+        let mut tracker = ctx.tracker(&SANITIZER_REGEX, function);
+        // Derived variable -> the variable it was computed from.
+        let mut derived: HashMap<String, String> = HashMap::new();
+        let mut in_raw = false;
+        for (offset, line) in function.lines.iter().enumerate() {
+            let code = taint::strip_literals(line, &mut in_raw);
+
+            for name in taint::validated_names(&code, line, &VALIDATION_REGEXES) {
+                let mut name = Some(name.as_str());
+                let mut seen = HashSet::new();
+                while let Some(current) = name.filter(|n| seen.insert(*n)) {
+                    tracker.clear(current);
+                    name = derived.get(current).map(String::as_str);
+                }
+            }
+
+            for caps in SINK_REGEX.captures_iter(&code) {
+                let end = caps.get(0).unwrap().end();
+                let (index, address) = target_arg(&caps[0]);
+                let args = taint::call_arg_ranges(&code[end..]);
+                let range = match args.get(index) {
+                    Some(range) => end + range.start..end + range.end,
+                    None => continue,
+                };
+                let constant = if address {
+                    CONSTANT_ADDRESS_REGEX.is_match(&line[range.clone()])
+                } else {
+                    CONSTANT_URL_REGEX.is_match(&line[range.clone()])
+                        || BASE_PREFIX_REGEX
+                            .captures(&code[range.clone()])
+                            .is_some_and(|base| {
+                                bases.contains(&base[1]) && !tracker.is_tainted(&base[1])
+                            })
+                };
+                if constant {
+                    continue;
+                }
+                if let Some(taint) = tracker.tainted_by(&code[range]) {
+                    issues.push(ssrf_issue(
+                        file_path,
+                        line,
+                        function.start_line + offset,
+                        &caps,
+                        address,
+                        &taint,
+                        config,
+                    ));
+                }
+            }
+
+            for name in taint::assigned_names(&code) {
+                derived.remove(&name);
+            }
+            let links = [
+                &*DERIVED_CALL_REGEX,
+                &*DERIVED_HOST_REGEX,
+                &*DERIVED_RANGE_REGEX,
+            ];
+            if let Some(caps) = links.iter().find_map(|regex| regex.captures(&code)) {
+                derived.insert(caps[1].to_string(), caps[2].to_string());
+            }
+            tracker.observe(line, &code, function.start_line + offset);
+        }
+        issues
+    }
+}
+
+impl Scanner for SsrfGoScanner {
+    fn name(&self) -> &'static str {
+        "Server-Side Request Forgery Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let functions = match ctx.go_functions() {
+            Some(functions) => functions,
+            None => {
+                log::debug!(
+                    "Could not split {} into functions; skipping SSRF checks",
+                    ctx.file_path
+                );
+                return Ok(Vec::new());
+            }
+        };
+        let bases: HashSet<String> = ctx
+            .content
+            .lines()
+            .filter_map(|line| CONSTANT_BASE_REGEX.captures(line))
+            .map(|caps| caps[1].to_string())
+            .collect();
+        let issues = functions
+            .iter()
+            .take_while(|_| !ctx.expired())
+            .flat_map(|function| self.scan_function(ctx, function, &bases))
+            .collect();
+        Ok(ctx
+            .declared_functions()
+            .drop_safe_sinks(ctx.content, issues))
+    }
+}
@@ -0,0 +1,817 @@
+//! Basic intraprocedural taint tracking for Go handlers.
+//!
+//! Source files are split into functions by brace depth and each function
+//! body is walked top to bottom. Values read from the HTTP request are
+//! tainted, taint follows simple assignments and string concatenation, and
+//! wrapping a value in a rule-specific sanitizer call clears it. The analysis
+//! is line-based: multi-line expressions are not tracked, and flows across
+//! functions only in the `package` and `module` scopes, through the
+//! parameters recorded by [`ScopeIndex`](crate::scanner::ScopeIndex).
+//!
+//! Taint that passes through the arguments of a call the tracker does not
+//! know is assumed to survive the call, but with reduced confidence.
+//!
+//! Projects can declare functions of their own in the `[taint]` table of
+//! the configuration: `sanitizers` clear taint like the built-in ones, and
+//! the rules do not report calls to `safe-sinks` or sinks inside their
+//! arguments. [`DeclaredFunctions`] resolves them against a file's imports.
+
+use std::collections::HashMap;
+
+use crate::config::{split_function_ref, Confidence, TaintConfig};
+use crate::scanner::{Issue, TraceKind, TraceStep};
+
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+/// Calls that return request-controlled data.
+static SOURCE_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"\b\w+\.URL\.Query\(\)\.Get\(|\b\w+\.(?:FormValue|PostFormValue)\(|\bmux\.Vars\(")
+        .unwrap()
+});
+
+/// `a := expr`, `a, b = expr`, `var a T = expr` and `a += expr`.
+static ASSIGNMENT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(
+        r"^\s*(?:var\s+)?([A-Za-z_]\w*(?:\s*,\s*[A-Za-z_]\w*)*)(?:\s+[\w.\[\]*]+)?\s*(:=|\+=|=)(.*)$",
+    )
+    .unwrap()
+});
+
+static IDENT_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"[A-Za-z_]\w*").unwrap());
+
+/// Calls known to pass their argument through, matched against the text just
+/// before the opening parenthesis. Taint flowing through them keeps its
+/// confidence.
+static PROPAGATOR_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(
+        r"(?:\bstring|\[\]byte|\bfmt\.Sprint[fl]?n?|\bstrings\.\w+|\b(?:file)?path\.Join)\s*$",
+    )
+    .unwrap()
+});
+
+/// `alias "path"` or `"path"`, as written in an import declaration.
+static IMPORT_SPEC_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r#"^(?:([A-Za-z_]\w*|\.)\s+)?"([^"]+)""#).unwrap());
+
+/// A line that tests a condition, or continues one.
+static CONDITION_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^\s*(?:\}\s*else\s+)?(?:if|switch|case)\b|^\s*(?:&&|\|\|)").unwrap());
+
+/// Statement keywords that the assignment pattern would otherwise mistake for
+/// a variable name (e.g. `for i := 0; ...`).
+const KEYWORDS: &[&str] = &[
+    "if", "for", "switch", "return", "go", "defer", "case", "else",
+];
+
+/// A top-level Go function, including any closures declared inside it.
+#[derive(Debug)]
+pub struct GoFunction<'a> {
+    /// 1-based line number of the `func` keyword.
+    pub start_line: usize,
+    /// Source lines of the function, starting with its declaration.
+    pub lines: Vec<&'a str>,
+}
+
+/// Blanks out the contents of string and rune literals and removes line
+/// comments, preserving byte offsets so match positions map back onto the
+/// original line. `in_raw` carries raw (backtick) string state across lines.
+pub fn strip_literals(line: &str, in_raw: &mut bool) -> String {
+    let mut out = String::with_capacity(line.len());
+    let mut chars = line.chars().peekable();
+    let mut quote: Option<char> = if *in_raw { Some('`') } else { None };
+    while let Some(c) = chars.next() {
+        match quote {
+            Some(q) => {
+                if c == q {
+                    quote = None;
+                    out.push(c);
+                } else if c == '\\' && q != '`' {
+                    out.push(' ');
+                    if let Some(escaped) = chars.next() {
+                        out.extend(std::iter::repeat(' ').take(escaped.len_utf8()));
+                    }
+                } else {
+                    out.extend(std::iter::repeat(' ').take(c.len_utf8()));
+                }
+            }
+            None => {
+                if c == '/' && chars.peek() == Some(&'/') {
+                    out.extend(std::iter::repeat(' ').take(line.len() - out.len()));
+                    break;
+                }
+                if c == '"' || c == '\'' || c == '`' {
+                    quote = Some(c);
+                }
+                out.push(c);
+            }
+        }
+    }
+    *in_raw = quote == Some('`');
+    out
+}
+
+/// Splits Go source into top-level functions by tracking brace depth.
+///
+/// Returns `None` when the braces do not balance, in which case callers
+/// should fall back to per-line matching.
+pub fn split_functions(content: &str) -> Option<Vec<GoFunction<'_>>> {
+    let mut functions = Vec::new();
+    let mut current: Option<GoFunction> = None;
+    let mut body_started = false;
+    let mut depth: i32 = 0;
+    let mut in_raw = false;
+
+    for (i, line) in content.lines().enumerate() {
+        let code = strip_literals(line, &mut in_raw);
+        if current.is_none() && depth == 0 && code.trim_start().starts_with("func ") {
+            current = Some(GoFunction {
+                start_line: i + 1,
+                lines: Vec::new(),
+            });
+            body_started = false;
+        }
+        for c in code.chars() {
+            match c {
+                '{' => {
+                    depth += 1;
+                    if current.is_some() {
+                        body_started = true;
+                    }
+                }
+                '}' => {
+                    depth -= 1;
+                    if depth < 0 {
+                        return None;
+                    }
+                }
+                _ => {}
+            }
+        }
+        if let Some(function) = current.as_mut() {
+            function.lines.push(line);
+            if body_started && depth == 0 {
+                functions.extend(current.take());
+            }
+        }
+    }
+
+    if depth != 0 || current.is_some() {
+        return None;
+    }
+    Some(functions)
+}
+
+/// Returns the body of the function whose `func` keyword is at byte `start`
+/// of `code[line]`, or `None` when it does not close. `code` holds the lines
+/// of the file with literals stripped.
+pub fn function_body(code: &[String], line: usize, start: usize) -> Option<String> {
+    let mut out = String::new();
+    let mut depth = 0;
+    let mut opened = false;
+    for (i, text) in code.iter().enumerate().skip(line) {
+        let text = if i == line {
+            &text[start..]
+        } else {
+            text.as_str()
+        };
+        for c in text.chars() {
+            match c {
+                '{' => {
+                    depth += 1;
+                    if !opened {
+                        opened = true;
+                        continue;
+                    }
+                }
+                '}' if opened => {
+                    depth -= 1;
+                    if depth == 0 {
+                        return Some(out);
+                    }
+                }
+                _ => {}
+            }
+            if opened {
+                out.push(c);
+            }
+        }
+        if opened {
+            out.push('\n');
+        }
+    }
+    None
+}
+
+/// Returns the 1-based line and a description of the first brace or raw
+/// string that does not balance in Go source, if any.
+pub fn syntax_error(content: &str) -> Option<(usize, &'static str)> {
+    let mut open: Vec<usize> = Vec::new();
+    let mut in_raw = false;
+    let mut raw_start = 0;
+    for (i, line) in content.lines().enumerate() {
+        let was_raw = in_raw;
+        for c in strip_literals(line, &mut in_raw).chars() {
+            match c {
+                '{' => open.push(i + 1),
+                '}' if open.pop().is_none() => return Some((i + 1, "unexpected `}`")),
+                _ => {}
+            }
+        }
+        if in_raw && !was_raw {
+            raw_start = i + 1;
+        }
+    }
+    if in_raw {
+        return Some((raw_start, "unterminated raw string"));
+    }
+    open.last().map(|&line| (line, "unclosed `{`"))
+}
+
+/// Removes every call matched by `sanitizers` (including its balanced
+/// argument list) from `expr`. Sanitizer patterns must end at the opening
+/// parenthesis of the call.
+pub fn strip_sanitized(expr: &str, sanitizers: &Regex) -> String {
+    let mut expr = expr.to_string();
+    while let Some(m) = sanitizers.find(&expr) {
+        let mut depth = 1;
+        let mut end = expr.len();
+        for (offset, c) in expr[m.end()..].char_indices() {
+            match c {
+                '(' => depth += 1,
+                ')' => {
+                    depth -= 1;
+                    if depth == 0 {
+                        end = m.end() + offset + 1;
+                        break;
+                    }
+                }
+                _ => {}
+            }
+        }
+        expr.replace_range(m.start()..end, "");
+    }
+    expr
+}
+
+/// A file's import declarations as (local name, import path) pairs. Blank
+/// and dot imports are skipped.
+pub fn imports(content: &str) -> Vec<(String, String)> {
+    let mut imports = Vec::new();
+    let mut in_block = false;
+    for line in content.lines() {
+        let trimmed = line.trim();
+        if trimmed.starts_with("func ") || trimmed.starts_with("type ") {
+            break;
+        }
+        let spec = if in_block {
+            if trimmed.starts_with(')') {
+                in_block = false;
+                continue;
+            }
+            trimmed
+        } else if let Some(rest) = trimmed.strip_prefix("import") {
+            let rest = rest.trim_start();
+            if let Some(block) = rest.strip_prefix('(') {
+                in_block = true;
+                block.trim()
+            } else {
+                rest
+            }
+        } else {
+            continue;
+        };
+        if let Some(caps) = IMPORT_SPEC_REGEX.captures(spec) {
+            let path = caps[2].to_string();
+            let name = match caps.get(1) {
+                Some(alias) => alias.as_str().to_string(),
+                None => default_package_name(&path),
+            };
+            if name != "_" && name != "." {
+                imports.push((name, path));
+            }
+        }
+    }
+    imports
+}
+
+/// The name a package is referred to by when imported without an alias: the
+/// last element of its path, skipping a major version suffix such as `/v2`
+/// or `.v3`.
+fn default_package_name(path: &str) -> String {
+    let mut elements = path.rsplit('/');
+    let mut name = elements.next().unwrap_or(path);
+    let is_version = |e: &str| {
+        e.strip_prefix('v')
+            .is_some_and(|n| !n.is_empty() && n.chars().all(|c| c.is_ascii_digit()))
+    };
+    if is_version(name) {
+        name = elements.next().unwrap_or(name);
+    }
+    if let Some((base, version)) = name.rsplit_once('.') {
+        if is_version(version) {
+            name = base;
+        }
+    }
+    name.replace('-', "_")
+}
+
+/// Builds a pattern matching calls to `functions` through the local names of
+/// the file's imports, ending at the opening parenthesis of the call.
+fn calls_regex(functions: &[String], imports: &[(String, String)]) -> Option<Regex> {
+    let callees: Vec<String> = functions
+        .iter()
+        .filter_map(|function| split_function_ref(function))
+        .flat_map(|(path, name)| {
+            imports
+                .iter()
+                .filter(move |(_, import)| import == path)
+                .map(move |(local, _)| format!(r"{}\s*\.\s*{}", regex::escape(local), name))
+        })
+        .collect();
+    if callees.is_empty() {
+        return None;
+    }
+    Regex::new(&format!(r"\b(?:{})\s*\(", callees.join("|"))).ok()
+}
+
+/// The `[taint]` functions of a project, resolved against the imports of one
+/// file. Calls are recognized through the name or alias the package is
+/// imported under.
+#[derive(Debug, Default)]
+pub struct DeclaredFunctions {
+    sanitizers: Option<Regex>,
+    safe_sinks: Option<Regex>,
+}
+
+impl DeclaredFunctions {
+    /// Resolves the functions declared in `config` for the Go file `content`.
+    pub fn new(config: &TaintConfig, content: &str) -> Self {
+        if config.sanitizers.is_empty() && config.safe_sinks.is_empty() {
+            return Self::default();
+        }
+        let imports = imports(content);
+        Self {
+            sanitizers: calls_regex(&config.sanitizers, &imports),
+            safe_sinks: calls_regex(&config.safe_sinks, &imports),
+        }
+    }
+
+    /// Returns the pattern matching calls to the declared sanitizers, if the
+    /// file imports any of them. See [`TaintTracker::with_sanitizers`].
+    pub fn sanitizers(&self) -> Option<&Regex> {
+        self.sanitizers.as_ref()
+    }
+
+    /// Returns `true` if the byte offset `pos` in already-stripped code is a
+    /// call to a declared safe sink or lies inside the arguments of one.
+    pub fn in_safe_sink(&self, code: &str, pos: usize) -> bool {
+        let safe_sinks = match &self.safe_sinks {
+            Some(safe_sinks) => safe_sinks,
+            None => return false,
+        };
+        safe_sinks.find_iter(code).any(|m| {
+            let args = call_arg_ranges(&code[m.end()..]);
+            let end = m.end() + args.last().map_or(0, |range| range.end);
+            m.start() <= pos && pos <= end
+        })
+    }
+
+    /// Drops the issues of `content` that are reported at a declared safe
+    /// sink, judged by the line and start column of each issue.
+    pub fn drop_safe_sinks(&self, content: &str, issues: Vec<Issue>) -> Vec<Issue> {
+        if self.safe_sinks.is_none() {
+            return issues;
+        }
+        let lines: Vec<&str> = content.lines().collect();
+        issues
+            .into_iter()
+            .filter(|issue| {
+                let line = match issue.line_number.checked_sub(1).and_then(|i| lines.get(i)) {
+                    Some(line) => line,
+                    None => return true,
+                };
+                let code = strip_literals(line, &mut false);
+                let column = issue.column.unwrap_or(1).saturating_sub(1);
+                let pos = code
+                    .char_indices()
+                    .nth(column)
+                    .map_or(code.len(), |(i, _)| i);
+                !self.in_safe_sink(&code, pos)
+            })
+            .collect()
+    }
+}
+
+/// Returns the first request source call in already-stripped code.
+pub fn find_source(code: &str) -> Option<String> {
+    SOURCE_REGEX
+        .find(code)
+        .map(|m| m.as_str().trim_end_matches('(').to_string())
+}
+
+/// Returns the byte ranges of every request source call in already-stripped
+/// code. Each range ends just before the call's opening parenthesis.
+pub fn source_ranges(code: &str) -> Vec<std::ops::Range<usize>> {
+    SOURCE_REGEX
+        .find_iter(code)
+        .map(|m| m.start()..m.end() - 1)
+        .collect()
+}
+
+/// Returns `true` if the byte offset `pos` in `expr` lies inside the argument
+/// list of a call that is not a known propagator.
+fn inside_unknown_call(expr: &str, pos: usize) -> bool {
+    let mut open: Vec<usize> = Vec::new();
+    for (i, c) in expr[..pos].char_indices() {
+        match c {
+            '(' => open.push(i),
+            ')' => {
+                open.pop();
+            }
+            _ => {}
+        }
+    }
+    open.into_iter().any(|paren| {
+        let callee = &expr[..paren];
+        let is_call = callee
+            .trim_end()
+            .chars()
+            .last()
+            .map_or(false, |c| c.is_alphanumeric() || c == '_' || c == ']');
+        is_call && !PROPAGATOR_REGEX.is_match(callee)
+    })
+}
+
+/// Returns the offset just past the parenthesis closing the one at `open`
+/// in already-stripped `code`, or the end of the line if it is not closed.
+fn closing_paren(code: &str, open: usize) -> usize {
+    let mut depth = 0;
+    for (i, c) in code[open..].char_indices() {
+        match c {
+            '(' => depth += 1,
+            ')' if depth == 1 => return open + i + 1,
+            ')' => depth -= 1,
+            _ => {}
+        }
+    }
+    code.len()
+}
+
+/// Returns the step for the source call `origin` at or after byte `from` of
+/// `line`, with its arguments.
+fn source_step(
+    origin: &str,
+    line: &str,
+    code: &str,
+    from: usize,
+    line_number: usize,
+) -> Option<TraceStep> {
+    let start = from + code[from..].find(origin)?;
+    let end = match code[start + origin.len()..].starts_with('(') {
+        true => closing_paren(code, start + origin.len()),
+        false => start + origin.len(),
+    };
+    Some(TraceStep {
+        kind: TraceKind::Source,
+        expression: line[start..end].to_string(),
+        line: line_number,
+    })
+}
+
+/// The source of taint in an expression.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct Taint {
+    /// The source call or variable carrying the taint.
+    pub origin: String,
+    pub confidence: Confidence,
+    /// The steps that carried request data into the variable `origin`.
+    /// Empty when `origin` is a source call.
+    pub trace: Vec<TraceStep>,
+}
+
+impl Taint {
+    /// Sets the [`Issue::trace`] of an issue flagged at the sink that starts
+    /// at the issue's column of `line`: the steps that carried the data to
+    /// the sink, and the sink call itself.
+    pub fn attach_trace(&self, issue: &mut Issue, line: &str) {
+        let code = strip_literals(line, &mut false);
+        let start = issue
+            .column
+            .and_then(|column| line.char_indices().nth(column.saturating_sub(1)))
+            .map_or(0, |(i, _)| i);
+        // Include the receiver when the column points at a method name.
+        let start = code[..start]
+            .char_indices()
+            .rev()
+            .take_while(|(_, c)| c.is_alphanumeric() || *c == '_' || *c == '.')
+            .last()
+            .map_or(start, |(i, _)| i);
+        let name_end = code[start..]
+            .find(|c: char| !(c.is_alphanumeric() || c == '_' || c == '.'))
+            .map_or(code.len(), |i| start + i);
+        let end = if code[name_end..].starts_with('(') {
+            closing_paren(&code, name_end)
+        } else {
+            let end_column = issue.end_column.unwrap_or_default();
+            line.char_indices()
+                .nth(end_column.saturating_sub(1))
+                .map_or(line.len(), |(i, _)| i)
+                .max(name_end)
+        };
+        let mut trace = self.trace.clone();
+        if trace.is_empty() {
+            let from = if code[start..end].contains(&self.origin) {
+                start
+            } else {
+                0
+            };
+            trace.extend(source_step(
+                &self.origin,
+                line,
+                &code,
+                from,
+                issue.line_number,
+            ));
+        }
+        trace.push(TraceStep {
+            kind: TraceKind::Sink,
+            expression: line[start..end].to_string(),
+            line: issue.line_number,
+        });
+        issue.trace = trace;
+    }
+}
+
+/// A variable holding request data.
+struct Tainted {
+    confidence: Confidence,
+    /// The steps that carried the data into the variable.
+    trace: Vec<TraceStep>,
+}
+
+/// Tracks which local variables hold request-derived data.
+pub struct TaintTracker<'a> {
+    sanitizers: &'a Regex,
+    declared: Option<&'a Regex>,
+    tainted: HashMap<String, Tainted>,
+}
+
+impl<'a> TaintTracker<'a> {
+    /// Creates a tracker that treats calls matched by `sanitizers` as clean.
+    pub fn new(sanitizers: &'a Regex) -> Self {
+        Self {
+            sanitizers,
+            declared: None,
+            tainted: HashMap::new(),
+        }
+    }
+
+    /// Also treats calls matched by `declared`, typically
+    /// [`DeclaredFunctions::sanitizers`], as clean.
+    pub fn with_sanitizers(mut self, declared: Option<&'a Regex>) -> Self {
+        self.declared = declared;
+        self
+    }
+
+    /// Returns the sanitizer calls in `expr`, each ending at the opening
+    /// parenthesis of the call.
+    pub fn sanitizer_calls<'e>(&self, expr: &'e str) -> Vec<regex::Match<'e>> {
+        let mut calls: Vec<regex::Match> = self.sanitizers.find_iter(expr).collect();
+        if let Some(declared) = self.declared {
+            calls.extend(declared.find_iter(expr));
+        }
+        calls.sort_by_key(|m| m.start());
+        calls
+    }
+
+    /// Removes every sanitizer call from `expr`.
+    fn strip_sanitized(&self, expr: &str) -> String {
+        let expr = strip_sanitized(expr, self.sanitizers);
+        match self.declared {
+            Some(declared) => strip_sanitized(&expr, declared),
+            None => expr,
+        }
+    }
+
+    /// Updates taint state for an assignment at `line_number`. `code` is
+    /// `line` with its literals stripped.
+    pub fn observe(&mut self, line: &str, code: &str, line_number: usize) {
+        let caps = match ASSIGNMENT_REGEX.captures(code) {
+            Some(caps) => caps,
+            None => return,
+        };
+        let rhs = &caps[3];
+        // `==` is a comparison, not an assignment.
+        if rhs.starts_with('=') {
+            return;
+        }
+        let names: Vec<&str> = caps[1].split(',').map(str::trim).collect();
+        if names.iter().any(|n| KEYWORDS.contains(n)) {
+            return;
+        }
+        let appends = &caps[2] == "+=";
+        let taint = self.tainted_by(rhs).map(|taint| {
+            let rhs_start = caps.get(3).unwrap().start();
+            let trace = assignment_trace(&taint, line, code, rhs_start, line_number, appends);
+            (taint.confidence, trace)
+        });
+        for name in names.into_iter().filter(|n| *n != "_") {
+            match &taint {
+                Some((confidence, trace)) => {
+                    let confidence = match self.tainted.get(name) {
+                        Some(existing) if appends => (*confidence).max(existing.confidence),
+                        _ => *confidence,
+                    };
+                    let trace = trace.clone();
+                    self.tainted
+                        .insert(name.to_string(), Tainted { confidence, trace });
+                }
+                None if !appends => {
+                    self.tainted.remove(name);
+                }
+                None => {}
+            }
+        }
+    }
+
+    /// Marks the variable `name` as clean, for example after the rule has
+    /// seen it validated.
+    pub fn clear(&mut self, name: &str) {
+        self.tainted.remove(name);
+    }
+
+    /// Marks the variable `name` as holding request data that `trace`
+    /// carried into it, for example a parameter that callers pass request
+    /// data to.
+    pub fn mark(&mut self, name: &str, confidence: Confidence, trace: Vec<TraceStep>) {
+        self.tainted
+            .insert(name.to_string(), Tainted { confidence, trace });
+    }
+
+    /// Returns `true` if the variable `name` currently holds request data.
+    pub fn is_tainted(&self, name: &str) -> bool {
+        self.tainted.contains_key(name)
+    }
+
+    /// Returns the source call or variable that taints `expr`, if any. When
+    /// several do, the one reaching `expr` with the highest confidence wins.
+    pub fn tainted_by(&self, expr: &str) -> Option<Taint> {
+        let expr = self.strip_sanitized(expr);
+        let sources = SOURCE_REGEX.find_iter(&expr).map(|m| {
+            let origin = m.as_str().trim_end_matches('(').to_string();
+            (m.start(), origin, Confidence::High, None)
+        });
+        let variables = IDENT_REGEX
+            .find_iter(&expr)
+            .filter(|m| !expr[..m.start()].ends_with('.'))
+            .filter_map(|m| {
+                let tainted = self.tainted.get(m.as_str())?;
+                let origin = m.as_str().to_string();
+                Some((m.start(), origin, tainted.confidence, Some(&tainted.trace)))
+            });
+
+        let mut best: Option<Taint> = None;
+        for (pos, origin, confidence, trace) in sources.chain(variables) {
+            let confidence = if inside_unknown_call(&expr, pos) {
+                confidence.min(Confidence::Medium)
+            } else {
+                confidence
+            };
+            if best.as_ref().map_or(true, |b| confidence > b.confidence) {
+                let trace = trace.cloned().unwrap_or_default();
+                best = Some(Taint {
+                    origin,
+                    confidence,
+                    trace,
+                });
+            }
+        }
+        best
+    }
+
+    /// Like [`TaintTracker::tainted_by`], but only checks for direct request
+    /// sources, ignoring tracked variables.
+    pub fn directly_tainted_by(&self, expr: &str) -> Option<Taint> {
+        let expr = self.strip_sanitized(expr);
+        let m = SOURCE_REGEX.find(&expr)?;
+        let confidence = if inside_unknown_call(&expr, m.start()) {
+            Confidence::Medium
+        } else {
+            Confidence::High
+        };
+        Some(Taint {
+            origin: m.as_str().trim_end_matches('(').to_string(),
+            confidence,
+            trace: Vec::new(),
+        })
+    }
+}
+
+/// Returns the trace of the data `taint` carries into an assignment whose
+/// right-hand side starts at byte `rhs` of `line`. Copying a variable is an
+/// assign step, `+` or `+=` a concat step, and any other expression around
+/// the value a call step; reading a source directly adds no step.
+fn assignment_trace(
+    taint: &Taint,
+    line: &str,
+    code: &str,
+    rhs: usize,
+    line_number: usize,
+    appends: bool,
+) -> Vec<TraceStep> {
+    let value = code[rhs..].trim();
+    let mut trace = taint.trace.clone();
+    let (whole, from_source) = if trace.is_empty() {
+        match source_step(&taint.origin, line, code, rhs, line_number) {
+            Some(step) => {
+                let whole = step.expression.len() == value.len();
+                trace.push(step);
+                (whole, true)
+            }
+            None => (false, true),
+        }
+    } else {
+        (value == taint.origin, false)
+    };
+    let kind = if appends || value.contains('+') {
+        Some(TraceKind::Concat)
+    } else if !whole {
+        Some(TraceKind::Call)
+    } else if !from_source {
+        Some(TraceKind::Assign)
+    } else {
+        None
+    };
+    if let Some(kind) = kind {
+        trace.push(TraceStep {
+            kind,
+            expression: line.trim().to_string(),
+            line: line_number,
+        });
+    }
+    trace
+}
+
+/// Returns the variables assigned by the statement in `code`, if it is an
+/// assignment.
+pub fn assigned_names(code: &str) -> Vec<String> {
+    match ASSIGNMENT_REGEX.captures(code) {
+        Some(caps) if !caps[3].starts_with('=') => caps[1]
+            .split(',')
+            .map(str::trim)
+            .filter(|n| *n != "_" && !KEYWORDS.contains(n))
+            .map(str::to_string)
+            .collect(),
+        _ => Vec::new(),
+    }
+}
+
+/// Splits the argument list of a call at top-level commas. `args` starts just
+/// after the opening parenthesis; splitting stops at the matching `)` or at
+/// the end of the line.
+pub fn call_args(args: &str) -> Vec<&str> {
+    call_arg_ranges(args)
+        .into_iter()
+        .map(|range| &args[range])
+        .collect()
+}
+
+/// Like [`call_args`], but returns the byte range of each argument in `args`.
+pub fn call_arg_ranges(args: &str) -> Vec<std::ops::Range<usize>> {
+    let mut parts = Vec::new();
+    let mut depth = 0;
+    let mut start = 0;
+    for (i, c) in args.char_indices() {
+        match c {
+            '(' | '[' | '{' => depth += 1,
+            ')' | ']' | '}' if depth == 0 => {
+                parts.push(start..i);
+                return parts;
+            }
+            ')' | ']' | '}' => depth -= 1,
+            ',' if depth == 0 => {
+                parts.push(start..i);
+                start = i + 1;
+            }
+            _ => {}
+        }
+    }
+    parts.push(start..args.len());
+    parts
+}
+
+/// Returns the variables a guard on `line` validates. `code` is the line with
+/// literals stripped; unless it tests a condition nothing is validated.
+/// Otherwise each match of `validators` against the original line validates
+/// the variable in its first capture group.
+pub fn validated_names(code: &str, line: &str, validators: &[Regex]) -> Vec<String> {
+    if !CONDITION_REGEX.is_match(code) {
+        return Vec::new();
+    }
+    validators
+        .iter()
+        .flat_map(|regex| regex.captures_iter(line))
+        .map(|caps| caps[1].to_string())
+        .collect()
+}
@@ -0,0 +1,251 @@
+//! A scanner for values converted to the trusted `html/template` types.
+//!
+//! `html/template` escapes everything it inserts except values of the types
+//! `template.HTML`, `template.HTMLAttr`, `template.JS`, `template.JSStr`,
+//! `template.CSS`, `template.URL` and `template.Srcset`, which it trusts to be
+//! safe for their context. Converting anything other than a constant to one
+//! of them defeats the escaping. Conversions of string literals and named
+//! constants are not flagged; conversions of request data, tracked with the
+//! shared taint tracker, are reported with the tracker's confidence, and
+//! other non-constant values with low confidence.
+
+use std::collections::HashSet;
+
+use once_cell::sync::Lazy;
+use regex::{Captures, Regex};
+
+use crate::config::{Confidence, Config};
+use crate::error::Result;
+use crate::scanner::taint::{self, Taint, TaintTracker};
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+pub struct UnescapedTemplateGoScanner;
+
+/// A conversion to one of the types `html/template` does not escape.
+static SINK_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\btemplate\.(HTML|HTMLAttr|JS|JSStr|CSS|URL|Srcset)\(").unwrap());
+
+/// Calls whose result is escaped or cannot carry markup.
+static SANITIZER_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"\bhtml\.EscapeString\(|\btemplate\.(?:HTML|JS|URL)EscapeString\(|\burl\.(?:PathEscape|QueryEscape)\(|\bstrconv\.(?:Itoa|FormatInt)\(")
+        .unwrap()
+});
+
+/// `const name = ...`, or `name = ...` inside a `const (...)` block.
+static CONST_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^\s*(?:const\s+)?(\w+)(?:\s+[\w.]+)?\s*=").unwrap());
+
+static IDENT_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"[A-Za-z_]\w*").unwrap());
+
+/// What makes a converted value unsafe.
+enum Unescaped {
+    /// The value carries request data.
+    Tainted(Taint),
+    /// The value is not a constant but could not be traced to the request.
+    NonConstant,
+}
+
+/// Returns the names of the constants declared anywhere in the file.
+fn constants(content: &str) -> HashSet<String> {
+    let mut names = HashSet::new();
+    let mut in_block = false;
+    let mut in_raw = false;
+    for line in content.lines() {
+        let code = taint::strip_literals(line, &mut in_raw);
+        let trimmed = code.trim();
+        if trimmed.starts_with("const (") {
+            in_block = true;
+            continue;
+        }
+        if in_block && trimmed.starts_with(')') {
+            in_block = false;
+            continue;
+        }
+        if in_block || trimmed.starts_with("const ") {
+            if let Some(caps) = CONST_REGEX.captures(&code) {
+                names.insert(caps[1].to_string());
+            }
+        }
+    }
+    names
+}
+
+/// Returns `true` if `arg`, in already-stripped code, is built only from
+/// string literals and named constants once escaped values are removed.
+fn is_constant(arg: &str, constants: &HashSet<String>) -> bool {
+    let arg = taint::strip_sanitized(arg, &SANITIZER_REGEX);
+    IDENT_REGEX
+        .find_iter(&arg)
+        .filter(|m| !arg[..m.start()].ends_with('.'))
+        .all(|m| constants.contains(m.as_str()))
+}
+
+fn unescaped_template_issue(
+    file_path: &str,
+    line: &str,
+    line_number: usize,
+    call: &Captures,
+    value: &str,
+    unescaped: &Unescaped,
+    config: &Config,
+) -> Issue {
+    let m = call.get(0).unwrap();
+    let conversion = format!("template.{}", &call[1]);
+    let (column, end_column) = columns_for(line, m.start(), m.end() - 1);
+    let (description, confidence) = match unescaped {
+        Unescaped::Tainted(taint) => (
+            format!(
+                "Request data from `{}` is converted to `{}`, which html/template inserts without escaping.",
+                taint.origin, conversion
+            ),
+            taint.confidence,
+        ),
+        Unescaped::NonConstant => (
+            format!(
+                "`{}` is converted to `{}`, which html/template inserts without escaping; it must never contain user input.",
+                value, conversion
+            ),
+            Confidence::Low,
+        ),
+    };
+    let mut issue = Issue {
+        rule_id: "unescaped-template-go".to_string(),
+        title: "Unescaped Template Content".to_string(),
+        description,
+        file_path: file_path.to_string(),
+        line_number,
+        column: Some(column),
+        end_column: Some(end_column),
+        severity: config.rules.unescaped_template_go.severity.clone(),
+        confidence,
+        suggested_fix: Some(format!(
+            "Pass the value to the template as a plain `string` so html/template escapes it, and only convert constants to `{}`.",
+            conversion
+        )),
+        diff: None,
+        ..Default::default()
+    };
+    if let Unescaped::Tainted(taint) = unescaped {
+        taint.attach_trace(&mut issue, line);
+    }
+    issue
+}
+
+/// Checks the conversions on one line. `taint_of` reports the taint of an
+/// argument expression.
+fn scan_line(
+    file_path: &str,
+    line: &str,
+    code: &str,
+    line_number: usize,
+    constants: &HashSet<String>,
+    taint_of: impl Fn(&str) -> Option<Taint>,
+    config: &Config,
+) -> Vec<Issue> {
+    let mut issues = Vec::new();
+    for caps in SINK_REGEX.captures_iter(code) {
+        let end = caps.get(0).unwrap().end();
+        let range = match taint::call_arg_ranges(&code[end..]).first() {
+            Some(range) => end + range.start..end + range.end,
+            None => continue,
+        };
+        let unescaped = match taint_of(&code[range.clone()]) {
+            Some(taint) => Unescaped::Tainted(taint),
+            None if !is_constant(&code[range.clone()], constants) => Unescaped::NonConstant,
+            None => continue,
+        };
+        issues.push(unescaped_template_issue(
+            file_path,
+            line,
+            line_number,
+            &caps,
+            line[range].trim(),
+            &unescaped,
+            config,
+        ));
+    }
+    issues
+}
+
+impl UnescapedTemplateGoScanner {
+    /// Taint-tracking pass over each function body.
+    fn scan_functions(
+        &self,
+        ctx: &AnalysisContext,
+        functions: &[taint::GoFunction],
+        constants: &HashSet<String>,
+    ) -> Vec<Issue> {
+        let (file_path, config) = (ctx.file_path, ctx.config);
+        let mut issues = Vec::new();
+        for function in functions {
+            if ctx.expired() {
+                break;
+            }
+            let mut tracker = ctx.tracker(&SANITIZER_REGEX, function);
+            let mut in_raw = false;
+            for (offset, line) in function.lines.iter().enumerate() {
+                let code = taint::strip_literals(line, &mut in_raw);
+                issues.extend(scan_line(
+                    file_path,
+                    line,
+                    &code,
+                    function.start_line + offset,
+                    constants,
+                    |expr| tracker.tainted_by(expr),
+                    config,
+                ));
+                tracker.observe(line, &code, function.start_line + offset);
+            }
+        }
+        issues
+    }
+
+    /// Per-line fallback used when the file cannot be split into functions.
+    /// Only arguments that read the request directly are reported as
+    /// tainted.
+    fn scan_lines(&self, ctx: &AnalysisContext, constants: &HashSet<String>) -> Vec<Issue> {
+        let (file_path, content, config) = (ctx.file_path, ctx.content, ctx.config);
+        let tracker = TaintTracker::new(&SANITIZER_REGEX)
+            .with_sanitizers(ctx.declared_functions().sanitizers());
+        let mut issues = Vec::new();
+        let mut in_raw = false;
+        for (i, line) in content.lines().enumerate() {
+            let code = taint::strip_literals(line, &mut in_raw);
+            issues.extend(scan_line(
+                file_path,
+                line,
+                &code,
+                i + 1,
+                constants,
+                |expr| tracker.directly_tainted_by(expr),
+                config,
+            ));
+        }
+        issues
+    }
+}
+
+impl Scanner for UnescapedTemplateGoScanner {
+    fn name(&self) -> &'static str {
+        "Unescaped Template Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let (file_path, content) = (ctx.file_path, ctx.content);
+        if !SINK_REGEX.is_match(content) {
+            return Ok(Vec::new());
+        }
+        let constants = constants(content);
+        let issues = match ctx.go_functions() {
+            Some(functions) => self.scan_functions(ctx, functions, &constants),
+            None => {
+                log::debug!(
+                    "Could not split {} into functions; using per-line template conversion matching",
+                    file_path
+                );
+                self.scan_lines(ctx, &constants)
+            }
+        };
+        Ok(ctx.declared_functions().drop_safe_sinks(content, issues))
+    }
+}
@@ -0,0 +1,242 @@
+//! A scanner for Go code that bypasses the language's memory safety.
+//!
+//! Every call site of the `unsafe` package that converts pointers or builds
+//! slices and strings over raw memory is reported, and so are the `reflect`
+//! APIs that hand out raw addresses. Such code can be correct, but a mistake
+//! in it corrupts memory instead of panicking, so each use should be
+//! justified in review and then suppressed inline.
+//!
+//! `unsafe.Sizeof`, `unsafe.Alignof` and `unsafe.Offsetof` are evaluated at
+//! compile time and are not reported. A blank import of `unsafe`, used to
+//! enable `//go:linkname`, is reported on the import.
+
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+use crate::config::{Confidence, Config};
+use crate::error::Result;
+use crate::scanner::taint;
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+pub struct UnsafeGoScanner;
+
+/// An import of `unsafe` or `reflect`, alone or in an import block.
+static IMPORT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"^\s*(?:import\s+)?(?:(\w+|\.)\s+)?"(unsafe|reflect)"\s*$"#).unwrap()
+});
+
+/// `uintptr` arithmetic, as in `uintptr(p) + off`.
+static ARITHMETIC_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\buintptr\s*\(.*\)\s*[-+]|[-+]\s*uintptr\s*\(").unwrap());
+
+/// `reflect.Value` methods that return the address of the value.
+static ADDRESS_METHOD_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\.(UnsafeAddr|UnsafePointer)\(\)").unwrap());
+
+const JUSTIFY: &str = "Each use must be justified and reviewed.";
+
+/// The local names of `unsafe` and `reflect` in a file.
+#[derive(Default)]
+struct Imports {
+    unsafe_name: Option<String>,
+    reflect_name: Option<String>,
+    /// The line of a blank import of `unsafe`.
+    blank_unsafe: Option<usize>,
+}
+
+fn imports(content: &str) -> Imports {
+    let mut imports = Imports::default();
+    let mut in_block = false;
+    for (i, line) in content.lines().enumerate() {
+        let trimmed = line.trim();
+        if trimmed.starts_with("func ") {
+            break;
+        }
+        if trimmed.starts_with("import (") {
+            in_block = true;
+            continue;
+        }
+        if in_block && trimmed.starts_with(')') {
+            in_block = false;
+            continue;
+        }
+        if !in_block && !trimmed.starts_with("import ") {
+            continue;
+        }
+        let caps = match IMPORT_REGEX.captures(line) {
+            Some(caps) => caps,
+            None => continue,
+        };
+        let name = caps.get(1).map_or(&caps[2], |m| m.as_str()).to_string();
+        match (&caps[2], name.as_str()) {
+            ("unsafe", "_") => imports.blank_unsafe = Some(i + 1),
+            // Dot-imported members cannot be told apart from local names.
+            (_, ".") => {}
+            ("unsafe", _) => imports.unsafe_name = Some(name),
+            _ => imports.reflect_name = Some(name),
+        }
+    }
+    imports
+}
+
+/// Returns how a use of `member` on a line bypasses memory safety, and the
+/// confidence and remediation of the finding.
+fn describe(member: &str, call: bool, code: &str) -> (String, Confidence, &'static str) {
+    let (what, fix) = match member {
+        "unsafe.Pointer" if ARITHMETIC_REGEX.is_match(code) => (
+            "`unsafe.Pointer` converts the result of pointer arithmetic on a `uintptr`. Go does not bounds-check the address, and the garbage collector does not keep the object alive through a `uintptr`.",
+            "Index a slice instead of computing addresses; if the arithmetic is needed, use `unsafe.Add` in a single expression and check the offset against the size of the object.",
+        ),
+        "unsafe.Pointer" if call => (
+            "`unsafe.Pointer` converts between pointer types, bypassing Go's type and memory safety.",
+            "Use a plain conversion, `encoding/binary` or `math.Float64bits`-style helpers instead of reinterpreting memory.",
+        ),
+        "unsafe.Pointer" => (
+            "The code handles an `unsafe.Pointer`, which bypasses Go's type and memory safety wherever it is converted back.",
+            "Prefer typed pointers, or `sync/atomic.Pointer[T]` for atomically swapped values.",
+        ),
+        "unsafe.Add" => (
+            "`unsafe.Add` performs pointer arithmetic, which Go does not bounds-check.",
+            "Index a slice instead of computing addresses.",
+        ),
+        "unsafe.Slice" | "unsafe.SliceData" => (
+            "The code builds a slice over raw memory, or takes the address of a slice's backing array, outside Go's bounds checks.",
+            "Copy the data into a normal slice unless profiling shows the copy matters.",
+        ),
+        "unsafe.String" | "unsafe.StringData" => (
+            "The code shares memory between a string and bytes; modifying the bytes afterwards breaks the immutability of the string.",
+            "Convert with `string(b)` or `[]byte(s)`, which copy, unless profiling shows the copy matters.",
+        ),
+        "reflect.SliceHeader" | "reflect.StringHeader" => (
+            "The code manipulates a slice or string header directly. The header types are deprecated, and a header that outlives or mismatches its data points at freed or foreign memory.",
+            "Use `unsafe.Slice` or `unsafe.String`, or better, avoid reinterpreting the data.",
+        ),
+        "reflect.NewAt" => (
+            "`reflect.NewAt` creates a value at an arbitrary address.",
+            "Work with `reflect.New` and the `Set` methods of `reflect.Value` instead.",
+        ),
+        _ => (
+            "The code takes the raw address of a reflected value, which the garbage collector may move or free once it is only held as a `uintptr`.",
+            "Use the `Interface`, `Set` and `Elem` methods of `reflect.Value` instead of addresses.",
+        ),
+    };
+    let confidence = if call || member.starts_with("reflect.") || member.starts_with('.') {
+        Confidence::High
+    } else {
+        Confidence::Medium
+    };
+    (format!("{} {}", what, JUSTIFY), confidence, fix)
+}
+
+fn unsafe_issue(
+    file_path: &str,
+    line_number: usize,
+    columns: Option<(usize, usize)>,
+    description: String,
+    confidence: Confidence,
+    fix: &str,
+    config: &Config,
+) -> Issue {
+    Issue {
+        rule_id: "unsafe-go".to_string(),
+        title: "Unsafe Memory Access".to_string(),
+        description,
+        file_path: file_path.to_string(),
+        line_number,
+        column: columns.map(|c| c.0),
+        end_column: columns.map(|c| c.1),
+        severity: config.rules.unsafe_go.severity.clone(),
+        confidence,
+        suggested_fix: Some(format!(
+            "{} If the use is sound, document why and suppress it with `// reviewlens:ignore unsafe-go <reason>`.",
+            fix
+        )),
+        diff: None,
+        ..Default::default()
+    }
+}
+
+impl Scanner for UnsafeGoScanner {
+    fn name(&self) -> &'static str {
+        "Unsafe Memory Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let (file_path, content, config) = (ctx.file_path, ctx.content, ctx.config);
+        if !file_path.ends_with(".go") {
+            return Ok(Vec::new());
+        }
+        let imports = imports(content);
+        let mut issues = Vec::new();
+        if let Some(line_number) = imports.blank_unsafe {
+            issues.push(unsafe_issue(
+                file_path,
+                line_number,
+                None,
+                format!(
+                    "`unsafe` is imported for its side effects, which enables `//go:linkname` access to the unexported symbols of other packages. {}",
+                    JUSTIFY
+                ),
+                Confidence::High,
+                "Use the exported API of the package instead.",
+                config,
+            ));
+        }
+        let mut patterns = Vec::new();
+        if let Some(name) = &imports.unsafe_name {
+            patterns.push(format!(
+                r"\b{}\.(Pointer|Add|Slice|SliceData|String|StringData)\b",
+                regex::escape(name)
+            ));
+        }
+        if let Some(name) = &imports.reflect_name {
+            patterns.push(format!(
+                r"\b{}\.(SliceHeader|StringHeader|NewAt)\b",
+                regex::escape(name)
+            ));
+        }
+        if patterns.is_empty() {
+            return Ok(issues);
+        }
+        let member_regex = Regex::new(&patterns.join("|")).unwrap();
+
+        let mut in_raw = false;
+        for (i, line) in content.lines().enumerate() {
+            let code = taint::strip_literals(line, &mut in_raw);
+            let found = member_regex
+                .captures(&code)
+                .map(|caps| {
+                    let m = caps.get(0).unwrap();
+                    let package = if caps.get(1).is_some() {
+                        "unsafe"
+                    } else {
+                        "reflect"
+                    };
+                    let member = caps.get(1).or_else(|| caps.get(2)).unwrap().as_str();
+                    (m, format!("{}.{}", package, member))
+                })
+                .or_else(|| {
+                    imports.reflect_name.as_ref()?;
+                    let caps = ADDRESS_METHOD_REGEX.captures(&code)?;
+                    Some((caps.get(0).unwrap(), format!(".{}", &caps[1])))
+                });
+            let (m, member) = match found {
+                Some(found) => found,
+                None => continue,
+            };
+            let call = code[m.end()..].trim_start().starts_with('(');
+            let (description, confidence, fix) = describe(&member, call, &code);
+            let end = m.end() - usize::from(member.starts_with('.')) * 2;
+            issues.push(unsafe_issue(
+                file_path,
+                i + 1,
+                Some(columns_for(line, m.start(), end)),
+                description,
+                confidence,
+                fix,
+                config,
+            ));
+        }
+        Ok(issues)
+    }
+}
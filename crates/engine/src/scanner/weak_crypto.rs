@@ -0,0 +1,393 @@
+//! A scanner for weak cryptographic primitives in Go code.
+//!
+//! Files are checked for the packages they import, under any alias:
+//!
+//! - `crypto/md5`, `crypto/des` and `crypto/rc4` are flagged wherever their
+//!   hashes or ciphers are used, since they are broken for any purpose;
+//! - `crypto/sha1` is only flagged where the surrounding code looks like
+//!   password or token hashing or signing, since SHA-1 is still fine for
+//!   checksums and content addressing;
+//! - `math/rand` is flagged when its output ends up in a variable named like a
+//!   key, token, secret or nonce, or is used in a function named like one.
+//!   The output is followed through simple assignments within the function.
+//!
+//! A package that is imported but whose use cannot be located, such as a
+//! blank import, is reported on the import itself.
+
+use std::collections::{HashMap, HashSet};
+
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+use crate::config::{Confidence, Config};
+use crate::error::Result;
+use crate::scanner::taint;
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+pub struct WeakCryptoGoScanner;
+
+/// An import of one of the checked packages, alone or in an import block.
+static IMPORT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r#"^\s*(?:import\s+)?(?:(\w+|\.)\s+)?"(crypto/(?:md5|sha1|des|rc4)|math/rand(?:/v2)?)"\s*$"#)
+        .unwrap()
+});
+
+static FUNC_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^func\s+(?:\([^)]*\)\s*)?(\w+)").unwrap());
+
+/// Names suggesting a hash protects a password or token, or signs data.
+static HASH_CONTEXT_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)passw|pwd|token|secret|sign|hmac|auth|credential|session|api_?key").unwrap()
+});
+
+/// Names of values that must be unpredictable.
+static SECRET_NAME_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(r"(?i)token|key|secret|nonce|salt|passw|otp|session|csrf|^iv$").unwrap()
+});
+
+/// The target of an element assignment, e.g. `b[i] = ...`.
+static INDEX_ASSIGN_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"^\s*(\w+)\[[^\]]*\]\s*=[^=]").unwrap());
+
+static IDENT_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"[A-Za-z_]\w*").unwrap());
+
+/// The packages checked by the rule.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum Primitive {
+    Md5,
+    Sha1,
+    Des,
+    Rc4,
+    MathRand,
+}
+
+impl Primitive {
+    fn from_path(path: &str) -> Option<Self> {
+        match path {
+            "crypto/md5" => Some(Self::Md5),
+            "crypto/sha1" => Some(Self::Sha1),
+            "crypto/des" => Some(Self::Des),
+            "crypto/rc4" => Some(Self::Rc4),
+            "math/rand" | "math/rand/v2" => Some(Self::MathRand),
+            _ => None,
+        }
+    }
+
+    /// The package members whose use is flagged.
+    fn members(self) -> &'static str {
+        match self {
+            Self::Md5 | Self::Sha1 => "New|Sum",
+            Self::Des => "NewCipher|NewTripleDESCipher",
+            Self::Rc4 => "NewCipher",
+            Self::MathRand => {
+                "Int|Intn|IntN|Int31|Int31n|Int32|Int32N|Int63|Int63n|Int64|Int64N|Uint32|Uint64|Uint32N|Uint64N|UintN|N|Float32|Float64|Perm|Shuffle|Read"
+            }
+        }
+    }
+
+    fn title(self) -> &'static str {
+        match self {
+            Self::Md5 | Self::Sha1 => "Weak Hash Function",
+            Self::Des | Self::Rc4 => "Weak Cipher",
+            Self::MathRand => "Insecure Randomness",
+        }
+    }
+
+    fn weakness(self) -> &'static str {
+        match self {
+            Self::Md5 => "MD5 is broken: collisions can be computed in seconds",
+            Self::Sha1 => "SHA-1 collisions are practical, so it must not protect passwords, tokens or signatures",
+            Self::Des => "DES keys can be brute-forced, and DES and Triple DES have a 64-bit block size that leaks plaintext over long sessions",
+            Self::Rc4 => "the RC4 keystream is biased and leaks plaintext",
+            Self::MathRand => "math/rand is not cryptographically secure and its output can be predicted",
+        }
+    }
+
+    fn remediation(self) -> &'static str {
+        match self {
+            Self::Md5 | Self::Sha1 => "Use SHA-256 (`crypto/sha256`) for digests and HMAC-SHA256 or Ed25519 for signatures; hash passwords with bcrypt, scrypt or Argon2 from `golang.org/x/crypto`.",
+            Self::Des | Self::Rc4 => "Use AES-GCM (`crypto/aes` with `cipher.NewGCM`) or ChaCha20-Poly1305 from `golang.org/x/crypto/chacha20poly1305`.",
+            Self::MathRand => "Generate keys, tokens and nonces with `crypto/rand`, e.g. `rand.Read` into a byte slice, or `rand.Int` for a bounded number.",
+        }
+    }
+}
+
+/// A checked package imported by the file.
+struct Import {
+    primitive: Primitive,
+    path: String,
+    line_number: usize,
+    /// Matches uses of the flagged members under the package's alias.
+    usage: Option<Regex>,
+}
+
+/// Returns the checked packages imported by the file.
+fn imports(content: &str) -> Vec<Import> {
+    let mut imports = Vec::new();
+    let mut in_block = false;
+    for (i, line) in content.lines().enumerate() {
+        let trimmed = line.trim();
+        if trimmed.starts_with("func ") {
+            break;
+        }
+        if trimmed.starts_with("import (") {
+            in_block = true;
+            continue;
+        }
+        if in_block && trimmed.starts_with(')') {
+            in_block = false;
+            continue;
+        }
+        if !in_block && !trimmed.starts_with("import ") {
+            continue;
+        }
+        let caps = match IMPORT_REGEX.captures(line) {
+            Some(caps) => caps,
+            None => continue,
+        };
+        let path = caps[2].to_string();
+        let primitive = match Primitive::from_path(&path) {
+            Some(primitive) => primitive,
+            None => continue,
+        };
+        let alias = match caps.get(1).map(|m| m.as_str()) {
+            Some(alias) => alias.to_string(),
+            None if primitive == Primitive::MathRand => "rand".to_string(),
+            None => path.rsplit('/').next().unwrap_or_default().to_string(),
+        };
+        // Blank and dot imports have no name to find uses by.
+        let usage = (alias != "_" && alias != ".").then(|| {
+            Regex::new(&format!(
+                r"\b{}\.({})\b",
+                regex::escape(&alias),
+                primitive.members()
+            ))
+            .unwrap()
+        });
+        imports.push(Import {
+            primitive,
+            path,
+            line_number: i + 1,
+            usage,
+        });
+    }
+    imports
+}
+
+fn weak_crypto_issue(
+    file_path: &str,
+    line_number: usize,
+    columns: Option<(usize, usize)>,
+    primitive: Primitive,
+    description: String,
+    confidence: Confidence,
+    config: &Config,
+) -> Issue {
+    Issue {
+        rule_id: "weak-crypto-go".to_string(),
+        title: primitive.title().to_string(),
+        description,
+        file_path: file_path.to_string(),
+        line_number,
+        column: columns.map(|c| c.0),
+        end_column: columns.map(|c| c.1),
+        severity: config.rules.weak_crypto_go.severity.clone(),
+        confidence,
+        suggested_fix: Some(primitive.remediation().to_string()),
+        diff: None,
+        ..Default::default()
+    }
+}
+
+/// Variables holding math/rand output -> the call it came from and the line
+/// of that call.
+type RandomValues = HashMap<String, (String, usize)>;
+
+/// Follows math/rand output through the statement in `code` on line
+/// `line_number`, where `call` is the flagged call on the line, if any.
+/// Returns the description and confidence of a finding when the output
+/// reaches a value named like a secret, or is produced in a function named
+/// like one, together with the line of the call it came from.
+fn random_use(
+    code: &str,
+    line_number: usize,
+    call: Option<&str>,
+    function: &str,
+    random: &mut RandomValues,
+) -> Option<(String, Confidence, usize)> {
+    let (origin, origin_line) = match call {
+        Some(call) => (call.to_string(), line_number),
+        None => IDENT_REGEX
+            .find_iter(code)
+            .find_map(|m| random.get(m.as_str()).cloned())?,
+    };
+    let mut targets = taint::assigned_names(code);
+    if let Some(caps) = INDEX_ASSIGN_REGEX.captures(code) {
+        targets.push(caps[1].to_string());
+    }
+    // `rand.Read(buf)` fills its argument.
+    if let Some(call) = call.filter(|call| call.ends_with(".Read")) {
+        let start = code.find(call).unwrap_or(0) + call.len();
+        if let Some(arg) = code[start..]
+            .strip_prefix('(')
+            .and_then(|rest| taint::call_args(rest).first().copied())
+        {
+            targets.push(arg.trim().to_string());
+        }
+    }
+    for target in &targets {
+        random.insert(target.clone(), (origin.clone(), origin_line));
+    }
+
+    let weakness = Primitive::MathRand.weakness();
+    if let Some(name) = targets.iter().find(|t| SECRET_NAME_REGEX.is_match(t)) {
+        return Some((
+            format!(
+                "The output of `{}` is used for `{}`, which looks like a key, token or nonce, but {}.",
+                origin, name, weakness
+            ),
+            Confidence::High,
+            origin_line,
+        ));
+    }
+    if call.is_some() && SECRET_NAME_REGEX.is_match(function) {
+        return Some((
+            format!(
+                "`{}` is used in `{}`, which looks like it generates a key, token or nonce, but {}.",
+                origin, function, weakness
+            ),
+            Confidence::Medium,
+            origin_line,
+        ));
+    }
+    None
+}
+
+impl WeakCryptoGoScanner {
+    /// Reports the uses of one imported package. Returns `None` when no use
+    /// was found at all, even one that is not flagged.
+    fn scan_import(
+        &self,
+        file_path: &str,
+        lines: &[(&str, String)],
+        import: &Import,
+        config: &Config,
+    ) -> Option<Vec<Issue>> {
+        let usage = import.usage.as_ref()?;
+        let mut used = false;
+        let mut issues = Vec::new();
+        let mut function = String::new();
+        let mut random = RandomValues::new();
+        // Lines of math/rand calls whose output was found to reach a secret.
+        let mut reached_secret = HashSet::new();
+        for (i, (line, code)) in lines.iter().enumerate() {
+            if let Some(caps) = FUNC_REGEX.captures(code) {
+                function = caps[1].to_string();
+                random.clear();
+            }
+            let call = usage.find(code);
+            used |= call.is_some();
+            let columns = call.map(|m| columns_for(line, m.start(), m.end()));
+            let call = call.map(|m| m.as_str().to_string());
+
+            let flagged = match import.primitive {
+                Primitive::Md5 | Primitive::Des | Primitive::Rc4 => call.map(|call| {
+                    let sensitive =
+                        HASH_CONTEXT_REGEX.is_match(code) || HASH_CONTEXT_REGEX.is_match(&function);
+                    let confidence = match import.primitive {
+                        Primitive::Md5 if !sensitive => Confidence::Medium,
+                        _ => Confidence::High,
+                    };
+                    let description = format!(
+                        "`{}` is used here, but {}.",
+                        call,
+                        import.primitive.weakness()
+                    );
+                    (description, confidence)
+                }),
+                Primitive::Sha1 => call
+                    .filter(|_| {
+                        HASH_CONTEXT_REGEX.is_match(code) || HASH_CONTEXT_REGEX.is_match(&function)
+                    })
+                    .map(|call| {
+                        let description = format!(
+                            "`{}` appears to hash a password or token, or to sign data, but {}.",
+                            call,
+                            import.primitive.weakness()
+                        );
+                        (description, Confidence::Medium)
+                    }),
+                Primitive::MathRand => {
+                    random_use(code, i + 1, call.as_deref(), &function, &mut random).map(
+                        |(description, confidence, origin_line)| {
+                            if confidence == Confidence::High {
+                                reached_secret.insert(origin_line);
+                            }
+                            (description, confidence)
+                        },
+                    )
+                }
+            };
+            if let Some((description, confidence)) = flagged {
+                issues.push(weak_crypto_issue(
+                    file_path,
+                    i + 1,
+                    columns,
+                    import.primitive,
+                    description,
+                    confidence,
+                    config,
+                ));
+            }
+        }
+        // A call in a function named like a generator is not reported again
+        // when its output was also seen reaching a secret.
+        issues.retain(|issue| {
+            issue.confidence == Confidence::High || !reached_secret.contains(&issue.line_number)
+        });
+        used.then_some(issues)
+    }
+}
+
+impl Scanner for WeakCryptoGoScanner {
+    fn name(&self) -> &'static str {
+        "Weak Crypto Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let (file_path, content, config) = (ctx.file_path, ctx.content, ctx.config);
+        let imports = imports(content);
+        if imports.is_empty() {
+            return Ok(Vec::new());
+        }
+        let mut in_raw = false;
+        let lines: Vec<(&str, String)> = content
+            .lines()
+            .map(|line| (line, taint::strip_literals(line, &mut in_raw)))
+            .collect();
+
+        let mut issues = Vec::new();
+        for import in &imports {
+            match self.scan_import(file_path, &lines, import, config) {
+                Some(found) => issues.extend(found),
+                // Whether math/rand and SHA-1 are weak depends on their use.
+                None if matches!(import.primitive, Primitive::Sha1 | Primitive::MathRand) => {}
+                None => issues.push(weak_crypto_issue(
+                    file_path,
+                    import.line_number,
+                    None,
+                    import.primitive,
+                    format!(
+                        "The file imports `{}`, but {}.",
+                        import.path,
+                        import.primitive.weakness()
+                    ),
+                    Confidence::Medium,
+                    config,
+                )),
+            }
+        }
+        issues.sort_by_key(|issue| (issue.line_number, issue.column));
+        Ok(issues)
+    }
+}
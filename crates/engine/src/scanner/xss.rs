@@ -0,0 +1,331 @@
+//! A scanner for reflected cross-site scripting in Go HTTP handlers.
+
+use once_cell::sync::Lazy;
+use regex::Regex;
+
+use crate::config::{Confidence, Config};
+use crate::error::Result;
+use crate::fix::{Fix, TextEdit};
+use crate::scanner::taint::{self, Taint, TaintTracker};
+use crate::scanner::{columns_for, AnalysisContext, Issue, Scanner};
+
+pub struct XssGoScanner;
+
+/// Calls that write directly to a response writer. The writer argument is
+/// captured so it can be checked against the function's `http.ResponseWriter`
+/// parameters.
+static SINK_REGEX: Lazy<Regex> = Lazy::new(|| {
+    Regex::new(
+        r"\bfmt\.Fprint(?:f|ln)?\(\s*(\w+)\s*,|\bio\.WriteString\(\s*(\w+)\s*,|\b(\w+)\.Write\(",
+    )
+    .unwrap()
+});
+
+/// Calls that HTML-escape their argument.
+static SANITIZER_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"\bhtml\.EscapeString\(|\btemplate\.\w+\(").unwrap());
+
+static WRITER_PARAM_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r"(\w+)\s+http\.ResponseWriter\b").unwrap());
+
+/// A string literal concatenated with a non-literal operand, used by the
+/// per-line fallback.
+static CONCAT_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r#""\s*\+\s*[A-Za-z_]|[\w)\]]\s*\+\s*""#).unwrap());
+
+static IDENT_REGEX: Lazy<Regex> = Lazy::new(|| Regex::new(r"[A-Za-z_]\w*").unwrap());
+
+/// An existing import of the `html` package, on its own or in an import block.
+static HTML_IMPORT_REGEX: Lazy<Regex> =
+    Lazy::new(|| Regex::new(r#"^\s*(?:import\s+)?"html"\s*$"#).unwrap());
+
+/// A sink call found on a line.
+struct Sink {
+    name: String,
+    start: usize,
+    end: usize,
+}
+
+/// Returns the first sink on `code` that writes to one of `writers`.
+fn find_sink(code: &str, writers: &[String]) -> Option<Sink> {
+    SINK_REGEX.captures_iter(code).find_map(|caps| {
+        let writer = caps.get(1).or(caps.get(2)).or(caps.get(3))?.as_str();
+        if !writers.iter().any(|w| w == writer) {
+            return None;
+        }
+        let m = caps.get(0)?;
+        let name = m.as_str().split('(').next().unwrap_or("").to_string();
+        Some(Sink {
+            name,
+            start: m.start(),
+            end: m.end(),
+        })
+    })
+}
+
+/// Returns the edit that imports `html` into a Go file: an empty list if it is
+/// already imported, or `None` if the file has no package clause to anchor
+/// the import to.
+fn html_import(content: &str) -> Option<Vec<TextEdit>> {
+    let mut package = None;
+    for (i, line) in content.lines().enumerate() {
+        let trimmed = line.trim();
+        if HTML_IMPORT_REGEX.is_match(line) {
+            return Some(Vec::new());
+        }
+        if trimmed.starts_with("import (") {
+            return Some(vec![TextEdit::insert(i + 2, 1, "\t\"html\"\n")]);
+        }
+        if trimmed.starts_with("import ") {
+            return Some(vec![TextEdit::insert(i + 2, 1, "import \"html\"\n")]);
+        }
+        if package.is_none() && trimmed.starts_with("package ") {
+            package = Some(i + 1);
+        }
+        if trimmed.starts_with("func ") {
+            break;
+        }
+    }
+    package.map(|line| vec![TextEdit::insert(line + 1, 1, "\nimport \"html\"\n")])
+}
+
+/// Returns the end of the argument list starting at `from`: the offset of
+/// the unmatched `)`, or the end of the line.
+fn args_end(code: &str, from: usize) -> usize {
+    let mut depth = 0;
+    for (i, c) in code[from..].char_indices() {
+        match c {
+            '(' | '[' | '{' => depth += 1,
+            ')' | ']' | '}' if depth == 0 => return from + i,
+            ')' | ']' | '}' => depth -= 1,
+            _ => {}
+        }
+    }
+    code.len()
+}
+
+/// Extends a source call ending just before `(` at `end` over its argument
+/// list and any index expressions that follow, as in `mux.Vars(r)["id"]`.
+fn extend_call(code: &str, end: usize) -> usize {
+    let mut end = end;
+    while code[end..].starts_with(['(', '[']) {
+        let close = args_end(code, end + 1);
+        if close >= code.len() {
+            return code.len();
+        }
+        end = close + 1;
+    }
+    end
+}
+
+/// Builds a fix that wraps every tainted value among the sink's arguments in
+/// `html.EscapeString`. Values passed straight to `Write`, which takes a
+/// byte slice, cannot be wrapped and yield no fix.
+fn escape_fix(
+    line: &str,
+    code: &str,
+    line_number: usize,
+    sink: &Sink,
+    tracker: &TaintTracker,
+    import: &[TextEdit],
+) -> Option<(Fix, String)> {
+    let start = sink.end;
+    let end = args_end(code, start);
+    let args = &code[start..end];
+
+    // Values that are already escaped are left alone.
+    let sanitized: Vec<(usize, usize)> = tracker
+        .sanitizer_calls(args)
+        .into_iter()
+        .map(|m| (start + m.start(), args_end(code, start + m.end())))
+        .collect();
+    let covered = |spans: &[(usize, usize)], s: usize, e: usize| {
+        spans.iter().any(|(a, b)| *a <= s && e <= *b)
+    };
+
+    let mut spans: Vec<(usize, usize)> = taint::source_ranges(args)
+        .into_iter()
+        .map(|r| (start + r.start, extend_call(code, start + r.end).min(end)))
+        .filter(|(s, e)| !covered(&sanitized, *s, *e))
+        .collect();
+    for m in IDENT_REGEX.find_iter(args) {
+        let (s, e) = (start + m.start(), start + m.end());
+        let qualified = code[..s].ends_with('.') || code[e..].starts_with(['.', '(']);
+        if !qualified
+            && !covered(&spans, s, e)
+            && !covered(&sanitized, s, e)
+            && tracker.is_tainted(m.as_str())
+        {
+            spans.push((s, e));
+        }
+    }
+    if spans.is_empty() {
+        return None;
+    }
+    spans.sort();
+
+    let writes_bytes = sink.name.ends_with(".Write");
+    let mut edits = import.to_vec();
+    let mut fixed = line.to_string();
+    let mut names = Vec::new();
+    for &(s, e) in spans.iter().rev() {
+        let depth = code[start..s].matches('(').count() - code[start..s].matches(')').count();
+        if writes_bytes && depth == 0 {
+            return None;
+        }
+        let value = &line[s..e];
+        let replacement = format!("html.EscapeString({})", value);
+        let (column, end_column) = columns_for(line, s, e);
+        edits.push(TextEdit {
+            start_line: line_number,
+            start_column: column,
+            end_line: line_number,
+            end_column,
+            replacement: replacement.clone(),
+        });
+        fixed.replace_range(s..e, &replacement);
+        names.push(format!("`{}`", value));
+    }
+    names.reverse();
+    edits.sort();
+
+    let fix = Fix {
+        description: format!("Escape {} with html.EscapeString", names.join(", ")),
+        edits,
+    };
+    let diff = format!("-{}\n+{}", line.trim(), fixed.trim());
+    Some((fix, diff))
+}
+
+fn xss_issue(
+    file_path: &str,
+    line: &str,
+    line_number: usize,
+    sink: &Sink,
+    taint: &Taint,
+    config: &Config,
+) -> Issue {
+    let (column, end_column) = columns_for(line, sink.start, sink.end);
+    let mut issue = Issue {
+        rule_id: "xss-go".to_string(),
+        title: "Potential Cross-Site Scripting".to_string(),
+        description: format!(
+            "Request data from `{}` is written to the response by `{}` without HTML escaping.",
+            taint.origin, sink.name
+        ),
+        file_path: file_path.to_string(),
+        line_number,
+        column: Some(column),
+        end_column: Some(end_column),
+        severity: config.rules.xss_go.severity.clone(),
+        confidence: taint.confidence,
+        suggested_fix: Some(
+            "Escape the value with html.EscapeString or render it with html/template.".to_string(),
+        ),
+        diff: None,
+        ..Default::default()
+    };
+    taint.attach_trace(&mut issue, line);
+    issue
+}
+
+impl XssGoScanner {
+    /// Taint-tracking pass over each function body. Findings get a fix that
+    /// escapes the tainted values when one can be built.
+    fn scan_functions(&self, ctx: &AnalysisContext, functions: &[taint::GoFunction]) -> Vec<Issue> {
+        let (file_path, content, config) = (ctx.file_path, ctx.content, ctx.config);
+        let import = html_import(content);
+        let import = import.as_deref();
+        let mut issues = Vec::new();
+        for function in functions {
+            if ctx.expired() {
+                break;
+            }
+            let mut writers: Vec<String> = function
+                .lines
+                .iter()
+                .flat_map(|l| WRITER_PARAM_REGEX.captures_iter(l))
+                .map(|caps| caps[1].to_string())
+                .collect();
+            if writers.is_empty() {
+                writers.push("w".to_string());
+            }
+
+            let mut tracker = ctx.tracker(&SANITIZER_REGEX, function);
+            let mut in_raw = false;
+            for (offset, line) in function.lines.iter().enumerate() {
+                let code = taint::strip_literals(line, &mut in_raw);
+                let line_number = function.start_line + offset;
+                if let Some(sink) = find_sink(&code, &writers) {
+                    if let Some(taint) = tracker.tainted_by(&code[sink.end..]) {
+                        let mut issue =
+                            xss_issue(file_path, line, line_number, &sink, &taint, config);
+                        if let Some((fix, diff)) = import.and_then(|import| {
+                            escape_fix(line, &code, line_number, &sink, &tracker, import)
+                        }) {
+                            issue.fix = Some(fix);
+                            issue.diff = Some(diff);
+                        }
+                        issues.push(issue);
+                    }
+                }
+                tracker.observe(line, &code, line_number);
+            }
+        }
+        issues
+    }
+
+    /// Per-line fallback used when the file cannot be split into functions.
+    /// Flags sinks whose arguments read the request directly or, with low
+    /// confidence, concatenate a string literal with another value.
+    fn scan_lines(&self, ctx: &AnalysisContext) -> Vec<Issue> {
+        let (file_path, content, config) = (ctx.file_path, ctx.content, ctx.config);
+        let writers = vec!["w".to_string()];
+        let tracker = TaintTracker::new(&SANITIZER_REGEX)
+            .with_sanitizers(ctx.declared_functions().sanitizers());
+        let mut issues = Vec::new();
+        let mut in_raw = false;
+        for (i, line) in content.lines().enumerate() {
+            let code = taint::strip_literals(line, &mut in_raw);
+            let sink = match find_sink(&code, &writers) {
+                Some(sink) => sink,
+                None => continue,
+            };
+            let args = &code[sink.end..];
+            let taint = tracker.directly_tainted_by(args).or_else(|| {
+                let unsanitized = tracker.sanitizer_calls(args).is_empty();
+                (unsanitized && CONCAT_REGEX.is_match(args)).then(|| Taint {
+                    origin: "string concatenation".to_string(),
+                    confidence: Confidence::Low,
+                    trace: Vec::new(),
+                })
+            });
+            if let Some(taint) = taint {
+                issues.push(xss_issue(file_path, line, i + 1, &sink, &taint, config));
+            }
+        }
+        issues
+    }
+}
+
+impl Scanner for XssGoScanner {
+    fn name(&self) -> &'static str {
+        "XSS Scanner (Go)"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let issues = match ctx.go_functions() {
+            Some(functions) => self.scan_functions(ctx, functions),
+            None => {
+                log::debug!(
+                    "Could not split {} into functions; using per-line XSS matching",
+                    ctx.file_path
+                );
+                self.scan_lines(ctx)
+            }
+        };
+        Ok(ctx
+            .declared_functions()
+            .drop_safe_sinks(ctx.content, issues))
+    }
+}
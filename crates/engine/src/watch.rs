@@ -0,0 +1,416 @@
+//! Detects changes to the files of a working tree, for incremental scans.
+//!
+//! [`TreeWatcher`] keeps the size and modification time of every file the
+//! path filters include. It subscribes to the platform's filesystem
+//! notifications (inotify, FSEvents, kqueue or `ReadDirectoryChangesW`,
+//! through `notify`), and when one arrives only the paths it names are read
+//! again, so an idle tree costs nothing however large it is. Where
+//! notifications are unavailable, for example on some network filesystems
+//! or when the inotify watch limit is reached, the tree is walked once per
+//! interval instead. [`TreeWatcher::wait`] debounces the changes, so an
+//! editor save that writes a file several times in quick succession is
+//! reported once.
+//!
+//! The `.reviewlensignore` files of the tree are watched too. When one
+//! changes, the filters are rebuilt and every file they now include is
+//! reported as modified.
+
+use std::collections::{BTreeSet, HashMap};
+use std::path::{Component, Path, PathBuf};
+use std::sync::mpsc::{self, Receiver, RecvTimeoutError};
+use std::time::{Duration, Instant, SystemTime};
+
+use notify::{RecommendedWatcher, RecursiveMode, Watcher};
+use walkdir::WalkDir;
+
+use crate::config::PathsConfig;
+use crate::error::Result;
+use crate::paths::{PathFilter, IGNORE_FILE, VCS_DIRS};
+
+/// What identifies a version of a file without reading it.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+struct Stamp {
+    modified: Option<SystemTime>,
+    len: u64,
+}
+
+/// Files that changed between two polls, as paths relative to the root of
+/// the tree with `/` separators.
+#[derive(Debug, Clone, Default, PartialEq, Eq)]
+pub struct Changes {
+    /// Files that were created or whose contents changed.
+    pub modified: BTreeSet<String>,
+    /// Files that were deleted or are no longer included by the filters.
+    pub removed: BTreeSet<String>,
+}
+
+impl Changes {
+    /// Returns `true` if nothing changed.
+    pub fn is_empty(&self) -> bool {
+        self.modified.is_empty() && self.removed.is_empty()
+    }
+
+    /// Adds later changes, keeping the latest state of each file.
+    fn merge(&mut self, later: Changes) {
+        for path in later.modified {
+            self.removed.remove(&path);
+            self.modified.insert(path);
+        }
+        for path in later.removed {
+            self.modified.remove(&path);
+            self.removed.insert(path);
+        }
+    }
+}
+
+/// The filesystem notifications for a tree.
+struct Notifications {
+    /// Delivers the events until it is dropped.
+    _watcher: RecommendedWatcher,
+    events: Receiver<notify::Result<notify::Event>>,
+}
+
+impl Notifications {
+    fn new(root: &Path) -> notify::Result<Self> {
+        let (sender, events) = mpsc::channel();
+        let mut watcher = notify::recommended_watcher(move |event| {
+            let _ = sender.send(event);
+        })?;
+        watcher.watch(root, RecursiveMode::Recursive)?;
+        Ok(Self {
+            _watcher: watcher,
+            events,
+        })
+    }
+}
+
+/// The paths named by the events received while debouncing, relative to the
+/// root of the tree.
+#[derive(Default)]
+struct Touched {
+    paths: BTreeSet<PathBuf>,
+    /// An event was lost or covers the whole tree, which must be walked.
+    everything: bool,
+}
+
+/// Watches the files of a working tree.
+pub struct TreeWatcher {
+    root: PathBuf,
+    /// `root` as the notifications may name it.
+    canonical_root: PathBuf,
+    paths: PathsConfig,
+    filter: PathFilter,
+    files: HashMap<String, Stamp>,
+    ignore_files: HashMap<String, Stamp>,
+    /// `None` when the tree is polled.
+    notifications: Option<Notifications>,
+}
+
+impl TreeWatcher {
+    /// Starts watching the tree at `root`, recording the current state of the
+    /// files included by `paths` and the tree's `.reviewlensignore` files.
+    pub fn new(root: impl Into<PathBuf>, paths: &PathsConfig) -> Result<Self> {
+        let root = root.into();
+        // Subscribe before the first snapshot so no change falls in between.
+        let notifications = match Notifications::new(&root) {
+            Ok(notifications) => Some(notifications),
+            Err(e) => {
+                log::warn!(
+                    "File notifications are unavailable ({}); polling the tree instead",
+                    e
+                );
+                None
+            }
+        };
+        Self::start(root, paths, notifications)
+    }
+
+    /// Like [`TreeWatcher::new`], but always finds changes by walking the
+    /// tree, as when filesystem notifications are unavailable.
+    pub fn polling(root: impl Into<PathBuf>, paths: &PathsConfig) -> Result<Self> {
+        Self::start(root.into(), paths, None)
+    }
+
+    fn start(
+        root: PathBuf,
+        paths: &PathsConfig,
+        notifications: Option<Notifications>,
+    ) -> Result<Self> {
+        let filter = PathFilter::new(paths)?.with_ignore_files(&root);
+        let mut watcher = Self {
+            canonical_root: root.canonicalize().unwrap_or_else(|_| root.clone()),
+            root,
+            paths: paths.clone(),
+            filter,
+            files: HashMap::new(),
+            ignore_files: HashMap::new(),
+            notifications,
+        };
+        let (files, ignore_files) = watcher.snapshot();
+        watcher.files = files;
+        watcher.ignore_files = ignore_files;
+        Ok(watcher)
+    }
+
+    /// Returns `true` if changes are found by walking the tree, because
+    /// filesystem notifications are unavailable.
+    pub fn is_polling(&self) -> bool {
+        self.notifications.is_none()
+    }
+
+    /// Returns the watched files, sorted.
+    pub fn files(&self) -> Vec<String> {
+        let mut files: Vec<String> = self.files.keys().cloned().collect();
+        files.sort();
+        files
+    }
+
+    /// Walks the whole tree and returns the files that changed since the
+    /// last walk or [`TreeWatcher::wait`].
+    pub fn poll(&mut self) -> Result<Changes> {
+        let (files, ignore_files) = self.snapshot();
+        let mut changes = Changes::default();
+        if ignore_files != self.ignore_files {
+            log::debug!("Ignore files changed; rebuilding path filters");
+            self.filter = PathFilter::new(&self.paths)?.with_ignore_files(&self.root);
+            self.ignore_files = ignore_files;
+            let (files, _) = self.snapshot();
+            changes.modified = files.keys().cloned().collect();
+            changes.removed = self
+                .files
+                .keys()
+                .filter(|path| !files.contains_key(*path))
+                .cloned()
+                .collect();
+            self.files = files;
+            return Ok(changes);
+        }
+        for (path, stamp) in &files {
+            if self.files.get(path) != Some(stamp) {
+                changes.modified.insert(path.clone());
+            }
+        }
+        for path in self.files.keys() {
+            if !files.contains_key(path) {
+                changes.removed.insert(path.clone());
+            }
+        }
+        self.files = files;
+        Ok(changes)
+    }
+
+    /// Blocks until files change, then until no further change has been seen
+    /// for `debounce`, and returns everything that changed. Without
+    /// filesystem notifications, the tree is polled every `interval`.
+    pub fn wait(&mut self, interval: Duration, debounce: Duration) -> Result<Changes> {
+        while self.notifications.is_some() {
+            let touched = match self.next_events(debounce) {
+                Some(touched) => touched,
+                None => {
+                    log::warn!("File notifications stopped; polling the tree instead");
+                    self.notifications = None;
+                    break;
+                }
+            };
+            let changes = if touched.everything {
+                self.poll()?
+            } else {
+                self.update(&touched.paths)?
+            };
+            if !changes.is_empty() {
+                return Ok(changes);
+            }
+        }
+        self.wait_polling(interval, debounce)
+    }
+
+    /// Blocks until an event arrives, then collects events until none has
+    /// arrived for `debounce`. Returns `None` if the notifications stopped
+    /// before any event.
+    fn next_events(&self, debounce: Duration) -> Option<Touched> {
+        let events = &self.notifications.as_ref()?.events;
+        let mut touched = Touched::default();
+        while !self.record(events.recv().ok()?, &mut touched) {}
+        let mut deadline = Instant::now() + debounce;
+        loop {
+            match events.recv_timeout(deadline.saturating_duration_since(Instant::now())) {
+                Ok(event) => {
+                    if self.record(event, &mut touched) {
+                        deadline = Instant::now() + debounce;
+                    }
+                }
+                Err(RecvTimeoutError::Timeout) => return Some(touched),
+                // The pending events are handled; the next wait polls.
+                Err(RecvTimeoutError::Disconnected) => {
+                    touched.everything = true;
+                    return Some(touched);
+                }
+            }
+        }
+    }
+
+    /// Adds the paths of `event` to `touched`. Returns `false` if it can be
+    /// ignored: reads, and changes inside version control directories.
+    fn record(&self, event: notify::Result<notify::Event>, touched: &mut Touched) -> bool {
+        let event = match event {
+            Ok(event) => event,
+            Err(e) => {
+                log::debug!("File notification error: {}", e);
+                touched.everything = true;
+                return true;
+            }
+        };
+        if event.need_rescan() {
+            touched.everything = true;
+            return true;
+        }
+        if event.kind.is_access() {
+            return false;
+        }
+        let mut recorded = false;
+        for path in &event.paths {
+            let relative = path
+                .strip_prefix(&self.root)
+                .or_else(|_| path.strip_prefix(&self.canonical_root));
+            let relative = match relative {
+                Ok(relative) => relative,
+                Err(_) => continue,
+            };
+            let in_vcs_dir = relative.components().any(|c| match c {
+                Component::Normal(name) => VCS_DIRS.contains(&name.to_string_lossy().as_ref()),
+                _ => false,
+            });
+            if !in_vcs_dir {
+                touched.paths.insert(relative.to_path_buf());
+                recorded = true;
+            }
+        }
+        recorded
+    }
+
+    /// Reads the stamps of the files at or below each of `touched` again and
+    /// returns what changed.
+    fn update(&mut self, touched: &BTreeSet<PathBuf>) -> Result<Changes> {
+        let whole_tree = touched.iter().any(|path| {
+            path.as_os_str().is_empty() || path.file_name().is_some_and(|name| name == IGNORE_FILE)
+        });
+        if whole_tree {
+            return self.poll();
+        }
+        let mut changes = Changes::default();
+        for path in touched {
+            let name = relative_name(path);
+            let below = format!("{}/", name);
+            let (current, _) = self.walk(&self.root.join(path));
+            let gone: Vec<String> = self
+                .files
+                .keys()
+                .filter(|known| **known == name || known.starts_with(&below))
+                .filter(|known| !current.contains_key(*known))
+                .cloned()
+                .collect();
+            for known in gone {
+                self.files.remove(&known);
+                changes.removed.insert(known);
+            }
+            for (file, stamp) in current {
+                if self.files.insert(file.clone(), stamp) != Some(stamp) {
+                    changes.removed.remove(&file);
+                    changes.modified.insert(file);
+                }
+            }
+        }
+        Ok(changes)
+    }
+
+    /// [`TreeWatcher::wait`] without notifications.
+    fn wait_polling(&mut self, interval: Duration, debounce: Duration) -> Result<Changes> {
+        let mut pending = loop {
+            std::thread::sleep(interval);
+            let changes = self.poll()?;
+            if !changes.is_empty() {
+                break changes;
+            }
+        };
+        let mut last_change = Instant::now();
+        while last_change.elapsed() < debounce {
+            std::thread::sleep(interval.min(debounce));
+            let changes = self.poll()?;
+            if !changes.is_empty() {
+                pending.merge(changes);
+                last_change = Instant::now();
+            }
+        }
+        Ok(pending)
+    }
+
+    /// Reads the stamps of the included files and of the ignore files.
+    fn snapshot(&self) -> (HashMap<String, Stamp>, HashMap<String, Stamp>) {
+        self.walk(&self.root)
+    }
+
+    /// Reads the stamps of the included files and of the ignore files at or
+    /// below `start`, a path inside the tree.
+    fn walk(&self, start: &Path) -> (HashMap<String, Stamp>, HashMap<String, Stamp>) {
+        let mut files = HashMap::new();
+        let mut ignore_files = HashMap::new();
+        let entries = WalkDir::new(start)
+            .into_iter()
+            .filter_entry(|e| {
+                !(e.file_type().is_dir()
+                    && VCS_DIRS.contains(&e.file_name().to_string_lossy().as_ref()))
+            })
+            .filter_map(|e| e.ok())
+            .filter(|e| e.file_type().is_file());
+        for entry in entries {
+            let rel_path = entry
+                .path()
+                .strip_prefix(&self.root)
+                .unwrap_or(entry.path());
+            let metadata = match entry.path().metadata() {
+                Ok(metadata) => metadata,
+                Err(_) => continue,
+            };
+            let stamp = Stamp {
+                modified: metadata.modified().ok(),
+                len: metadata.len(),
+            };
+            let path = relative_name(rel_path);
+            if entry.file_name() == IGNORE_FILE {
+                ignore_files.insert(path.clone(), stamp);
+            }
+            if self.filter.is_included(rel_path) {
+                files.insert(path, stamp);
+            }
+        }
+        (files, ignore_files)
+    }
+}
+
+/// Returns `paths` together with the other Go files of their packages, the
+/// `.go` files in the same directories among `files`. Paths that are not Go
+/// files are returned as they are.
+pub fn with_package_mates<'a>(
+    paths: impl IntoIterator<Item = &'a String>,
+    files: &[String],
+) -> Vec<String> {
+    let mut out: BTreeSet<String> = BTreeSet::new();
+    let mut packages: BTreeSet<&Path> = BTreeSet::new();
+    for path in paths {
+        if path.ends_with(".go") {
+            packages.insert(Path::new(path).parent().unwrap_or(Path::new("")));
+        }
+        out.insert(path.clone());
+    }
+    for file in files {
+        let dir = Path::new(file).parent().unwrap_or(Path::new(""));
+        if file.ends_with(".go") && packages.contains(dir) {
+            out.insert(file.clone());
+        }
+    }
+    out.into_iter().collect()
+}
+
+fn relative_name(path: &Path) -> String {
+    path.to_string_lossy().replace('\\', "/")
+}
@@ -0,0 +1,169 @@
+use engine::analyzer::{Analyzer, CancellationToken, SourceFile};
+use engine::config::{Category, Confidence, Config, Severity};
+use engine::error::EngineError;
+use std::fs;
+use tempfile::tempdir;
+
+const HANDLER: &str = r#"package main
+
+func greet(w http.ResponseWriter, r *http.Request) {
+    user := r.URL.Query().Get("user")
+    fmt.Fprintf(w, "<p>"+user+"</p>")
+}
+"#;
+
+#[test]
+fn scans_files_and_returns_sorted_findings() {
+    let dir = tempdir().unwrap();
+    let b = dir.path().join("b.go");
+    let a = dir.path().join("a.go");
+    fs::write(&b, HANDLER).unwrap();
+    fs::write(&a, HANDLER).unwrap();
+
+    let analyzer = Analyzer::new(Config::default());
+    let findings = analyzer
+        .scan_files(&[&b, &a], &CancellationToken::new())
+        .expect("scan should succeed");
+
+    assert_eq!(findings.len(), 2);
+    assert!(findings[0].file.ends_with("a.go"));
+    assert!(findings[1].file.ends_with("b.go"));
+
+    let finding = &findings[0];
+    assert_eq!(finding.rule_id, "xss-go");
+    assert_eq!(finding.severity, Severity::High);
+    assert_eq!(finding.category, Category::Security);
+    assert!(finding
+        .message
+        .starts_with("Potential Cross-Site Scripting: "));
+    assert_eq!(finding.start_line, 5);
+    assert_eq!(finding.end_line, 5);
+    assert_eq!(finding.start_col, Some(5));
+    assert!(finding.end_col > finding.start_col);
+}
+
+#[test]
+fn scans_sources_held_in_memory() {
+    let files = vec![
+        SourceFile {
+            path: "b.go".to_string(),
+            content: HANDLER.as_bytes().to_vec(),
+        },
+        SourceFile {
+            path: "a.go".to_string(),
+            content: vec![0xff, 0xfe],
+        },
+    ];
+    let findings = Analyzer::new(Config::default())
+        .scan_sources(&files, &CancellationToken::new())
+        .expect("scan should succeed");
+
+    let found: Vec<(&str, &str, usize)> = findings
+        .iter()
+        .map(|f| (f.file.as_str(), f.rule_id.as_str(), f.start_line))
+        .collect();
+    assert_eq!(
+        found,
+        vec![("a.go", "parse-error", 1), ("b.go", "xss-go", 5)]
+    );
+}
+
+#[test]
+fn cancelled_scan_returns_error() {
+    let dir = tempdir().unwrap();
+    let path = dir.path().join("main.go");
+    fs::write(&path, HANDLER).unwrap();
+
+    let cancel = CancellationToken::new();
+    cancel.cancel();
+    let result = Analyzer::new(Config::default()).scan_files(&[&path], &cancel);
+    assert!(matches!(result, Err(EngineError::Cancelled)));
+}
+
+#[test]
+fn min_confidence_drops_weaker_findings() {
+    let dir = tempdir().unwrap();
+    let path = dir.path().join("main.go");
+    fs::write(
+        &path,
+        format!(
+            "{}\nconst signature = \"9fQ2xLk7Rv1bTz4MwP8aYc3NdE6gHj0S\"\n",
+            HANDLER
+        ),
+    )
+    .unwrap();
+
+    let mut config = Config::default();
+    let findings = Analyzer::new(config.clone())
+        .scan_files(&[&path], &CancellationToken::new())
+        .unwrap();
+    let rules: Vec<(&str, Confidence)> = findings
+        .iter()
+        .map(|f| (f.rule_id.as_str(), f.confidence))
+        .collect();
+    assert_eq!(
+        rules,
+        vec![("xss-go", Confidence::High), ("secrets", Confidence::Low)]
+    );
+
+    config.scan.min_confidence = Some(Confidence::Medium);
+    let findings = Analyzer::new(config)
+        .scan_files(&[&path], &CancellationToken::new())
+        .unwrap();
+    assert_eq!(findings.len(), 1);
+    assert_eq!(findings[0].rule_id, "xss-go");
+}
+
+#[test]
+fn path_severity_overrides_apply_after_rule_severities() {
+    let config: Config = toml::from_str(
+        r#"
+[rules.xss-go]
+severity = "medium"
+
+[[path-severity-overrides]]
+paths = ["internal/handlers/"]
+adjust = 1
+
+[[path-severity-overrides]]
+paths = ["examples/", "fixtures/"]
+adjust = -1
+
+[[path-severity-overrides]]
+paths = ["internal/handlers/legacy/"]
+adjust = -3
+"#,
+    )
+    .expect("valid config");
+    config.validate().unwrap();
+    let files: Vec<SourceFile> = [
+        "cmd/main.go",
+        "examples/main.go",
+        "internal/handlers/user.go",
+        "internal/handlers/legacy/user.go",
+    ]
+    .iter()
+    .map(|path| SourceFile {
+        path: path.to_string(),
+        content: HANDLER.as_bytes().to_vec(),
+    })
+    .collect();
+    let findings = Analyzer::new(config)
+        .scan_sources(&files, &CancellationToken::new())
+        .unwrap();
+    let severities: Vec<(&str, Severity)> = findings
+        .iter()
+        .map(|f| (f.file.as_str(), f.severity.clone()))
+        .collect();
+    // The rule's configured severity is adjusted by the last matching entry,
+    // and never drops below low.
+    assert_eq!(
+        severities,
+        vec![
+            ("cmd/main.go", Severity::Medium),
+            ("examples/main.go", Severity::Low),
+            ("internal/handlers/legacy/user.go", Severity::Low),
+            ("internal/handlers/user.go", Severity::High),
+        ]
+    );
+}
@@ -0,0 +1,94 @@
+use engine::baseline::{assign_fingerprints, compare, Baseline};
+use engine::config::Severity;
+use engine::scanner::Issue;
+use tempfile::tempdir;
+
+fn issue(rule_id: &str, line_number: usize) -> Issue {
+    Issue {
+        rule_id: rule_id.into(),
+        title: "Finding".into(),
+        file_path: "main.go".into(),
+        line_number,
+        severity: Severity::High,
+        ..Default::default()
+    }
+}
+
+fn fingerprints(content: &str, mut issues: Vec<Issue>) -> Vec<String> {
+    assign_fingerprints(&mut issues, content);
+    issues.into_iter().map(|i| i.fingerprint.unwrap()).collect()
+}
+
+#[test]
+fn fingerprint_survives_inserted_lines_and_reindentation() {
+    let before = "package main\n\nfunc f() {\n    db.Query(q)\n}\n";
+    let after =
+        "package main\n\nimport \"fmt\"\n\nfunc f() {\n    fmt.Println(1)\n\t\tdb.Query(q)\n}\n";
+    let old = fingerprints(before, vec![issue("sql-injection-go", 4)]);
+    let new = fingerprints(after, vec![issue("sql-injection-go", 7)]);
+    assert_eq!(old, new);
+}
+
+#[test]
+fn fingerprint_distinguishes_rules_and_repeated_code() {
+    let content = "db.Query(q)\ndb.Query(q)\n";
+    let fps = fingerprints(
+        content,
+        vec![
+            issue("sql-injection-go", 1),
+            issue("sql-injection-go", 2),
+            issue("xss-go", 1),
+        ],
+    );
+    assert_ne!(fps[0], fps[1]);
+    assert_ne!(fps[0], fps[2]);
+}
+
+#[test]
+fn baseline_downgrades_known_findings_only() {
+    let content = "db.Query(a)\ndb.Query(b)\n";
+    let mut known = vec![issue("sql-injection-go", 1)];
+    assign_fingerprints(&mut known, content);
+    let baseline = Baseline::from_issues(&known);
+
+    let dir = tempdir().unwrap();
+    let path = dir.path().join("baseline.json");
+    baseline.save(&path).unwrap();
+    let baseline = Baseline::load(&path).unwrap();
+
+    let mut issues = vec![issue("sql-injection-go", 1), issue("sql-injection-go", 2)];
+    assign_fingerprints(&mut issues, content);
+    assert_eq!(baseline.apply(&mut issues), 1);
+    assert!(issues[0].baselined);
+    assert_eq!(issues[0].severity, Severity::Info);
+    assert!(!issues[1].baselined);
+    assert_eq!(issues[1].severity, Severity::High);
+}
+
+#[test]
+fn compare_matches_findings_that_moved() {
+    let before = "package main\n\nfunc f() {\n    db.Query(a)\n    db.Query(b)\n}\n";
+    let after =
+        "package main\n\n// f runs queries.\nfunc f() {\n    db.Query(b)\n    db.Query(c)\n}\n";
+    let mut old = vec![issue("sql-injection-go", 4), issue("sql-injection-go", 5)];
+    assign_fingerprints(&mut old, before);
+    let mut new = vec![issue("sql-injection-go", 5), issue("sql-injection-go", 6)];
+    assign_fingerprints(&mut new, after);
+
+    let comparison = compare(&old, &new);
+    let lines = |issues: &[engine::scanner::Issue]| -> Vec<usize> {
+        issues.iter().map(|i| i.line_number).collect()
+    };
+    assert_eq!(lines(&comparison.added), vec![6]);
+    assert_eq!(lines(&comparison.fixed), vec![4]);
+    assert_eq!(lines(&comparison.unchanged), vec![5]);
+}
+
+#[test]
+fn invalid_baseline_is_a_config_error() {
+    let dir = tempdir().unwrap();
+    let path = dir.path().join("baseline.json");
+    std::fs::write(&path, "not json").unwrap();
+    let err = Baseline::load(&path).unwrap_err();
+    assert!(matches!(err, engine::error::EngineError::Config(_)));
+}
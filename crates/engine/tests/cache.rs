@@ -0,0 +1,91 @@
+use engine::analyzer::{Analyzer, CancellationToken};
+use engine::cache::AnalysisCache;
+use engine::config::{Config, Severity};
+use engine::scanner::Issue;
+use std::fs;
+use tempfile::tempdir;
+
+const SECRET: &str = "api_key = \"ABCDEFGHIJKLMNOPQRSTUVWX\"\n";
+
+#[test]
+fn key_depends_on_content_path_and_detector_config() {
+    let config = Config::default();
+    let cache = AnalysisCache::new("unused", &config);
+    let key = cache.key("a.go", "x");
+    assert_eq!(key.len(), 64);
+    assert_eq!(key, cache.key("a.go", "x"));
+    assert_ne!(key, cache.key("a.go", "y"));
+    assert_ne!(key, cache.key("b.go", "x"));
+
+    let mut changed = config.clone();
+    changed.rules.xss_go.enabled = false;
+    assert_ne!(key, AnalysisCache::new("unused", &changed).key("a.go", "x"));
+}
+
+#[test]
+fn key_with_dependencies_changes_with_them() {
+    let cache = AnalysisCache::new("unused", &Config::default());
+    let key = cache.key_with("a.go", "x", "package-1");
+    assert_eq!(key, cache.key_with("a.go", "x", "package-1"));
+    assert_ne!(key, cache.key_with("a.go", "x", "package-2"));
+    assert_ne!(key, cache.key("a.go", "x"));
+}
+
+#[test]
+fn reuses_cached_findings_for_unchanged_files() {
+    let dir = tempdir().unwrap();
+    let cache_dir = dir.path().join("cache");
+    let file = dir.path().join("config.txt");
+    fs::write(&file, SECRET).unwrap();
+    let path = file.to_string_lossy().into_owned();
+
+    let mut config = Config::default();
+    config.scan.cache_dir = Some(cache_dir.to_string_lossy().into());
+    let scan = |config: &Config| {
+        Analyzer::new(config.clone())
+            .scan_files(&[&file], &CancellationToken::new())
+            .unwrap()
+    };
+
+    let first = scan(&config);
+    assert_eq!(first.len(), 1);
+    let cache = AnalysisCache::new(&cache_dir, &config);
+    let key = cache.key(&path, SECRET);
+    assert!(cache.get(&key).is_some());
+
+    // Replace the entry to prove the second run reads it instead of scanning.
+    cache.put(
+        &key,
+        &[Issue {
+            rule_id: "secrets".into(),
+            title: "Cached".into(),
+            file_path: path.clone(),
+            line_number: 1,
+            severity: Severity::Low,
+            ..Default::default()
+        }],
+    );
+    let second = scan(&config);
+    assert_eq!(second.len(), 1);
+    assert!(second[0].message.starts_with("Cached"));
+
+    // A different detector configuration misses the cache.
+    config.rules.secrets.severity = Severity::Critical;
+    let third = scan(&config);
+    assert_eq!(third[0].severity, Severity::Critical);
+    assert!(third[0].message.starts_with("Potential Secret Found"));
+}
+
+#[test]
+fn corrupt_entries_are_treated_as_misses() {
+    let dir = tempdir().unwrap();
+    let cache = AnalysisCache::new(dir.path(), &Config::default());
+    let key = cache.key("a.go", "x");
+    fs::create_dir_all(dir.path().join(&key[..2])).unwrap();
+    fs::write(
+        dir.path().join(&key[..2]).join(format!("{}.json", key)),
+        "{",
+    )
+    .unwrap();
+    assert!(cache.get(&key).is_none());
+}
@@ -0,0 +1,90 @@
+use engine::config::{Confidence, Config};
+use engine::scanner::{CommandInjectionGoScanner, Issue, Scanner};
+
+fn scan(content: &str) -> Vec<Issue> {
+    CommandInjectionGoScanner
+        .scan("server.go", content, &Config::default())
+        .expect("scan should work")
+}
+
+#[test]
+fn flags_tainted_shell_scripts() {
+    let content = r#"
+func lookup(w http.ResponseWriter, r *http.Request) {
+    host := r.URL.Query().Get("host")
+    script := "nslookup " + host
+    exec.Command("sh", "-c", script).Run()
+    exec.CommandContext(r.Context(), "/bin/bash", "-c", "ping -c1 "+host).Run()
+}
+"#;
+    let issues = scan(content);
+    let lines: Vec<usize> = issues.iter().map(|i| i.line_number).collect();
+    assert_eq!(lines, vec![5, 6]);
+    let issue = &issues[0];
+    assert_eq!(issue.rule_id, "command-injection-go");
+    assert_eq!(issue.column, Some(5));
+    assert_eq!(issue.end_column, Some(17));
+    assert_eq!(issue.confidence, Confidence::High);
+    assert!(issue.description.contains("`script`"));
+    assert!(issue.description.contains("shell"));
+}
+
+#[test]
+fn flags_tainted_program_names_and_arguments() {
+    let content = r#"
+func run(w http.ResponseWriter, r *http.Request) {
+    tool := r.FormValue("tool")
+    exec.Command(tool, "--version").Run()
+    exec.Command("git", "log", mux.Vars(r)["branch"]).Run()
+}
+"#;
+    let issues = scan(content);
+    let confidence: Vec<(usize, Confidence)> = issues
+        .iter()
+        .map(|i| (i.line_number, i.confidence))
+        .collect();
+    assert_eq!(
+        confidence,
+        vec![(4, Confidence::High), (5, Confidence::Medium)]
+    );
+    assert!(issues[0].description.contains("selects the program"));
+    assert!(issues[1].description.contains("as an argument"));
+}
+
+#[test]
+fn fixed_and_sanitized_arguments_are_not_flagged() {
+    let content = r#"
+func lookup(w http.ResponseWriter, r *http.Request) {
+    exec.Command("nslookup", "-type=A", "example.com").Run()
+    exec.Command("sh", "-c", "uptime").Run()
+    n, _ := strconv.Atoi(r.FormValue("n"))
+    exec.Command("head", "-n", strconv.Itoa(n), "log.txt").Run()
+    host := shellescape.Quote(r.FormValue("host"))
+    exec.Command("sh", "-c", "nslookup -- "+host).Run()
+}
+"#;
+    let issues = scan(content);
+    // The quoted script is still assembled by concatenation.
+    assert_eq!(issues.len(), 1);
+    assert_eq!(issues[0].line_number, 8);
+    assert_eq!(issues[0].confidence, Confidence::Low);
+}
+
+#[test]
+fn falls_back_to_per_line_matching_on_unbalanced_braces() {
+    let content = r#"
+func lookup(w http.ResponseWriter, r *http.Request) {
+    exec.Command("sh", "-c", "nslookup "+r.FormValue("host")).Run()
+    exec.Command("sh", "-c", "nslookup "+host).Run()
+    exec.Command("nslookup", host).Run()
+"#;
+    let issues = scan(content);
+    let confidence: Vec<(usize, Confidence)> = issues
+        .iter()
+        .map(|i| (i.line_number, i.confidence))
+        .collect();
+    assert_eq!(
+        confidence,
+        vec![(3, Confidence::High), (4, Confidence::Low)]
+    );
+}
@@ -0,0 +1,77 @@
+use engine::config::{Config, Severity};
+use engine::error::Result;
+use engine::scanner::{register_scanner, rule_info, AnalysisContext, Issue, Scanner};
+use engine::ReviewEngine;
+
+fn diff_for_file(path: &str, line: &str) -> String {
+    format!(
+        "diff --git a/{0} b/{0}\n--- a/{0}\n+++ b/{0}\n@@ -0,0 +1 @@\n+{1}\n",
+        path, line
+    )
+}
+
+/// Flags every file, but panics on files whose name contains `boom`.
+struct FlakyScanner;
+
+impl Scanner for FlakyScanner {
+    fn name(&self) -> &'static str {
+        "Flaky Scanner"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let file_path = ctx.file_path;
+        if file_path.contains("boom") {
+            panic!("detector bug");
+        }
+        Ok(vec![Issue {
+            rule_id: "secrets".into(),
+            title: "Flagged".into(),
+            file_path: file_path.into(),
+            line_number: 1,
+            severity: Severity::High,
+            ..Default::default()
+        }])
+    }
+}
+
+#[tokio::test]
+async fn merges_results_deterministically_and_recovers_from_panics() {
+    // Replace the built-in secrets scanner for this test binary only.
+    let info = rule_info("secrets").unwrap();
+    register_scanner(info, || Box::new(FlakyScanner));
+
+    let temp = tempfile::tempdir().unwrap();
+    let names = ["d.txt", "boom.txt", "a.txt", "c.txt", "b.txt"];
+    let mut diff = String::new();
+    for name in names {
+        std::fs::write(temp.path().join(name), "hello").unwrap();
+        diff.push_str(&diff_for_file(name, "hello"));
+    }
+
+    let mut config = Config::default();
+    config.rules.sql_injection_go.enabled = false;
+    config.rules.http_timeouts_go.enabled = false;
+    config.rules.xss_go.enabled = false;
+    config.rules.conventions.enabled = false;
+    config.scan.concurrency = Some(4);
+    let engine = ReviewEngine::new(config).unwrap();
+    std::env::set_current_dir(temp.path()).unwrap();
+    let report = engine.run(&diff).await.unwrap();
+
+    let found: Vec<(&str, &str)> = report
+        .issues
+        .iter()
+        .map(|i| (i.file_path.as_str(), i.rule_id.as_str()))
+        .collect();
+    assert_eq!(
+        found,
+        vec![
+            ("a.txt", "secrets"),
+            ("b.txt", "secrets"),
+            ("boom.txt", "internal-error"),
+            ("c.txt", "secrets"),
+            ("d.txt", "secrets"),
+        ]
+    );
+    assert!(report.issues[2].description.contains("detector bug"));
+}
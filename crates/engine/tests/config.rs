@@ -1,4 +1,5 @@
 use engine::config::{Config, Provider, Severity};
+use engine::error::EngineError;
 use std::time::{SystemTime, UNIX_EPOCH};
 use std::{env, fs};
 
@@ -60,3 +61,94 @@ fn default_config_is_sane() {
     assert!(config.rules.secrets.enabled);
     assert_eq!(config.rules.secrets.severity, Severity::High);
 }
+
+#[test]
+fn rule_overrides_keep_unset_values_at_defaults() {
+    let config: Config = toml::from_str(
+        r#"
+[rules.xss-go]
+enabled = false
+
+[rules.sql-injection-go]
+severity = "info"
+"#,
+    )
+    .expect("config should parse");
+
+    assert!(!config.rules.xss_go.enabled);
+    assert_eq!(config.rules.xss_go.severity, Severity::High);
+    assert!(config.rules.sql_injection_go.enabled);
+    assert_eq!(config.rules.sql_injection_go.severity, Severity::Info);
+    assert_eq!(config.rules.secrets, Config::default().rules.secrets);
+}
+
+#[test]
+fn unknown_rule_id_is_a_config_error() {
+    let dir = tempfile::tempdir().unwrap();
+    let path = dir.path().join("reviewlens.toml");
+    fs::write(&path, "[rules.sql-injection]\nenabled = false\n").unwrap();
+
+    let err = Config::load_from_path(&path).unwrap_err();
+    assert!(matches!(err, EngineError::Config(_)));
+    let message = err.to_string();
+    assert!(
+        message.contains("unknown field `sql-injection`"),
+        "{}",
+        message
+    );
+    assert!(message.contains("sql-injection-go"), "{}", message);
+}
+
+#[test]
+fn discover_walks_up_to_the_closest_config() {
+    let dir = tempfile::tempdir().unwrap();
+    let nested = dir.path().join("services").join("api");
+    fs::create_dir_all(&nested).unwrap();
+    assert_eq!(Config::discover(&nested), None);
+
+    fs::write(dir.path().join("reviewlens.toml"), "").unwrap();
+    let found = Config::discover(&nested).expect("config should be found");
+    assert_eq!(
+        found,
+        dir.path().canonicalize().unwrap().join("reviewlens.toml")
+    );
+
+    fs::write(nested.join("reviewlens.toml"), "").unwrap();
+    let found = Config::discover(&nested).expect("config should be found");
+    assert_eq!(
+        found,
+        nested.canonicalize().unwrap().join("reviewlens.toml")
+    );
+}
+
+#[test]
+fn path_severity_overrides_are_validated() {
+    let dir = tempfile::tempdir().unwrap();
+    let path = dir.path().join("reviewlens.toml");
+    fs::write(
+        &path,
+        "[[path-severity-overrides]]\npaths = [\"src/[\"]\nadjust = 1\n",
+    )
+    .unwrap();
+    let err = Config::load_from_path(&path).unwrap_err();
+    assert!(matches!(err, EngineError::Config(_)));
+    assert!(
+        err.to_string().contains("path-severity-overrides"),
+        "{}",
+        err
+    );
+
+    fs::write(
+        &path,
+        "[[path-severity-overrides]]\npaths = []\nadjust = 1\n",
+    )
+    .unwrap();
+    let err = Config::load_from_path(&path).unwrap_err();
+    assert!(err.to_string().contains("has no paths"), "{}", err);
+
+    assert_eq!(Severity::Critical.adjusted(1), Severity::Critical);
+    assert_eq!(Severity::High.adjusted(1), Severity::Critical);
+    assert_eq!(Severity::Medium.adjusted(-1), Severity::Low);
+    assert_eq!(Severity::Low.adjusted(-2), Severity::Low);
+    assert_eq!(Severity::Info.adjusted(2), Severity::Info);
+}
@@ -0,0 +1,117 @@
+use engine::config::{Confidence, Config};
+use engine::fix::apply_edits;
+use engine::scanner::{ContextPropagationGoScanner, Issue, Scanner};
+
+fn scan(content: &str) -> Vec<Issue> {
+    ContextPropagationGoScanner
+        .scan("client.go", content, &Config::default())
+        .expect("scan should work")
+}
+
+#[test]
+fn flags_requests_without_the_context_in_scope() {
+    let content = r#"
+func fetch(ctx context.Context, client *http.Client, url string) error {
+    resp, err := http.Get(url)
+    req, err := http.NewRequest("POST", url, body)
+    resp, err = client.Do(req)
+    return err
+}
+"#;
+    let issues = scan(content);
+    let found: Vec<(usize, Confidence)> = issues
+        .iter()
+        .map(|i| (i.line_number, i.confidence))
+        .collect();
+    assert_eq!(found, vec![(3, Confidence::High), (5, Confidence::High)]);
+
+    let get = &issues[0];
+    assert_eq!(get.rule_id, "context-propagation-go");
+    assert_eq!(get.column, Some(18));
+    assert_eq!(get.end_column, Some(26));
+    assert!(get.description.contains("`http.Get`"));
+    assert!(get
+        .suggested_fix
+        .as_deref()
+        .unwrap()
+        .contains("http.NewRequestWithContext(ctx, http.MethodGet, ...)"));
+    assert!(get.fix.is_none());
+
+    let send = &issues[1];
+    assert!(send.description.contains("line 4"));
+    assert_eq!(
+        send.diff.as_deref(),
+        Some("-req, err := http.NewRequest(\"POST\", url, body)\n+req, err := http.NewRequestWithContext(ctx, \"POST\", url, body)")
+    );
+    let (fixed, skipped) = apply_edits(content, &send.fix.as_ref().unwrap().edits).unwrap();
+    assert!(skipped.is_empty());
+    assert!(
+        fixed.contains("    req, err := http.NewRequestWithContext(ctx, \"POST\", url, body)\n")
+    );
+}
+
+#[test]
+fn uses_the_incoming_request_context_or_a_derived_one() {
+    let content = r#"
+func proxy(w http.ResponseWriter, r *http.Request) {
+    req, _ := http.NewRequest(http.MethodGet, upstream, nil)
+    http.DefaultClient.Do(req)
+}
+
+func poll(url string) {
+    ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+    defer cancel()
+    http.Post(url, "text/plain", nil)
+}
+"#;
+    let issues = scan(content);
+    let found: Vec<(usize, Confidence)> = issues
+        .iter()
+        .map(|i| (i.line_number, i.confidence))
+        .collect();
+    assert_eq!(found, vec![(4, Confidence::Medium), (10, Confidence::High)]);
+    assert_eq!(
+        issues[0].fix.as_ref().unwrap().edits[0].replacement,
+        "http.NewRequestWithContext(r.Context(), "
+    );
+    assert!(issues[1].description.contains("`ctx`"));
+}
+
+#[test]
+fn functions_without_a_context_are_not_flagged() {
+    let content = r#"
+func main() {
+    ctx := context.Background()
+    http.Get("https://example.com")
+}
+
+func init() {
+    req, _ := http.NewRequest("GET", "https://example.com", nil)
+    http.DefaultClient.Do(req)
+}
+
+func fetch(url string) {
+    http.Get(url)
+}
+
+func ignored(_ context.Context, url string) {
+    http.Get(url)
+}
+"#;
+    assert!(scan(content).is_empty());
+}
+
+#[test]
+fn requests_with_a_context_are_not_flagged() {
+    let content = r#"
+func fetch(ctx context.Context, client *http.Client, url string) error {
+    req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+    client.Do(req)
+    legacy, err := http.NewRequest("GET", url, nil)
+    legacy = legacy.WithContext(ctx)
+    client.Do(legacy)
+    return err
+}
+"#;
+    assert!(scan(content).is_empty());
+}
@@ -0,0 +1,115 @@
+use engine::config::{Confidence, Config, Severity};
+use engine::error::Result;
+use engine::scanner::{dedup_issues, register_scanner, rule_info, AnalysisContext, Issue, Scanner};
+use engine::ReviewEngine;
+
+fn issue(rule_id: &str, line_number: usize, confidence: Confidence) -> Issue {
+    Issue {
+        rule_id: rule_id.into(),
+        title: "Flagged".into(),
+        description: format!("{} with {:?} confidence", rule_id, confidence),
+        file_path: "server.go".into(),
+        line_number,
+        severity: Severity::High,
+        confidence,
+        ..Default::default()
+    }
+}
+
+#[test]
+fn keeps_the_most_confident_finding_of_each_rule_per_line() {
+    let issues = vec![
+        issue("xss-go", 4, Confidence::Low),
+        issue("xss-go", 4, Confidence::High),
+        issue("secrets", 4, Confidence::Medium),
+        issue("xss-go", 4, Confidence::Medium),
+        issue("xss-go", 5, Confidence::Low),
+    ];
+    let found: Vec<(usize, String, Confidence)> = dedup_issues(issues)
+        .into_iter()
+        .map(|i| (i.line_number, i.rule_id, i.confidence))
+        .collect();
+    assert_eq!(
+        found,
+        vec![
+            (4, "secrets".to_string(), Confidence::Medium),
+            (4, "xss-go".to_string(), Confidence::High),
+            (5, "xss-go".to_string(), Confidence::Low),
+        ]
+    );
+}
+
+#[test]
+fn result_does_not_depend_on_input_order() {
+    let mut fixed = issue("xss-go", 4, Confidence::High);
+    fixed.column = Some(9);
+    fixed.fix = Some(engine::fix::Fix {
+        description: "Escape".into(),
+        edits: vec![],
+    });
+    let mut critical = issue("xss-go", 4, Confidence::High);
+    critical.severity = Severity::Critical;
+    let plain = issue("xss-go", 4, Confidence::High);
+
+    let orders = [
+        vec![fixed.clone(), critical.clone(), plain.clone()],
+        vec![plain.clone(), fixed.clone(), critical.clone()],
+        vec![critical.clone(), plain.clone(), fixed.clone()],
+    ];
+    for issues in orders {
+        let kept = dedup_issues(issues);
+        assert_eq!(kept.len(), 1);
+        assert_eq!(kept[0].severity, Severity::Critical);
+    }
+
+    let kept = dedup_issues(vec![plain.clone(), fixed.clone()]);
+    assert!(kept[0].fix.is_some());
+    let kept = dedup_issues(vec![fixed, plain]);
+    assert!(kept[0].fix.is_some());
+}
+
+/// Reports the same sink as the built-in XSS rule, with lower confidence.
+struct InlineXssScanner;
+
+impl Scanner for InlineXssScanner {
+    fn name(&self) -> &'static str {
+        "Inline XSS Scanner"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        let file_path = ctx.file_path;
+        Ok(vec![Issue {
+            rule_id: "xss-go".into(),
+            title: "Potential Cross-Site Scripting".into(),
+            file_path: file_path.into(),
+            line_number: 5,
+            severity: Severity::High,
+            confidence: Confidence::Low,
+            ..Default::default()
+        }])
+    }
+}
+
+#[test]
+fn duplicates_from_different_scanners_are_collapsed() {
+    // Replace the built-in HTTP timeouts scanner for this test binary only.
+    let info = rule_info("http-timeouts-go").unwrap();
+    register_scanner(info, || Box::new(InlineXssScanner));
+
+    let source = r#"package main
+
+func greet(w http.ResponseWriter, r *http.Request) {
+    user := r.URL.Query().Get("user")
+    fmt.Fprintf(w, "<p>"+user+"</p>")
+}
+"#;
+    let engine = ReviewEngine::new(Config::default()).unwrap();
+    let report = engine.run_source("server.go", source.as_bytes()).unwrap();
+
+    let found: Vec<(usize, &str, Confidence)> = report
+        .issues
+        .iter()
+        .map(|i| (i.line_number, i.rule_id.as_str(), i.confidence))
+        .collect();
+    assert_eq!(found, vec![(5, "xss-go", Confidence::High)]);
+}
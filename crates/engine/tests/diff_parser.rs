@@ -52,6 +52,7 @@ rename to new.txt
     assert_eq!(files.len(), 1);
     let file = &files[0];
     assert_eq!(file.path, "new.txt");
+    assert_eq!(file.old_path.as_deref(), Some("old.txt"));
     assert!(file.hunks.is_empty());
 }
 
@@ -111,3 +112,70 @@ fn parse_multiple_hunks() {
     assert!(matches!(h2.lines[2], Line::Added(ref l) if l == "line5mod"));
     assert!(matches!(h2.lines[3], Line::Added(ref l) if l == "line6"));
 }
+
+#[test]
+fn parse_new_file_diff() {
+    let diff = r#"diff --git a/new.go b/new.go
+new file mode 100644
+index 0000000..3b18e51
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,2 @@
++package main
++func main() {}
+"#;
+
+    let files = diff_parser::parse(diff).expect("should parse");
+    assert_eq!(files.len(), 1);
+    let file = &files[0];
+    assert_eq!(file.path, "new.go");
+    assert_eq!(file.old_path, None);
+    assert!(!file.deleted);
+    assert_eq!(file.hunks[0].new_start, 1);
+    assert_eq!(file.hunks[0].lines.len(), 2);
+}
+
+#[test]
+fn parse_deleted_file_diff() {
+    let diff = r#"diff --git a/gone.go b/gone.go
+deleted file mode 100644
+index 3b18e51..0000000
+--- a/gone.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package main
+-func main() {}
+"#;
+
+    let files = diff_parser::parse(diff).expect("should parse");
+    assert_eq!(files.len(), 1);
+    let file = &files[0];
+    assert_eq!(file.path, "gone.go");
+    assert!(file.deleted);
+}
+
+#[test]
+fn parse_rename_with_changes() {
+    use engine::diff_parser::Line;
+
+    let diff = r#"diff --git a/old.go b/new.go
+similarity index 80%
+rename from old.go
+rename to new.go
+index 3b18e51..a1b2c3d 100644
+--- a/old.go
++++ b/new.go
+@@ -1,2 +1,2 @@
+ package main
+-func old() {}
++func renamed() {}
+"#;
+
+    let files = diff_parser::parse(diff).expect("should parse");
+    assert_eq!(files.len(), 1);
+    let file = &files[0];
+    assert_eq!(file.path, "new.go");
+    assert_eq!(file.old_path.as_deref(), Some("old.go"));
+    assert!(!file.deleted);
+    assert!(matches!(&file.hunks[0].lines[2], Line::Added(l) if l == "func renamed() {}"));
+}
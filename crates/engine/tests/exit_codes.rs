@@ -1,4 +1,7 @@
+use engine::config::{Category, Severity};
 use engine::error::EngineError;
+use engine::findings_exit_code;
+use engine::scanner::Issue;
 
 fn map_error_to_exit_code(err: anyhow::Error) -> i32 {
     if let Some(engine_error) = err.downcast_ref::<EngineError>() {
@@ -23,3 +26,64 @@ fn runtime_error_returns_exit_code_three() {
     let err: anyhow::Error = EngineError::Io(io_err).into();
     assert_eq!(map_error_to_exit_code(err), 3);
 }
+
+fn issue(severity: Severity) -> Issue {
+    Issue {
+        rule_id: "secrets".into(),
+        severity,
+        ..Default::default()
+    }
+}
+
+#[test]
+fn findings_at_or_above_threshold_fail() {
+    let issues = vec![issue(Severity::Low), issue(Severity::High)];
+    assert_eq!(findings_exit_code(&issues, &Severity::High, &[]), 1);
+    assert_eq!(findings_exit_code(&issues, &Severity::Medium, &[]), 1);
+    assert_eq!(findings_exit_code(&issues, &Severity::Critical, &[]), 0);
+}
+
+#[test]
+fn findings_below_threshold_or_baselined_pass() {
+    assert_eq!(findings_exit_code(&[], &Severity::Info, &[]), 0);
+    assert_eq!(
+        findings_exit_code(&[issue(Severity::Medium)], &Severity::High, &[]),
+        0
+    );
+
+    let mut known = issue(Severity::Critical);
+    known.baselined = true;
+    assert_eq!(findings_exit_code(&[known], &Severity::Info, &[]), 0);
+}
+
+#[test]
+fn only_selected_categories_fail() {
+    let style = Issue {
+        rule_id: "conventions".into(),
+        severity: Severity::Critical,
+        category: Category::Style,
+        ..Default::default()
+    };
+    let security = Issue {
+        category: Category::Security,
+        ..issue(Severity::High)
+    };
+    let issues = vec![style.clone(), security];
+
+    assert_eq!(
+        findings_exit_code(&[style.clone()], &Severity::High, &[]),
+        1
+    );
+    assert_eq!(
+        findings_exit_code(&[style], &Severity::High, &[Category::Security]),
+        0
+    );
+    assert_eq!(
+        findings_exit_code(&issues, &Severity::High, &[Category::Security]),
+        1
+    );
+    assert_eq!(
+        findings_exit_code(&issues, &Severity::Critical, &[Category::Security]),
+        0
+    );
+}
@@ -0,0 +1,120 @@
+use engine::analyzer::{Analyzer, CancellationToken};
+use engine::config::{AnalysisScope, Config};
+use engine::scanner::{
+    AnalysisContext, Scanner, ScopeIndex, SqlInjectionGoScanner, SsrfGoScanner, TraceKind,
+    XssGoScanner,
+};
+use std::fs;
+use tempfile::tempdir;
+
+const GREET: &str = r#"package main
+
+func greet(w http.ResponseWriter, r *http.Request) {
+    user := r.URL.Query().Get("user")
+    message := "<p>Hello, " + user + "</p>"
+    fmt.Fprintf(w, message)
+}
+"#;
+
+fn steps(trace: &[engine::scanner::TraceStep]) -> Vec<(TraceKind, &str, usize)> {
+    trace
+        .iter()
+        .map(|step| (step.kind, step.expression.as_str(), step.line))
+        .collect()
+}
+
+#[test]
+fn traces_follow_the_data_from_source_to_sink() {
+    let issues = XssGoScanner
+        .scan("main.go", GREET, &Config::default())
+        .unwrap();
+    assert_eq!(issues.len(), 1);
+    assert_eq!(
+        steps(&issues[0].trace),
+        vec![
+            (TraceKind::Source, r#"r.URL.Query().Get("user")"#, 4),
+            (
+                TraceKind::Concat,
+                r#"message := "<p>Hello, " + user + "</p>""#,
+                5
+            ),
+            (TraceKind::Sink, "fmt.Fprintf(w, message)", 6),
+        ]
+    );
+}
+
+#[test]
+fn traces_record_assignments_calls_and_direct_sources() {
+    let content = r#"
+func handler(w http.ResponseWriter, r *http.Request) {
+    id := r.FormValue("id")
+    key := id
+    name := strings.TrimSpace(key)
+    rows, err := db.Query(name)
+    http.Get(r.FormValue("url"))
+}
+"#;
+    let config = Config::default();
+    let issues = SqlInjectionGoScanner
+        .scan("h.go", content, &config)
+        .unwrap();
+    let trace = &issues[0].trace;
+    assert_eq!(
+        steps(trace),
+        vec![
+            (TraceKind::Source, r#"r.FormValue("id")"#, 3),
+            (TraceKind::Assign, "key := id", 4),
+            (TraceKind::Call, "name := strings.TrimSpace(key)", 5),
+            (TraceKind::Sink, "db.Query(name)", 6),
+        ]
+    );
+
+    let issues = SsrfGoScanner.scan("h.go", content, &config).unwrap();
+    assert_eq!(
+        steps(&issues[0].trace),
+        vec![
+            (TraceKind::Source, r#"r.FormValue("url")"#, 7),
+            (TraceKind::Sink, r#"http.Get(r.FormValue("url"))"#, 7),
+        ]
+    );
+}
+
+#[test]
+fn traces_start_at_parameters_in_wider_scopes() {
+    let handler = "package api\n\nfunc h(w http.ResponseWriter, r *http.Request) {\n    fetch(r.FormValue(\"url\"))\n}\n";
+    let helper = "package api\n\nfunc fetch(target string) {\n    http.Get(target)\n}\n";
+    let files = vec![
+        ("api/h.go".to_string(), handler.to_string()),
+        ("api/fetch.go".to_string(), helper.to_string()),
+    ];
+    let config = Config::default();
+    let index = ScopeIndex::new(AnalysisScope::Package, &files, &config);
+    let ctx = AnalysisContext::new("api/fetch.go", helper, &config).with_scope(Some(&index));
+    let issues = SsrfGoScanner.check(&ctx).unwrap();
+    assert_eq!(
+        steps(&issues[0].trace),
+        vec![
+            (TraceKind::Parameter, "target", 3),
+            (TraceKind::Sink, "http.Get(target)", 4),
+        ]
+    );
+}
+
+#[test]
+fn analyzer_only_keeps_traces_when_explaining() {
+    let dir = tempdir().unwrap();
+    let path = dir.path().join("main.go");
+    fs::write(&path, GREET).unwrap();
+    let cancel = CancellationToken::new();
+
+    let findings = Analyzer::new(Config::default())
+        .scan_files(&[&path], &cancel)
+        .unwrap();
+    assert_eq!(findings.len(), 1);
+    assert!(findings[0].trace.is_empty());
+
+    let mut config = Config::default();
+    config.scan.explain = true;
+    let findings = Analyzer::new(config).scan_files(&[&path], &cancel).unwrap();
+    assert_eq!(findings[0].trace.len(), 3);
+}
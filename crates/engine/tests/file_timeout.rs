@@ -0,0 +1,102 @@
+use std::time::{Duration, Instant};
+
+use engine::config::{Category, Confidence, Config, Severity};
+use engine::error::Result;
+use engine::scanner::{register_scanner, AnalysisContext, Issue, RuleInfo, Scanner};
+use engine::ReviewEngine;
+
+fn diff_for_file(path: &str, line: &str) -> String {
+    format!(
+        "diff --git a/{0} b/{0}\n--- a/{0}\n+++ b/{0}\n@@ -0,0 +1 @@\n+{1}\n",
+        path, line
+    )
+}
+
+/// Flags every file, but spins until the deadline on files containing
+/// `spin`.
+struct SpinningScanner;
+
+impl Scanner for SpinningScanner {
+    fn name(&self) -> &'static str {
+        "Spinning Scanner"
+    }
+
+    fn check(&self, ctx: &AnalysisContext) -> Result<Vec<Issue>> {
+        if ctx.content.contains("spin") {
+            // Bounded so a broken deadline fails the test instead of hanging.
+            let give_up = Instant::now() + Duration::from_secs(30);
+            while !ctx.expired() && Instant::now() < give_up {
+                std::thread::sleep(Duration::from_millis(10));
+            }
+        }
+        Ok(vec![Issue {
+            rule_id: "spinning-rule".into(),
+            title: "Flagged".into(),
+            file_path: ctx.file_path.into(),
+            line_number: 1,
+            severity: Severity::Medium,
+            confidence: Confidence::High,
+            ..Default::default()
+        }])
+    }
+}
+
+#[tokio::test]
+async fn files_exceeding_the_time_limit_are_reported_and_the_run_continues() {
+    register_scanner(
+        RuleInfo {
+            id: "spinning-rule",
+            short_description: "Spins on some files",
+            help_uri: "https://example.com/rules/spinning-rule",
+            category: Category::Style,
+            ..Default::default()
+        },
+        || Box::new(SpinningScanner),
+    );
+
+    let temp = tempfile::tempdir().unwrap();
+    let mut diff = String::new();
+    for (name, line) in [("a.txt", "hello"), ("slow.txt", "spin")] {
+        std::fs::write(temp.path().join(name), line).unwrap();
+        diff.push_str(&diff_for_file(name, line));
+    }
+
+    let mut config = Config::default();
+    config.scan.file_timeout = Some(1);
+    let engine = ReviewEngine::new(config).unwrap();
+    std::env::set_current_dir(temp.path()).unwrap();
+    let started = Instant::now();
+    let report = engine.run(&diff).await.unwrap();
+    assert!(started.elapsed() < Duration::from_secs(20));
+
+    let found: Vec<(&str, &str)> = report
+        .issues
+        .iter()
+        .map(|i| (i.file_path.as_str(), i.rule_id.as_str()))
+        .collect();
+    assert_eq!(
+        found,
+        vec![
+            ("a.txt", "spinning-rule"),
+            ("slow.txt", "internal-error"),
+            ("slow.txt", "spinning-rule"),
+        ]
+    );
+    let timeout = &report.issues[1];
+    assert_eq!(timeout.title, "Analysis Timed Out");
+    assert!(timeout.description.contains("Spinning Scanner"));
+    assert!(timeout.description.contains("1s"));
+}
+
+#[test]
+fn file_timeout_defaults_to_thirty_seconds_and_zero_disables_it() {
+    let mut config = Config::default();
+    assert_eq!(config.scan.file_timeout(), Some(Duration::from_secs(30)));
+    config.scan.file_timeout = Some(0);
+    assert_eq!(config.scan.file_timeout(), None);
+
+    let ctx = AnalysisContext::new("a.go", "", &config);
+    assert!(!ctx.expired());
+    let ctx = ctx.with_deadline(Some(Instant::now()));
+    assert!(ctx.expired());
+}
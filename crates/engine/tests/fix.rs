@@ -0,0 +1,79 @@
+use engine::fix::{apply_edits, apply_fixes, Fix, TextEdit};
+use engine::scanner::Issue;
+
+fn replace(line: usize, start: usize, end: usize, text: &str) -> TextEdit {
+    TextEdit {
+        start_line: line,
+        start_column: start,
+        end_line: line,
+        end_column: end,
+        replacement: text.into(),
+    }
+}
+
+#[test]
+fn applies_replacements_and_insertions() {
+    let content = "package main\n\nfunc f() {\n\tuse(ü, x)\n}\n";
+    let edits = vec![
+        replace(4, 9, 10, "y"),
+        TextEdit::insert(2, 1, "import \"html\"\n"),
+        replace(4, 6, 7, "z"),
+    ];
+    let (out, skipped) = apply_edits(content, &edits).unwrap();
+    assert!(skipped.is_empty());
+    assert_eq!(
+        out,
+        "package main\nimport \"html\"\n\nfunc f() {\n\tuse(z, y)\n}\n"
+    );
+}
+
+#[test]
+fn deduplicates_identical_edits_and_skips_overlaps() {
+    let content = "abcdef\n";
+    let import = TextEdit::insert(2, 1, "x\n");
+    let edits = vec![
+        import.clone(),
+        replace(1, 2, 5, "-"),
+        import,
+        replace(1, 4, 6, "+"),
+    ];
+    let (out, skipped) = apply_edits(content, &edits).unwrap();
+    assert_eq!(out, "a-ef\nx\n");
+    assert_eq!(skipped, vec![replace(1, 4, 6, "+")]);
+}
+
+#[test]
+fn edits_outside_the_file_are_errors() {
+    assert!(apply_edits("one\n", &[replace(3, 1, 2, "x")]).is_err());
+    assert!(apply_edits("one\n", &[replace(1, 2, 9, "x")]).is_err());
+    assert!(apply_edits("one\n", &[replace(1, 0, 2, "x")]).is_err());
+    assert!(apply_edits("one\n", &[replace(0, 1, 2, "x")]).is_err());
+}
+
+#[test]
+fn apply_fixes_rewrites_files_and_skips_baselined_issues() {
+    let dir = tempfile::tempdir().unwrap();
+    std::fs::write(dir.path().join("a.go"), "hello\n").unwrap();
+    std::fs::write(dir.path().join("b.go"), "hello\n").unwrap();
+    let issue = |file: &str, baselined: bool| Issue {
+        file_path: file.into(),
+        line_number: 1,
+        baselined,
+        fix: Some(Fix {
+            description: "Shout".into(),
+            edits: vec![replace(1, 1, 6, "HELLO")],
+        }),
+        ..Default::default()
+    };
+
+    let changed = apply_fixes(dir.path(), &[issue("a.go", false), issue("b.go", true)]).unwrap();
+    assert_eq!(changed, vec![dir.path().join("a.go")]);
+    assert_eq!(
+        std::fs::read_to_string(dir.path().join("a.go")).unwrap(),
+        "HELLO\n"
+    );
+    assert_eq!(
+        std::fs::read_to_string(dir.path().join("b.go")).unwrap(),
+        "hello\n"
+    );
+}
@@ -0,0 +1,93 @@
+use engine::config::{Category, Config, Severity};
+use engine::report::{GithubGenerator, ReportGenerator, ReviewReport, RuntimeMetadata};
+use engine::scanner::Issue;
+
+fn report_with(issues: Vec<Issue>) -> ReviewReport {
+    ReviewReport {
+        summary: "Issues".into(),
+        issues,
+        code_quality: vec![],
+        hotspots: vec![],
+        mermaid_diagram: None,
+        config: Config::default(),
+        metadata: RuntimeMetadata {
+            ruleset_version: "v1".into(),
+            model: None,
+            driver: "null".into(),
+            timings: engine::report::TimingInfo { total_ms: 0 },
+            index_warm: false,
+            files_scanned: 0,
+            files_skipped: 0,
+        },
+    }
+}
+
+#[test]
+fn emits_one_workflow_command_per_issue_by_severity() {
+    let sql = Issue {
+        rule_id: "sql-injection-go".into(),
+        category: Category::Security,
+        title: "Potential SQL Injection".into(),
+        description: "Dynamic SQL query construction detected.".into(),
+        file_path: "db/user.go".into(),
+        line_number: 12,
+        column: Some(5),
+        end_column: Some(20),
+        severity: Severity::Critical,
+        ..Default::default()
+    };
+    let timeout = Issue {
+        rule_id: "http-timeouts-go".into(),
+        title: "HTTP Request Without Timeout".into(),
+        description: "No timeout.".into(),
+        file_path: "client.go".into(),
+        line_number: 3,
+        severity: Severity::Medium,
+        ..Default::default()
+    };
+    let baselined = Issue {
+        rule_id: "secrets".into(),
+        category: Category::Security,
+        title: "Potential Secret Found".into(),
+        description: "Known.".into(),
+        file_path: "config.go".into(),
+        line_number: 1,
+        severity: Severity::Info,
+        baselined: true,
+        ..Default::default()
+    };
+
+    let out = GithubGenerator
+        .generate(&report_with(vec![sql, timeout, baselined]))
+        .unwrap();
+    let lines: Vec<&str> = out.lines().collect();
+    assert_eq!(
+        lines,
+        vec![
+            "::error file=db/user.go,line=12,col=5,endColumn=20,title=[security] Potential SQL Injection (sql-injection-go)::Dynamic SQL query construction detected.",
+            "::warning file=client.go,line=3,title=[correctness] HTTP Request Without Timeout (http-timeouts-go)::No timeout.",
+            "::notice file=config.go,line=1,title=[security] Potential Secret Found (secrets)::Known.",
+        ]
+    );
+}
+
+#[test]
+fn encodes_newlines_and_reserved_characters() {
+    let issue = Issue {
+        rule_id: "xss-go".into(),
+        category: Category::Security,
+        title: "XSS: reflected, unescaped".into(),
+        description: "Line one\nLine two at 100%".into(),
+        file_path: "web/a,b.go".into(),
+        line_number: 7,
+        severity: Severity::High,
+        suggested_fix: Some("Escape it.".into()),
+        ..Default::default()
+    };
+
+    let out = GithubGenerator.generate(&report_with(vec![issue])).unwrap();
+    assert_eq!(
+        out,
+        "::error file=web/a%2Cb.go,line=7,title=[security] XSS%3A reflected%2C unescaped (xss-go)::Line one%0ALine two at 100%25%0ASuggested fix: Escape it.\n"
+    );
+}
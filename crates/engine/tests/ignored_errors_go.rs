@@ -0,0 +1,190 @@
+use std::fs;
+
+use engine::config::{Confidence, Config};
+use engine::scanner::{IgnoredErrorsGoScanner, Issue, Scanner};
+
+fn scan(file_path: &str, content: &str) -> Vec<Issue> {
+    IgnoredErrorsGoScanner
+        .scan(file_path, content, &Config::default())
+        .expect("scan should work")
+}
+
+fn lines(issues: &[Issue]) -> Vec<usize> {
+    issues.iter().map(|i| i.line_number).collect()
+}
+
+#[test]
+fn flags_unchecked_random_reads() {
+    let content = r#"package keys
+
+import (
+	"crypto/rand"
+)
+
+func newKey() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	nonce := make([]byte, 12)
+	n, _ := rand.Read(nonce)
+	_ = n
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}
+"#;
+    let issues = scan("keys.go", content);
+    assert_eq!(lines(&issues), vec![9, 11]);
+    let issue = &issues[0];
+    assert_eq!(issue.rule_id, "ignored-errors-go");
+    assert_eq!(issue.column, Some(2));
+    assert_eq!(issue.end_column, Some(11));
+    assert_eq!(issue.confidence, Confidence::High);
+    assert!(issue
+        .description
+        .contains("`crypto/rand.Read` is discarded"));
+    assert!(issue.description.contains("predictable"));
+    assert!(issues[1].description.contains("is assigned to `_`"));
+}
+
+#[test]
+fn math_rand_and_aliases_are_told_apart() {
+    let content = r#"package keys
+
+import (
+	crand "crypto/rand"
+	"math/rand"
+)
+
+func fill(b []byte) {
+	rand.Read(b)
+	crand.Read(b)
+}
+"#;
+    assert_eq!(lines(&scan("keys.go", content)), vec![10]);
+}
+
+#[test]
+fn flags_unchecked_decodes_and_row_closes() {
+    let content = r#"package store
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+func load(db *sql.DB, raw []byte, other *sql.Rows) (*Settings, error) {
+	var s Settings
+	json.Unmarshal(raw, &s)
+	_ = json.Unmarshal(raw, &s)
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, err
+	}
+	rows, err := db.Query("SELECT name FROM users")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	other.Close()
+	file.Close()
+	return &s, rows.Close()
+}
+"#;
+    let issues = scan("store.go", content);
+    assert_eq!(lines(&issues), vec![10, 11, 20]);
+    assert!(issues[0].description.contains("`encoding/json.Unmarshal`"));
+    assert!(issues[2]
+        .description
+        .contains("`(*database/sql.Rows).Close`"));
+}
+
+#[test]
+fn copies_are_only_flagged_when_they_feed_a_security_decision() {
+    let content = r#"package upload
+
+import (
+	"crypto/sha256"
+	"io"
+	"os"
+)
+
+func verify(dst *os.File, src io.Reader, want []byte) bool {
+	io.Copy(dst, src)
+	h := sha256.New()
+	io.Copy(h, src)
+	n, _ := io.Copy(dst, src)
+	if n > maxUpload {
+		return false
+	}
+	m, _ := io.Copy(dst, src)
+	log.Printf("copied %d bytes", m)
+	return bytes.Equal(h.Sum(nil), want)
+}
+"#;
+    assert_eq!(lines(&scan("upload.go", content)), vec![12, 13]);
+}
+
+#[test]
+fn test_files_and_unlisted_functions_are_skipped() {
+    let content = r#"package keys
+
+import "crypto/rand"
+
+func TestKey(t *testing.T) {
+	rand.Read(buf)
+}
+"#;
+    assert!(scan("keys_test.go", content).is_empty());
+
+    let mut config = Config::default();
+    config.rules.ignored_errors_go.functions = vec!["example.com/app/audit.Record".into()];
+    let content = r#"package keys
+
+import (
+	"crypto/rand"
+
+	"example.com/app/audit"
+)
+
+func rotate() {
+	rand.Read(buf)
+	audit.Record("rotated")
+}
+"#;
+    let issues = IgnoredErrorsGoScanner
+        .scan("keys.go", content, &config)
+        .unwrap();
+    assert_eq!(lines(&issues), vec![11]);
+    assert!(issues[0]
+        .description
+        .contains("it reports a failure the caller must handle"));
+}
+
+#[test]
+fn function_list_is_validated_at_load() {
+    let dir = tempfile::tempdir().unwrap();
+    let path = dir.path().join("reviewlens.toml");
+    fs::write(
+        &path,
+        "[rules.ignored-errors-go]\nfunctions = [\"crypto/rand.Read\", \"(*sql.Rows.Close\"]\n",
+    )
+    .unwrap();
+    let message = Config::load_from_path(&path).unwrap_err().to_string();
+    assert!(
+        message.contains("rules.ignored-errors-go.functions: `(*sql.Rows.Close`"),
+        "{}",
+        message
+    );
+
+    fs::write(
+        &path,
+        "[rules.ignored-errors-go]\nfunctions = [\"crypto/rand.Read\", \"(example.com/app/store.Tx).Commit\"]\n",
+    )
+    .unwrap();
+    let config = Config::load_from_path(&path).unwrap();
+    assert_eq!(
+        config.rules.ignored_errors_go.functions,
+        vec!["crypto/rand.Read", "(example.com/app/store.Tx).Commit"]
+    );
+    assert!(config.rules.ignored_errors_go.enabled);
+}
@@ -0,0 +1,179 @@
+use engine::config::{Confidence, Config};
+use engine::scanner::{InsecureDeserializationGoScanner, Issue, Scanner};
+
+fn scan(content: &str) -> Vec<Issue> {
+    InsecureDeserializationGoScanner
+        .scan("api.go", content, &Config::default())
+        .expect("scan should work")
+}
+
+fn found(issues: &[Issue]) -> Vec<(usize, &str, Confidence)> {
+    issues
+        .iter()
+        .map(|i| (i.line_number, i.title.as_str(), i.confidence))
+        .collect()
+}
+
+#[test]
+fn flags_gob_decoding_of_request_bodies_into_interfaces() {
+    let content = r#"package api
+
+func load(w http.ResponseWriter, r *http.Request) {
+    r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+    var v interface{}
+    if err := gob.NewDecoder(r.Body).Decode(&v); err != nil {
+        return
+    }
+}
+
+func loadJob(w http.ResponseWriter, r *http.Request) {
+    r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+    dec := gob.NewDecoder(r.Body)
+    var job Job
+    dec.Decode(&job)
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(
+        found(&issues),
+        vec![(6, "Insecure Deserialization", Confidence::High)]
+    );
+    assert_eq!(issues[0].rule_id, "insecure-deserialization-go");
+    assert_eq!(issues[0].column, Some(15));
+    assert_eq!(issues[0].end_column, Some(48));
+    assert!(issues[0].description.contains("`r.Body`"));
+
+    // Registered types can fill interface fields of any target.
+    let registered = content.replace(
+        "package api\n",
+        "package api\n\nfunc init() {\n    gob.Register(Shell{})\n}\n",
+    );
+    assert_eq!(
+        found(&scan(&registered)),
+        vec![
+            (10, "Insecure Deserialization", Confidence::High),
+            (19, "Insecure Deserialization", Confidence::Medium),
+        ]
+    );
+}
+
+#[test]
+fn flags_untyped_json_bodies_without_a_size_limit() {
+    let content = r#"package api
+
+func create(w http.ResponseWriter, r *http.Request) {
+    data, err := io.ReadAll(r.Body)
+    if err != nil {
+        return
+    }
+    var payload map[string]interface{}
+    json.Unmarshal(data, &payload)
+}
+
+func update(w http.ResponseWriter, r *http.Request) {
+    body := http.MaxBytesReader(w, r.Body, 1<<20)
+    data, _ := io.ReadAll(body)
+    var payload map[string]any
+    json.Unmarshal(data, &payload)
+}
+
+func patch(c *gin.Context) {
+    var fields []any
+    json.NewDecoder(c.Request.Body).Decode(&fields)
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(
+        found(&issues),
+        vec![
+            (4, "Unbounded Request Body", Confidence::Low),
+            (9, "Untyped Decoding Without Size Limit", Confidence::Medium),
+            (
+                21,
+                "Untyped Decoding Without Size Limit",
+                Confidence::Medium
+            ),
+        ]
+    );
+    assert_eq!(issues[0].column, Some(18));
+    assert_eq!(issues[0].end_column, Some(36));
+    assert!(issues[1].description.contains("`payload`"));
+    assert!(issues[2].description.contains("`c.Request.Body`"));
+}
+
+#[test]
+fn typed_and_limited_reads_are_not_flagged() {
+    let content = r#"package api
+
+func limit(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+        next.ServeHTTP(w, r)
+    })
+}
+
+func create(w http.ResponseWriter, r *http.Request) {
+    var item Item
+    json.NewDecoder(r.Body).Decode(&item)
+}
+
+func fetch(resp *http.Response) {
+    var v interface{}
+    json.NewDecoder(resp.Body).Decode(&v)
+}
+"#;
+    assert!(scan(content).is_empty());
+
+    // Without the middleware, the typed read is still unbounded.
+    let unlimited = content.replace(
+        "        r.Body = http.MaxBytesReader(w, r.Body, 1<<20)\n",
+        "",
+    );
+    assert_eq!(
+        found(&scan(&unlimited)),
+        vec![(11, "Unbounded Request Body", Confidence::Low)]
+    );
+
+    let issues = InsecureDeserializationGoScanner
+        .scan("api_test.go", &unlimited, &Config::default())
+        .unwrap();
+    assert!(issues.is_empty());
+}
+
+#[test]
+fn decode_targets_on_the_next_line_do_not_panic() {
+    let content = r#"package api
+
+func init() {
+    gob.Register(Shell{})
+}
+
+func load(w http.ResponseWriter, r *http.Request) {
+    dec := gob.NewDecoder(r.Body)
+    var job Job
+    if err := dec.Decode(
+        &job); err != nil {
+        return
+    }
+}
+
+func loadAny(w http.ResponseWriter, r *http.Request) {
+    var v interface{}
+    if err := gob.NewDecoder(r.Body).Decode(
+        &v); err != nil {
+        return
+    }
+}
+"#;
+    let issues = scan(content);
+    // The targets are not followed, but the unlimited bodies are reported.
+    assert_eq!(
+        found(&issues),
+        vec![
+            (8, "Unbounded Request Body", Confidence::Low),
+            (18, "Unbounded Request Body", Confidence::Low),
+        ]
+    );
+    assert_eq!(issues[1].column, Some(15));
+    assert_eq!(issues[1].end_column, Some(37));
+}
@@ -0,0 +1,190 @@
+use engine::config::{Confidence, Config};
+use engine::scanner::{InsecureTlsGoScanner, Issue, Scanner};
+
+fn scan_file(file_path: &str, content: &str, config: &Config) -> Vec<Issue> {
+    InsecureTlsGoScanner
+        .scan(file_path, content, config)
+        .expect("scan should work")
+}
+
+fn scan(content: &str) -> Vec<Issue> {
+    scan_file("client.go", content, &Config::default())
+}
+
+fn found(issues: &[Issue]) -> Vec<(usize, Confidence)> {
+    issues
+        .iter()
+        .map(|i| (i.line_number, i.confidence))
+        .collect()
+}
+
+#[test]
+fn flags_skipped_verification() {
+    let content = r#"package client
+
+import "crypto/tls"
+
+func insecure() *tls.Config {
+    return &tls.Config{InsecureSkipVerify: true}
+}
+
+func assigned() *tls.Config {
+    cfg := &tls.Config{}
+    cfg.InsecureSkipVerify = true
+    return cfg
+}
+
+func verified() *tls.Config {
+    return &tls.Config{InsecureSkipVerify: false, ServerName: "api.example.com"}
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(
+        found(&issues),
+        vec![(6, Confidence::High), (11, Confidence::High)]
+    );
+    let issue = &issues[0];
+    assert_eq!(issue.rule_id, "insecure-tls-go");
+    assert_eq!(issue.title, "Insecure TLS Configuration");
+    assert_eq!(issue.column, Some(24));
+    assert_eq!(issue.end_column, Some(48));
+    assert!(issue.suggested_fix.as_deref().unwrap().contains("RootCAs"));
+}
+
+#[test]
+fn flags_verification_skipped_behind_a_variable_with_lower_confidence() {
+    let content = r#"package client
+
+import (
+    "crypto/tls"
+    "os"
+)
+
+func config(insecure bool) *tls.Config {
+    return &tls.Config{
+        InsecureSkipVerify: insecure,
+    }
+}
+
+func fromEnv() *tls.Config {
+    cfg := &tls.Config{}
+    cfg.InsecureSkipVerify = os.Getenv("TLS_INSECURE") == "1"
+    if cfg.InsecureSkipVerify == true {
+        return nil
+    }
+    return cfg
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(
+        found(&issues),
+        vec![(10, Confidence::Medium), (16, Confidence::Medium)]
+    );
+    assert!(issues[0].description.contains("`insecure`"));
+    assert!(issues[1]
+        .description
+        .contains("`os.Getenv(\"TLS_INSECURE\") == \"1\"`"));
+}
+
+#[test]
+fn flags_protocol_versions_older_than_tls_1_2() {
+    let content = r#"package server
+
+import "crypto/tls"
+
+var legacy = &tls.Config{MinVersion: tls.VersionTLS10}
+
+func configure(cfg *tls.Config) {
+    cfg.MinVersion = tls.VersionTLS11
+}
+
+var modern = &tls.Config{MinVersion: tls.VersionTLS12}
+
+var pinned = &tls.Config{
+    MinVersion: tls.VersionTLS13,
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(
+        found(&issues),
+        vec![(5, Confidence::High), (8, Confidence::High)]
+    );
+    assert!(issues[0].description.contains("`tls.VersionTLS10`"));
+    assert!(issues[1].description.contains("`tls.VersionTLS11`"));
+}
+
+#[test]
+fn flags_verification_callbacks_that_accept_everything() {
+    let content = r#"package client
+
+import (
+    "crypto/tls"
+    "crypto/x509"
+    "errors"
+)
+
+func acceptAll(rawCerts [][]byte, chains [][]*x509.Certificate) error {
+    return nil
+}
+
+func pinned(cs tls.ConnectionState) error {
+    if len(cs.PeerCertificates) == 0 {
+        return errors.New("no certificate")
+    }
+    return nil
+}
+
+func configs() []*tls.Config {
+    literal := &tls.Config{
+        InsecureSkipVerify: false,
+        VerifyPeerCertificate: func(rawCerts [][]byte, chains [][]*x509.Certificate) error {
+            return nil
+        },
+    }
+    named := &tls.Config{VerifyPeerCertificate: acceptAll}
+    checked := &tls.Config{VerifyConnection: pinned}
+    checked.VerifyConnection = func(cs tls.ConnectionState) error { return nil }
+    return []*tls.Config{literal, named, checked}
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(
+        found(&issues),
+        vec![
+            (23, Confidence::High),
+            (27, Confidence::High),
+            (29, Confidence::High),
+        ]
+    );
+    assert!(issues[0].description.contains("`VerifyPeerCertificate`"));
+    assert!(issues[2].description.contains("`VerifyConnection`"));
+}
+
+#[test]
+fn skips_test_files_unless_configured() {
+    let content = r#"package client
+
+import "crypto/tls"
+
+var insecure = &tls.Config{InsecureSkipVerify: true}
+"#;
+    let mut config = Config::default();
+    assert!(scan_file("client_test.go", content, &config).is_empty());
+
+    config.rules.insecure_tls_go.include_tests = true;
+    assert_eq!(scan_file("client_test.go", content, &config).len(), 1);
+}
+
+#[test]
+fn include_tests_is_read_from_the_rule_table() {
+    let config: Config = toml::from_str(
+        r#"
+[rules.insecure-tls-go]
+include-tests = true
+"#,
+    )
+    .unwrap();
+    assert!(config.rules.insecure_tls_go.include_tests);
+    assert!(config.rules.insecure_tls_go.enabled);
+    assert!(!Config::default().rules.insecure_tls_go.include_tests);
+}
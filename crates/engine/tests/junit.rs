@@ -0,0 +1,94 @@
+use engine::config::{Config, Severity};
+use engine::report::{JunitGenerator, ReportGenerator, ReviewReport, RuntimeMetadata};
+use engine::scanner::Issue;
+
+fn report_with(issues: Vec<Issue>) -> ReviewReport {
+    ReviewReport {
+        summary: "Issues".into(),
+        issues,
+        code_quality: vec![],
+        hotspots: vec![],
+        mermaid_diagram: None,
+        config: Config::default(),
+        metadata: RuntimeMetadata {
+            ruleset_version: "v1".into(),
+            model: None,
+            driver: "null".into(),
+            timings: engine::report::TimingInfo { total_ms: 1250 },
+            index_warm: false,
+            files_scanned: 2,
+            files_skipped: 0,
+        },
+    }
+}
+
+fn issue(rule_id: &str, file_path: &str, line_number: usize) -> Issue {
+    Issue {
+        rule_id: rule_id.into(),
+        title: "Potential Cross-Site Scripting".into(),
+        description: "Request data from `name` is written to the response.".into(),
+        file_path: file_path.into(),
+        line_number,
+        severity: Severity::High,
+        ..Default::default()
+    }
+}
+
+#[test]
+fn groups_findings_into_one_suite_per_rule() {
+    let mut xss = issue("xss-go", "server.go", 12);
+    xss.column = Some(5);
+    xss.suggested_fix = Some("Escape the value with html.EscapeString.".into());
+    let mut known = issue("secrets", "config.go", 3);
+    known.baselined = true;
+    let report = report_with(vec![
+        known,
+        xss,
+        issue("xss-go", "server.go", 20),
+        issue("secrets", "main.go", 1),
+    ]);
+
+    let out = JunitGenerator.generate(&report).unwrap();
+    assert!(out.starts_with("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"));
+    assert!(out.contains(
+        "<testsuites name=\"reviewlens\" tests=\"4\" failures=\"3\" errors=\"0\" skipped=\"1\" time=\"1.250\">"
+    ));
+    assert!(out.contains(
+        "<testsuite name=\"secrets\" tests=\"2\" failures=\"1\" errors=\"0\" skipped=\"1\">"
+    ));
+    assert!(out.contains(
+        "<testsuite name=\"xss-go\" tests=\"2\" failures=\"2\" errors=\"0\" skipped=\"0\">"
+    ));
+    assert!(out.find("name=\"secrets\"").unwrap() < out.find("name=\"xss-go\"").unwrap());
+    assert_eq!(out.matches("<testcase ").count(), 4);
+    assert_eq!(out.matches("<failure ").count(), 3);
+    assert!(out.contains("<skipped message=\"Recorded in the baseline\"/>"));
+
+    assert!(out.contains(
+        "<testcase name=\"server.go:12:5\" classname=\"xss-go\" file=\"server.go\" line=\"12\">"
+    ));
+    assert!(out.contains(
+        "<failure message=\"Potential Cross-Site Scripting\" type=\"high\">server.go:12:5\nRequest data from `name` is written to the response.\nSuggested fix: Escape the value with html.EscapeString.</failure>"
+    ));
+}
+
+#[test]
+fn escapes_xml_in_messages() {
+    let mut xss = issue("xss-go", "a&b.go", 1);
+    xss.title = "Uses <script> \"tags\"".into();
+    xss.description = "x < y && y > z\u{1}".into();
+    let out = JunitGenerator.generate(&report_with(vec![xss])).unwrap();
+
+    assert!(out.contains("file=\"a&amp;b.go\""));
+    assert!(out.contains("message=\"Uses &lt;script&gt; &quot;tags&quot;\""));
+    assert!(out.contains(">a&amp;b.go:1\nx &lt; y &amp;&amp; y &gt; z</failure>"));
+}
+
+#[test]
+fn empty_reports_have_no_suites() {
+    let out = JunitGenerator.generate(&report_with(vec![])).unwrap();
+    assert_eq!(
+        out,
+        "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<testsuites name=\"reviewlens\" tests=\"0\" failures=\"0\" errors=\"0\" skipped=\"0\" time=\"1.250\">\n</testsuites>\n"
+    );
+}
@@ -0,0 +1,216 @@
+use std::fs;
+
+use engine::config::{Confidence, Config};
+use engine::scanner::{Issue, MissingAuthGoScanner, Scanner};
+
+fn scan(file_path: &str, content: &str) -> Vec<Issue> {
+    MissingAuthGoScanner
+        .scan(file_path, content, &Config::default())
+        .expect("scan should work")
+}
+
+fn lines(issues: &[Issue]) -> Vec<usize> {
+    issues.iter().map(|i| i.line_number).collect()
+}
+
+#[test]
+fn flags_mutating_routes_without_auth_markers() {
+    let content = r#"package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func routes(r chi.Router) {
+	r.Get("/items", listItems)
+	r.Post("/items", createItem)
+	r.Delete("/items/{id}", deleteItem)
+	r.With(RequireAuth).Put("/items/{id}", updateItem)
+}
+
+func listItems(w http.ResponseWriter, r *http.Request) {}
+
+func createItem(w http.ResponseWriter, r *http.Request) {
+	user := CurrentUser(r)
+	store.Create(user, r.FormValue("name"))
+}
+
+func deleteItem(w http.ResponseWriter, r *http.Request) {
+	store.Delete(chi.URLParam(r, "id"))
+}
+
+func updateItem(w http.ResponseWriter, r *http.Request) {}
+"#;
+    let issues = scan("api.go", content);
+    assert_eq!(lines(&issues), vec![12]);
+    let issue = &issues[0];
+    assert_eq!(issue.rule_id, "missing-auth-go");
+    assert_eq!(issue.confidence, Confidence::Low);
+    assert_eq!(issue.column, Some(26));
+    assert_eq!(issue.end_column, Some(36));
+    assert!(issue
+        .description
+        .contains("`deleteItem` handles `DELETE /items/{id}`"));
+    assert!(issue.description.contains("`RequireAuth`"));
+}
+
+#[test]
+fn recognises_method_patterns_gorilla_and_inline_handlers() {
+    let content = r#"package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func register(mux *http.ServeMux, r *mux.Router) {
+	mux.HandleFunc("POST /accounts", createAccount)
+	mux.HandleFunc("GET /accounts", listAccounts)
+	r.HandleFunc("/accounts/{id}", removeAccount).Methods("DELETE")
+	r.HandleFunc("/accounts/{id}", showAccount).Methods("GET")
+	mux.HandleFunc("PATCH /profile", func(w http.ResponseWriter, r *http.Request) {
+		profiles.Update(r.FormValue("name"))
+	})
+	mux.HandleFunc("PUT /settings", func(w http.ResponseWriter, r *http.Request) {
+		if !HasPermission(r, "settings") {
+			return
+		}
+	})
+	mux.Handle("DELETE /sessions", http.HandlerFunc(endSession))
+}
+
+func createAccount(w http.ResponseWriter, r *http.Request) {}
+
+func listAccounts(w http.ResponseWriter, r *http.Request) {}
+
+func removeAccount(w http.ResponseWriter, r *http.Request) {}
+
+func showAccount(w http.ResponseWriter, r *http.Request) {}
+
+func endSession(w http.ResponseWriter, r *http.Request) {
+	Authenticate(r)
+}
+"#;
+    let issues = scan("api.go", content);
+    assert_eq!(lines(&issues), vec![10, 12, 14]);
+    assert!(issues[0]
+        .description
+        .contains("`createAccount` handles `POST /accounts`"));
+    assert!(issues[1]
+        .description
+        .contains("`removeAccount` handles `DELETE /accounts/{id}`"));
+    assert!(issues[2]
+        .description
+        .starts_with("The handler handles `PATCH /profile`"));
+}
+
+#[test]
+fn protected_routers_cover_their_routes() {
+    let content = r#"package api
+
+import "github.com/gin-gonic/gin"
+
+func routes(r *gin.Engine) {
+	admin := r.Group("/admin", RequireRole("admin"))
+	admin.DELETE("/users/:id", deleteUser)
+	r.POST("/feedback", postFeedback)
+}
+
+func deleteUser(c *gin.Context) {}
+
+func postFeedback(c *gin.Context) {}
+"#;
+    assert_eq!(lines(&scan("api.go", content)), vec![8]);
+
+    let content = r#"package api
+
+import "github.com/go-chi/chi/v5"
+
+func routes(r chi.Router) {
+	r.Use(RequireLogin)
+	r.Post("/items", createItem)
+}
+
+func createItem(w http.ResponseWriter, r *http.Request) {}
+"#;
+    assert!(scan("api.go", content).is_empty());
+}
+
+#[test]
+fn flags_handlers_named_after_mutations() {
+    let content = r#"package api
+
+import "net/http"
+
+func handleDelete(w http.ResponseWriter, r *http.Request) {
+	store.Delete(r.FormValue("id"))
+}
+
+func handleUpdate(w http.ResponseWriter, r *http.Request) {
+	if err := checkPermission(r, "update"); err != nil {
+		return
+	}
+}
+
+func handleSettings(w http.ResponseWriter, r *http.Request) {}
+
+func deleteFile(path string) error { return nil }
+
+func (s *Server) UpdateUser(w http.ResponseWriter, r *http.Request) {}
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /users/edit", s.UpdateUser)
+}
+"#;
+    let issues = scan("handlers.go", content);
+    assert_eq!(lines(&issues), vec![5]);
+    assert_eq!(issues[0].column, Some(6));
+    assert!(issues[0]
+        .description
+        .contains("`handleDelete` looks like a handler that changes state"));
+}
+
+#[test]
+fn markers_are_configurable_and_validated() {
+    let content = r#"package api
+
+import "net/http"
+
+func handleDelete(w http.ResponseWriter, r *http.Request) {
+	session.MustAdmin(r)
+}
+"#;
+    assert_eq!(lines(&scan("handlers.go", content)), vec![5]);
+    assert!(scan("handlers_test.go", content).is_empty());
+
+    let dir = tempfile::tempdir().unwrap();
+    let path = dir.path().join("reviewlens.toml");
+    fs::write(
+        &path,
+        "[rules.missing-auth-go]\nauth-markers = [\"MustAdmin\"]\n",
+    )
+    .unwrap();
+    let config = Config::load_from_path(&path).unwrap();
+    assert_eq!(config.rules.missing_auth_go.auth_markers, vec!["MustAdmin"]);
+    let issues = MissingAuthGoScanner
+        .scan("handlers.go", content, &config)
+        .unwrap();
+    assert!(issues.is_empty(), "{:?}", issues);
+
+    fs::write(
+        &path,
+        "[rules.missing-auth-go]\nauth-markers = [\"session.MustAdmin\"]\n",
+    )
+    .unwrap();
+    let message = Config::load_from_path(&path).unwrap_err().to_string();
+    assert!(
+        message.contains(
+            "rules.missing-auth-go.auth-markers: `session.MustAdmin` is not an identifier"
+        ),
+        "{}",
+        message
+    );
+}
@@ -0,0 +1,98 @@
+use engine::config::{Confidence, Config};
+use engine::scanner::{Issue, OpenRedirectGoScanner, Scanner};
+
+fn scan(content: &str) -> Vec<Issue> {
+    OpenRedirectGoScanner
+        .scan("server.go", content, &Config::default())
+        .expect("scan should work")
+}
+
+#[test]
+fn flags_redirects_to_request_values() {
+    let content = r#"
+func login(w http.ResponseWriter, r *http.Request) {
+    next := r.URL.Query().Get("next")
+    http.Redirect(w, r, next, http.StatusFound)
+    http.Redirect(w, r, r.FormValue("to"), http.StatusSeeOther)
+    u, _ := url.Parse(next)
+    http.Redirect(w, r, u.String(), http.StatusFound)
+}
+"#;
+    let issues = scan(content);
+    let found: Vec<(usize, Confidence)> = issues
+        .iter()
+        .map(|i| (i.line_number, i.confidence))
+        .collect();
+    assert_eq!(
+        found,
+        vec![
+            (4, Confidence::High),
+            (5, Confidence::High),
+            (7, Confidence::Medium)
+        ]
+    );
+    let issue = &issues[0];
+    assert_eq!(issue.rule_id, "open-redirect-go");
+    assert_eq!(issue.column, Some(5));
+    assert_eq!(issue.end_column, Some(18));
+    assert!(issue.description.contains("`next`"));
+}
+
+#[test]
+fn constant_targets_and_host_prefixes_are_not_flagged() {
+    let content = r#"
+func login(w http.ResponseWriter, r *http.Request) {
+    next := r.URL.Query().Get("next")
+    http.Redirect(w, r, "/home", http.StatusFound)
+    http.Redirect(w, r, "https://example.com/"+next, http.StatusFound)
+    http.Redirect(w, r, "/profile?tab="+url.QueryEscape(next), http.StatusFound)
+    http.Redirect(w, r, "/"+next, http.StatusFound)
+    http.Redirect(w, r, "https://example.com"+next, http.StatusFound)
+}
+"#;
+    // A bare "/" or a host without a trailing slash can still be redirected
+    // elsewhere ("//evil.com", "https://example.com.evil.com").
+    let lines: Vec<usize> = scan(content).iter().map(|i| i.line_number).collect();
+    assert_eq!(lines, vec![7, 8]);
+}
+
+#[test]
+fn validated_targets_are_not_flagged() {
+    let content = r#"
+func relative(w http.ResponseWriter, r *http.Request) {
+    next := r.URL.Query().Get("next")
+    if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") {
+        next = "/"
+    }
+    http.Redirect(w, r, next, http.StatusFound)
+}
+
+func allowlisted(w http.ResponseWriter, r *http.Request) {
+    next := r.FormValue("next")
+    u, err := url.Parse(next)
+    if err != nil || !allowedHosts[u.Host] {
+        http.Error(w, "bad redirect", http.StatusBadRequest)
+        return
+    }
+    http.Redirect(w, r, next, http.StatusFound)
+}
+
+func helper(w http.ResponseWriter, r *http.Request) {
+    next := r.FormValue("next")
+    if !isSafeRedirect(next) {
+        return
+    }
+    http.Redirect(w, r, next, http.StatusFound)
+}
+
+func unchecked(w http.ResponseWriter, r *http.Request) {
+    next := r.FormValue("next")
+    if next == "" {
+        log.Println("no redirect target")
+    }
+    http.Redirect(w, r, next, http.StatusFound)
+}
+"#;
+    let lines: Vec<usize> = scan(content).iter().map(|i| i.line_number).collect();
+    assert_eq!(lines, vec![33]);
+}
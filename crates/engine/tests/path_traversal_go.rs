@@ -0,0 +1,113 @@
+use engine::config::{Confidence, Config};
+use engine::scanner::{Issue, PathTraversalGoScanner, Scanner};
+
+fn scan(content: &str) -> Vec<Issue> {
+    PathTraversalGoScanner
+        .scan("server.go", content, &Config::default())
+        .expect("scan should work")
+}
+
+fn lines(issues: &[Issue]) -> Vec<usize> {
+    issues.iter().map(|i| i.line_number).collect()
+}
+
+#[test]
+fn flags_request_values_joined_onto_a_directory() {
+    let content = r#"
+func download(w http.ResponseWriter, r *http.Request) {
+    http.ServeFile(w, r, filepath.Join(baseDir, r.URL.Query().Get("file")))
+    name := r.FormValue("name")
+    path := filepath.Join("/srv/files", name)
+    data, _ := os.ReadFile(path)
+    f, _ := os.Open(name)
+    old, _ := ioutil.ReadFile(filepath.Join(baseDir, "docs", name))
+    w.Write(data)
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(lines(&issues), vec![3, 6, 7, 8]);
+    let issue = &issues[0];
+    assert_eq!(issue.rule_id, "path-traversal-go");
+    assert_eq!(issue.confidence, Confidence::High);
+    assert_eq!(issue.column, Some(5));
+    assert_eq!(issue.end_column, Some(19));
+    assert!(issue.description.contains("joined onto a directory"));
+    assert!(issues[1].description.contains("joined onto a directory"));
+    assert!(issues[2].description.contains("used as the path"));
+    assert!(issues[3].description.contains("`ioutil.ReadFile`"));
+}
+
+#[test]
+fn constant_and_validated_paths_are_not_flagged() {
+    let content = r#"
+func download(w http.ResponseWriter, r *http.Request) {
+    http.ServeFile(w, r, "/srv/files/index.html")
+    name := r.URL.Query().Get("file")
+    if !allowedFiles[name] {
+        return
+    }
+    http.ServeFile(w, r, filepath.Join(baseDir, name))
+}
+
+func report(w http.ResponseWriter, r *http.Request) {
+    kind := r.FormValue("kind")
+    switch kind {
+    case "daily", "weekly":
+    default:
+        return
+    }
+    data, _ := os.ReadFile(filepath.Join(baseDir, kind+".csv"))
+    w.Write(data)
+}
+
+func avatar(w http.ResponseWriter, r *http.Request) {
+    name := filepath.Base(r.URL.Query().Get("user"))
+    http.ServeFile(w, r, filepath.Join(avatarDir, name))
+}
+
+func static(w http.ResponseWriter, r *http.Request) {
+    f, _ := http.Dir(baseDir).Open(r.URL.Query().Get("file"))
+    g, _ := root.Open(r.URL.Query().Get("file"))
+}
+"#;
+    assert!(scan(content).is_empty(), "{:?}", scan(content));
+}
+
+#[test]
+fn containment_checks_require_a_cleaned_path() {
+    let content = r#"
+func checked(w http.ResponseWriter, r *http.Request) {
+    path := filepath.Join(baseDir, r.URL.Query().Get("file"))
+    if !strings.HasPrefix(path, baseDir+string(filepath.Separator)) {
+        return
+    }
+    http.ServeFile(w, r, path)
+}
+
+func relative(w http.ResponseWriter, r *http.Request) {
+    path := filepath.Join(baseDir, r.URL.Query().Get("file"))
+    rel, err := filepath.Rel(baseDir, path)
+    if err != nil || strings.HasPrefix(rel, "..") {
+        return
+    }
+    http.ServeFile(w, r, path)
+}
+
+func local(w http.ResponseWriter, r *http.Request) {
+    name := r.URL.Query().Get("file")
+    if !filepath.IsLocal(name) {
+        return
+    }
+    http.ServeFile(w, r, filepath.Join(baseDir, name))
+}
+
+func unchecked(w http.ResponseWriter, r *http.Request) {
+    path := baseDir + "/" + r.URL.Query().Get("file")
+    if !strings.HasPrefix(path, baseDir) {
+        return
+    }
+    http.ServeFile(w, r, path)
+}
+"#;
+    assert_eq!(lines(&scan(content)), vec![32]);
+}
@@ -6,7 +6,7 @@ use engine::scanner::Issue;
 
 #[test]
 fn markdown_generator_no_issues() {
-    let generator = MarkdownGenerator;
+    let generator = MarkdownGenerator::default();
     let report = ReviewReport {
         summary: "All good".into(),
         issues: vec![],
@@ -20,6 +20,8 @@ fn markdown_generator_no_issues() {
             driver: "null".into(),
             timings: TimingInfo { total_ms: 0 },
             index_warm: true,
+            files_scanned: 0,
+            files_skipped: 0,
         },
     };
     let md = generator.generate(&report).unwrap();
@@ -31,8 +33,9 @@ fn markdown_generator_no_issues() {
 
 #[test]
 fn markdown_generator_with_issues() {
-    let generator = MarkdownGenerator;
+    let generator = MarkdownGenerator::default();
     let issue = Issue {
+        rule_id: "test-rule".into(),
         title: "Test issue".into(),
         description: "This is a test".into(),
         file_path: "lib.rs".into(),
@@ -40,6 +43,7 @@ fn markdown_generator_with_issues() {
         severity: Severity::High,
         suggested_fix: Some("Apply the recommended change".into()),
         diff: Some("-old\n+new".into()),
+        ..Default::default()
     };
     let report = ReviewReport {
         summary: "Issues".into(),
@@ -54,6 +58,8 @@ fn markdown_generator_with_issues() {
             driver: "null".into(),
             timings: TimingInfo { total_ms: 0 },
             index_warm: false,
+            files_scanned: 0,
+            files_skipped: 0,
         },
     };
     let md = generator.generate(&report).unwrap();
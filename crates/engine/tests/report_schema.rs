@@ -0,0 +1,148 @@
+use engine::config::{Category, Confidence, Config, Severity};
+use engine::fix::{Fix, TextEdit};
+use engine::report::schema::{report_schema, validate_report, REPORT_SCHEMA_VERSION};
+use engine::report::{JsonGenerator, ReportGenerator, ReviewReport, RuntimeMetadata, TimingInfo};
+use engine::scanner::{Issue, TraceKind, TraceStep};
+use serde_json::{json, Value};
+use sha2::{Digest, Sha256};
+
+/// The schema version and the digest of the schema it was recorded with.
+/// Changing the schema changes the digest: bump `REPORT_SCHEMA_VERSION`
+/// (the major component for removed, renamed or retyped fields) and record
+/// the new pair here.
+const RECORDED_SCHEMA: (&str, &str) = (
+    "1.1",
+    "ffea6825f4fca2040c97e2fcaf1840104545b76384c8ebb91d07602f3f165816",
+);
+
+/// A report in which every optional field of every finding is set, so a
+/// field added to `Issue` without a schema update fails validation.
+fn full_report() -> ReviewReport {
+    let issue = Issue {
+        rule_id: "xss-go".into(),
+        title: "Cross-Site Scripting".into(),
+        description: "Request data is written to the response.".into(),
+        file_path: "handler.go".into(),
+        line_number: 12,
+        column: Some(5),
+        end_column: Some(20),
+        severity: Severity::High,
+        category: Category::Security,
+        cwe: vec!["CWE-79".into()],
+        owasp: Some("A03:2021".into()),
+        confidence: Confidence::Medium,
+        suggested_fix: Some("Escape the value.".into()),
+        diff: Some("-old\n+new".into()),
+        fix: Some(Fix {
+            description: "Escape the value".into(),
+            edits: vec![TextEdit::insert(12, 5, "html.EscapeString(")],
+        }),
+        fingerprint: Some("abc123".into()),
+        baselined: true,
+        trace: vec![
+            TraceStep {
+                kind: TraceKind::Source,
+                expression: "r.FormValue(\"name\")".into(),
+                line: 10,
+            },
+            TraceStep {
+                kind: TraceKind::Sink,
+                expression: "fmt.Fprintf(w, name)".into(),
+                line: 12,
+            },
+        ],
+    };
+    ReviewReport {
+        summary: "One issue".into(),
+        issues: vec![issue, Issue::default()],
+        code_quality: vec!["Prefer log:: macros".into()],
+        hotspots: vec!["handler.go".into()],
+        mermaid_diagram: Some("graph TD;A-->B;".into()),
+        config: Config::default(),
+        metadata: RuntimeMetadata {
+            ruleset_version: "v1".into(),
+            model: Some("test-model".into()),
+            driver: "null".into(),
+            timings: TimingInfo { total_ms: 42 },
+            index_warm: true,
+            files_scanned: 1,
+            files_skipped: 0,
+        },
+    }
+}
+
+fn generated(report: &ReviewReport) -> Value {
+    serde_json::from_str(&JsonGenerator.generate(report).unwrap()).unwrap()
+}
+
+#[test]
+fn json_reports_lead_with_the_schema_version() {
+    let output = JsonGenerator.generate(&full_report()).unwrap();
+    assert!(output.starts_with(&format!(
+        "{{\n  \"schemaVersion\": \"{}\"",
+        REPORT_SCHEMA_VERSION
+    )));
+}
+
+#[test]
+fn generated_reports_match_the_schema() {
+    let violations = validate_report(&generated(&full_report()));
+    assert!(violations.is_empty(), "{:?}", violations);
+
+    let mut minimal = full_report();
+    minimal.issues.clear();
+    minimal.mermaid_diagram = None;
+    minimal.metadata.model = None;
+    let violations = validate_report(&generated(&minimal));
+    assert!(violations.is_empty(), "{:?}", violations);
+}
+
+#[test]
+fn schema_changes_require_a_version_bump() {
+    let schema = report_schema();
+    assert_eq!(
+        schema["properties"]["schemaVersion"]["const"], REPORT_SCHEMA_VERSION,
+        "the schema's `schemaVersion` must match REPORT_SCHEMA_VERSION"
+    );
+    let digest = format!("{:x}", Sha256::digest(schema.to_string()));
+    assert_eq!(
+        (REPORT_SCHEMA_VERSION, digest.as_str()),
+        RECORDED_SCHEMA,
+        "the report schema changed: bump REPORT_SCHEMA_VERSION and record the new version and digest"
+    );
+}
+
+#[test]
+fn reports_violations_with_their_location() {
+    let mut report = generated(&full_report());
+    report["schemaVersion"] = json!("0.9");
+    report["issues"][0]["severity"] = json!("urgent");
+    report["issues"][1]
+        .as_object_mut()
+        .unwrap()
+        .remove("rule_id");
+    report["issues"][1]["line_number"] = json!("12");
+    report["metadata"]["extra"] = json!(true);
+
+    let violations: Vec<String> = validate_report(&report)
+        .iter()
+        .map(ToString::to_string)
+        .collect();
+    assert_eq!(
+        violations,
+        vec![
+            "/issues/0/severity: expected one of \"critical\", \"high\", \"medium\", \"low\", \"info\", found \"urgent\"",
+            "/issues/1: missing required property `rule_id`",
+            "/issues/1/line_number: expected integer, found string",
+            "/metadata/extra: unexpected property",
+            "/schemaVersion: expected \"1.1\", found \"0.9\"",
+        ]
+    );
+}
+
+#[test]
+fn non_object_reports_are_rejected() {
+    let violations = validate_report(&json!([]));
+    assert_eq!(violations.len(), 1);
+    assert_eq!(violations[0].to_string(), "/: expected object, found array");
+}
@@ -0,0 +1,56 @@
+use engine::config::{Category, RulesConfig};
+use engine::scanner::{owasp_name, registered_rules, rule_info};
+
+#[test]
+fn registered_rules_are_sorted_and_documented() {
+    let rules = registered_rules();
+    let ids: Vec<&str> = rules.iter().map(|info| info.id).collect();
+    let mut sorted = ids.clone();
+    sorted.sort();
+    assert_eq!(ids, sorted);
+    assert!(ids.contains(&"xss-go"));
+
+    let defaults = RulesConfig::default();
+    for info in &rules {
+        assert!(
+            defaults.severity(info.id).is_some(),
+            "{} has no [rules] entry",
+            info.id
+        );
+        assert!(!info.description.is_empty(), "{}", info.id);
+        assert!(!info.example.is_empty(), "{}", info.id);
+        assert!(!info.remediation.is_empty(), "{}", info.id);
+    }
+}
+
+#[test]
+fn severity_lookup_uses_rule_ids() {
+    let rules: RulesConfig =
+        toml::from_str("[xss-go]\nseverity = \"low\"\n").expect("valid rules table");
+    assert_eq!(rules.severity("xss-go").map(|s| s.as_str()), Some("low"));
+    assert_eq!(
+        rules.severity("sql-injection-go").map(|s| s.as_str()),
+        Some("critical")
+    );
+    assert!(rules.severity("xss_go").is_none());
+    assert!(rule_info("xss_go").is_none());
+}
+
+#[test]
+fn security_rules_map_to_cwe_and_owasp() {
+    for info in registered_rules() {
+        for id in info.cwe {
+            assert!(id.starts_with("CWE-"), "{}: {}", info.id, id);
+        }
+        if let Some(owasp) = info.owasp {
+            assert!(owasp_name(owasp).is_some(), "{}: {}", info.id, owasp);
+        }
+        if info.category == Category::Security {
+            assert!(!info.cwe.is_empty(), "{} has no CWE", info.id);
+            assert!(info.owasp.is_some(), "{} has no OWASP category", info.id);
+        }
+    }
+    let xss = rule_info("xss-go").unwrap();
+    assert_eq!(xss.cwe, ["CWE-79"]);
+    assert_eq!(xss.owasp, Some("A03:2021"));
+}
@@ -0,0 +1,187 @@
+use engine::config::{Category, Config, Severity};
+use engine::fix::{Fix, TextEdit};
+use engine::report::{ReportGenerator, ReviewReport, RuntimeMetadata, SarifGenerator};
+use engine::scanner::Issue;
+use serde_json::Value;
+
+fn report_with(issues: Vec<Issue>) -> ReviewReport {
+    ReviewReport {
+        summary: "Issues".into(),
+        issues,
+        code_quality: vec![],
+        hotspots: vec![],
+        mermaid_diagram: None,
+        config: Config::default(),
+        metadata: RuntimeMetadata {
+            ruleset_version: "v1".into(),
+            model: None,
+            driver: "null".into(),
+            timings: engine::report::TimingInfo { total_ms: 0 },
+            index_warm: false,
+            files_scanned: 0,
+            files_skipped: 0,
+        },
+    }
+}
+
+fn sql_issue(column: Option<usize>, end_column: Option<usize>) -> Issue {
+    Issue {
+        rule_id: "sql-injection-go".into(),
+        title: "Potential SQL Injection".into(),
+        description: "Dynamic SQL query construction detected.".into(),
+        file_path: "db/user.go".into(),
+        line_number: 12,
+        column,
+        end_column,
+        severity: Severity::Critical,
+        category: Category::Security,
+        ..Default::default()
+    }
+}
+
+#[test]
+fn sarif_generator_emits_results_and_fired_rules() {
+    let secret = Issue {
+        rule_id: "secrets".into(),
+        title: "Potential Secret Found".into(),
+        description: "A secret was found.".into(),
+        file_path: "config.go".into(),
+        line_number: 3,
+        severity: Severity::Medium,
+        ..Default::default()
+    };
+    let report = report_with(vec![sql_issue(Some(5), Some(20)), secret]);
+    let sarif: Value = serde_json::from_str(&SarifGenerator.generate(&report).unwrap()).unwrap();
+
+    assert_eq!(sarif["version"], "2.1.0");
+    let run = &sarif["runs"][0];
+    let rules = run["tool"]["driver"]["rules"].as_array().unwrap();
+    let ids: Vec<&str> = rules.iter().map(|r| r["id"].as_str().unwrap()).collect();
+    assert_eq!(ids, vec!["secrets", "sql-injection-go"]);
+    assert!(rules[1]["helpUri"]
+        .as_str()
+        .unwrap()
+        .ends_with("docs/sql_injection_go.md"));
+    assert!(rules[1]["shortDescription"]["text"].is_string());
+    assert_eq!(
+        rules[1]["properties"]["tags"],
+        serde_json::json!(["security", "external/cwe/cwe-089"])
+    );
+
+    let results = run["results"].as_array().unwrap();
+    assert_eq!(results.len(), 2);
+    let sql = &results[0];
+    assert_eq!(sql["ruleId"], "sql-injection-go");
+    assert_eq!(sql["ruleIndex"], 1);
+    assert_eq!(sql["level"], "error");
+    assert_eq!(sql["properties"]["category"], "security");
+    let location = &sql["locations"][0]["physicalLocation"];
+    assert_eq!(location["artifactLocation"]["uri"], "db/user.go");
+    assert_eq!(location["region"]["startLine"], 12);
+    assert_eq!(location["region"]["startColumn"], 5);
+    assert_eq!(location["region"]["endLine"], 12);
+    assert_eq!(location["region"]["endColumn"], 20);
+    assert_eq!(results[1]["level"], "warning");
+}
+
+#[test]
+fn sarif_rules_reference_cwe_and_owasp_taxa() {
+    let report = report_with(vec![sql_issue(Some(5), Some(20))]);
+    let sarif: Value = serde_json::from_str(&SarifGenerator.generate(&report).unwrap()).unwrap();
+    let run = &sarif["runs"][0];
+
+    let relationships = &run["tool"]["driver"]["rules"][0]["relationships"];
+    assert_eq!(
+        relationships,
+        &serde_json::json!([
+            {
+                "target": { "id": "CWE-89", "toolComponent": { "name": "CWE" } },
+                "kinds": ["superset"],
+            },
+            {
+                "target": { "id": "A03:2021", "toolComponent": { "name": "OWASP Top 10" } },
+                "kinds": ["superset"],
+            },
+        ])
+    );
+    assert_eq!(
+        run["tool"]["driver"]["supportedTaxonomies"],
+        serde_json::json!([{ "name": "CWE" }, { "name": "OWASP Top 10" }])
+    );
+
+    let taxonomies = run["taxonomies"].as_array().unwrap();
+    assert_eq!(taxonomies.len(), 2);
+    assert_eq!(taxonomies[0]["name"], "CWE");
+    assert_eq!(taxonomies[0]["taxa"][0]["id"], "CWE-89");
+    assert_eq!(
+        taxonomies[0]["taxa"][0]["helpUri"],
+        "https://cwe.mitre.org/data/definitions/89.html"
+    );
+    assert_eq!(taxonomies[1]["name"], "OWASP Top 10");
+    assert_eq!(
+        taxonomies[1]["taxa"],
+        serde_json::json!([{ "id": "A03:2021", "name": "Injection" }])
+    );
+}
+
+#[test]
+fn sarif_region_without_columns_only_has_start_line() {
+    let report = report_with(vec![sql_issue(None, None)]);
+    let sarif: Value = serde_json::from_str(&SarifGenerator.generate(&report).unwrap()).unwrap();
+    let region = &sarif["runs"][0]["results"][0]["locations"][0]["physicalLocation"]["region"];
+    let keys: Vec<&String> = region.as_object().unwrap().keys().collect();
+    assert_eq!(keys, vec!["startLine"]);
+    assert_eq!(region["startLine"], 12);
+}
+
+#[test]
+fn sarif_generator_with_no_issues_has_empty_rules() {
+    let report = report_with(vec![]);
+    let sarif: Value = serde_json::from_str(&SarifGenerator.generate(&report).unwrap()).unwrap();
+    assert!(sarif["runs"][0]["results"].as_array().unwrap().is_empty());
+    assert!(sarif["runs"][0]["tool"]["driver"]["rules"]
+        .as_array()
+        .unwrap()
+        .is_empty());
+}
+
+#[test]
+fn sarif_result_includes_fixes() {
+    let mut issue = sql_issue(Some(5), Some(9));
+    issue.fix = Some(Fix {
+        description: "Escape `user` with html.EscapeString".into(),
+        edits: vec![
+            TextEdit::insert(3, 1, "\t\"html\"\n"),
+            TextEdit {
+                start_line: 12,
+                start_column: 5,
+                end_line: 12,
+                end_column: 9,
+                replacement: "html.EscapeString(user)".into(),
+            },
+        ],
+    });
+    let out = SarifGenerator.generate(&report_with(vec![issue])).unwrap();
+    let sarif: Value = serde_json::from_str(&out).unwrap();
+    let fix = &sarif["runs"][0]["results"][0]["fixes"][0];
+    assert_eq!(
+        fix["description"]["text"],
+        "Escape `user` with html.EscapeString"
+    );
+    let change = &fix["artifactChanges"][0];
+    assert_eq!(change["artifactLocation"]["uri"], "db/user.go");
+    let replacements = change["replacements"].as_array().unwrap();
+    assert_eq!(replacements.len(), 2);
+    assert_eq!(replacements[0]["deletedRegion"]["startLine"], 3);
+    assert_eq!(replacements[0]["deletedRegion"]["endColumn"], 1);
+    assert_eq!(
+        replacements[1]["insertedContent"]["text"],
+        "html.EscapeString(user)"
+    );
+
+    let plain = SarifGenerator
+        .generate(&report_with(vec![sql_issue(None, None)]))
+        .unwrap();
+    let sarif: Value = serde_json::from_str(&plain).unwrap();
+    assert!(sarif["runs"][0]["results"][0].get("fixes").is_none());
+}
@@ -0,0 +1,124 @@
+use engine::config::{AnalysisScope, Confidence, Config};
+use engine::scanner::{AnalysisContext, Issue, Scanner, ScopeIndex, SsrfGoScanner, XssGoScanner};
+
+const HANDLER: &str = r#"package api
+
+func search(w http.ResponseWriter, r *http.Request) {
+    term := r.URL.Query().Get("q")
+    results := lookup(r.FormValue("source"), term)
+    render(w, term, results)
+    hooks.Notify(r.FormValue("hook"))
+}
+"#;
+
+const HELPERS: &str = r#"package api
+
+func lookup(source, term string) []Row {
+    resp, _ := http.Get(source)
+    return decode(resp, term)
+}
+
+func render(w http.ResponseWriter, title string, rows []Row) {
+    fmt.Fprintf(w, "<h1>%s</h1>", title)
+}
+
+func (h *Hooks) Notify(hook string) {
+    http.Post(hook, "text/plain", nil)
+}
+"#;
+
+fn files() -> Vec<(String, String)> {
+    vec![
+        ("api/handler.go".to_string(), HANDLER.to_string()),
+        ("api/helpers.go".to_string(), HELPERS.to_string()),
+    ]
+}
+
+fn check(scanner: &dyn Scanner, scope: Option<&ScopeIndex>, config: &Config) -> Vec<Issue> {
+    let ctx = AnalysisContext::new("api/helpers.go", HELPERS, config).with_scope(scope);
+    scanner.check(&ctx).expect("scan should work")
+}
+
+#[test]
+fn file_scope_misses_helpers_in_other_files() {
+    let config = Config::default();
+    assert!(check(&SsrfGoScanner, None, &config).is_empty());
+    assert!(check(&XssGoScanner, None, &config).is_empty());
+}
+
+#[test]
+fn package_scope_follows_calls_into_other_files() {
+    let config = Config::default();
+    let index = ScopeIndex::new(AnalysisScope::Package, &files(), &config);
+
+    let issues = check(&SsrfGoScanner, Some(&index), &config);
+    let found: Vec<(usize, Confidence)> = issues
+        .iter()
+        .map(|i| (i.line_number, i.confidence))
+        .collect();
+    assert_eq!(
+        found,
+        vec![(4, Confidence::Medium), (13, Confidence::Medium)]
+    );
+
+    let issues = check(&XssGoScanner, Some(&index), &config);
+    assert_eq!(issues.len(), 1);
+    assert_eq!(issues[0].line_number, 9);
+}
+
+#[test]
+fn other_packages_are_only_followed_in_module_scope() {
+    let handler = r#"package main
+
+import "example.com/app/api"
+
+func search(w http.ResponseWriter, r *http.Request) {
+    api.Fetch(r.FormValue("url"))
+}
+"#;
+    let helper = r#"package api
+
+func Fetch(target string) {
+    http.Get(target)
+}
+"#;
+    let files = vec![
+        ("go.mod".to_string(), "module example.com/app\n".to_string()),
+        ("main.go".to_string(), handler.to_string()),
+        ("api/lookup.go".to_string(), helper.to_string()),
+    ];
+    let config = Config::default();
+    let scan = |scope| {
+        let index = ScopeIndex::new(scope, &files, &config);
+        let ctx = AnalysisContext::new("api/lookup.go", helper, &config).with_scope(Some(&index));
+        SsrfGoScanner.check(&ctx).expect("scan should work")
+    };
+    assert!(scan(AnalysisScope::Package).is_empty());
+    let issues = scan(AnalysisScope::Module);
+    assert_eq!(issues.len(), 1);
+    assert_eq!(issues[0].line_number, 4);
+}
+
+#[test]
+fn sanitized_arguments_and_changes_to_the_package_are_tracked() {
+    let handler = r#"package api
+
+func search(w http.ResponseWriter, r *http.Request) {
+    render(w, html.EscapeString(r.FormValue("q")), nil)
+}
+"#;
+    let files = vec![
+        ("api/handler.go".to_string(), handler.to_string()),
+        ("api/helpers.go".to_string(), HELPERS.to_string()),
+    ];
+    let config = Config::default();
+    let index = ScopeIndex::new(AnalysisScope::Package, &files, &config);
+    assert!(check(&XssGoScanner, Some(&index), &config).is_empty());
+
+    let changed = ScopeIndex::new(AnalysisScope::Package, &self::files(), &config);
+    assert_ne!(
+        index.digest("api/helpers.go"),
+        changed.digest("api/helpers.go")
+    );
+    assert!(index.digest("other/main.go").is_none());
+}
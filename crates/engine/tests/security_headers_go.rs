@@ -0,0 +1,136 @@
+use engine::analyzer::{Analyzer, CancellationToken, SourceFile};
+use engine::config::{Confidence, Config};
+use engine::scanner::{Issue, Scanner, SecurityHeadersGoScanner};
+
+fn scan(path: &str, content: &str) -> Vec<Issue> {
+    SecurityHeadersGoScanner
+        .scan(path, content, &Config::default())
+        .expect("scan should work")
+}
+
+fn found(issues: &[Issue]) -> Vec<(usize, &str, Confidence)> {
+    issues
+        .iter()
+        .map(|i| (i.line_number, i.title.as_str(), i.confidence))
+        .collect()
+}
+
+const SERVER: &str = r#"package main
+
+import "net/http"
+
+func main() {
+    http.ListenAndServe(":8080", mux())
+}
+"#;
+
+#[test]
+fn flags_wildcard_origins_with_credentials() {
+    let content = r#"package api
+
+func cors(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Access-Control-Allow-Origin", "*")
+        w.Header().Set("Access-Control-Allow-Credentials", "true")
+        next.ServeHTTP(w, r)
+    })
+}
+
+func public(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Access-Control-Allow-Origin", "*")
+}
+
+func router() http.Handler {
+    c := cors.New(cors.Options{
+        AllowedOrigins:   []string{"*"},
+        AllowCredentials: true,
+    })
+    return c.Handler(mux())
+}
+"#;
+    let issues = scan("cors.go", content);
+    assert_eq!(
+        found(&issues),
+        vec![
+            (5, "CORS Wildcard With Credentials", Confidence::High),
+            (17, "CORS Wildcard With Credentials", Confidence::High),
+        ]
+    );
+    let issue = &issues[0];
+    assert_eq!(issue.rule_id, "security-headers-go");
+    assert_eq!(issue.column, Some(9));
+    assert_eq!(issue.end_column, Some(59));
+    assert!(issue
+        .suggested_fix
+        .as_deref()
+        .unwrap()
+        .contains("allowlist"));
+}
+
+#[test]
+fn flags_weak_header_values() {
+    let content = r#"package api
+
+func headers(w http.ResponseWriter) {
+    w.Header().Set("X-Content-Type-Options", "sniff")
+    w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'self' 'unsafe-inline'")
+}
+
+func strict(c *gin.Context) {
+    c.Header("X-Content-Type-Options", "nosniff")
+    c.Header("Content-Security-Policy", "default-src 'self'; img-src *")
+}
+"#;
+    let issues = scan("headers.go", content);
+    assert_eq!(
+        found(&issues),
+        vec![
+            (4, "Weak Security Header", Confidence::Low),
+            (5, "Weak Security Header", Confidence::Low),
+        ]
+    );
+    assert!(issues[0].description.contains("`sniff`"));
+}
+
+#[test]
+fn servers_that_never_set_the_headers_are_reported_with_low_confidence() {
+    let issues = scan("main.go", SERVER);
+    assert_eq!(
+        found(&issues),
+        vec![(6, "Missing Security Headers", Confidence::Low)]
+    );
+    assert_eq!(issues[0].column, Some(10));
+    assert!(issues[0]
+        .description
+        .contains("`Content-Security-Policy` and `X-Content-Type-Options: nosniff`"));
+
+    let hardened = SERVER.replace(
+        "    http.ListenAndServe",
+        "    w.Header().Set(\"X-Content-Type-Options\", \"nosniff\")\n    http.ListenAndServe",
+    );
+    let issues = scan("main.go", &hardened);
+    assert_eq!(issues.len(), 1);
+    assert!(!issues[0].description.contains("X-Content-Type-Options"));
+
+    let middleware = SERVER.replace("mux())", "secure.New(secure.Options{}).Handler(mux()))");
+    assert!(scan("main.go", &middleware).is_empty());
+    assert!(scan("main_test.go", SERVER).is_empty());
+}
+
+#[test]
+fn rule_is_disabled_by_default() {
+    let files = vec![SourceFile {
+        path: "main.go".to_string(),
+        content: SERVER.as_bytes().to_vec(),
+    }];
+    let cancel = CancellationToken::new();
+    let findings = Analyzer::new(Config::default())
+        .scan_sources(&files, &cancel)
+        .unwrap();
+    assert!(findings.iter().all(|f| f.rule_id != "security-headers-go"));
+
+    let config: Config =
+        toml::from_str("[rules.security-headers-go]\nenabled = true\n").expect("valid config");
+    let findings = Analyzer::new(config).scan_sources(&files, &cancel).unwrap();
+    assert!(findings.iter().any(|f| f.rule_id == "security-headers-go"));
+}
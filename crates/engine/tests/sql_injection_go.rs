@@ -49,3 +49,63 @@ fn allows_parameterized_query() {
         .expect("scan should work");
     assert!(issues.is_empty());
 }
+
+#[test]
+fn tracks_request_values_into_query_calls() {
+    let scanner = SqlInjectionGoScanner;
+    let content = r#"
+func search(w http.ResponseWriter, r *http.Request) {
+    name := r.URL.Query().Get("name")
+    query := fmt.Sprintf("SELECT * FROM users WHERE name = '%s'", name)
+    rows, _ := db.QueryContext(r.Context(), query)
+    tx.Exec("DELETE FROM users WHERE name = '" + r.FormValue("name") + "'")
+}
+"#;
+    let config = test_config();
+    let issues = scanner
+        .scan("user.go", content, &config)
+        .expect("scan should work");
+    let lines: Vec<usize> = issues.iter().map(|i| i.line_number).collect();
+    assert_eq!(lines, vec![5, 6]);
+    assert!(issues[0].description.contains("`query`"));
+    assert!(issues[0].description.contains("`QueryContext`"));
+}
+
+#[test]
+fn does_not_report_concatenated_query_twice() {
+    let scanner = SqlInjectionGoScanner;
+    let content = r#"
+func find(r *http.Request) {
+    id := r.FormValue("id")
+    query := "SELECT * FROM users WHERE id = " + id
+    db.Query(query)
+}
+"#;
+    let config = test_config();
+    let issues = scanner
+        .scan("user.go", content, &config)
+        .expect("scan should work");
+    assert_eq!(issues.len(), 1);
+    assert_eq!(issues[0].line_number, 4);
+}
+
+#[test]
+fn allows_request_values_passed_as_arguments() {
+    let scanner = SqlInjectionGoScanner;
+    let content = r#"
+func find(r *http.Request) {
+    vars := mux.Vars(r)
+    id, _ := strconv.Atoi(vars["id"])
+    db.QueryRow(fmt.Sprintf("SELECT * FROM users WHERE id = %d", id))
+    db.QueryRowContext(ctx, "SELECT * FROM users WHERE name = $1", vars["name"])
+    tx.Exec("UPDATE users SET seen = now() WHERE name = ?", r.FormValue("name"))
+}
+"#;
+    let config = test_config();
+    let issues = scanner
+        .scan("user.go", content, &config)
+        .expect("scan should work");
+    let lines: Vec<usize> = issues.iter().map(|i| i.line_number).collect();
+    // Only the pattern pass fires, on the inline `Sprintf`.
+    assert_eq!(lines, vec![5]);
+}
@@ -0,0 +1,130 @@
+use engine::config::{Confidence, Config};
+use engine::scanner::{Issue, Scanner, SsrfGoScanner};
+
+fn scan(content: &str) -> Vec<Issue> {
+    SsrfGoScanner
+        .scan("server.go", content, &Config::default())
+        .expect("scan should work")
+}
+
+fn lines(issues: &[Issue]) -> Vec<usize> {
+    issues.iter().map(|i| i.line_number).collect()
+}
+
+#[test]
+fn flags_requests_to_request_values() {
+    let content = r#"
+func fetch(w http.ResponseWriter, r *http.Request) {
+    target := r.URL.Query().Get("url")
+    resp, err := http.Get(target)
+    req, _ := http.NewRequest(http.MethodPost, r.FormValue("hook"), nil)
+    req, _ = http.NewRequestWithContext(r.Context(), "GET", target, nil)
+    conn, err := net.Dial("tcp", net.JoinHostPort(r.FormValue("host"), "443"))
+    u, _ := url.Parse(target)
+    http.Post(u.String(), "application/json", body)
+}
+"#;
+    let issues = scan(content);
+    let found: Vec<(usize, Confidence)> = issues
+        .iter()
+        .map(|i| (i.line_number, i.confidence))
+        .collect();
+    assert_eq!(
+        found,
+        vec![
+            (4, Confidence::High),
+            (5, Confidence::High),
+            (6, Confidence::High),
+            (7, Confidence::Medium),
+            (9, Confidence::Medium),
+        ]
+    );
+    let issue = &issues[0];
+    assert_eq!(issue.rule_id, "ssrf-go");
+    assert_eq!(issue.column, Some(18));
+    assert_eq!(issue.end_column, Some(26));
+    assert!(issue
+        .description
+        .contains("`target` is used as the URL passed to `http.Get`"));
+    assert!(issues[3]
+        .description
+        .contains("address passed to `net.Dial`"));
+}
+
+#[test]
+fn constant_urls_and_bases_are_not_flagged() {
+    let content = r#"
+const apiBase = "https://api.example.com/v1/"
+
+var statusHost = "https://status.example.com"
+
+func proxy(w http.ResponseWriter, r *http.Request) {
+    id := r.URL.Query().Get("id")
+    http.Get("https://api.example.com/health")
+    http.Get("https://api.example.com/users/" + id)
+    http.Get(fmt.Sprintf("https://api.example.com/users/%s", id))
+    http.Get(apiBase + id)
+    net.Dial("tcp", "db.internal:"+r.FormValue("port"))
+    http.Get(statusHost + id)
+    http.Get("https://" + id + ".example.com/")
+}
+"#;
+    // A base without a trailing slash can still be pointed elsewhere
+    // ("https://status.example.com.evil.com"), as can a request value in
+    // the host itself.
+    assert_eq!(lines(&scan(content)), vec![13, 14]);
+}
+
+#[test]
+fn validated_hosts_are_not_flagged() {
+    let content = r#"
+func allowlisted(w http.ResponseWriter, r *http.Request) {
+    target := r.URL.Query().Get("url")
+    u, err := url.Parse(target)
+    if err != nil || !allowedHosts[u.Hostname()] {
+        http.Error(w, "host not allowed", http.StatusBadRequest)
+        return
+    }
+    http.Get(target)
+}
+
+func compared(w http.ResponseWriter, r *http.Request) {
+    u, err := url.Parse(r.FormValue("url"))
+    if err != nil || u.Hostname() != "hooks.example.com" {
+        return
+    }
+    http.Get(u.String())
+}
+
+func resolved(w http.ResponseWriter, r *http.Request) {
+    target := r.FormValue("url")
+    u, _ := url.Parse(target)
+    ips, err := net.LookupIP(u.Hostname())
+    for _, ip := range ips {
+        if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() {
+            return
+        }
+    }
+    http.Get(target)
+}
+
+func helper(w http.ResponseWriter, r *http.Request) {
+    target := r.FormValue("url")
+    if !isAllowedURL(target) {
+        return
+    }
+    http.Get(target)
+}
+
+func schemeOnly(w http.ResponseWriter, r *http.Request) {
+    target := r.FormValue("url")
+    u, _ := url.Parse(target)
+    if u.Scheme != "https" {
+        return
+    }
+    http.Get(target)
+}
+"#;
+    // Checking the scheme alone still lets the request reach any host.
+    assert_eq!(lines(&scan(content)), vec![46]);
+}
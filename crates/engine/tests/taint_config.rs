@@ -0,0 +1,169 @@
+use std::fs;
+
+use engine::config::{split_function_ref, Config, TaintConfig};
+use engine::error::EngineError;
+use engine::scanner::{
+    CommandInjectionGoScanner, Issue, Scanner, SqlInjectionGoScanner, XssGoScanner,
+};
+
+fn config(sanitizers: &[&str], safe_sinks: &[&str]) -> Config {
+    Config {
+        taint: TaintConfig {
+            sanitizers: sanitizers.iter().map(|s| s.to_string()).collect(),
+            safe_sinks: safe_sinks.iter().map(|s| s.to_string()).collect(),
+        },
+        ..Config::default()
+    }
+}
+
+fn lines(issues: &[Issue]) -> Vec<usize> {
+    issues.iter().map(|i| i.line_number).collect()
+}
+
+const XSS: &str = r#"package web
+
+import (
+	"fmt"
+	"net/http"
+
+	"example.com/app/render"
+	esc "example.com/app/render/v2"
+)
+
+func greet(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	fmt.Fprintf(w, "<p>%s</p>", render.Escape(name))
+	safe := esc.Escape(name)
+	fmt.Fprintf(w, "<p>%s</p>", safe)
+	fmt.Fprintf(w, "<p>%s</p>", render.Highlight(name))
+}
+"#;
+
+#[test]
+fn declared_sanitizers_clear_taint() {
+    let issues = XssGoScanner
+        .scan("web.go", XSS, &Config::default())
+        .unwrap();
+    assert_eq!(lines(&issues), vec![13, 15, 16]);
+
+    let config = config(
+        &[
+            "example.com/app/render.Escape",
+            "example.com/app/render/v2.Escape",
+        ],
+        &[],
+    );
+    let issues = XssGoScanner.scan("web.go", XSS, &config).unwrap();
+    assert_eq!(lines(&issues), vec![16]);
+}
+
+#[test]
+fn sanitizers_of_packages_the_file_does_not_import_are_ignored() {
+    let config = config(&["example.com/other/render.Escape"], &[]);
+    let issues = XssGoScanner.scan("web.go", XSS, &config).unwrap();
+    assert_eq!(lines(&issues), vec![13, 15, 16]);
+}
+
+#[test]
+fn declared_safe_sinks_never_fire() {
+    let content = r#"package jobs
+
+import (
+	"net/http"
+	"os/exec"
+
+	"example.com/app/sandbox"
+	"example.com/app/store"
+)
+
+func run(w http.ResponseWriter, r *http.Request) {
+	script := r.FormValue("script")
+	sandbox.Run(exec.Command("sh", "-c", script))
+	exec.Command("sh", "-c", script).Run()
+	store.Exec(db, "DELETE FROM jobs WHERE id = "+r.FormValue("id"))
+}
+"#;
+    let defaults = Config::default();
+    let commands = CommandInjectionGoScanner
+        .scan("jobs.go", content, &defaults)
+        .unwrap();
+    assert_eq!(lines(&commands), vec![13, 14]);
+    let queries = SqlInjectionGoScanner
+        .scan("jobs.go", content, &defaults)
+        .unwrap();
+    assert_eq!(lines(&queries), vec![15]);
+
+    let config = config(
+        &[],
+        &["example.com/app/sandbox.Run", "example.com/app/store.Exec"],
+    );
+    let commands = CommandInjectionGoScanner
+        .scan("jobs.go", content, &config)
+        .unwrap();
+    assert_eq!(lines(&commands), vec![14]);
+    let queries = SqlInjectionGoScanner
+        .scan("jobs.go", content, &config)
+        .unwrap();
+    assert!(queries.is_empty(), "{:?}", queries);
+}
+
+#[test]
+fn function_references_are_an_import_path_and_a_name() {
+    assert_eq!(
+        split_function_ref("example.com/app/render.Escape"),
+        Some(("example.com/app/render", "Escape"))
+    );
+    assert_eq!(
+        split_function_ref("gopkg.in/yaml.v3.Marshal"),
+        Some(("gopkg.in/yaml.v3", "Marshal"))
+    );
+    assert_eq!(
+        split_function_ref("html.EscapeString"),
+        Some(("html", "EscapeString"))
+    );
+    for invalid in [
+        "Escape",
+        "render.",
+        "example.com//render.Escape",
+        "example.com/app/render.Escape(",
+        "example.com/my app/render.Escape",
+        "render.1Escape",
+    ] {
+        assert_eq!(split_function_ref(invalid), None, "{}", invalid);
+    }
+}
+
+#[test]
+fn invalid_function_references_are_a_config_error() {
+    let dir = tempfile::tempdir().unwrap();
+    let path = dir.path().join("reviewlens.toml");
+    fs::write(
+        &path,
+        "[taint]\nsanitizers = [\"example.com/app/render.Escape\"]\nsafe-sinks = [\"SafeHTML\"]\n",
+    )
+    .unwrap();
+
+    let err = Config::load_from_path(&path).unwrap_err();
+    assert!(matches!(err, EngineError::Config(_)));
+    let message = err.to_string();
+    assert!(
+        message.contains("taint.safe-sinks: `SafeHTML` is not a function reference"),
+        "{}",
+        message
+    );
+
+    fs::write(
+        &path,
+        "[taint]\nsanitizers = [\"example.com/app/render.Escape\"]\nsafe-sinks = [\"example.com/app/render.SafeHTML\"]\n",
+    )
+    .unwrap();
+    let config = Config::load_from_path(&path).unwrap();
+    assert_eq!(
+        config.taint.sanitizers,
+        vec!["example.com/app/render.Escape"]
+    );
+    assert_eq!(
+        config.taint.safe_sinks,
+        vec!["example.com/app/render.SafeHTML"]
+    );
+}
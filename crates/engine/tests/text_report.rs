@@ -0,0 +1,272 @@
+use engine::config::{Config, Severity};
+use engine::report::{
+    sort_issues, CompactTextGenerator, GroupBy, MarkdownGenerator, ReportGenerator, ReportStats,
+    ReviewReport, RuntimeMetadata, SortKey, TextGenerator, TimingInfo,
+};
+use engine::scanner::{rule_category, Issue};
+
+fn issue(rule_id: &str, severity: Severity, line_number: usize) -> Issue {
+    Issue {
+        rule_id: rule_id.into(),
+        title: format!("{} finding", rule_id),
+        description: "Something is wrong".into(),
+        file_path: "main.go".into(),
+        line_number,
+        severity,
+        category: rule_category(rule_id),
+        ..Default::default()
+    }
+}
+
+fn report(issues: Vec<Issue>) -> ReviewReport {
+    ReviewReport {
+        summary: "Review done".into(),
+        issues,
+        code_quality: vec![],
+        hotspots: vec![],
+        mermaid_diagram: None,
+        config: Config::default(),
+        metadata: RuntimeMetadata {
+            ruleset_version: "v1".into(),
+            model: None,
+            driver: "null".into(),
+            timings: TimingInfo { total_ms: 1250 },
+            index_warm: false,
+            files_scanned: 3,
+            files_skipped: 2,
+        },
+    }
+}
+
+fn sample() -> ReviewReport {
+    report(vec![
+        issue("xss-go", Severity::High, 10),
+        issue("sql-injection-go", Severity::Critical, 20),
+        issue("xss-go", Severity::High, 30),
+        issue("http-timeouts-go", Severity::Low, 40),
+    ])
+}
+
+#[test]
+fn stats_count_findings_by_severity_and_rule() {
+    let stats = ReportStats::from_report(&sample());
+    assert_eq!(stats.total, 4);
+    assert_eq!(
+        stats.by_severity,
+        vec![
+            (Severity::Critical, 1),
+            (Severity::High, 2),
+            (Severity::Low, 1)
+        ]
+    );
+    let by_rule: Vec<(&str, usize)> = stats
+        .by_rule
+        .iter()
+        .map(|(rule, count)| (rule.as_str(), *count))
+        .collect();
+    assert_eq!(
+        by_rule,
+        vec![
+            ("http-timeouts-go", 1),
+            ("sql-injection-go", 1),
+            ("xss-go", 2)
+        ]
+    );
+    assert_eq!(stats.files_scanned, 3);
+    assert_eq!(stats.files_skipped, 2);
+    assert_eq!(stats.duration_ms, 1250);
+}
+
+#[test]
+fn text_report_lists_findings_then_statistics() {
+    let text = TextGenerator::default().generate(&sample()).unwrap();
+    assert!(text.starts_with("Summary: Review done\n"));
+    assert!(text.contains("\nmain.go (4 findings)\n  high main.go:10 xss-go [security]: xss-go finding\n      Something is wrong\n"));
+    assert!(!text.contains('\x1b'));
+    assert!(text.contains("critical main.go:20 sql-injection-go"));
+    let footer = text.find("Findings: 4\n").expect("statistics footer");
+    assert!(footer > text.find("main.go:40").unwrap());
+    assert!(text.contains("By severity: critical 1, high 2, low 1\n"));
+    assert!(text.contains("By category: security 3, correctness 1\n"));
+    assert!(text.contains("By rule: http-timeouts-go 1, sql-injection-go 1, xss-go 2\n"));
+    assert!(text.contains("Files: 3 scanned, 2 skipped\n"));
+    assert!(text.ends_with("Duration: 1.25s\n"));
+}
+
+#[test]
+fn quiet_text_report_prints_only_statistics() {
+    let text = TextGenerator {
+        quiet: true,
+        ..Default::default()
+    }
+    .generate(&sample())
+    .unwrap();
+    assert!(text.starts_with("Findings: 4\n"));
+    assert!(!text.contains("main.go"));
+    assert!(!text.contains("Summary:"));
+}
+
+#[test]
+fn statistics_without_findings() {
+    let text = TextGenerator {
+        quiet: true,
+        ..Default::default()
+    }
+    .generate(&report(vec![]))
+    .unwrap();
+    assert!(text.contains("Findings: 0\nBy severity: none\nBy category: none\nBy rule: none\n"));
+}
+
+#[test]
+fn text_report_groups_findings_by_file() {
+    let mut issues = vec![
+        issue("xss-go", Severity::High, 10),
+        issue("http-timeouts-go", Severity::Low, 5),
+        issue("xss-go", Severity::Medium, 30),
+    ];
+    issues[1].file_path = "client.go".into();
+    issues[1].column = Some(7);
+    let text = TextGenerator::default().generate(&report(issues)).unwrap();
+    let main = text
+        .find("\nmain.go (2 findings)\n")
+        .expect("main.go header");
+    let client = text
+        .find("\nclient.go (1 finding)\n")
+        .expect("client.go header");
+    assert!(main < client);
+    // Both main.go findings are listed under its header.
+    assert!(text.find("main.go:30").unwrap() < client);
+    assert!(text.contains("  low client.go:5:7 http-timeouts-go [correctness]"));
+}
+
+#[test]
+fn colored_text_report_highlights_severities_and_rules() {
+    let text = TextGenerator {
+        color: true,
+        ..Default::default()
+    }
+    .generate(&sample())
+    .unwrap();
+    assert!(text.contains("\x1b[1mmain.go\x1b[0m (4 findings)\n"));
+    assert!(text.contains(
+        "  \x1b[31mhigh\x1b[0m main.go:10 \x1b[36mxss-go\x1b[0m [security]: xss-go finding\n"
+    ));
+    assert!(text.contains("\x1b[1;31mcritical\x1b[0m"));
+    assert!(text.contains("\x1b[34mlow\x1b[0m"));
+    // The statistics stay plain.
+    assert!(text.contains("By severity: critical 1, high 2, low 1\n"));
+}
+
+#[test]
+fn compact_text_report_prints_one_line_per_finding() {
+    let mut issues = vec![
+        issue("xss-go", Severity::High, 10),
+        issue("sql-injection-go", Severity::Critical, 20),
+    ];
+    issues[0].column = Some(5);
+    let text = CompactTextGenerator.generate(&report(issues)).unwrap();
+    assert_eq!(
+        text,
+        "main.go:10:5: [high] xss-go: xss-go finding\n\
+         main.go:20: [critical] sql-injection-go: sql-injection-go finding\n"
+    );
+    assert_eq!(CompactTextGenerator.generate(&report(vec![])).unwrap(), "");
+}
+
+#[test]
+fn markdown_report_ends_with_statistics() {
+    let md = MarkdownGenerator::default().generate(&sample()).unwrap();
+    let stats = md.find("## Statistics").expect("statistics section");
+    assert!(stats < md.find("## Appendix").unwrap());
+    assert!(md.contains("- By rule: http-timeouts-go 1, sql-injection-go 1, xss-go 2\n"));
+}
+
+#[test]
+fn text_report_groups_findings_by_rule_or_not_at_all() {
+    let mut issues = sample().issues;
+    issues[2].file_path = "api.go".into();
+    let by_rule = TextGenerator {
+        group_by: GroupBy::Rule,
+        ..Default::default()
+    }
+    .generate(&report(issues.clone()))
+    .unwrap();
+    let xss = by_rule
+        .find("\nxss-go (2 findings)\n")
+        .expect("xss-go header");
+    let sql = by_rule
+        .find("\nsql-injection-go (1 finding)\n")
+        .expect("sql-injection-go header");
+    assert!(xss < sql);
+    assert!(by_rule.find("api.go:30").unwrap() < sql);
+    assert!(!by_rule.contains("main.go (3 findings)"));
+
+    let flat = TextGenerator {
+        group_by: GroupBy::None,
+        ..Default::default()
+    }
+    .generate(&report(issues))
+    .unwrap();
+    assert!(flat.contains("Summary: Review done\n\n  high main.go:10 xss-go"));
+    assert!(!flat.contains("finding)"));
+    assert!(!flat.contains("findings)"));
+}
+
+#[test]
+fn issues_sort_by_severity_file_or_rule_with_stable_ties() {
+    let mut issues = sample().issues;
+    issues[3].file_path = "api.go".into();
+    issues.push(issue("xss-go", Severity::High, 5));
+    let order = |key: SortKey| {
+        let mut sorted = issues.clone();
+        sort_issues(&mut sorted, key);
+        sorted
+            .iter()
+            .map(|i| (i.file_path.clone(), i.line_number))
+            .collect::<Vec<_>>()
+    };
+    let at = |file: &str, line: usize| (file.to_string(), line);
+
+    assert_eq!(
+        order(SortKey::Severity),
+        vec![
+            at("main.go", 20),
+            at("main.go", 5),
+            at("main.go", 10),
+            at("main.go", 30),
+            at("api.go", 40)
+        ]
+    );
+    assert_eq!(
+        order(SortKey::File),
+        vec![
+            at("api.go", 40),
+            at("main.go", 5),
+            at("main.go", 10),
+            at("main.go", 20),
+            at("main.go", 30)
+        ]
+    );
+    assert_eq!(
+        order(SortKey::Rule),
+        vec![
+            at("api.go", 40),
+            at("main.go", 20),
+            at("main.go", 5),
+            at("main.go", 10),
+            at("main.go", 30)
+        ]
+    );
+
+    // Markdown lists the most severe findings first unless told otherwise.
+    let md = MarkdownGenerator::default()
+        .generate(&report(issues.clone()))
+        .unwrap();
+    assert!(md.find("`main.go:20`").unwrap() < md.find("`main.go:5`").unwrap());
+    let md = MarkdownGenerator {
+        sort: Some(SortKey::File),
+    }
+    .generate(&report(issues))
+    .unwrap();
+    assert!(md.find("`api.go:40`").unwrap() < md.find("`main.go:20`").unwrap());
+}
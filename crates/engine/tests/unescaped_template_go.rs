@@ -0,0 +1,85 @@
+use engine::config::{Confidence, Config};
+use engine::scanner::{Issue, Scanner, UnescapedTemplateGoScanner};
+
+fn scan(content: &str) -> Vec<Issue> {
+    UnescapedTemplateGoScanner
+        .scan("server.go", content, &Config::default())
+        .expect("scan should work")
+}
+
+#[test]
+fn flags_request_data_converted_to_trusted_types() {
+    let content = r#"
+func profile(w http.ResponseWriter, r *http.Request) {
+    bio := r.FormValue("bio")
+    data := map[string]any{
+        "Bio":  template.HTML(bio),
+        "Next": template.URL(r.URL.Query().Get("next")),
+    }
+    tmpl.Execute(w, data)
+}
+"#;
+    let issues = scan(content);
+    let found: Vec<(usize, Confidence)> = issues
+        .iter()
+        .map(|i| (i.line_number, i.confidence))
+        .collect();
+    assert_eq!(found, vec![(5, Confidence::High), (6, Confidence::High)]);
+    let issue = &issues[0];
+    assert_eq!(issue.rule_id, "unescaped-template-go");
+    assert_eq!(issue.column, Some(17));
+    assert_eq!(issue.end_column, Some(30));
+    assert!(issue.description.contains("`bio`"));
+    assert!(issue.description.contains("`template.HTML`"));
+    assert!(issues[1].description.contains("`template.URL`"));
+}
+
+#[test]
+fn constants_and_escaped_values_are_not_flagged() {
+    let content = r#"
+const footer = "<footer>&copy; Example</footer>"
+
+const (
+    banner = `<div class="banner">` + "Welcome" + `</div>`
+)
+
+func page(w http.ResponseWriter, r *http.Request) {
+    name := r.FormValue("name")
+    tmpl.Execute(w, map[string]any{
+        "Footer": template.HTML(footer),
+        "Banner": template.HTML(banner + "<hr>"),
+        "Static": template.HTML("<b>hello</b>"),
+        "Name":   template.HTML("<b>" + template.HTMLEscapeString(name) + "</b>"),
+    })
+}
+"#;
+    assert!(scan(content).is_empty(), "{:?}", scan(content));
+}
+
+#[test]
+fn other_non_constant_values_are_flagged_with_low_confidence() {
+    let content = r#"
+func render(w http.ResponseWriter, post Post) {
+    tmpl.Execute(w, template.HTML(post.Body))
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(issues.len(), 1);
+    assert_eq!(issues[0].confidence, Confidence::Low);
+    assert!(issues[0].description.contains("`post.Body`"));
+}
+
+#[test]
+fn falls_back_to_direct_sources_when_braces_do_not_balance() {
+    let content = r#"
+func profile(w http.ResponseWriter, r *http.Request) {
+    bio := template.HTML(r.FormValue("bio"))
+    safe := template.HTML("<br>")
+"#;
+    let issues = scan(content);
+    let found: Vec<(usize, Confidence)> = issues
+        .iter()
+        .map(|i| (i.line_number, i.confidence))
+        .collect();
+    assert_eq!(found, vec![(3, Confidence::High)]);
+}
@@ -0,0 +1,139 @@
+use engine::config::{Confidence, Config};
+use engine::scanner::{apply_ignore_directives, Issue, Scanner, UnsafeGoScanner};
+
+fn scan(content: &str) -> Vec<Issue> {
+    UnsafeGoScanner
+        .scan("mem.go", content, &Config::default())
+        .expect("scan should work")
+}
+
+fn found(issues: &[Issue]) -> Vec<(usize, Confidence)> {
+    issues
+        .iter()
+        .map(|i| (i.line_number, i.confidence))
+        .collect()
+}
+
+#[test]
+fn flags_unsafe_and_reflect_memory_access() {
+    let content = r#"package mem
+
+import (
+    "reflect"
+    u "unsafe"
+)
+
+type node struct {
+    next u.Pointer
+}
+
+func bytesToString(b []byte) string {
+    return *(*string)(u.Pointer(&b))
+}
+
+func field(p *node, off uintptr) *int {
+    return (*int)(u.Pointer(uintptr(u.Pointer(p)) + off))
+}
+
+func view(p *byte, n int) []byte {
+    return u.Slice(p, n)
+}
+
+func data(b []byte) uintptr {
+    return (*reflect.SliceHeader)(u.Pointer(&b)).Data
+}
+
+func address(v reflect.Value) uintptr {
+    return v.UnsafeAddr()
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(
+        found(&issues),
+        vec![
+            (9, Confidence::Medium),
+            (13, Confidence::High),
+            (17, Confidence::High),
+            (21, Confidence::High),
+            (25, Confidence::High),
+            (29, Confidence::High),
+        ]
+    );
+    let issue = &issues[1];
+    assert_eq!(issue.rule_id, "unsafe-go");
+    assert_eq!(issue.severity, Config::default().rules.unsafe_go.severity);
+    assert_eq!(issue.column, Some(23));
+    assert_eq!(issue.end_column, Some(32));
+    assert!(issue.description.contains("justified"));
+    assert!(issue
+        .suggested_fix
+        .as_deref()
+        .unwrap()
+        .contains("reviewlens:ignore unsafe-go"));
+    assert!(issues[2].description.contains("pointer arithmetic"));
+    assert!(issues[4].description.contains("header"));
+    assert!(issues[5].description.contains("raw address"));
+}
+
+#[test]
+fn compile_time_queries_and_unrelated_files_are_not_flagged() {
+    let content = r#"package mem
+
+import "unsafe"
+
+type header struct {
+    kind uint8
+    size uint32
+}
+
+// unsafe.Pointer is not used here.
+func layout() (uintptr, uintptr, uintptr) {
+    var h header
+    return unsafe.Sizeof(h), unsafe.Alignof(h), unsafe.Offsetof(h.size)
+}
+
+func describe() string {
+    return "unsafe.Pointer(&x)"
+}
+"#;
+    assert!(scan(content).is_empty());
+
+    let content = "package mem\n\nfunc address(v value) uintptr {\n    return v.UnsafeAddr()\n}\n";
+    assert!(scan(content).is_empty());
+}
+
+#[test]
+fn blank_imports_for_linkname_are_reported() {
+    let content = r#"package clock
+
+import _ "unsafe"
+
+//go:linkname nanotime runtime.nanotime
+func nanotime() int64
+"#;
+    let issues = scan(content);
+    assert_eq!(found(&issues), vec![(3, Confidence::High)]);
+    assert!(issues[0].description.contains("go:linkname"));
+}
+
+#[test]
+fn justified_uses_can_be_suppressed() {
+    let content = r#"package mem
+
+import "unsafe"
+
+func bytesToString(b []byte) string {
+    // reviewlens:ignore unsafe-go b is never modified after the conversion
+    return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+func stringToBytes(s string) []byte {
+    return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(issues.len(), 2);
+    let (issues, unused) = apply_ignore_directives("mem.go", content, issues);
+    assert_eq!(found(&issues), vec![(11, Confidence::High)]);
+    assert!(unused.is_empty());
+}
@@ -0,0 +1,157 @@
+use std::collections::BTreeSet;
+use std::fs;
+use std::path::PathBuf;
+use std::time::Duration;
+
+use engine::config::PathsConfig;
+use engine::watch::{with_package_mates, Changes, TreeWatcher};
+use tempfile::tempdir;
+
+fn set(paths: &[&str]) -> BTreeSet<String> {
+    paths.iter().map(|p| p.to_string()).collect()
+}
+
+#[test]
+fn poll_reports_modified_created_and_removed_files() {
+    let temp = tempdir().unwrap();
+    let root = temp.path();
+    fs::create_dir(root.join("api")).unwrap();
+    fs::write(root.join("main.go"), "package main\n").unwrap();
+    fs::write(root.join("api/handler.go"), "package api\n").unwrap();
+    fs::create_dir(root.join(".git")).unwrap();
+    fs::write(root.join(".git/HEAD"), "ref: refs/heads/main\n").unwrap();
+
+    let mut watcher = TreeWatcher::new(root, &PathsConfig::default()).unwrap();
+    assert_eq!(watcher.files(), vec!["api/handler.go", "main.go"]);
+    assert!(watcher.poll().unwrap().is_empty());
+
+    fs::write(root.join("main.go"), "package main\n\nfunc main() {}\n").unwrap();
+    fs::write(root.join("api/routes.go"), "package api\n").unwrap();
+    fs::remove_file(root.join("api/handler.go")).unwrap();
+    fs::write(root.join(".git/HEAD"), "ref: refs/heads/other\n").unwrap();
+
+    let changes = watcher.poll().unwrap();
+    assert_eq!(changes.modified, set(&["api/routes.go", "main.go"]));
+    assert_eq!(changes.removed, set(&["api/handler.go"]));
+    assert_eq!(watcher.files(), vec!["api/routes.go", "main.go"]);
+    assert!(watcher.poll().unwrap().is_empty());
+}
+
+#[test]
+fn editing_an_ignore_file_rebuilds_the_filters() {
+    let temp = tempdir().unwrap();
+    let root = temp.path();
+    fs::write(root.join("main.go"), "package main\n").unwrap();
+    fs::write(root.join("gen.go"), "package main\n").unwrap();
+
+    let mut watcher = TreeWatcher::new(root, &PathsConfig::default()).unwrap();
+    fs::write(root.join(".reviewlensignore"), "gen.go\n").unwrap();
+
+    let changes = watcher.poll().unwrap();
+    assert_eq!(changes.modified, set(&[".reviewlensignore", "main.go"]));
+    assert_eq!(changes.removed, set(&["gen.go"]));
+    assert_eq!(watcher.files(), vec![".reviewlensignore", "main.go"]);
+}
+
+#[test]
+fn wait_debounces_successive_writes() {
+    let temp = tempdir().unwrap();
+    let root = temp.path().to_path_buf();
+    fs::write(root.join("main.go"), "package main\n").unwrap();
+    let watcher = TreeWatcher::new(&root, &PathsConfig::default()).unwrap();
+    debounces_successive_writes(root, watcher);
+}
+
+#[test]
+fn polling_wait_debounces_successive_writes() {
+    let temp = tempdir().unwrap();
+    let root = temp.path().to_path_buf();
+    fs::write(root.join("main.go"), "package main\n").unwrap();
+    let watcher = TreeWatcher::polling(&root, &PathsConfig::default()).unwrap();
+    assert!(watcher.is_polling());
+    debounces_successive_writes(root, watcher);
+}
+
+/// Rewrites `main.go` in `root` several times and creates `util.go`, and
+/// checks that `watcher` reports them in one batch.
+fn debounces_successive_writes(root: PathBuf, mut watcher: TreeWatcher) {
+    let writer = std::thread::spawn({
+        let root = root.clone();
+        move || {
+            std::thread::sleep(Duration::from_millis(50));
+            let mut content = String::from("package main\n");
+            for i in 0..5 {
+                content.push_str("// edit\n");
+                fs::write(root.join("main.go"), &content).unwrap();
+                if i == 2 {
+                    fs::write(root.join("util.go"), "package main\n").unwrap();
+                }
+                std::thread::sleep(Duration::from_millis(20));
+            }
+        }
+    });
+
+    let changes = watcher
+        .wait(Duration::from_millis(10), Duration::from_millis(150))
+        .unwrap();
+    writer.join().unwrap();
+    assert_eq!(
+        changes,
+        Changes {
+            modified: set(&["main.go", "util.go"]),
+            removed: BTreeSet::new(),
+        }
+    );
+    assert!(watcher.poll().unwrap().is_empty());
+}
+
+#[test]
+fn wait_reports_the_files_of_removed_directories() {
+    let temp = tempdir().unwrap();
+    let root = temp.path().to_path_buf();
+    fs::create_dir(root.join("api")).unwrap();
+    fs::write(root.join("api/handler.go"), "package api\n").unwrap();
+    fs::write(root.join("api/routes.go"), "package api\n").unwrap();
+    fs::write(root.join("main.go"), "package main\n").unwrap();
+    let mut watcher = TreeWatcher::new(&root, &PathsConfig::default()).unwrap();
+
+    let remover = std::thread::spawn({
+        let root = root.clone();
+        move || {
+            std::thread::sleep(Duration::from_millis(50));
+            fs::remove_dir_all(root.join("api")).unwrap();
+        }
+    });
+    let changes = watcher
+        .wait(Duration::from_millis(10), Duration::from_millis(150))
+        .unwrap();
+    remover.join().unwrap();
+    assert_eq!(changes.removed, set(&["api/handler.go", "api/routes.go"]));
+    assert!(changes.modified.is_empty());
+    assert_eq!(watcher.files(), vec!["main.go"]);
+}
+
+#[test]
+fn package_mates_are_the_go_files_of_the_same_directory() {
+    let files: Vec<String> = [
+        "README.md",
+        "api/handler.go",
+        "api/handler_test.go",
+        "api/v2/handler.go",
+        "main.go",
+        "util.go",
+    ]
+    .iter()
+    .map(|p| p.to_string())
+    .collect();
+    let changed = set(&["README.md", "api/handler.go"]);
+    assert_eq!(
+        with_package_mates(&changed, &files),
+        vec!["README.md", "api/handler.go", "api/handler_test.go"]
+    );
+    let changed = set(&["util.go"]);
+    assert_eq!(
+        with_package_mates(&changed, &files),
+        vec!["main.go", "util.go"]
+    );
+}
@@ -0,0 +1,181 @@
+use engine::config::{Confidence, Config};
+use engine::scanner::{Issue, Scanner, WeakCryptoGoScanner};
+
+fn scan(content: &str) -> Vec<Issue> {
+    WeakCryptoGoScanner
+        .scan("crypto.go", content, &Config::default())
+        .expect("scan should work")
+}
+
+fn found(issues: &[Issue]) -> Vec<(usize, &str, Confidence)> {
+    issues
+        .iter()
+        .map(|i| (i.line_number, i.title.as_str(), i.confidence))
+        .collect()
+}
+
+#[test]
+fn flags_broken_hashes_and_ciphers() {
+    let content = r#"package store
+
+import (
+    "crypto/des"
+    "crypto/md5"
+    weak "crypto/rc4"
+)
+
+func etag(body []byte) [16]byte {
+    return md5.Sum(body)
+}
+
+func hashPassword(password string) []byte {
+    h := md5.New()
+    h.Write([]byte(password))
+    return h.Sum(nil)
+}
+
+func encrypt(key []byte) {
+    block, _ := des.NewTripleDESCipher(key)
+    stream, _ := weak.NewCipher(key)
+    _, _ = block, stream
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(
+        found(&issues),
+        vec![
+            (10, "Weak Hash Function", Confidence::Medium),
+            (14, "Weak Hash Function", Confidence::High),
+            (20, "Weak Cipher", Confidence::High),
+            (21, "Weak Cipher", Confidence::High),
+        ]
+    );
+    let issue = &issues[0];
+    assert_eq!(issue.rule_id, "weak-crypto-go");
+    assert_eq!(issue.column, Some(12));
+    assert_eq!(issue.end_column, Some(19));
+    assert!(issue.description.contains("`md5.Sum`"));
+    assert!(issue
+        .suggested_fix
+        .as_deref()
+        .unwrap()
+        .contains("crypto/sha256"));
+    assert!(issues[3].description.contains("`weak.NewCipher`"));
+    assert!(issues[3]
+        .suggested_fix
+        .as_deref()
+        .unwrap()
+        .contains("AES-GCM"));
+}
+
+#[test]
+fn sha1_is_only_flagged_for_passwords_tokens_and_signatures() {
+    let content = r#"package store
+
+import (
+    "crypto/hmac"
+    "crypto/sha1"
+)
+
+func contentID(body []byte) [20]byte {
+    return sha1.Sum(body)
+}
+
+func signWebhook(secret, body []byte) []byte {
+    mac := hmac.New(sha1.New, secret)
+    mac.Write(body)
+    return mac.Sum(nil)
+}
+
+func check(token string) [20]byte {
+    return sha1.Sum([]byte(token))
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(
+        found(&issues),
+        vec![
+            (13, "Weak Hash Function", Confidence::Medium),
+            (19, "Weak Hash Function", Confidence::Medium),
+        ]
+    );
+}
+
+#[test]
+fn flags_math_rand_output_used_for_secrets() {
+    let content = r#"package auth
+
+import (
+    crand "crypto/rand"
+    "math/rand"
+)
+
+func newSessionToken() string {
+    b := make([]byte, 16)
+    for i := range b {
+        b[i] = letters[rand.Intn(len(letters))]
+    }
+    return string(b)
+}
+
+func newKey() {
+    n := rand.Int63()
+    apiKey := strconv.FormatInt(n, 36)
+    var nonce [12]byte
+    rand.Read(nonce[:])
+    _ = apiKey
+}
+
+func jitter() time.Duration {
+    return time.Duration(rand.Intn(100)) * time.Millisecond
+}
+
+func secure() {
+    key := make([]byte, 32)
+    crand.Read(key)
+}
+"#;
+    // `n := rand.Int63()` on line 17 is reported through `apiKey` alone.
+    let issues = scan(content);
+    assert_eq!(
+        found(&issues),
+        vec![
+            (11, "Insecure Randomness", Confidence::Medium),
+            (18, "Insecure Randomness", Confidence::High),
+            (20, "Insecure Randomness", Confidence::High),
+        ]
+    );
+    assert!(issues[0].description.contains("`newSessionToken`"));
+    assert!(issues[1].description.contains("`rand.Int63`"));
+    assert!(issues[1].description.contains("`apiKey`"));
+    assert!(issues[2].description.contains("`nonce[:]`"));
+}
+
+#[test]
+fn imports_without_located_uses_are_reported() {
+    let content = r#"package legacy
+
+import _ "crypto/md5"
+import "math/rand"
+"#;
+    let issues = scan(content);
+    assert_eq!(
+        found(&issues),
+        vec![(3, "Weak Hash Function", Confidence::Medium)]
+    );
+    assert!(issues[0].description.contains("`crypto/md5`"));
+}
+
+#[test]
+fn files_without_weak_packages_are_not_flagged() {
+    let content = r#"package store
+
+import "crypto/sha256"
+
+// md5.Sum is not used here.
+func digest(body []byte) [32]byte {
+    return sha256.Sum256(body)
+}
+"#;
+    assert!(scan(content).is_empty());
+}
@@ -0,0 +1,173 @@
+use engine::config::{Confidence, Config};
+use engine::fix::apply_edits;
+use engine::scanner::{Scanner, XssGoScanner};
+
+fn scan(content: &str) -> Vec<engine::scanner::Issue> {
+    XssGoScanner
+        .scan("server.go", content, &Config::default())
+        .expect("scan should work")
+}
+
+#[test]
+fn tracks_taint_through_intermediate_variables() {
+    let content = r#"
+func greet(w http.ResponseWriter, r *http.Request) {
+    user := r.URL.Query().Get("user")
+    message := "<p>" + user + "</p>"
+    fmt.Fprintf(w, message)
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(issues.len(), 1);
+    let issue = &issues[0];
+    assert_eq!(issue.rule_id, "xss-go");
+    assert_eq!(issue.line_number, 5);
+    assert_eq!(issue.column, Some(5));
+    assert!(issue.description.contains("`message`"));
+    assert!(issue.description.contains("`fmt.Fprintf`"));
+}
+
+#[test]
+fn detects_mux_vars_and_other_sinks() {
+    let content = r#"
+func show(resp http.ResponseWriter, req *http.Request) {
+    vars := mux.Vars(req)
+    id := vars["id"]
+    resp.Write([]byte(id))
+    io.WriteString(resp, req.FormValue("q"))
+}
+"#;
+    let issues = scan(content);
+    let lines: Vec<usize> = issues.iter().map(|i| i.line_number).collect();
+    assert_eq!(lines, vec![5, 6]);
+}
+
+#[test]
+fn escaped_values_are_not_flagged() {
+    let content = r#"
+func greet(w http.ResponseWriter, r *http.Request) {
+    user := r.FormValue("user")
+    safe := html.EscapeString(user)
+    fmt.Fprintf(w, "<p>%s</p>", safe)
+    fmt.Fprintf(w, "<p>%s</p>", template.HTMLEscapeString(user))
+    user = "anonymous"
+    w.Write([]byte(user))
+}
+"#;
+    assert!(scan(content).is_empty());
+}
+
+#[test]
+fn taint_does_not_leak_across_functions() {
+    let content = r#"
+func a(w http.ResponseWriter, r *http.Request) {
+    user := r.PostFormValue("user")
+    _ = user
+}
+
+func b(w http.ResponseWriter, r *http.Request) {
+    fmt.Fprintf(w, user)
+}
+"#;
+    assert!(scan(content).is_empty());
+}
+
+#[test]
+fn falls_back_to_per_line_matching_on_unbalanced_braces() {
+    let content = r#"
+func greet(w http.ResponseWriter, r *http.Request) {
+    fmt.Fprintf(w, "<p>"+user+"</p>")
+    fmt.Fprintf(w, "<p>static</p>")
+"#;
+    let issues = scan(content);
+    assert_eq!(issues.len(), 1);
+    assert_eq!(issues[0].line_number, 3);
+    assert_eq!(issues[0].confidence, Confidence::Low);
+}
+
+#[test]
+fn unresolved_calls_lower_confidence() {
+    let content = r#"
+func greet(w http.ResponseWriter, r *http.Request) {
+    user := r.URL.Query().Get("user")
+    fmt.Fprintf(w, "<p>"+user+"</p>")
+    w.Write([]byte(strings.TrimSpace(user)))
+    card := renderCard(user)
+    fmt.Fprintf(w, card)
+}
+"#;
+    let issues = scan(content);
+    let confidence: Vec<(usize, Confidence)> = issues
+        .iter()
+        .map(|i| (i.line_number, i.confidence))
+        .collect();
+    assert_eq!(
+        confidence,
+        vec![
+            (4, Confidence::High),
+            (5, Confidence::High),
+            (7, Confidence::Medium)
+        ]
+    );
+}
+
+#[test]
+fn suggests_escaping_the_tainted_value_and_importing_html() {
+    let content = r#"package main
+
+import (
+    "fmt"
+    "net/http"
+)
+
+func greet(w http.ResponseWriter, r *http.Request) {
+    user := r.URL.Query().Get("user")
+    fmt.Fprintf(w, "<p>"+user+"</p>")
+    fmt.Fprintln(w, r.FormValue("q"), html.EscapeString(user))
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(issues.len(), 2);
+
+    let fix = issues[0].fix.as_ref().expect("fix");
+    assert_eq!(fix.description, "Escape `user` with html.EscapeString");
+    assert_eq!(
+        issues[0].diff.as_deref(),
+        Some("-fmt.Fprintf(w, \"<p>\"+user+\"</p>\")\n+fmt.Fprintf(w, \"<p>\"+html.EscapeString(user)+\"</p>\")")
+    );
+    for issue in &issues {
+        let (fixed, _) = apply_edits(content, &issue.fix.as_ref().unwrap().edits).unwrap();
+        assert!(fixed.contains("import (\n\t\"html\"\n    \"fmt\""));
+    }
+
+    // Only the unescaped source call is wrapped.
+    let (fixed, _) = apply_edits(content, &issues[1].fix.as_ref().unwrap().edits).unwrap();
+    assert!(fixed.contains(
+        "fmt.Fprintln(w, html.EscapeString(r.FormValue(\"q\")), html.EscapeString(user))"
+    ));
+}
+
+#[test]
+fn fix_handles_byte_conversions_and_existing_imports() {
+    let content = r#"package main
+
+import "html"
+
+func show(w http.ResponseWriter, r *http.Request) {
+    id := mux.Vars(r)["id"]
+    w.Write([]byte(mux.Vars(r)["id"]))
+    data := []byte(id)
+    w.Write(data)
+}
+"#;
+    let issues = scan(content);
+    assert_eq!(issues.len(), 2);
+    let fix = issues[0].fix.as_ref().expect("fix");
+    assert_eq!(fix.edits.len(), 1);
+    assert_eq!(
+        fix.edits[0].replacement,
+        "html.EscapeString(mux.Vars(r)[\"id\"])"
+    );
+    // A byte slice passed straight to Write cannot be escaped in place.
+    assert!(issues[1].fix.is_none());
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "fmt"
+)
+
+// resetToken ignores the error from rand.Read, so a failed read leaves the
+// token all zeros.
+func resetToken() string {
+    b := make([]byte, 16)
+    rand.Read(b)
+    return hex.EncodeToString(b)
+}
+
+// sessionKey checks that the key was filled.
+func sessionKey() ([]byte, error) {
+    key := make([]byte, 32)
+    if _, err := rand.Read(key); err != nil {
+        return nil, err
+    }
+    return key, nil
+}
+
+func main() {
+    key, err := sessionKey()
+    fmt.Println(resetToken(), key, err)
+}
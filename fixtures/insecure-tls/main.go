@@ -0,0 +1,29 @@
+package main
+
+import (
+    "crypto/tls"
+    "fmt"
+    "net/http"
+)
+
+// legacyClient talks to an internal service with a self-signed certificate.
+func legacyClient() *http.Client {
+    return &http.Client{
+        Transport: &http.Transport{
+            TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+        },
+    }
+}
+
+// modernClient only negotiates TLS 1.3 and verifies the server.
+func modernClient() *http.Client {
+    return &http.Client{
+        Transport: &http.Transport{
+            TLSClientConfig: &tls.Config{MinVersion: tls.VersionTLS13},
+        },
+    }
+}
+
+func main() {
+    fmt.Println(legacyClient(), modernClient())
+}
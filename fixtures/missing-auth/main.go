@@ -0,0 +1,33 @@
+package main
+
+import (
+    "net/http"
+)
+
+var items = map[string]string{}
+
+// createItem checks that the caller is signed in.
+func createItem(w http.ResponseWriter, r *http.Request) {
+    if CurrentUser(r) == "" {
+        http.Error(w, "unauthorized", http.StatusUnauthorized)
+        return
+    }
+    items[r.FormValue("id")] = r.FormValue("name")
+}
+
+// deleteItem removes any item for any caller.
+func deleteItem(w http.ResponseWriter, r *http.Request) {
+    delete(items, r.PathValue("id"))
+}
+
+func CurrentUser(r *http.Request) string {
+    return r.Header.Get("X-User")
+}
+
+func main() {
+    mux := http.NewServeMux()
+    mux.HandleFunc("POST /items", createItem)
+    mux.HandleFunc("DELETE /items/{id}", deleteItem)
+    srv := &http.Server{Addr: ":8080", Handler: mux, ReadHeaderTimeout: 5e9}
+    srv.ListenAndServe()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+    "fmt"
+    "net/http"
+    "time"
+)
+
+func withCORS(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Access-Control-Allow-Origin", "*")
+        w.Header().Set("Access-Control-Allow-Credentials", "true")
+        next.ServeHTTP(w, r)
+    })
+}
+
+func withSecurityHeaders(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Security-Policy", "default-src 'self'")
+        w.Header().Set("X-Content-Type-Options", "nosniff")
+        next.ServeHTTP(w, r)
+    })
+}
+
+func hello(w http.ResponseWriter, r *http.Request) {
+    fmt.Fprintln(w, "hello")
+}
+
+func main() {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/", hello)
+    server := &http.Server{
+        Addr:              ":8080",
+        Handler:           withSecurityHeaders(withCORS(mux)),
+        ReadHeaderTimeout: 5 * time.Second,
+    }
+    server.ListenAndServe()
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+    "net/http"
+    "os/exec"
+)
+
+func lookup(w http.ResponseWriter, r *http.Request) {
+    host := r.URL.Query().Get("host")
+    if err := exec.Command("sh", "-c", "nslookup "+host).Run(); err != nil {
+        http.Error(w, "lookup failed", http.StatusBadGateway)
+    }
+}
+
+func lookupFixed(w http.ResponseWriter, r *http.Request) {
+    if err := exec.Command("nslookup", "-type=A", "example.com").Run(); err != nil {
+        http.Error(w, "lookup failed", http.StatusBadGateway)
+    }
+}
+
+func main() {
+    http.HandleFunc("/lookup", lookup)
+    http.HandleFunc("/lookup-fixed", lookupFixed)
+    http.ListenAndServe(":8080", nil)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+    "encoding/gob"
+    "net/http"
+)
+
+type Job struct {
+    Name  string
+    Steps []string
+}
+
+func importSession(w http.ResponseWriter, r *http.Request) {
+    r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+    var session interface{}
+    if err := gob.NewDecoder(r.Body).Decode(&session); err != nil {
+        http.Error(w, "invalid session", http.StatusBadRequest)
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func importJob(w http.ResponseWriter, r *http.Request) {
+    r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+    var job Job
+    if err := gob.NewDecoder(r.Body).Decode(&job); err != nil {
+        http.Error(w, "invalid job", http.StatusBadRequest)
+        return
+    }
+    w.WriteHeader(http.StatusNoContent)
+}
+
+func main() {
+    http.HandleFunc("/session", importSession)
+    http.HandleFunc("/job", importJob)
+    http.ListenAndServe(":8080", nil)
+}
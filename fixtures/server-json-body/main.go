@@ -0,0 +1,40 @@
+package main
+
+import (
+    "encoding/json"
+    "io"
+    "net/http"
+)
+
+type Event struct {
+    Kind string `json:"kind"`
+}
+
+func ingest(w http.ResponseWriter, r *http.Request) {
+    var payload map[string]interface{}
+    if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+        http.Error(w, "invalid payload", http.StatusBadRequest)
+        return
+    }
+    w.WriteHeader(http.StatusAccepted)
+}
+
+func ingestEvent(w http.ResponseWriter, r *http.Request) {
+    data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+    if err != nil {
+        http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+        return
+    }
+    var event Event
+    if err := json.Unmarshal(data, &event); err != nil {
+        http.Error(w, "invalid event", http.StatusBadRequest)
+        return
+    }
+    w.WriteHeader(http.StatusAccepted)
+}
+
+func main() {
+    http.HandleFunc("/ingest", ingest)
+    http.HandleFunc("/events", ingestEvent)
+    http.ListenAndServe(":8080", nil)
+}
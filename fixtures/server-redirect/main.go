@@ -0,0 +1,25 @@
+package main
+
+import (
+    "net/http"
+    "strings"
+)
+
+func login(w http.ResponseWriter, r *http.Request) {
+    next := r.URL.Query().Get("next")
+    http.Redirect(w, r, next, http.StatusFound)
+}
+
+func loginChecked(w http.ResponseWriter, r *http.Request) {
+    next := r.URL.Query().Get("next")
+    if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") {
+        next = "/"
+    }
+    http.Redirect(w, r, next, http.StatusFound)
+}
+
+func main() {
+    http.HandleFunc("/login", login)
+    http.HandleFunc("/login-checked", loginChecked)
+    http.ListenAndServe(":8080", nil)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+    "database/sql"
+    "fmt"
+    "net/http"
+)
+
+var db *sql.DB
+
+func search(w http.ResponseWriter, r *http.Request) {
+    name := r.URL.Query().Get("name")
+    query := fmt.Sprintf("SELECT id FROM users WHERE name = '%s'", name)
+    rows, _ := db.QueryContext(r.Context(), query)
+    defer rows.Close()
+}
+
+func searchSafe(w http.ResponseWriter, r *http.Request) {
+    name := r.URL.Query().Get("name")
+    rows, _ := db.QueryContext(r.Context(), "SELECT id FROM users WHERE name = $1", name)
+    defer rows.Close()
+}
+
+func main() {
+    http.HandleFunc("/search", search)
+    http.HandleFunc("/search-safe", searchSafe)
+    http.ListenAndServe(":8080", nil)
+}
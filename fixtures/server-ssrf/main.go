@@ -0,0 +1,44 @@
+package main
+
+import (
+    "io"
+    "net/http"
+    "net/url"
+)
+
+var allowedHosts = map[string]bool{
+    "hooks.example.com": true,
+}
+
+func fetch(w http.ResponseWriter, r *http.Request) {
+    target := r.URL.Query().Get("url")
+    resp, err := http.Get(target)
+    if err != nil {
+        http.Error(w, "fetch failed", http.StatusBadGateway)
+        return
+    }
+    defer resp.Body.Close()
+    io.Copy(w, resp.Body)
+}
+
+func fetchChecked(w http.ResponseWriter, r *http.Request) {
+    target := r.URL.Query().Get("url")
+    u, err := url.Parse(target)
+    if err != nil || !allowedHosts[u.Hostname()] {
+        http.Error(w, "host not allowed", http.StatusBadRequest)
+        return
+    }
+    resp, err := http.Get(u.String())
+    if err != nil {
+        http.Error(w, "fetch failed", http.StatusBadGateway)
+        return
+    }
+    defer resp.Body.Close()
+    io.Copy(w, resp.Body)
+}
+
+func main() {
+    http.HandleFunc("/fetch", fetch)
+    http.HandleFunc("/fetch-checked", fetchChecked)
+    http.ListenAndServe(":8080", nil)
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+    "html/template"
+    "net/http"
+)
+
+const welcome = "<p>Welcome to <b>Example</b></p>"
+
+var page = template.Must(template.New("page").Parse(`<div>{{.Bio}}</div>`))
+
+func profile(w http.ResponseWriter, r *http.Request) {
+    userInput := r.FormValue("bio")
+    page.Execute(w, map[string]any{"Bio": template.HTML(userInput)})
+}
+
+func home(w http.ResponseWriter, r *http.Request) {
+    page.Execute(w, map[string]any{"Bio": template.HTML(welcome)})
+}
+
+func main() {
+    http.HandleFunc("/profile", profile)
+    http.HandleFunc("/", home)
+    http.ListenAndServe(":8080", nil)
+}
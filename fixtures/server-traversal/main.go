@@ -0,0 +1,29 @@
+package main
+
+import (
+    "net/http"
+    "path/filepath"
+    "strings"
+)
+
+const baseDir = "/srv/files"
+
+func download(w http.ResponseWriter, r *http.Request) {
+    http.ServeFile(w, r, filepath.Join(baseDir, r.URL.Query().Get("file")))
+}
+
+func downloadChecked(w http.ResponseWriter, r *http.Request) {
+    name := r.URL.Query().Get("file")
+    path := filepath.Join(baseDir, name)
+    if !strings.HasPrefix(path, baseDir+string(filepath.Separator)) {
+        http.Error(w, "invalid file", http.StatusBadRequest)
+        return
+    }
+    http.ServeFile(w, r, path)
+}
+
+func main() {
+    http.HandleFunc("/download", download)
+    http.HandleFunc("/download-checked", downloadChecked)
+    http.ListenAndServe(":8080", nil)
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+    "fmt"
+    "html"
+    "net/http"
+)
+
+func greet(w http.ResponseWriter, r *http.Request) {
+    user := r.URL.Query().Get("user")
+    message := "<p>Hello, " + user + "</p>"
+    fmt.Fprintf(w, message)
+}
+
+func greetEscaped(w http.ResponseWriter, r *http.Request) {
+    user := html.EscapeString(r.URL.Query().Get("user"))
+    fmt.Fprintf(w, "<p>Hello, %s</p>", user)
+}
+
+func main() {
+    http.HandleFunc("/greet", greet)
+    http.HandleFunc("/greet-escaped", greetEscaped)
+    http.ListenAndServe(":8080", nil)
+}
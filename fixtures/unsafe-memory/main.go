@@ -0,0 +1,23 @@
+package main
+
+import (
+    "fmt"
+    "unsafe"
+)
+
+type header struct {
+    kind uint8
+    size uint32
+}
+
+func bytesToString(b []byte) string {
+    return *(*string)(unsafe.Pointer(&b))
+}
+
+func headerSize() uintptr {
+    return unsafe.Sizeof(header{})
+}
+
+func main() {
+    fmt.Println(bytesToString([]byte("hello")), headerSize())
+}
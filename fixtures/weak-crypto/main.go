@@ -0,0 +1,26 @@
+package main
+
+import (
+    crand "crypto/rand"
+    "encoding/hex"
+    "fmt"
+    mrand "math/rand"
+)
+
+func newResetToken() string {
+    token := make([]byte, 16)
+    mrand.Read(token)
+    return hex.EncodeToString(token)
+}
+
+func newSessionKey() string {
+    key := make([]byte, 32)
+    if _, err := crand.Read(key); err != nil {
+        panic(err)
+    }
+    return hex.EncodeToString(key)
+}
+
+func main() {
+    fmt.Println(newResetToken(), newSessionKey(), mrand.Intn(10))
+}
@@ -1,8 +1,8 @@
 #!/usr/bin/env bash
 set -euo pipefail
 
-fixtures=("secrets" "sql-injection" "http-timeout" "clean")
-expected=(1 1 1 0)
+fixtures=("secrets" "sql-injection" "http-timeout" "server-xss" "server-sqli" "server-cmdi" "server-redirect" "client-context" "server-template" "weak-crypto" "server-traversal" "insecure-tls" "ignored-errors" "missing-auth" "server-ssrf" "unsafe-memory" "security-headers" "server-gob" "server-json-body" "clean")
+expected=(1 1 1 1 1 1 1 1 1 1 1 1 1 1 1 1 1 1 1 0)
 
 total_tp=0
 total_fp=0